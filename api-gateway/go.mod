@@ -1,5 +1,8 @@
 module gitea.wkav.cc/tony/jobapp/api-gateway
 
-go 1.22.3
+go 1.23.0
 
-require github.com/joho/godotenv v1.5.1
+require (
+	github.com/joho/godotenv v1.5.1
+	golang.org/x/time v0.12.0
+)
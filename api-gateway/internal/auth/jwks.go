@@ -0,0 +1,195 @@
+package auth
+
+import (
+    "context"
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rsa"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "math/big"
+    "net/http"
+    "sync"
+    "time"
+)
+
+// jwksMinFetchInterval limits how often the key set is fetched, so tokens with made-up
+// kids (or an unreachable endpoint) cannot turn every request into a JWKS fetch.
+const jwksMinFetchInterval = 10 * time.Second
+
+// jwksCache holds the keys published at a JWKS URL, refreshed every refresh interval
+// and when a token names an unknown key, but at most once per jwksMinFetchInterval.
+// Lookups share a read lock; the fetch itself runs outside the lock, and requests that
+// need keys while it runs wait for it rather than start their own.
+type jwksCache struct {
+    url     string
+    refresh time.Duration
+    client  *http.Client
+
+    mu          sync.RWMutex
+    keys        map[string]crypto.PublicKey
+    fetchedAt   time.Time
+    lastAttempt time.Time
+    // fetching is closed when the fetch in flight finishes; nil when none is.
+    fetching chan struct{}
+}
+
+func newJWKSCache(url string, refresh time.Duration) *jwksCache {
+    return &jwksCache{
+        url:     url,
+        refresh: refresh,
+        client:  &http.Client{Timeout: 5 * time.Second},
+    }
+}
+
+// key returns the public key for kid, fetching the key set if it is stale or lacks kid.
+func (c *jwksCache) key(kid string) (crypto.PublicKey, error) {
+    key, known, fresh := c.lookup(kid)
+    if !known || !fresh {
+        c.refreshKeys()
+        key, known, _ = c.lookup(kid)
+    }
+    if !known {
+        return nil, fmt.Errorf("unknown key id %q", kid)
+    }
+    return key, nil
+}
+
+// lookup returns the cached key for kid and whether the key set is within its refresh interval.
+func (c *jwksCache) lookup(kid string) (key crypto.PublicKey, known, fresh bool) {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    key, known = c.keys[kid]
+    return key, known, c.keys != nil && time.Since(c.fetchedAt) <= c.refresh
+}
+
+// refreshKeys fetches the key set, or waits for the fetch already in flight. It does
+// nothing if a fetch was attempted within jwksMinFetchInterval.
+func (c *jwksCache) refreshKeys() {
+    c.mu.Lock()
+    if done := c.fetching; done != nil {
+        c.mu.Unlock()
+        <-done
+        return
+    }
+    if time.Since(c.lastAttempt) < jwksMinFetchInterval {
+        c.mu.Unlock()
+        return
+    }
+    c.lastAttempt = time.Now()
+    done := make(chan struct{})
+    c.fetching = done
+    c.mu.Unlock()
+
+    keys, err := c.fetch()
+
+    c.mu.Lock()
+    if err != nil {
+        // Keep serving the previous keys; an outage of the JWKS endpoint
+        // should not reject tokens signed with keys we already have.
+        slog.Warn("Failed to refresh JWKS", "url", c.url, "error", err)
+    } else {
+        c.keys = keys
+        c.fetchedAt = time.Now()
+    }
+    c.fetching = nil
+    c.mu.Unlock()
+    close(done)
+}
+
+type jsonWebKey struct {
+    KeyType string `json:"kty"`
+    KeyID   string `json:"kid"`
+    Use     string `json:"use"`
+    N       string `json:"n"`
+    E       string `json:"e"`
+    Curve   string `json:"crv"`
+    X       string `json:"x"`
+    Y       string `json:"y"`
+}
+
+func (c *jwksCache) fetch() (map[string]crypto.PublicKey, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), c.client.Timeout)
+    defer cancel()
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+    if err != nil {
+        return nil, err
+    }
+    resp, err := c.client.Do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("unexpected response: %s", resp.Status)
+    }
+
+    var set struct {
+        Keys []jsonWebKey `json:"keys"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+        return nil, err
+    }
+
+    keys := make(map[string]crypto.PublicKey, len(set.Keys))
+    for _, jwk := range set.Keys {
+        if jwk.Use != "" && jwk.Use != "sig" {
+            continue
+        }
+        key, err := jwk.publicKey()
+        if err != nil {
+            slog.Warn("Skipping unusable JWKS key", "kid", jwk.KeyID, "error", err)
+            continue
+        }
+        keys[jwk.KeyID] = key
+    }
+    return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+    switch k.KeyType {
+    case "RSA":
+        n, err := decodeBigInt(k.N)
+        if err != nil {
+            return nil, err
+        }
+        e, err := decodeBigInt(k.E)
+        if err != nil {
+            return nil, err
+        }
+        return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+    case "EC":
+        var curve elliptic.Curve
+        switch k.Curve {
+        case "P-256":
+            curve = elliptic.P256()
+        case "P-384":
+            curve = elliptic.P384()
+        case "P-521":
+            curve = elliptic.P521()
+        default:
+            return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+        }
+        x, err := decodeBigInt(k.X)
+        if err != nil {
+            return nil, err
+        }
+        y, err := decodeBigInt(k.Y)
+        if err != nil {
+            return nil, err
+        }
+        return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+    }
+    return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+    data, err := base64.RawURLEncoding.DecodeString(value)
+    if err != nil {
+        return nil, err
+    }
+    return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,189 @@
+package auth
+
+import (
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/hmac"
+    "crypto/rsa"
+    "crypto/sha256"
+    "crypto/sha512"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "hash"
+    "math/big"
+    "strings"
+    "time"
+)
+
+// clockSkew is how far exp and nbf may be off to tolerate clock drift between hosts.
+const clockSkew = 60 * time.Second
+
+var (
+    errInvalidToken = errors.New("invalid token")
+    errForbidden    = errors.New("token not accepted for this gateway")
+)
+
+// claims holds the registered JWT claims the gateway checks.
+type claims struct {
+    Subject   string   `json:"sub"`
+    Issuer    string   `json:"iss"`
+    Audience  audience `json:"aud"`
+    ExpiresAt *int64   `json:"exp"`
+    NotBefore *int64   `json:"nbf"`
+}
+
+// audience accepts both forms of the aud claim: a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+    var single string
+    if err := json.Unmarshal(data, &single); err == nil {
+        *a = audience{single}
+        return nil
+    }
+    var multiple []string
+    if err := json.Unmarshal(data, &multiple); err != nil {
+        return err
+    }
+    *a = multiple
+    return nil
+}
+
+type header struct {
+    Algorithm string `json:"alg"`
+    KeyID     string `json:"kid"`
+}
+
+// keyLookup returns the public key for a key ID, for asymmetric algorithms.
+type keyLookup func(kid string) (crypto.PublicKey, error)
+
+// verifyJWT checks a compact JWS token's signature and time-based claims. HS* tokens
+// are verified with secret; RS* and ES* tokens with the key lookup returns. A token
+// without exp never expires, so it is rejected unless allowNoExpiry is set.
+// errInvalidToken wraps every failure so callers can answer 401.
+func verifyJWT(token string, secret []byte, lookup keyLookup, allowNoExpiry bool, now time.Time) (*claims, error) {
+    parts := strings.Split(token, ".")
+    if len(parts) != 3 {
+        return nil, fmt.Errorf("%w: malformed", errInvalidToken)
+    }
+
+    var h header
+    if err := decodeSegment(parts[0], &h); err != nil {
+        return nil, fmt.Errorf("%w: header: %v", errInvalidToken, err)
+    }
+    signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+    if err != nil {
+        return nil, fmt.Errorf("%w: signature encoding", errInvalidToken)
+    }
+    if err := verifySignature(h, parts[0]+"."+parts[1], signature, secret, lookup); err != nil {
+        return nil, fmt.Errorf("%w: %v", errInvalidToken, err)
+    }
+
+    var c claims
+    if err := decodeSegment(parts[1], &c); err != nil {
+        return nil, fmt.Errorf("%w: claims: %v", errInvalidToken, err)
+    }
+    if c.ExpiresAt == nil && !allowNoExpiry {
+        return nil, fmt.Errorf("%w: no expiry", errInvalidToken)
+    }
+    if c.ExpiresAt != nil && now.After(time.Unix(*c.ExpiresAt, 0).Add(clockSkew)) {
+        return nil, fmt.Errorf("%w: expired", errInvalidToken)
+    }
+    if c.NotBefore != nil && now.Add(clockSkew).Before(time.Unix(*c.NotBefore, 0)) {
+        return nil, fmt.Errorf("%w: not yet valid", errInvalidToken)
+    }
+    return &c, nil
+}
+
+func decodeSegment(segment string, v any) error {
+    data, err := base64.RawURLEncoding.DecodeString(segment)
+    if err != nil {
+        return err
+    }
+    return json.Unmarshal(data, v)
+}
+
+func verifySignature(h header, signingInput string, signature, secret []byte, lookup keyLookup) error {
+    newHash, cryptoHash, err := hashFor(h.Algorithm)
+    if err != nil {
+        return err
+    }
+
+    switch h.Algorithm[:2] {
+    case "HS":
+        if len(secret) == 0 {
+            return fmt.Errorf("%s tokens are not accepted", h.Algorithm)
+        }
+        mac := hmac.New(newHash, secret)
+        mac.Write([]byte(signingInput))
+        if !hmac.Equal(mac.Sum(nil), signature) {
+            return errors.New("signature mismatch")
+        }
+        return nil
+    }
+
+    if lookup == nil {
+        return fmt.Errorf("%s tokens are not accepted", h.Algorithm)
+    }
+    key, err := lookup(h.KeyID)
+    if err != nil {
+        return err
+    }
+    digest := newHash()
+    digest.Write([]byte(signingInput))
+    sum := digest.Sum(nil)
+
+    switch h.Algorithm[:2] {
+    case "RS":
+        rsaKey, ok := key.(*rsa.PublicKey)
+        if !ok {
+            return fmt.Errorf("key %q is not an RSA key", h.KeyID)
+        }
+        if err := rsa.VerifyPKCS1v15(rsaKey, cryptoHash, sum, signature); err != nil {
+            return errors.New("signature mismatch")
+        }
+    case "ES":
+        ecKey, ok := key.(*ecdsa.PublicKey)
+        if !ok {
+            return fmt.Errorf("key %q is not an EC key", h.KeyID)
+        }
+        // Each ES algorithm is defined for one curve; a key on another curve would
+        // let a token signed for one algorithm pass as another.
+        if ecKey.Curve != curveFor[h.Algorithm] {
+            return fmt.Errorf("key %q is on %s, not the curve %s requires", h.KeyID, ecKey.Curve.Params().Name, h.Algorithm)
+        }
+        size := (ecKey.Curve.Params().BitSize + 7) / 8
+        if len(signature) != 2*size {
+            return errors.New("signature mismatch")
+        }
+        r := new(big.Int).SetBytes(signature[:size])
+        s := new(big.Int).SetBytes(signature[size:])
+        if !ecdsa.Verify(ecKey, sum, r, s) {
+            return errors.New("signature mismatch")
+        }
+    }
+    return nil
+}
+
+// curveFor maps each ES algorithm to the curve its keys must use.
+var curveFor = map[string]elliptic.Curve{
+    "ES256": elliptic.P256(),
+    "ES384": elliptic.P384(),
+    "ES512": elliptic.P521(),
+}
+
+// hashFor maps a JWS algorithm to its hash. "none" and unknown algorithms are rejected.
+func hashFor(algorithm string) (func() hash.Hash, crypto.Hash, error) {
+    switch algorithm {
+    case "HS256", "RS256", "ES256":
+        return sha256.New, crypto.SHA256, nil
+    case "HS384", "RS384", "ES384":
+        return sha512.New384, crypto.SHA384, nil
+    case "HS512", "RS512", "ES512":
+        return sha512.New, crypto.SHA512, nil
+    }
+    return nil, 0, fmt.Errorf("unsupported algorithm %q", algorithm)
+}
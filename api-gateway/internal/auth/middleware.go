@@ -0,0 +1,174 @@
+package auth
+
+import (
+    "crypto"
+    "crypto/subtle"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strings"
+    "time"
+
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/middleware"
+)
+
+// Options configures request authentication. With no secret, JWKS URL, or API keys
+// configured, authentication is disabled.
+type Options struct {
+    // JWTSecret verifies HS256/384/512 bearer tokens.
+    JWTSecret string
+    // JWKSURL publishes the keys that verify RS* and ES* bearer tokens.
+    JWKSURL     string
+    JWKSRefresh time.Duration
+    // Issuer and Audience, when set, must match the token's iss and aud claims.
+    Issuer   string
+    Audience string
+    // AllowNoExpiry accepts bearer tokens without an exp claim, which otherwise are
+    // rejected because they would be valid forever.
+    AllowNoExpiry bool
+    // APIKeys are accepted in APIKeyHeader as an alternative to a bearer token.
+    APIKeys      []string
+    APIKeyHeader string
+    // ExemptPaths are path prefixes served without authentication.
+    ExemptPaths []string
+    // ExemptExactPaths are served without authentication only when requested exactly,
+    // so paths beneath them still require credentials.
+    ExemptExactPaths []string
+}
+
+// Authenticator checks bearer JWTs and API keys before requests are proxied.
+type Authenticator struct {
+    opts Options
+    jwks *jwksCache
+}
+
+// NewAuthenticator creates an authenticator; JWKS keys are fetched on first use.
+func NewAuthenticator(opts Options) *Authenticator {
+    if opts.APIKeyHeader == "" {
+        opts.APIKeyHeader = "X-API-Key"
+    }
+    if opts.JWKSRefresh <= 0 {
+        opts.JWKSRefresh = time.Hour
+    }
+    a := &Authenticator{opts: opts}
+    if opts.JWKSURL != "" {
+        a.jwks = newJWKSCache(opts.JWKSURL, opts.JWKSRefresh)
+    }
+    return a
+}
+
+// Enabled reports whether any credential type is configured.
+func (a *Authenticator) Enabled() bool {
+    return a.opts.JWTSecret != "" || a.jwks != nil || len(a.opts.APIKeys) > 0
+}
+
+// Middleware rejects requests without valid credentials with 401, and requests whose
+// credentials are valid but not accepted here (wrong issuer or audience, unknown API
+// key) with 403. Exempt paths and CORS preflights pass through. When authentication is
+// not configured it returns next unchanged.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+    if !a.Enabled() {
+        return next
+    }
+
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Method == http.MethodOptions || a.exempt(r.URL.Path) {
+            next.ServeHTTP(w, r)
+            return
+        }
+
+        err := a.authenticate(r)
+        if err == nil {
+            next.ServeHTTP(w, r)
+            return
+        }
+
+        status := http.StatusUnauthorized
+        if errors.Is(err, errForbidden) {
+            status = http.StatusForbidden
+        }
+        slog.Warn("Rejected unauthenticated request",
+            "request_id", middleware.RequestIDFromContext(r.Context()),
+            "method", r.Method,
+            "path", r.URL.Path,
+            "status", status,
+            "error", err,
+        )
+        writeError(w, status)
+    })
+}
+
+func (a *Authenticator) exempt(path string) bool {
+    if contains(a.opts.ExemptExactPaths, path) {
+        return true
+    }
+    for _, prefix := range a.opts.ExemptPaths {
+        if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+            return true
+        }
+    }
+    return false
+}
+
+// authenticate accepts an API key from the allowlist or a verified bearer token.
+func (a *Authenticator) authenticate(r *http.Request) error {
+    if key := r.Header.Get(a.opts.APIKeyHeader); key != "" && len(a.opts.APIKeys) > 0 {
+        for _, allowed := range a.opts.APIKeys {
+            if subtle.ConstantTimeCompare([]byte(key), []byte(allowed)) == 1 {
+                return nil
+            }
+        }
+        return fmt.Errorf("%w: API key not in allowlist", errForbidden)
+    }
+
+    token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+    if !ok || token == "" {
+        return errors.New("missing credentials")
+    }
+    if a.opts.JWTSecret == "" && a.jwks == nil {
+        return errors.New("bearer tokens are not accepted")
+    }
+
+    var lookup keyLookup
+    if a.jwks != nil {
+        lookup = func(kid string) (crypto.PublicKey, error) { return a.jwks.key(kid) }
+    }
+    c, err := verifyJWT(strings.TrimSpace(token), []byte(a.opts.JWTSecret), lookup, a.opts.AllowNoExpiry, time.Now())
+    if err != nil {
+        return err
+    }
+
+    if a.opts.Issuer != "" && c.Issuer != a.opts.Issuer {
+        return fmt.Errorf("%w: unexpected issuer", errForbidden)
+    }
+    if a.opts.Audience != "" && !contains(c.Audience, a.opts.Audience) {
+        return fmt.Errorf("%w: unexpected audience", errForbidden)
+    }
+    return nil
+}
+
+func contains(values []string, want string) bool {
+    for _, value := range values {
+        if value == want {
+            return true
+        }
+    }
+    return false
+}
+
+// writeError sends the JSON error body used elsewhere in the gateway.
+func writeError(w http.ResponseWriter, status int) {
+    response := map[string]string{"error": "unauthorized", "detail": "valid credentials are required"}
+    if status == http.StatusForbidden {
+        response = map[string]string{"error": "forbidden", "detail": "credentials are not allowed to access this gateway"}
+    } else {
+        w.Header().Set("WWW-Authenticate", `Bearer realm="api-gateway"`)
+    }
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    if err := json.NewEncoder(w).Encode(response); err != nil {
+        slog.Error("Failed to encode auth error response", "error", err)
+    }
+}
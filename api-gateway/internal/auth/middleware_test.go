@@ -0,0 +1,286 @@
+package auth
+
+import (
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha256"
+    "crypto/sha512"
+    "encoding/base64"
+    "encoding/json"
+    "hash"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+const testSecret = "test-secret"
+
+// signHS256 builds a token signed with secret carrying the given claims.
+func signHS256(t *testing.T, secret string, claims map[string]any) string {
+    t.Helper()
+    encode := func(v any) string {
+        data, err := json.Marshal(v)
+        if err != nil {
+            t.Fatal(err)
+        }
+        return base64.RawURLEncoding.EncodeToString(data)
+    }
+    signed := encode(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encode(claims)
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte(signed))
+    return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newTestAuthenticator() http.Handler {
+    return NewAuthenticator(Options{
+        JWTSecret:        testSecret,
+        Issuer:           "https://issuer.example",
+        APIKeys:          []string{"key-1"},
+        ExemptPaths:      []string{"/health"},
+        ExemptExactPaths: []string{"/metrics"},
+    }).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte("backend"))
+    }))
+}
+
+func TestAuthenticatorCredentials(t *testing.T) {
+    valid := map[string]any{"sub": "user-1", "iss": "https://issuer.example", "exp": time.Now().Add(time.Hour).Unix()}
+    expired := map[string]any{"sub": "user-1", "iss": "https://issuer.example", "exp": time.Now().Add(-time.Hour).Unix()}
+    otherIssuer := map[string]any{"sub": "user-1", "iss": "https://other.example", "exp": time.Now().Add(time.Hour).Unix()}
+    noExpiry := map[string]any{"sub": "user-1", "iss": "https://issuer.example"}
+
+    tests := []struct {
+        name    string
+        headers map[string]string
+        want    int
+    }{
+        {"valid bearer token", map[string]string{"Authorization": "Bearer " + signHS256(t, testSecret, valid)}, http.StatusOK},
+        {"valid API key", map[string]string{"X-API-Key": "key-1"}, http.StatusOK},
+        {"no credentials", nil, http.StatusUnauthorized},
+        {"malformed token", map[string]string{"Authorization": "Bearer not-a-jwt"}, http.StatusUnauthorized},
+        {"wrong signature", map[string]string{"Authorization": "Bearer " + signHS256(t, "other-secret", valid)}, http.StatusUnauthorized},
+        {"expired token", map[string]string{"Authorization": "Bearer " + signHS256(t, testSecret, expired)}, http.StatusUnauthorized},
+        {"token without expiry", map[string]string{"Authorization": "Bearer " + signHS256(t, testSecret, noExpiry)}, http.StatusUnauthorized},
+        {"basic auth", map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, http.StatusUnauthorized},
+        {"unexpected issuer", map[string]string{"Authorization": "Bearer " + signHS256(t, testSecret, otherIssuer)}, http.StatusForbidden},
+        {"unknown API key", map[string]string{"X-API-Key": "key-2"}, http.StatusForbidden},
+    }
+    handler := newTestAuthenticator()
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            req := httptest.NewRequest(http.MethodGet, "/api/jobs", nil)
+            for name, value := range tt.headers {
+                req.Header.Set(name, value)
+            }
+            rec := httptest.NewRecorder()
+            handler.ServeHTTP(rec, req)
+
+            if rec.Code != tt.want {
+                t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+            }
+            if tt.want == http.StatusOK {
+                return
+            }
+            if rec.Body.String() == "backend" {
+                t.Error("rejected request reached the backend")
+            }
+            if challenge := rec.Header().Get("WWW-Authenticate"); (challenge != "") != (tt.want == http.StatusUnauthorized) {
+                t.Errorf("WWW-Authenticate = %q for a %d", challenge, tt.want)
+            }
+        })
+    }
+}
+
+func TestAuthenticatorExemptPaths(t *testing.T) {
+    tests := []struct {
+        path string
+        want int
+    }{
+        // Prefix exemptions cover whole segments beneath the prefix
+        {"/health", http.StatusOK},
+        {"/health/ready", http.StatusOK},
+        {"/healthz", http.StatusUnauthorized},
+        // Exact exemptions cover only the path itself
+        {"/metrics", http.StatusOK},
+        {"/metrics/", http.StatusUnauthorized},
+        {"/metrics/../api/jobs", http.StatusUnauthorized},
+        {"/metrics/admin", http.StatusUnauthorized},
+        {"/metricsz", http.StatusUnauthorized},
+        {"/api/jobs", http.StatusUnauthorized},
+    }
+    handler := newTestAuthenticator()
+    for _, tt := range tests {
+        req := httptest.NewRequest(http.MethodGet, "/", nil)
+        req.URL.Path = tt.path
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, req)
+        if rec.Code != tt.want {
+            t.Errorf("%s: status = %d, want %d", tt.path, rec.Code, tt.want)
+        }
+    }
+
+    // CORS preflights never carry credentials, so they always pass through
+    rec := httptest.NewRecorder()
+    handler.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/api/jobs", nil))
+    if rec.Code != http.StatusOK {
+        t.Errorf("preflight status = %d, want 200", rec.Code)
+    }
+}
+
+func TestAuthenticatorDisabledWithoutCredentials(t *testing.T) {
+    authenticator := NewAuthenticator(Options{ExemptPaths: []string{"/health"}})
+    if authenticator.Enabled() {
+        t.Fatal("authenticator enabled without a secret, JWKS URL, or API keys")
+    }
+    rec := httptest.NewRecorder()
+    authenticator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte("backend"))
+    })).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/jobs", nil))
+    if rec.Code != http.StatusOK {
+        t.Errorf("status = %d, want 200", rec.Code)
+    }
+}
+
+func TestAuthenticatorAllowNoExpiry(t *testing.T) {
+    token := signHS256(t, testSecret, map[string]any{"sub": "user-1"})
+    for _, allow := range []bool{false, true} {
+        handler := NewAuthenticator(Options{JWTSecret: testSecret, AllowNoExpiry: allow}).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            w.Write([]byte("backend"))
+        }))
+        req := httptest.NewRequest(http.MethodGet, "/api/jobs", nil)
+        req.Header.Set("Authorization", "Bearer "+token)
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, req)
+
+        want := http.StatusUnauthorized
+        if allow {
+            want = http.StatusOK
+        }
+        if rec.Code != want {
+            t.Errorf("AllowNoExpiry %v: status = %d, want %d", allow, rec.Code, want)
+        }
+    }
+}
+
+// signES builds a token for algorithm signed with key, naming kid.
+func signES(t *testing.T, algorithm, kid string, key *ecdsa.PrivateKey, newHash func() hash.Hash) string {
+    t.Helper()
+    encode := func(v any) string {
+        data, err := json.Marshal(v)
+        if err != nil {
+            t.Fatal(err)
+        }
+        return base64.RawURLEncoding.EncodeToString(data)
+    }
+    signed := encode(map[string]string{"alg": algorithm, "kid": kid}) + "." + encode(map[string]any{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+    digest := newHash()
+    digest.Write([]byte(signed))
+    r, s, err := ecdsa.Sign(rand.Reader, key, digest.Sum(nil))
+    if err != nil {
+        t.Fatal(err)
+    }
+    size := (key.Curve.Params().BitSize + 7) / 8
+    signature := make([]byte, 2*size)
+    r.FillBytes(signature[:size])
+    s.FillBytes(signature[size:])
+    return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// newJWKSServer publishes keys by kid and counts the requests it serves.
+func newJWKSServer(t *testing.T, keys map[string]*ecdsa.PrivateKey, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+    t.Helper()
+    var requests atomic.Int32
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        requests.Add(1)
+        time.Sleep(delay)
+        var set []map[string]string
+        for kid, key := range keys {
+            size := (key.Curve.Params().BitSize + 7) / 8
+            set = append(set, map[string]string{
+                "kty": "EC",
+                "kid": kid,
+                "crv": key.Curve.Params().Name,
+                "x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+                "y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+            })
+        }
+        json.NewEncoder(w).Encode(map[string]any{"keys": set})
+    }))
+    t.Cleanup(server.Close)
+    return server, &requests
+}
+
+func TestAuthenticatorESCurveMustMatchAlgorithm(t *testing.T) {
+    p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+    p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+    server, _ := newJWKSServer(t, map[string]*ecdsa.PrivateKey{"p256": p256, "p384": p384}, 0)
+    handler := NewAuthenticator(Options{JWKSURL: server.URL}).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte("backend"))
+    }))
+
+    tests := []struct {
+        name  string
+        token string
+        want  int
+    }{
+        {"ES256 with a P-256 key", signES(t, "ES256", "p256", p256, sha256.New), http.StatusOK},
+        {"ES384 with a P-384 key", signES(t, "ES384", "p384", p384, sha512.New384), http.StatusOK},
+        {"ES256 with a P-384 key", signES(t, "ES256", "p384", p384, sha256.New), http.StatusUnauthorized},
+        {"ES384 with a P-256 key", signES(t, "ES384", "p256", p256, sha512.New384), http.StatusUnauthorized},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            req := httptest.NewRequest(http.MethodGet, "/api/jobs", nil)
+            req.Header.Set("Authorization", "Bearer "+tt.token)
+            rec := httptest.NewRecorder()
+            handler.ServeHTTP(rec, req)
+            if rec.Code != tt.want {
+                t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+            }
+        })
+    }
+}
+
+func TestJWKSCacheFetchesOnceForConcurrentLookups(t *testing.T) {
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+    server, requests := newJWKSServer(t, map[string]*ecdsa.PrivateKey{"k1": key}, 100*time.Millisecond)
+    cache := newJWKSCache(server.URL, time.Hour)
+
+    var wg sync.WaitGroup
+    for i := 0; i < 20; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            if _, err := cache.key("k1"); err != nil {
+                t.Errorf("key: %v", err)
+            }
+        }()
+    }
+    wg.Wait()
+    if got := requests.Load(); got != 1 {
+        t.Errorf("JWKS fetched %d times for concurrent lookups, want 1", got)
+    }
+
+    // Known keys are served from the cache without waiting on a fetch
+    if _, err := cache.key("k1"); err != nil || requests.Load() != 1 {
+        t.Errorf("cached lookup: err %v, %d fetches", err, requests.Load())
+    }
+    // An unknown kid within the minimum fetch interval does not fetch again
+    if _, err := cache.key("k2"); err == nil || requests.Load() != 1 {
+        t.Errorf("unknown kid: err %v, %d fetches", err, requests.Load())
+    }
+}
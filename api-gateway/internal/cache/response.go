@@ -0,0 +1,239 @@
+package cache
+
+import (
+    "bytes"
+    "container/list"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Options configures gateway response caching.
+type Options struct {
+    // Paths are the path prefixes whose GET/HEAD responses may be cached; none disables caching.
+    Paths []string
+    TTL   time.Duration
+    // MaxEntries bounds how many responses are kept; the least recently used is evicted.
+    MaxEntries int
+    // MaxBodyBytes is the largest response body that is cached.
+    MaxBodyBytes int64
+    // CredentialHeaders name request headers that carry credentials besides
+    // Authorization and Cookie, such as the gateway's API key header.
+    CredentialHeaders []string
+}
+
+// entry is a cached response stored in the LRU list.
+type entry struct {
+    key     string
+    status  int
+    header  http.Header
+    body    []byte
+    expires time.Time
+}
+
+// ResponseCache caches successful idempotent responses in an LRU bounded by entry count.
+type ResponseCache struct {
+    opts    Options
+    mu      sync.Mutex
+    entries map[string]*list.Element
+    lru     *list.List
+    now     func() time.Time
+}
+
+// NewResponseCache creates a response cache.
+func NewResponseCache(opts Options) *ResponseCache {
+    if opts.MaxEntries < 1 {
+        opts.MaxEntries = 1000
+    }
+    return &ResponseCache{
+        opts:    opts,
+        entries: make(map[string]*list.Element),
+        lru:     list.New(),
+        now:     time.Now,
+    }
+}
+
+// Len returns the number of cached responses, including expired ones not yet evicted.
+func (c *ResponseCache) Len() int {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.lru.Len()
+}
+
+func (c *ResponseCache) get(key string) (*entry, bool) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    element, exists := c.entries[key]
+    if !exists {
+        return nil, false
+    }
+    cached := element.Value.(*entry)
+    if c.now().After(cached.expires) {
+        c.lru.Remove(element)
+        delete(c.entries, key)
+        return nil, false
+    }
+    c.lru.MoveToFront(element)
+    return cached, true
+}
+
+func (c *ResponseCache) set(cached *entry) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if element, exists := c.entries[cached.key]; exists {
+        element.Value = cached
+        c.lru.MoveToFront(element)
+        return
+    }
+
+    if c.lru.Len() >= c.opts.MaxEntries {
+        oldest := c.lru.Back()
+        c.lru.Remove(oldest)
+        delete(c.entries, oldest.Value.(*entry).key)
+    }
+    c.entries[cached.key] = c.lru.PushFront(cached)
+}
+
+// cacheable reports whether a request may be served from or stored in the cache.
+// Requests carrying credentials are never shared between clients.
+func (c *ResponseCache) cacheable(r *http.Request) bool {
+    if r.Method != http.MethodGet && r.Method != http.MethodHead {
+        return false
+    }
+    if r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != "" {
+        return false
+    }
+    for _, name := range c.opts.CredentialHeaders {
+        if r.Header.Get(name) != "" {
+            return false
+        }
+    }
+    for _, prefix := range c.opts.Paths {
+        if strings.HasPrefix(r.URL.Path, prefix) {
+            return true
+        }
+    }
+    return false
+}
+
+// cacheKey identifies a response by method, path, and query.
+func cacheKey(r *http.Request) string {
+    return r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery
+}
+
+// Middleware serves cached responses with X-Cache: HIT and stores 200 responses to
+// allowlisted GET/HEAD requests, marking them X-Cache: MISS. Responses the backend
+// marks no-store or private, or that vary by request headers, are not stored.
+// With no paths configured it returns next unchanged.
+func (c *ResponseCache) Middleware(next http.Handler) http.Handler {
+    if len(c.opts.Paths) == 0 || c.opts.TTL <= 0 {
+        return next
+    }
+
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if !c.cacheable(r) {
+            next.ServeHTTP(w, r)
+            return
+        }
+
+        key := cacheKey(r)
+        if cached, ok := c.get(key); ok {
+            header := w.Header()
+            for name, values := range cached.header {
+                header[name] = values
+            }
+            header.Set("X-Cache", "HIT")
+            w.WriteHeader(cached.status)
+            w.Write(cached.body)
+            return
+        }
+
+        // Headers already set by outer middleware (request ID, CORS) are per request
+        // and must not be replayed from the cache.
+        recorder := &responseRecorder{
+            ResponseWriter: w,
+            status:         http.StatusOK,
+            preset:         w.Header().Clone(),
+            maxBody:        c.opts.MaxBodyBytes,
+        }
+        w.Header().Set("X-Cache", "MISS")
+        next.ServeHTTP(recorder, r)
+
+        if !recorder.storable() {
+            return
+        }
+        c.set(&entry{
+            key:     key,
+            status:  recorder.status,
+            header:  recorder.header,
+            body:    recorder.body.Bytes(),
+            expires: c.now().Add(c.opts.TTL),
+        })
+    })
+}
+
+// responseRecorder passes a response through to the client while keeping a copy.
+type responseRecorder struct {
+    http.ResponseWriter
+    status      int
+    wroteHeader bool
+    preset      http.Header
+    header      http.Header
+    body        bytes.Buffer
+    maxBody     int64
+    tooLarge    bool
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+    if !rr.wroteHeader {
+        rr.wroteHeader = true
+        rr.status = status
+        rr.header = make(http.Header)
+        for name, values := range rr.ResponseWriter.Header() {
+            if _, preset := rr.preset[name]; !preset && name != "X-Cache" {
+                rr.header[name] = append([]string(nil), values...)
+            }
+        }
+    }
+    rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+    if !rr.wroteHeader {
+        rr.WriteHeader(http.StatusOK)
+    }
+    if !rr.tooLarge {
+        if rr.maxBody > 0 && int64(rr.body.Len()+len(b)) > rr.maxBody {
+            rr.tooLarge = true
+            rr.body.Reset()
+        } else {
+            rr.body.Write(b)
+        }
+    }
+    return rr.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer for flushing.
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+    return rr.ResponseWriter
+}
+
+// storable reports whether the recorded response may be cached.
+func (rr *responseRecorder) storable() bool {
+    if !rr.wroteHeader || rr.status != http.StatusOK || rr.tooLarge {
+        return false
+    }
+    if rr.header.Get("Vary") != "" || rr.header.Get("Set-Cookie") != "" {
+        return false
+    }
+    for _, directive := range strings.Split(strings.ToLower(rr.header.Get("Cache-Control")), ",") {
+        switch strings.TrimSpace(directive) {
+        case "no-store", "private":
+            return false
+        }
+    }
+    return true
+}
@@ -0,0 +1,146 @@
+package cache
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// countingBackend answers with the number of requests it has served so far.
+func countingBackend(calls *atomic.Int32, header http.Header) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        n := calls.Add(1)
+        for name, values := range header {
+            w.Header()[name] = values
+        }
+        w.Header().Set("Content-Type", "text/plain")
+        w.Write([]byte{byte('0' + n)})
+    })
+}
+
+// newTestCache caches /api/ for a minute on a clock the test advances.
+func newTestCache(maxEntries int) (*ResponseCache, *time.Time) {
+    now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+    c := NewResponseCache(Options{Paths: []string{"/api/"}, TTL: time.Minute, MaxEntries: maxEntries, CredentialHeaders: []string{"X-Gateway-Key"}})
+    c.now = func() time.Time { return now }
+    return c, &now
+}
+
+func serve(handler http.Handler, method, target string, headers map[string]string) *httptest.ResponseRecorder {
+    req := httptest.NewRequest(method, target, nil)
+    for name, value := range headers {
+        req.Header.Set(name, value)
+    }
+    rec := httptest.NewRecorder()
+    handler.ServeHTTP(rec, req)
+    return rec
+}
+
+func TestResponseCacheHitAndMiss(t *testing.T) {
+    c, _ := newTestCache(0)
+    var calls atomic.Int32
+    handler := c.Middleware(countingBackend(&calls, nil))
+
+    first := serve(handler, http.MethodGet, "/api/jobs?page=1", nil)
+    if got := first.Header().Get("X-Cache"); got != "MISS" || first.Body.String() != "1" {
+        t.Fatalf("first request = %s %q, want MISS from the backend", got, first.Body.String())
+    }
+    second := serve(handler, http.MethodGet, "/api/jobs?page=1", nil)
+    if got := second.Header().Get("X-Cache"); got != "HIT" || second.Body.String() != "1" {
+        t.Errorf("second request = %s %q, want HIT with the first body", got, second.Body.String())
+    }
+    if second.Header().Get("Content-Type") != "text/plain" {
+        t.Error("cached response lost the backend's headers")
+    }
+
+    // A different query or method is a different key
+    if rec := serve(handler, http.MethodGet, "/api/jobs?page=2", nil); rec.Header().Get("X-Cache") != "MISS" {
+        t.Error("different query was served from the cache")
+    }
+    if rec := serve(handler, http.MethodHead, "/api/jobs?page=1", nil); rec.Header().Get("X-Cache") != "MISS" {
+        t.Error("HEAD was served from the GET entry")
+    }
+    if calls.Load() != 3 {
+        t.Errorf("backend served %d requests, want 3", calls.Load())
+    }
+}
+
+func TestResponseCacheBypass(t *testing.T) {
+    tests := []struct {
+        name    string
+        method  string
+        target  string
+        headers map[string]string
+        backend http.Header
+    }{
+        {name: "POST", method: http.MethodPost, target: "/api/jobs"},
+        {name: "path not allowlisted", method: http.MethodGet, target: "/admin/jobs"},
+        {name: "authorization", method: http.MethodGet, target: "/api/jobs", headers: map[string]string{"Authorization": "Bearer token"}},
+        {name: "cookie", method: http.MethodGet, target: "/api/jobs", headers: map[string]string{"Cookie": "session=1"}},
+        {name: "API key", method: http.MethodGet, target: "/api/jobs", headers: map[string]string{"X-Gateway-Key": "key-1"}},
+        {name: "no-store", method: http.MethodGet, target: "/api/jobs", backend: http.Header{"Cache-Control": {"no-store"}}},
+        {name: "private", method: http.MethodGet, target: "/api/jobs", backend: http.Header{"Cache-Control": {"max-age=60, private"}}},
+        {name: "vary", method: http.MethodGet, target: "/api/jobs", backend: http.Header{"Vary": {"Accept-Language"}}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            c, _ := newTestCache(0)
+            var calls atomic.Int32
+            handler := c.Middleware(countingBackend(&calls, tt.backend))
+
+            serve(handler, tt.method, tt.target, tt.headers)
+            rec := serve(handler, tt.method, tt.target, tt.headers)
+            if rec.Header().Get("X-Cache") == "HIT" || calls.Load() != 2 {
+                t.Errorf("second request was a cache hit (backend calls %d)", calls.Load())
+            }
+            if c.Len() != 0 {
+                t.Errorf("cache holds %d entries, want 0", c.Len())
+            }
+        })
+    }
+}
+
+func TestResponseCacheTTLExpiry(t *testing.T) {
+    c, now := newTestCache(0)
+    var calls atomic.Int32
+    handler := c.Middleware(countingBackend(&calls, nil))
+
+    serve(handler, http.MethodGet, "/api/jobs", nil)
+    *now = now.Add(time.Minute)
+    if rec := serve(handler, http.MethodGet, "/api/jobs", nil); rec.Header().Get("X-Cache") != "HIT" {
+        t.Error("entry expired at exactly its TTL")
+    }
+
+    *now = now.Add(time.Second)
+    rec := serve(handler, http.MethodGet, "/api/jobs", nil)
+    if rec.Header().Get("X-Cache") != "MISS" || rec.Body.String() != "2" {
+        t.Errorf("after the TTL = %s %q, want a MISS refetched from the backend", rec.Header().Get("X-Cache"), rec.Body.String())
+    }
+    // The refetched response is cached afresh
+    if rec := serve(handler, http.MethodGet, "/api/jobs", nil); rec.Header().Get("X-Cache") != "HIT" || rec.Body.String() != "2" {
+        t.Errorf("refetched response = %s %q, want a HIT on the new body", rec.Header().Get("X-Cache"), rec.Body.String())
+    }
+}
+
+func TestResponseCacheEvictsLeastRecentlyUsed(t *testing.T) {
+    c, _ := newTestCache(2)
+    var calls atomic.Int32
+    handler := c.Middleware(countingBackend(&calls, nil))
+
+    serve(handler, http.MethodGet, "/api/a", nil)
+    serve(handler, http.MethodGet, "/api/b", nil)
+    serve(handler, http.MethodGet, "/api/a", nil) // a is now more recent than b
+    serve(handler, http.MethodGet, "/api/c", nil)
+
+    if c.Len() != 2 {
+        t.Errorf("cache holds %d entries, want 2", c.Len())
+    }
+    if rec := serve(handler, http.MethodGet, "/api/a", nil); rec.Header().Get("X-Cache") != "HIT" {
+        t.Error("recently used entry was evicted")
+    }
+    if rec := serve(handler, http.MethodGet, "/api/b", nil); rec.Header().Get("X-Cache") != "MISS" {
+        t.Error("least recently used entry was kept")
+    }
+}
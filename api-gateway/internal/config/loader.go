@@ -1,62 +1,235 @@
 package config
 
 import (
+    "encoding/json"
+    "fmt"
     "log"
     "os"
     "path/filepath"
-    "strconv"
     "strings"
 
     "github.com/joho/godotenv"
 )
 
+// Route maps a path prefix to a backend. StripPrefix removes the prefix
+// before the request is forwarded.
+type Route struct {
+    Prefix      string `json:"prefix"`
+    Target      string `json:"target"`
+    StripPrefix bool   `json:"strip_prefix"`
+}
+
 // Config holds all configuration for the application.
 type Config struct {
     GatewayPort   string
     BackendTarget string
+    // Path-based routes; BackendTarget handles anything that doesn't match
+    Routes []Route
+    // Request hardening settings
+    GatewayMaxRequestBytes int64
+    GatewayReadTimeoutMS   int
+    GatewayWriteTimeoutMS  int
+    GatewayIdleTimeoutMS   int
+    // CORS settings; no origins disables CORS handling
+    CORSOrigins          []string
+    CORSMethods          []string
+    CORSHeaders          []string
+    CORSAllowCredentials bool
+    CORSMaxAgeSeconds    int
+    // Per-client rate limiting; a non-positive RPS disables it
+    RateLimitRPS        float64
+    RateLimitBurst      int
+    RateLimitMaxClients int
+    TrustedProxies      []string
+    // Authentication; with no secret, JWKS URL, or API keys every request is let through
+    AuthJWTSecret     string
+    AuthJWKSURL       string
+    AuthJWKSRefreshMS int
+    AuthIssuer        string
+    AuthAudience      string
+    AuthAllowNoExpiry bool
+    AuthAPIKeys       []string
+    AuthAPIKeyHeader  string
+    AuthExemptPaths   []string
+    // Header rules for proxied traffic: added to and removed from backend
+    // requests, and removed from backend responses
+    RequestHeadersAdd     map[string]string
+    RequestHeadersRemove  []string
+    ResponseHeadersRemove []string
+    // Retries of transient backend failures for idempotent methods; off by default, since
+    // a retried request reaches the backend more than once
+    ProxyRetries           int
+    ProxyRetryBackoffMS    int
+    ProxyRetryMaxBodyBytes int64
+    // Response caching for GET/HEAD; no paths disables it
+    CachePaths        []string
+    CacheTTLMS        int
+    CacheMaxEntries   int
+    CacheMaxBodyBytes int64
+    // Health check settings
+    HealthTimeoutMS   int
+    BackendHealthPath string
+    // TLS termination; both cert and key must be set to enable HTTPS
+    TLSCertFile      string
+    TLSKeyFile       string
+    HTTPRedirectPort string
+    // Metrics endpoint; an empty token leaves it unauthenticated
+    MetricsPath  string
+    MetricsToken string
     // Logging configuration
     LogFormat        string
     LogLevel         string
     LogIngestEnabled bool
     LogIngestURL     string
+    // Valid destinations parsed from the comma-separated LogIngestURL
+    LogIngestURLs []string
+    // JSON keys for each ingested record's time, level, and message
+    LogIngestTimeKey    string
+    LogIngestLevelKey   string
+    LogIngestMessageKey string
+    // Credential sent with each ingestion POST; never logged
+    LogIngestAuthHeader string
+    LogIngestAuthToken  string
     // Anti-blocking resilience settings
     LogIngestTimeoutMS          int
     LogIngestQueueSize          int
+    LogIngestWorkers            int
     LogIngestRetryAttempts      int
     LogIngestLatencyThresholdMS int
     LogIngestFailureThreshold   int
+    LogIngestMaxRetryAfterMS    int
     LogIngestDropPolicy         string
+    // Fraction of DEBUG/INFO records forwarded to the ingest endpoint (0.0-1.0)
+    LogIngestSampleDebug float64
+    LogIngestSampleInfo  float64
+    // Optional JSONL file for records dropped while the circuit is open or the queue is full
+    LogIngestDLQPath      string
+    LogIngestDLQMaxBytes  int64
+    LogIngestDLQReplay    bool
+    LogIngestDLQReplayMax int
 }
 
 var appConfig Config
 
 // LoadEnv loads environment variables and populates the appConfig struct.
-func LoadEnv() {
+// Invalid optional values fall back to their defaults with a warning; critical
+// misconfigurations (ports, backend URL, routes) are returned as an error.
+func LoadEnv() error {
     loadDotEnv()
 
-    ingestEnabled, _ := strconv.ParseBool(getEnv("LOG_INGEST_ENABLED", "false"))
-    timeout, _ := strconv.Atoi(getEnv("LOG_INGEST_TIMEOUT_MS", "2000"))
-    queueSize, _ := strconv.Atoi(getEnv("LOG_INGEST_QUEUE_SIZE", "1000"))
-    retries, _ := strconv.Atoi(getEnv("LOG_INGEST_RETRY_ATTEMPTS", "3"))
-    latencyThreshold, _ := strconv.Atoi(getEnv("LOG_INGEST_LATENCY_THRESHOLD_MS", "1000"))
-    failureThreshold, _ := strconv.Atoi(getEnv("LOG_INGEST_FAILURE_THRESHOLD", "5"))
+    env := &envLoader{}
+    routes, err := loadRoutes()
+    if err != nil {
+        env.fail("invalid routes: %v", err)
+    }
+    requestHeadersAdd, err := loadHeaderMap("GATEWAY_REQUEST_HEADERS_ADD")
+    if err != nil {
+        env.fail("invalid request headers: %v", err)
+    }
 
     appConfig = Config{
-        GatewayPort:                 getEnv("GATEWAY_PORT", "8000"),
-        BackendTarget:               getEnv("GATEWAY_BACKEND_TARGET", "http://localhost:8048"),
-        LogFormat:                   strings.ToLower(getEnv("LOG_FORMAT", "text")),
-        LogLevel:                    strings.ToUpper(getEnv("LOG_LEVEL", "INFO")),
-        LogIngestEnabled:            ingestEnabled,
+        GatewayPort:                 env.portEnv("GATEWAY_PORT", "8000"),
+        BackendTarget:               env.urlEnv("GATEWAY_BACKEND_TARGET", "http://localhost:8048"),
+        Routes:                      routes,
+        GatewayMaxRequestBytes:      env.int64Env("GATEWAY_MAX_REQUEST_BYTES", 10485760, 0),
+        GatewayReadTimeoutMS:        env.intEnv("GATEWAY_READ_TIMEOUT_MS", 15000, 1),
+        GatewayWriteTimeoutMS:       env.intEnv("GATEWAY_WRITE_TIMEOUT_MS", 30000, 1),
+        GatewayIdleTimeoutMS:        env.intEnv("GATEWAY_IDLE_TIMEOUT_MS", 60000, 1),
+        CORSOrigins:                 splitList(getEnv("GATEWAY_CORS_ORIGINS", "")),
+        CORSMethods:                 splitList(getEnv("GATEWAY_CORS_METHODS", "GET,POST,PUT,PATCH,DELETE,OPTIONS")),
+        CORSHeaders:                 splitList(getEnv("GATEWAY_CORS_HEADERS", "Authorization,Content-Type,X-Request-ID")),
+        CORSAllowCredentials:        env.boolEnv("GATEWAY_CORS_CREDENTIALS", false),
+        CORSMaxAgeSeconds:           env.intEnv("GATEWAY_CORS_MAX_AGE", 600, 0),
+        RateLimitRPS:                env.floatEnv("GATEWAY_RATE_LIMIT_RPS", 100, 0),
+        RateLimitBurst:              env.intEnv("GATEWAY_RATE_LIMIT_BURST", 200, 1),
+        RateLimitMaxClients:         env.intEnv("GATEWAY_RATE_LIMIT_MAX_CLIENTS", 10000, 1),
+        TrustedProxies:              splitList(getEnv("GATEWAY_TRUSTED_PROXIES", "")),
+        AuthJWTSecret:               getEnv("GATEWAY_AUTH_JWT_SECRET", ""),
+        AuthJWKSURL:                 getEnv("GATEWAY_AUTH_JWKS_URL", ""),
+        AuthJWKSRefreshMS:           env.intEnv("GATEWAY_AUTH_JWKS_REFRESH_MS", 3600000, 1),
+        AuthIssuer:                  getEnv("GATEWAY_AUTH_ISSUER", ""),
+        AuthAudience:                getEnv("GATEWAY_AUTH_AUDIENCE", ""),
+        AuthAllowNoExpiry:           env.boolEnv("GATEWAY_AUTH_ALLOW_NO_EXP", false),
+        AuthAPIKeys:                 splitList(getEnv("GATEWAY_AUTH_API_KEYS", "")),
+        AuthAPIKeyHeader:            getEnv("GATEWAY_AUTH_API_KEY_HEADER", "X-API-Key"),
+        AuthExemptPaths:             splitList(getEnv("GATEWAY_AUTH_EXEMPT_PATHS", "/health")),
+        RequestHeadersAdd:           requestHeadersAdd,
+        RequestHeadersRemove:        splitList(getEnv("GATEWAY_REQUEST_HEADERS_REMOVE", "")),
+        ResponseHeadersRemove:       splitList(getEnv("GATEWAY_RESPONSE_HEADERS_REMOVE", "")),
+        ProxyRetries:                env.intEnv("GATEWAY_PROXY_RETRIES", 0, 0),
+        ProxyRetryBackoffMS:         env.intEnv("GATEWAY_PROXY_RETRY_BACKOFF_MS", 100, 1),
+        ProxyRetryMaxBodyBytes:      env.int64Env("GATEWAY_PROXY_RETRY_MAX_BODY_BYTES", 1048576, 0),
+        CachePaths:                  splitList(getEnv("GATEWAY_CACHE_PATHS", "")),
+        CacheTTLMS:                  env.intEnv("GATEWAY_CACHE_TTL_MS", 60000, 1),
+        CacheMaxEntries:             env.intEnv("GATEWAY_CACHE_MAX_ENTRIES", 1000, 1),
+        CacheMaxBodyBytes:           env.int64Env("GATEWAY_CACHE_MAX_BODY_BYTES", 1048576, 1),
+        HealthTimeoutMS:             env.intEnv("GATEWAY_HEALTH_TIMEOUT_MS", 2000, 1),
+        BackendHealthPath:           getEnv("GATEWAY_BACKEND_HEALTH_PATH", "/health"),
+        TLSCertFile:                 getEnv("GATEWAY_TLS_CERT", ""),
+        TLSKeyFile:                  getEnv("GATEWAY_TLS_KEY", ""),
+        HTTPRedirectPort:            env.portEnv("GATEWAY_HTTP_REDIRECT_PORT", ""),
+        MetricsPath:                 getEnv("GATEWAY_METRICS_PATH", "/metrics"),
+        MetricsToken:                getEnv("GATEWAY_METRICS_TOKEN", ""),
+        LogFormat:                   env.oneOfEnv("LOG_FORMAT", "text", strings.ToLower, "text", "json"),
+        LogLevel:                    env.oneOfEnv("LOG_LEVEL", "INFO", strings.ToUpper, "DEBUG", "INFO", "WARN", "ERROR"),
+        LogIngestEnabled:            env.boolEnv("LOG_INGEST_ENABLED", false),
         LogIngestURL:                getEnv("LOG_INGEST_URL", ""),
-        LogIngestTimeoutMS:          timeout,
-        LogIngestQueueSize:          queueSize,
-        LogIngestRetryAttempts:      retries,
-        LogIngestLatencyThresholdMS: latencyThreshold,
-        LogIngestFailureThreshold:   failureThreshold,
-        LogIngestDropPolicy:         strings.ToLower(getEnv("LOG_INGEST_DROP_POLICY", "newest")),
+        LogIngestTimeKey:            getEnv("LOG_INGEST_TIME_KEY", "time"),
+        LogIngestLevelKey:           getEnv("LOG_INGEST_LEVEL_KEY", "level"),
+        LogIngestMessageKey:         getEnv("LOG_INGEST_MESSAGE_KEY", "msg"),
+        LogIngestAuthHeader:         getEnv("LOG_INGEST_AUTH_HEADER", "Authorization"),
+        LogIngestAuthToken:          getEnv("LOG_INGEST_AUTH_TOKEN", ""),
+        LogIngestTimeoutMS:          env.intEnv("LOG_INGEST_TIMEOUT_MS", 2000, 1),
+        LogIngestQueueSize:          env.intEnv("LOG_INGEST_QUEUE_SIZE", 1000, 1),
+        LogIngestWorkers:            env.intEnv("LOG_INGEST_WORKERS", 4, 1),
+        LogIngestRetryAttempts:      env.intEnv("LOG_INGEST_RETRY_ATTEMPTS", 3, 1),
+        LogIngestLatencyThresholdMS: env.intEnv("LOG_INGEST_LATENCY_THRESHOLD_MS", 1000, 1),
+        LogIngestFailureThreshold:   env.intEnv("LOG_INGEST_FAILURE_THRESHOLD", 5, 1),
+        LogIngestMaxRetryAfterMS:    env.intEnv("LOG_INGEST_MAX_RETRY_AFTER_MS", 300000, 1),
+        LogIngestDropPolicy:         env.oneOfEnv("LOG_INGEST_DROP_POLICY", "newest", strings.ToLower, "newest", "oldest"),
+        LogIngestSampleDebug:        env.rateEnv("LOG_INGEST_SAMPLE_DEBUG", 1),
+        LogIngestSampleInfo:         env.rateEnv("LOG_INGEST_SAMPLE_INFO", 1),
+        LogIngestDLQPath:            getEnv("LOG_INGEST_DLQ_PATH", ""),
+        LogIngestDLQMaxBytes:        env.int64Env("LOG_INGEST_DLQ_MAX_BYTES", 10485760, 1),
+        LogIngestDLQReplay:          env.boolEnv("LOG_INGEST_DLQ_REPLAY", false),
+        LogIngestDLQReplayMax:       env.intEnv("LOG_INGEST_DLQ_REPLAY_MAX", 1000, 1),
+    }
+
+    if appConfig.AuthJWKSURL != "" {
+        // Auth is a security boundary, so a bad JWKS URL must not silently disable it.
+        if err := validateHTTPURL(appConfig.AuthJWKSURL); err != nil {
+            env.fail("GATEWAY_AUTH_JWKS_URL=%q is not a valid URL: %v", appConfig.AuthJWKSURL, err)
+        }
+    }
+    if !strings.HasPrefix(appConfig.MetricsPath, "/") {
+        env.fail("GATEWAY_METRICS_PATH=%q must start with '/'", appConfig.MetricsPath)
+    }
+    if keys := []string{appConfig.LogIngestTimeKey, appConfig.LogIngestLevelKey, appConfig.LogIngestMessageKey}; keys[0] == "" || keys[1] == "" || keys[2] == "" ||
+        keys[0] == keys[1] || keys[0] == keys[2] || keys[1] == keys[2] {
+        log.Printf("Warning: LOG_INGEST_TIME_KEY, LOG_INGEST_LEVEL_KEY, and LOG_INGEST_MESSAGE_KEY must be distinct and non-empty (got %q, %q, %q); using time, level, msg.", keys[0], keys[1], keys[2])
+        appConfig.LogIngestTimeKey, appConfig.LogIngestLevelKey, appConfig.LogIngestMessageKey = "time", "level", "msg"
+    }
+    if appConfig.LogIngestEnabled {
+        // Log shipping is optional, so skip bad destinations rather than refuse to start.
+        for _, destination := range splitList(appConfig.LogIngestURL) {
+            if err := validateHTTPURL(destination); err != nil {
+                log.Printf("Warning: LOG_INGEST_URL entry %q is not a valid URL (%v); skipping it.", destination, err)
+                continue
+            }
+            appConfig.LogIngestURLs = append(appConfig.LogIngestURLs, destination)
+        }
+        if len(appConfig.LogIngestURLs) == 0 {
+            log.Printf("Warning: LOG_INGEST_URL=%q has no valid URL; disabling log ingestion.", appConfig.LogIngestURL)
+            appConfig.LogIngestEnabled = false
+        }
+    }
+
+    if err := env.err(); err != nil {
+        return err
     }
 
     log.Println("✅ Configuration loaded.")
+    return nil
 }
 
 // ... (Get, getEnv, loadDotEnv, findProjectRoot functions remain the same) ...
@@ -64,6 +237,67 @@ func Get() Config {
     return appConfig
 }
 
+// loadRoutes reads the routing table from GATEWAY_ROUTES (inline JSON) or
+// GATEWAY_ROUTES_FILE (path to a JSON file). Inline routes take precedence.
+func loadRoutes() ([]Route, error) {
+    raw := getEnv("GATEWAY_ROUTES", "")
+    source := "GATEWAY_ROUTES"
+    if raw == "" {
+        routesFile := getEnv("GATEWAY_ROUTES_FILE", "")
+        if routesFile == "" {
+            return nil, nil
+        }
+        data, err := os.ReadFile(routesFile)
+        if err != nil {
+            return nil, fmt.Errorf("could not read routes file %s: %w", routesFile, err)
+        }
+        raw = string(data)
+        source = routesFile
+    }
+
+    var routes []Route
+    if err := json.Unmarshal([]byte(raw), &routes); err != nil {
+        return nil, fmt.Errorf("could not parse routes from %s: %w", source, err)
+    }
+    for _, route := range routes {
+        if err := validateHTTPURL(route.Target); err != nil {
+            return nil, fmt.Errorf("route %s target %q: %w", route.Prefix, route.Target, err)
+        }
+    }
+    return routes, nil
+}
+
+// loadHeaderMap parses a JSON object of header names to values, e.g.
+// {"X-Internal-Token": "secret"}. An unset variable yields no headers.
+func loadHeaderMap(key string) (map[string]string, error) {
+    raw := getEnv(key, "")
+    if raw == "" {
+        return nil, nil
+    }
+
+    var headers map[string]string
+    if err := json.Unmarshal([]byte(raw), &headers); err != nil {
+        return nil, fmt.Errorf("could not parse %s as a JSON object: %w", key, err)
+    }
+    for name := range headers {
+        if strings.TrimSpace(name) == "" || strings.ContainsAny(name, " :\r\n") {
+            return nil, fmt.Errorf("%s: invalid header name %q", key, name)
+        }
+    }
+    return headers, nil
+}
+
+// splitList parses a comma-separated env value, dropping empty entries.
+func splitList(value string) []string {
+    var items []string
+    for _, item := range strings.Split(value, ",") {
+        if item = strings.TrimSpace(item); item != "" {
+            items = append(items, item)
+        }
+    }
+    return items
+}
+
 func getEnv(key, fallback string) string {
     if value, ok := os.LookupEnv(key); ok {
         return value
@@ -0,0 +1,163 @@
+package config
+
+import (
+    "io"
+    "log"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+// useEnv isolates LoadEnv from the developer's .env file and sets the given variables.
+func useEnv(t *testing.T, vars map[string]string) {
+    t.Helper()
+    envFile := filepath.Join(t.TempDir(), ".env")
+    if err := os.WriteFile(envFile, nil, 0644); err != nil {
+        t.Fatal(err)
+    }
+    t.Setenv("ENV_FILE_PATH", envFile)
+    for key, value := range vars {
+        t.Setenv(key, value)
+    }
+
+    log.SetOutput(io.Discard)
+    t.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+func TestLoadEnvRejectsCriticalMisconfiguration(t *testing.T) {
+    tests := []struct {
+        name    string
+        vars    map[string]string
+        wantErr string
+    }{
+        {"non-numeric port", map[string]string{"GATEWAY_PORT": "http"}, `GATEWAY_PORT="http" is not a valid port`},
+        {"port out of range", map[string]string{"GATEWAY_PORT": "70000"}, "GATEWAY_PORT"},
+        {"redirect port", map[string]string{"GATEWAY_HTTP_REDIRECT_PORT": "0"}, "GATEWAY_HTTP_REDIRECT_PORT"},
+        {"backend scheme", map[string]string{"GATEWAY_BACKEND_TARGET": "ftp://backend"}, "scheme must be http or https"},
+        {"backend host", map[string]string{"GATEWAY_BACKEND_TARGET": "http://"}, "missing host"},
+        {"routes JSON", map[string]string{"GATEWAY_ROUTES": `{"prefix": "/api"}`}, "could not parse routes from GATEWAY_ROUTES"},
+        {"route target", map[string]string{"GATEWAY_ROUTES": `[{"prefix": "/api", "target": "backend:9000"}]`}, `route /api target "backend:9000"`},
+        {"routes file", map[string]string{"GATEWAY_ROUTES_FILE": "/nonexistent/routes.json"}, "could not read routes file"},
+        {"header map", map[string]string{"GATEWAY_REQUEST_HEADERS_ADD": `["X-Token"]`}, "GATEWAY_REQUEST_HEADERS_ADD"},
+        {"header name", map[string]string{"GATEWAY_REQUEST_HEADERS_ADD": `{"Bad Header": "x"}`}, `invalid header name "Bad Header"`},
+        {"JWKS URL", map[string]string{"GATEWAY_AUTH_JWKS_URL": "not a url"}, "GATEWAY_AUTH_JWKS_URL"},
+        {"metrics path", map[string]string{"GATEWAY_METRICS_PATH": "metrics"}, "GATEWAY_METRICS_PATH"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            useEnv(t, tt.vars)
+            err := LoadEnv()
+            if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                t.Errorf("LoadEnv() error = %v, want it to mention %q", err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestLoadEnvReportsEveryCriticalError(t *testing.T) {
+    useEnv(t, map[string]string{"GATEWAY_PORT": "x", "GATEWAY_BACKEND_TARGET": "backend"})
+    err := LoadEnv()
+    if err == nil || !strings.Contains(err.Error(), "GATEWAY_PORT") || !strings.Contains(err.Error(), "GATEWAY_BACKEND_TARGET") {
+        t.Errorf("LoadEnv() error = %v, want both misconfigurations", err)
+    }
+}
+
+func TestLoadEnvFallsBackOnInvalidOptionalValues(t *testing.T) {
+    useEnv(t, map[string]string{
+        "GATEWAY_READ_TIMEOUT_MS":   "soon",
+        "GATEWAY_MAX_REQUEST_BYTES": "-1",
+        "GATEWAY_RATE_LIMIT_RPS":    "-5",
+        "GATEWAY_CORS_CREDENTIALS":  "sometimes",
+        "LOG_FORMAT":                "xml",
+        "LOG_LEVEL":                 "warn",
+        "LOG_INGEST_SAMPLE_INFO":    "1.5",
+        "LOG_INGEST_SAMPLE_DEBUG":   "0.25",
+        "LOG_INGEST_TIME_KEY":       "msg",
+        "GATEWAY_PROXY_RETRIES":     "0",
+    })
+    if err := LoadEnv(); err != nil {
+        t.Fatalf("LoadEnv() = %v", err)
+    }
+    cfg := Get()
+
+    if cfg.GatewayReadTimeoutMS != 15000 || cfg.GatewayMaxRequestBytes != 10485760 || cfg.RateLimitRPS != 100 || cfg.CORSAllowCredentials {
+        t.Errorf("invalid numbers did not fall back to defaults: %+v", cfg)
+    }
+    if cfg.LogFormat != "text" {
+        t.Errorf("LogFormat = %q, want text", cfg.LogFormat)
+    }
+    // Valid values are normalized
+    if cfg.LogLevel != "WARN" {
+        t.Errorf("LogLevel = %q, want WARN", cfg.LogLevel)
+    }
+    if cfg.LogIngestSampleInfo != 1 || cfg.LogIngestSampleDebug != 0.25 {
+        t.Errorf("sample rates = %g/%g, want 1/0.25", cfg.LogIngestSampleInfo, cfg.LogIngestSampleDebug)
+    }
+    if cfg.LogIngestTimeKey != "time" || cfg.LogIngestLevelKey != "level" || cfg.LogIngestMessageKey != "msg" {
+        t.Errorf("clashing ingest keys were kept: %q %q %q", cfg.LogIngestTimeKey, cfg.LogIngestLevelKey, cfg.LogIngestMessageKey)
+    }
+    // A minimum of 0 allows disabling retries
+    if cfg.ProxyRetries != 0 {
+        t.Errorf("ProxyRetries = %d, want 0", cfg.ProxyRetries)
+    }
+}
+
+func TestLoadEnvProxyRetriesAreOptIn(t *testing.T) {
+    for value, want := range map[string]int{"": 0, "-1": 0, "3": 3} {
+        useEnv(t, map[string]string{"GATEWAY_PROXY_RETRIES": value})
+        if err := LoadEnv(); err != nil {
+            t.Fatalf("LoadEnv() = %v", err)
+        }
+        if got := Get().ProxyRetries; got != want {
+            t.Errorf("GATEWAY_PROXY_RETRIES=%q: ProxyRetries = %d, want %d", value, got, want)
+        }
+    }
+}
+
+func TestLoadEnvSkipsInvalidIngestURLs(t *testing.T) {
+    useEnv(t, map[string]string{
+        "LOG_INGEST_ENABLED": "true",
+        "LOG_INGEST_URL":     "http://collector-a/logs, collector-b/logs ,https://collector-c/logs",
+    })
+    if err := LoadEnv(); err != nil {
+        t.Fatalf("LoadEnv() = %v", err)
+    }
+    cfg := Get()
+    if !cfg.LogIngestEnabled || strings.Join(cfg.LogIngestURLs, ",") != "http://collector-a/logs,https://collector-c/logs" {
+        t.Errorf("ingest = %t %v", cfg.LogIngestEnabled, cfg.LogIngestURLs)
+    }
+
+    useEnv(t, map[string]string{"LOG_INGEST_ENABLED": "true", "LOG_INGEST_URL": "collector/logs"})
+    if err := LoadEnv(); err != nil {
+        t.Fatalf("LoadEnv() = %v", err)
+    }
+    if cfg := Get(); cfg.LogIngestEnabled {
+        t.Errorf("ingest stayed enabled with no valid URL: %v", cfg.LogIngestURLs)
+    }
+}
+
+func TestLoadEnvRoutes(t *testing.T) {
+    routesFile := filepath.Join(t.TempDir(), "routes.json")
+    os.WriteFile(routesFile, []byte(`[{"prefix": "/file", "target": "http://file-backend"}]`), 0644)
+
+    // Inline routes take precedence over the file
+    useEnv(t, map[string]string{
+        "GATEWAY_ROUTES_FILE": routesFile,
+        "GATEWAY_ROUTES":      `[{"prefix": "/api", "target": "http://api:9000", "strip_prefix": true}]`,
+    })
+    if err := LoadEnv(); err != nil {
+        t.Fatalf("LoadEnv() = %v", err)
+    }
+    if routes := Get().Routes; len(routes) != 1 || routes[0] != (Route{Prefix: "/api", Target: "http://api:9000", StripPrefix: true}) {
+        t.Errorf("routes = %+v", routes)
+    }
+
+    useEnv(t, map[string]string{"GATEWAY_ROUTES_FILE": routesFile, "GATEWAY_ROUTES": ""})
+    if err := LoadEnv(); err != nil {
+        t.Fatalf("LoadEnv() = %v", err)
+    }
+    if routes := Get().Routes; len(routes) != 1 || routes[0].Prefix != "/file" {
+        t.Errorf("routes = %+v", routes)
+    }
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "net/url"
+    "strconv"
+    "strings"
+)
+
+// envLoader parses environment values. Invalid non-critical values are logged and
+// replaced with their defaults; critical misconfigurations are collected as errors.
+type envLoader struct {
+    errs []error
+}
+
+// fail records a critical misconfiguration that should stop startup.
+func (l *envLoader) fail(format string, args ...any) {
+    l.errs = append(l.errs, fmt.Errorf(format, args...))
+}
+
+// err returns all critical misconfigurations, or nil if there were none.
+func (l *envLoader) err() error {
+    return errors.Join(l.errs...)
+}
+
+func warnDefault(key, value, requirement string, fallback any) {
+    log.Printf("Warning: Invalid %s=%q (%s); using default %v.", key, value, requirement, fallback)
+}
+
+// intEnv parses an integer that must be at least min.
+func (l *envLoader) intEnv(key string, fallback, min int) int {
+    value, ok := lookupEnv(key)
+    if !ok {
+        return fallback
+    }
+    parsed, err := strconv.Atoi(value)
+    if err != nil || parsed < min {
+        warnDefault(key, value, fmt.Sprintf("must be an integer >= %d", min), fallback)
+        return fallback
+    }
+    return parsed
+}
+
+// int64Env parses a 64-bit integer that must be at least min.
+func (l *envLoader) int64Env(key string, fallback, min int64) int64 {
+    value, ok := lookupEnv(key)
+    if !ok {
+        return fallback
+    }
+    parsed, err := strconv.ParseInt(value, 10, 64)
+    if err != nil || parsed < min {
+        warnDefault(key, value, fmt.Sprintf("must be an integer >= %d", min), fallback)
+        return fallback
+    }
+    return parsed
+}
+
+// floatEnv parses a number that must be at least min.
+func (l *envLoader) floatEnv(key string, fallback, min float64) float64 {
+    value, ok := lookupEnv(key)
+    if !ok {
+        return fallback
+    }
+    parsed, err := strconv.ParseFloat(value, 64)
+    if err != nil || parsed < min {
+        warnDefault(key, value, fmt.Sprintf("must be a number >= %g", min), fallback)
+        return fallback
+    }
+    return parsed
+}
+
+// rateEnv parses a sampling fraction between 0 and 1.
+func (l *envLoader) rateEnv(key string, fallback float64) float64 {
+    value, ok := lookupEnv(key)
+    if !ok {
+        return fallback
+    }
+    parsed, err := strconv.ParseFloat(value, 64)
+    if err != nil || parsed < 0 || parsed > 1 {
+        warnDefault(key, value, "must be a number between 0 and 1", fallback)
+        return fallback
+    }
+    return parsed
+}
+
+// boolEnv parses a boolean.
+func (l *envLoader) boolEnv(key string, fallback bool) bool {
+    value, ok := lookupEnv(key)
+    if !ok {
+        return fallback
+    }
+    parsed, err := strconv.ParseBool(value)
+    if err != nil {
+        warnDefault(key, value, "must be true or false", fallback)
+        return fallback
+    }
+    return parsed
+}
+
+// oneOfEnv returns the normalized value if it is one of allowed.
+func (l *envLoader) oneOfEnv(key, fallback string, normalize func(string) string, allowed ...string) string {
+    value := normalize(getEnv(key, fallback))
+    for _, candidate := range allowed {
+        if value == candidate {
+            return value
+        }
+    }
+    warnDefault(key, value, "must be one of "+strings.Join(allowed, ", "), fallback)
+    return fallback
+}
+
+// portEnv validates a TCP port. Ports are critical since the server can't start without one.
+func (l *envLoader) portEnv(key, fallback string) string {
+    value := getEnv(key, fallback)
+    if value == "" {
+        return value
+    }
+    if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+        l.fail("%s=%q is not a valid port", key, value)
+    }
+    return value
+}
+
+// urlEnv validates an absolute http(s) URL.
+func (l *envLoader) urlEnv(key, fallback string) string {
+    value := getEnv(key, fallback)
+    if err := validateHTTPURL(value); err != nil {
+        l.fail("%s=%q is not a valid URL: %v", key, value, err)
+    }
+    return value
+}
+
+func validateHTTPURL(value string) error {
+    parsed, err := url.Parse(value)
+    if err != nil {
+        return err
+    }
+    if parsed.Scheme != "http" && parsed.Scheme != "https" {
+        return errors.New("scheme must be http or https")
+    }
+    if parsed.Host == "" {
+        return errors.New("missing host")
+    }
+    return nil
+}
+
+// lookupEnv returns a variable's value, treating empty values as unset.
+func lookupEnv(key string) (string, bool) {
+    value := strings.TrimSpace(getEnv(key, ""))
+    return value, value != ""
+}
@@ -0,0 +1,115 @@
+package logger
+
+import (
+    "bufio"
+    "bytes"
+    "fmt"
+    "os"
+    "sync"
+)
+
+// deadLetterQueue appends log records that could not be delivered to a local JSONL file,
+// rotating it to <path>.1 once it would grow past maxBytes.
+type deadLetterQueue struct {
+    mu       sync.Mutex
+    path     string
+    maxBytes int64
+    file     *os.File
+    size     int64
+}
+
+func newDeadLetterQueue(path string, maxBytes int64) (*deadLetterQueue, error) {
+    q := &deadLetterQueue{path: path, maxBytes: maxBytes}
+    if err := q.open(); err != nil {
+        return nil, err
+    }
+    return q, nil
+}
+
+// open (re)opens the DLQ file for appending. Callers other than the constructor hold q.mu.
+func (q *deadLetterQueue) open() error {
+    file, err := os.OpenFile(q.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+    if err != nil {
+        return fmt.Errorf("open dead-letter file %s: %w", q.path, err)
+    }
+    info, err := file.Stat()
+    if err != nil {
+        file.Close()
+        return fmt.Errorf("stat dead-letter file %s: %w", q.path, err)
+    }
+    q.file = file
+    q.size = info.Size()
+    return nil
+}
+
+// append writes one JSON payload as a line, rotating first if it would exceed maxBytes.
+func (q *deadLetterQueue) append(payload []byte) error {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+
+    line := append(bytes.TrimRight(payload, "\n"), '\n')
+    if q.maxBytes > 0 && q.size > 0 && q.size+int64(len(line)) > q.maxBytes {
+        if err := q.rotate(); err != nil {
+            return err
+        }
+    }
+    n, err := q.file.Write(line)
+    q.size += int64(n)
+    return err
+}
+
+// rotate replaces <path>.1 with the current file and starts an empty one.
+func (q *deadLetterQueue) rotate() error {
+    q.file.Close()
+    if err := os.Rename(q.path, q.path+".1"); err != nil {
+        return fmt.Errorf("rotate dead-letter file %s: %w", q.path, err)
+    }
+    return q.open()
+}
+
+// take removes and returns up to limit of the oldest records in the current file.
+// Records already rotated to <path>.1 are not replayed.
+func (q *deadLetterQueue) take(limit int) ([][]byte, error) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+
+    data, err := os.ReadFile(q.path)
+    if err != nil {
+        return nil, err
+    }
+
+    var taken [][]byte
+    var rest bytes.Buffer
+    scanner := bufio.NewScanner(bytes.NewReader(data))
+    scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+    for scanner.Scan() {
+        line := scanner.Bytes()
+        if len(line) == 0 {
+            continue
+        }
+        if len(taken) < limit {
+            taken = append(taken, append([]byte(nil), line...))
+            continue
+        }
+        rest.Write(line)
+        rest.WriteByte('\n')
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, err
+    }
+    if len(taken) == 0 {
+        return nil, nil
+    }
+
+    q.file.Close()
+    if err := os.WriteFile(q.path, rest.Bytes(), 0o600); err != nil {
+        return nil, fmt.Errorf("rewrite dead-letter file %s: %w", q.path, err)
+    }
+    return taken, q.open()
+}
+
+func (q *deadLetterQueue) Close() error {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    return q.file.Close()
+}
@@ -0,0 +1,205 @@
+package logger
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "log/slog"
+    "net/http"
+    "os"
+    "path/filepath"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// dlqMessages returns the msg of every record in a dead-letter file, oldest first.
+func dlqMessages(t *testing.T, path string) []string {
+    t.Helper()
+    file, err := os.Open(path)
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer file.Close()
+
+    var messages []string
+    lines := bufio.NewScanner(file)
+    for lines.Scan() {
+        var payload map[string]any
+        if err := json.Unmarshal(lines.Bytes(), &payload); err != nil {
+            t.Fatalf("dead-letter line %q is not JSON: %v", lines.Text(), err)
+        }
+        messages = append(messages, payload["msg"].(string))
+    }
+    return messages
+}
+
+// failingCollector rejects every record with a 500 until accept is set.
+func failingCollector(t *testing.T) (*collector, *atomic.Bool) {
+    t.Helper()
+    endpoint := newCollector(t)
+    var accept atomic.Bool
+    endpoint.respond = func(w http.ResponseWriter, r *http.Request) bool {
+        if accept.Load() {
+            return false
+        }
+        w.WriteHeader(http.StatusInternalServerError)
+        return true
+    }
+    return endpoint, &accept
+}
+
+// newDLQHandler sends to url with one worker, no retries, and a circuit that opens on
+// the first failure, dead-lettering into a temporary file whose path it returns.
+func newDLQHandler(t *testing.T, url string, queueSize int) (*HTTPHandler, func(), string) {
+    t.Helper()
+    cfg := testIngestConfig(url)
+    cfg.LogIngestQueueSize = queueSize
+    cfg.LogIngestWorkers = 1
+    cfg.LogIngestRetryAttempts = 1
+    cfg.LogIngestFailureThreshold = 1
+    cfg.LogIngestDLQPath = filepath.Join(t.TempDir(), "dlq.jsonl")
+    cfg.LogIngestDLQReplay = true
+    handler, closeHandler := newTestHandler(t, cfg)
+    return handler, closeHandler, cfg.LogIngestDLQPath
+}
+
+func TestDroppedRecordsLandInDLQ(t *testing.T) {
+    endpoint, _ := failingCollector(t)
+    handler, closeHandler, path := newDLQHandler(t, endpoint.URL, 1000)
+
+    // The failed send opens the circuit; records while it is open are dropped
+    handler.Handle(context.Background(), record(slog.LevelError, "failed", "attempt", 1))
+    if !eventually(t, time.Second, handler.CircuitOpen) {
+        t.Fatal("circuit did not open after a failed send")
+    }
+    handler.Handle(context.Background(), record(slog.LevelInfo, "while open"))
+    closeHandler()
+
+    got := dlqMessages(t, path)
+    want := []string{"failed", "while open"}
+    if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+        t.Errorf("dead-lettered %q, want %q", got, want)
+    }
+    if len(endpoint.received()) != 0 {
+        t.Errorf("endpoint accepted %d records, want none", len(endpoint.received()))
+    }
+}
+
+func TestQueueOverflowLandsInDLQ(t *testing.T) {
+    endpoint := newCollector(t)
+    endpoint.delay = 200 * time.Millisecond
+    // The worker holds one record and the queue one more; the rest overflow
+    handler, closeHandler, path := newDLQHandler(t, endpoint.URL, 1)
+
+    const records = 5
+    for i := 0; i < records; i++ {
+        handler.Handle(context.Background(), record(slog.LevelInfo, "burst"))
+    }
+    closeHandler()
+
+    dropped := len(dlqMessages(t, path))
+    if delivered := len(endpoint.received()); dropped == 0 || dropped+delivered != records {
+        t.Errorf("%d delivered and %d dead-lettered of %d, want every overflow dead-lettered", delivered, dropped, records)
+    }
+}
+
+func TestDLQReplaysAfterRecovery(t *testing.T) {
+    endpoint, accept := failingCollector(t)
+    handler, _, path := newDLQHandler(t, endpoint.URL, 1000)
+    handler.mu.Lock()
+    handler.defaultRetryAfter, handler.retryAfter = 100*time.Millisecond, 100*time.Millisecond
+    handler.mu.Unlock()
+
+    handler.Handle(context.Background(), record(slog.LevelError, "lost 1"))
+    if !eventually(t, time.Second, handler.CircuitOpen) {
+        t.Fatal("circuit did not open after a failed send")
+    }
+    handler.Handle(context.Background(), record(slog.LevelError, "lost 2"))
+    if !eventually(t, time.Second, func() bool { return len(dlqMessages(t, path)) == 2 }) {
+        t.Fatalf("dead-lettered %q, want both records", dlqMessages(t, path))
+    }
+
+    // Once the endpoint recovers, the probe closes the circuit and the DLQ is replayed
+    accept.Store(true)
+    time.Sleep(150 * time.Millisecond)
+    handler.Handle(context.Background(), record(slog.LevelInfo, "probe"))
+    if !eventually(t, 2*time.Second, func() bool { return len(endpoint.received()) == 3 }) {
+        t.Fatalf("endpoint received %d records, want the probe and 2 replayed", len(endpoint.received()))
+    }
+
+    delivered := map[string]bool{}
+    for _, payload := range endpoint.received() {
+        delivered[payload["msg"].(string)] = true
+    }
+    for _, msg := range []string{"probe", "lost 1", "lost 2"} {
+        if !delivered[msg] {
+            t.Errorf("%q was not delivered", msg)
+        }
+    }
+    if !eventually(t, time.Second, func() bool { return len(dlqMessages(t, path)) == 0 }) {
+        t.Errorf("DLQ still holds %q after replay", dlqMessages(t, path))
+    }
+}
+
+func TestDLQReplayIsBounded(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "dlq.jsonl")
+    q, err := newDeadLetterQueue(path, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer q.Close()
+    for _, msg := range []string{"a", "b", "c"} {
+        if err := q.append([]byte(`{"msg":"` + msg + `"}` + "\n")); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    taken, err := q.take(2)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(taken) != 2 || string(taken[0]) != `{"msg":"a"}` || string(taken[1]) != `{"msg":"b"}` {
+        t.Errorf("took %q, want the 2 oldest records", taken)
+    }
+    if got := dlqMessages(t, path); len(got) != 1 || got[0] != "c" {
+        t.Errorf("DLQ holds %q after take, want [c]", got)
+    }
+    // Appends after a take still land in the file
+    if err := q.append([]byte(`{"msg":"d"}`)); err != nil {
+        t.Fatal(err)
+    }
+    if got := dlqMessages(t, path); len(got) != 2 || got[1] != "d" {
+        t.Errorf("DLQ holds %q, want [c d]", got)
+    }
+}
+
+func TestDLQRotatesBySize(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "dlq.jsonl")
+    line := []byte(`{"msg":"0123456789"}`) // 21 bytes with its newline
+    q, err := newDeadLetterQueue(path, 50)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer q.Close()
+    for i := 0; i < 5; i++ {
+        if err := q.append(line); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    // Two records fit in 50 bytes, so the fifth rotates 3-4 into .1, replacing 1-2
+    if got := len(dlqMessages(t, path)); got != 1 {
+        t.Errorf("current file holds %d records, want 1", got)
+    }
+    if got := len(dlqMessages(t, path+".1")); got != 2 {
+        t.Errorf("rotated file holds %d records, want 2", got)
+    }
+    info, err := os.Stat(path + ".1")
+    if err != nil || info.Size() > 50 {
+        t.Errorf("rotated file = %v, %v; want at most 50 bytes", info, err)
+    }
+}
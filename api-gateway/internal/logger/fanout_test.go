@@ -0,0 +1,125 @@
+package logger
+
+import (
+    "log"
+    "log/slog"
+    "net/http"
+    "os"
+    "path/filepath"
+    "sync"
+    "testing"
+    "time"
+
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/config"
+)
+
+// initForTest runs Init with console output discarded. The returned close restores the
+// default logger, since the ingestion workers log through it, and then drains every
+// ingestion handler; it runs at the end of the test if the test doesn't call it.
+func initForTest(t *testing.T, cfg config.Config) func() {
+    t.Helper()
+    devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    // SetDefault also routes the standard logger into slog, and restoring the original
+    // slog default does not undo that.
+    stdout, previous, logOutput, logFlags := os.Stdout, slog.Default(), log.Writer(), log.Flags()
+    os.Stdout = devNull
+    Init(cfg)
+    os.Stdout = stdout
+
+    var once sync.Once
+    closeAll := func() {
+        once.Do(func() {
+            slog.SetDefault(previous)
+            log.SetOutput(logOutput)
+            log.SetFlags(logFlags)
+            for _, handler := range ingestHandlers {
+                handler.Close()
+            }
+            devNull.Close()
+        })
+    }
+    t.Cleanup(func() {
+        closeAll()
+        ingestHandlers = nil
+    })
+    return closeAll
+}
+
+func TestFanOutIsolatesFailingCollector(t *testing.T) {
+    healthy := newCollector(t)
+    failing := newCollector(t)
+    failing.delay = time.Second
+    failing.respond = func(w http.ResponseWriter, r *http.Request) bool {
+        w.WriteHeader(http.StatusInternalServerError)
+        return true
+    }
+
+    cfg := testIngestConfig(healthy.URL)
+    cfg.LogIngestURLs = []string{healthy.URL, failing.URL}
+    cfg.LogLevel = "DEBUG"
+    cfg.LogIngestWorkers = 1
+    cfg.LogIngestRetryAttempts = 1
+    cfg.LogIngestFailureThreshold = 1
+    cfg.LogIngestDLQPath = filepath.Join(t.TempDir(), "dlq.jsonl")
+    closeAll := initForTest(t, cfg)
+
+    if len(ingestHandlers) != 2 {
+        t.Fatalf("Init started %d ingestion handlers, want one per destination", len(ingestHandlers))
+    }
+
+    const records = 20
+    for i := 0; i < records; i++ {
+        slog.Info("request served", "attempt", i)
+    }
+
+    // Every record reaches the healthy collector while the other is still failing its
+    // first, slow request
+    if !eventually(t, 800*time.Millisecond, func() bool { return count(messages(healthy.received()), "request served") == records }) {
+        t.Fatalf("healthy collector received %d of %d records while the other was failing", count(messages(healthy.received()), "request served"), records)
+    }
+
+    // Only the failing destination's circuit opens
+    if !eventually(t, 2*time.Second, IngestCircuitOpen) {
+        t.Fatal("failing destination's circuit did not open")
+    }
+    if ingestHandlers[0].CircuitOpen() || !ingestHandlers[1].CircuitOpen() {
+        t.Errorf("circuits open = %t, %t; want only the failing destination's", ingestHandlers[0].CircuitOpen(), ingestHandlers[1].CircuitOpen())
+    }
+    closeAll()
+
+    // Each destination dead-letters into its own file, so only the failing collector's
+    // records are kept for replay. The handlers' own warnings are logged, and so
+    // delivered, too, so only the test's records are counted.
+    if got := dlqMessages(t, destinationDLQPath(cfg.LogIngestDLQPath, 0)); len(got) != 0 {
+        t.Errorf("healthy destination dead-lettered %q, want nothing", got)
+    }
+    if got := count(dlqMessages(t, destinationDLQPath(cfg.LogIngestDLQPath, 1)), "request served"); got != records {
+        t.Errorf("failing destination dead-lettered %d records, want %d", got, records)
+    }
+    if got := count(messages(healthy.received()), "request served"); got != records {
+        t.Errorf("healthy collector received %d records, want %d", got, records)
+    }
+}
+
+// messages returns the msg of each payload.
+func messages(payloads []map[string]any) []string {
+    var msgs []string
+    for _, payload := range payloads {
+        msg, _ := payload["msg"].(string)
+        msgs = append(msgs, msg)
+    }
+    return msgs
+}
+
+func count(values []string, want string) int {
+    n := 0
+    for _, value := range values {
+        if value == want {
+            n++
+        }
+    }
+    return n
+}
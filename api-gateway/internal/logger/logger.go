@@ -4,15 +4,26 @@ import (
     "bytes"
     "context"
     "encoding/json"
+    "errors"
+    "fmt"
+    "io"
     "log/slog"
+    "math/rand/v2"
     "net/http"
     "os"
+    "path/filepath"
+    "strconv"
+    "strings"
     "sync"
+    "sync/atomic"
     "time"
 
     "gitea.wkav.cc/tony/jobapp/api-gateway/internal/config"
 )
 
+// ingestHandlers are the active log-ingestion handlers, one per destination.
+var ingestHandlers []*HTTPHandler
+
 // Init sets up the logger with potentially multiple destinations and resilience patterns.
 func Init(cfg config.Config) {
     var handlers []slog.Handler
@@ -26,11 +37,21 @@ func Init(cfg config.Config) {
         handlers = append(handlers, slog.NewTextHandler(os.Stdout, opts))
     }
 
-    // 2. Conditionally add the resilient HTTP ingestion handler
-    if cfg.LogIngestEnabled && cfg.LogIngestURL != "" {
-        httpHandler := NewHTTPHandler(cfg, opts)
-        handlers = append(handlers, httpHandler)
-        slog.Info("Log ingestion enabled", "url", cfg.LogIngestURL, "queue_size", cfg.LogIngestQueueSize)
+    // 2. Conditionally add one resilient HTTP ingestion handler per destination. Each has
+    // its own queue and circuit breaker, so a failing collector can't hold up the others.
+    if cfg.LogIngestEnabled {
+        for i, destination := range cfg.LogIngestURLs {
+            destinationCfg := cfg
+            destinationCfg.LogIngestURL = destination
+            if len(cfg.LogIngestURLs) > 1 && cfg.LogIngestDLQPath != "" {
+                destinationCfg.LogIngestDLQPath = destinationDLQPath(cfg.LogIngestDLQPath, i)
+            }
+
+            httpHandler := NewHTTPHandler(destinationCfg, opts)
+            ingestHandlers = append(ingestHandlers, httpHandler)
+            handlers = append(handlers, httpHandler)
+            slog.Info("Log ingestion enabled", "url", destination, "queue_size", cfg.LogIngestQueueSize, "workers", cfg.LogIngestWorkers)
+        }
     }
 
     // 3. Create a multi-handler that writes to all configured handlers
@@ -89,6 +110,14 @@ type HTTPHandler struct {
     client   http.Client
     url      string
     logQueue chan slog.Record
+    dropping atomic.Bool
+
+    // JSON keys for the record's time, level, and message, matching the collector's schema
+    fields recordFields
+
+    // Credential header set on every POST, e.g. "Authorization: Bearer <token>"
+    authHeader string
+    authValue  string
     wg       sync.WaitGroup
 
     // Circuit Breaker state
@@ -96,8 +125,22 @@ type HTTPHandler struct {
     consecutiveFailures int
     failureThreshold    int
     circuitOpen         bool
+    probing             bool
     lastFailureTime     time.Time
     retryAfter          time.Duration
+    defaultRetryAfter   time.Duration
+    maxRetryAfter       time.Duration
+
+    // Sampling rates for levels below WARN; WARN and above are never sampled out
+    sampleDebug float64
+    sampleInfo  float64
+
+    // Optional dead-letter file for records that could not be delivered, replayed
+    // (at most dlqReplayMax per recovery) when the circuit closes again
+    dlq          *deadLetterQueue
+    dlqReplay    bool
+    dlqReplayMax int
+    replaying    bool
 }
 
 func NewHTTPHandler(cfg config.Config, opts *slog.HandlerOptions) *HTTPHandler {
@@ -107,40 +150,142 @@ func NewHTTPHandler(cfg config.Config, opts *slog.HandlerOptions) *HTTPHandler {
         client: http.Client{
             Timeout: time.Duration(cfg.LogIngestTimeoutMS) * time.Millisecond,
         },
-        logQueue:         make(chan slog.Record, cfg.LogIngestQueueSize),
-        failureThreshold: cfg.LogIngestFailureThreshold,
-        retryAfter:       10 * time.Second, // Cooldown period for circuit breaker
+        logQueue:          make(chan slog.Record, cfg.LogIngestQueueSize),
+        fields:            recordFields{time: cfg.LogIngestTimeKey, level: cfg.LogIngestLevelKey, message: cfg.LogIngestMessageKey},
+        authHeader:        cfg.LogIngestAuthHeader,
+        authValue:         ingestAuthValue(cfg.LogIngestAuthHeader, cfg.LogIngestAuthToken),
+        failureThreshold:  cfg.LogIngestFailureThreshold,
+        retryAfter:        10 * time.Second, // Cooldown period for circuit breaker
+        defaultRetryAfter: 10 * time.Second,
+        maxRetryAfter:     time.Duration(cfg.LogIngestMaxRetryAfterMS) * time.Millisecond,
+        sampleDebug:       cfg.LogIngestSampleDebug,
+        sampleInfo:        cfg.LogIngestSampleInfo,
+        dlqReplay:         cfg.LogIngestDLQReplay,
+        dlqReplayMax:      cfg.LogIngestDLQReplayMax,
     }
 
-    // Start a dedicated worker goroutine to process the log queue.
-    handler.wg.Add(1)
-    go handler.worker(cfg)
+    if cfg.LogIngestDLQPath != "" {
+        dlq, err := newDeadLetterQueue(cfg.LogIngestDLQPath, cfg.LogIngestDLQMaxBytes)
+        if err != nil {
+            slog.Warn("Log ingestion dead-letter file unavailable; dropped records will be lost.", "error", err)
+        } else {
+            handler.dlq = dlq
+        }
+    }
+
+    // Start a pool of workers sharing the log queue so one slow POST doesn't
+    // serialize delivery. Circuit breaker state is shared under h.mu.
+    workers := cfg.LogIngestWorkers
+    if workers < 1 {
+        workers = 1
+    }
+    for i := 0; i < workers; i++ {
+        handler.wg.Add(1)
+        go handler.worker(cfg)
+    }
 
     return handler
 }
 
 // Handle is designed to be non-blocking. It sends the log record to a buffered channel.
+// Lower-severity records may be sampled out first; other handlers still see them.
 func (h *HTTPHandler) Handle(_ context.Context, r slog.Record) error {
+    if !h.sampled(r.Level) {
+        return nil
+    }
+
     select {
     case h.logQueue <- r:
         // Log successfully queued.
+        h.dropping.Store(false)
     default:
-        // Queue is full, log is dropped to prevent blocking.
-        slog.Warn("Log ingestion queue is full. Dropping log record.")
+        // Queue is full, log is dropped to prevent blocking. The warning goes to stderr
+        // once per run of drops: logging it through slog would re-enter this handler.
+        if !h.dropping.Swap(true) {
+            fmt.Fprintf(os.Stderr, "log ingestion: queue for %s is full; dropping log records\n", h.url)
+        }
+        h.deadLetter(r)
     }
     return nil
 }
 
+// deadLetter appends an undeliverable record to the DLQ file, if one is configured.
+// Errors go to stderr rather than slog, which would feed back into this handler.
+func (h *HTTPHandler) deadLetter(r slog.Record) {
+    if h.dlq == nil {
+        return
+    }
+    buf := getRecordBuffer()
+    defer putRecordBuffer(buf)
+    err := encodeRecord(r, buf, h.fields)
+    if err == nil {
+        err = h.dlq.append(buf.Bytes())
+    }
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "log ingestion: could not write dead-letter record: %v\n", err)
+    }
+}
+
+// replayDeadLetters re-sends up to dlqReplayMax records from the DLQ after the circuit
+// recovers. On the first failure the unsent records are appended back to the DLQ.
+func (h *HTTPHandler) replayDeadLetters() {
+    defer func() {
+        h.mu.Lock()
+        h.replaying = false
+        h.mu.Unlock()
+    }()
+
+    payloads, err := h.dlq.take(h.dlqReplayMax)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "log ingestion: could not read dead-letter file: %v\n", err)
+        return
+    }
+    for i, payload := range payloads {
+        if err := h.sendPayload(payload); err != nil {
+            for _, unsent := range payloads[i:] {
+                if err := h.dlq.append(unsent); err != nil {
+                    fmt.Fprintf(os.Stderr, "log ingestion: could not write dead-letter record: %v\n", err)
+                }
+            }
+            slog.Warn("Dead-letter replay stopped early.", "replayed", i, "error", err)
+            return
+        }
+    }
+    if len(payloads) > 0 {
+        slog.Info("Replayed dead-lettered log records.", "count", len(payloads))
+    }
+}
+
+// sampled reports whether a record at level should be forwarded to the endpoint.
+func (h *HTTPHandler) sampled(level slog.Level) bool {
+    var rate float64
+    switch {
+    case level >= slog.LevelWarn:
+        return true
+    case level >= slog.LevelInfo:
+        rate = h.sampleInfo
+    default:
+        rate = h.sampleDebug
+    }
+    return rate >= 1 || rand.Float64() < rate
+}
+
 // worker processes logs from the queue in the background.
 func (h *HTTPHandler) worker(cfg config.Config) {
     defer h.wg.Done()
     for record := range h.logQueue {
         if h.isCircuitOpen() {
-            continue // Drop log if circuit is open
+            h.deadLetter(record) // Drop log if circuit is open
+            continue
         }
 
         err := h.sendWithRetries(record, cfg.LogIngestRetryAttempts)
-        if err != nil {
+        var throttled *retryAfterError
+        if errors.As(err, &throttled) {
+            h.deadLetter(record)
+            h.backOff(throttled.delay)
+        } else if err != nil {
+            h.deadLetter(record)
             h.tripCircuit()
         } else {
             h.resetCircuit()
@@ -156,33 +301,76 @@ func (h *HTTPHandler) sendWithRetries(r slog.Record, maxRetries int) error {
         if lastErr == nil {
             return nil // Success
         }
+        // The endpoint asked us to wait; retrying now would only add load.
+        var throttled *retryAfterError
+        if errors.As(lastErr, &throttled) {
+            return lastErr
+        }
         time.Sleep(time.Duration(50*attempt) * time.Millisecond) // Simple backoff
     }
     slog.Error("Failed to send log after multiple retries", "error", lastErr)
     return lastErr
 }
 
-// send performs the actual HTTP request.
+// send performs the actual HTTP request, encoding the record into a pooled buffer
+// that is released once the transport is done with the request body.
 func (h *HTTPHandler) send(r slog.Record) error {
-    data := make(map[string]interface{})
-    data["time"] = r.Time
-    data["level"] = r.Level.String()
-    data["msg"] = r.Message
+    buf := getRecordBuffer()
+    if err := encodeRecord(r, buf, h.fields); err != nil {
+        putRecordBuffer(buf)
+        return err
+    }
+    return h.post(newPooledBody(buf), int64(buf.Len()))
+}
+
+// recordFields names the JSON keys of a record's built-in fields.
+type recordFields struct {
+    time    string
+    level   string
+    message string
+}
+
+// encodeRecord writes a record as the JSON object POSTed to the endpoint. The field map
+// comes from a pool and is cleared before it goes back.
+func encodeRecord(r slog.Record, buf *bytes.Buffer, fields recordFields) error {
+    data := recordFieldsPool.Get().(map[string]interface{})
+    defer func() {
+        clear(data)
+        recordFieldsPool.Put(data)
+    }()
+
+    data[fields.time] = r.Time
+    data[fields.level] = r.Level.String()
+    data[fields.message] = r.Message
     r.Attrs(func(a slog.Attr) bool {
         data[a.Key] = a.Value.Any()
         return true
     })
-
-    payload, err := json.Marshal(data)
-    if err != nil {
+    if err := json.NewEncoder(buf).Encode(data); err != nil {
         return err
     }
+    // Encode terminates the object with a newline, which is not part of the payload.
+    buf.Truncate(buf.Len() - 1)
+    return nil
+}
+
+// sendPayload POSTs an already encoded record to the endpoint.
+func (h *HTTPHandler) sendPayload(payload []byte) error {
+    return h.post(io.NopCloser(bytes.NewReader(payload)), int64(len(payload)))
+}
 
-    req, err := http.NewRequest(http.MethodPost, h.url, bytes.NewBuffer(payload))
+// post sends an encoded record; the transport closes body when it is done with it.
+func (h *HTTPHandler) post(body io.ReadCloser, length int64) error {
+    req, err := http.NewRequest(http.MethodPost, h.url, body)
     if err != nil {
+        body.Close()
         return err
     }
+    req.ContentLength = length
     req.Header.Set("Content-Type", "application/json")
+    if h.authValue != "" {
+        req.Header.Set(h.authHeader, h.authValue)
+    }
 
     resp, err := h.client.Do(req)
     if err != nil {
@@ -190,12 +378,55 @@ func (h *HTTPHandler) send(r slog.Record) error {
     }
     defer resp.Body.Close()
 
+    if delay, ok := parseRetryAfter(resp); ok {
+        return &retryAfterError{status: resp.Status, delay: delay}
+    }
     if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-        return &slog.Error{Msg: "received non-2xx response: " + resp.Status}
+        return fmt.Errorf("received non-2xx response: %s", resp.Status)
     }
     return nil
 }
 
+// ingestAuthValue returns the value for the ingestion credential header. A bare token
+// sent as Authorization becomes a bearer token; other headers carry the token as is.
+func ingestAuthValue(headerName, token string) string {
+    if token == "" {
+        return ""
+    }
+    if strings.EqualFold(headerName, "Authorization") && !strings.Contains(token, " ") {
+        return "Bearer " + token
+    }
+    return token
+}
+
+// retryAfterError is returned when the endpoint throttles us with a Retry-After header.
+type retryAfterError struct {
+    status string
+    delay  time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+    return fmt.Sprintf("received %s, retry after %s", e.status, e.delay)
+}
+
+// parseRetryAfter reads Retry-After (delta-seconds or HTTP date) from 429 and 503 responses.
+func parseRetryAfter(resp *http.Response) (time.Duration, bool) {
+    if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+        return 0, false
+    }
+    value := resp.Header.Get("Retry-After")
+    if value == "" {
+        return 0, false
+    }
+    if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+        return time.Duration(seconds) * time.Second, true
+    }
+    if date, err := http.ParseTime(value); err == nil {
+        return max(time.Until(date), 0), true
+    }
+    return 0, false
+}
+
 // --- Circuit Breaker Methods ---
 
 func (h *HTTPHandler) isCircuitOpen() bool {
@@ -205,9 +436,10 @@ func (h *HTTPHandler) isCircuitOpen() bool {
         return false
     }
     // If circuit is open, check if the cooldown period has passed.
-    if time.Since(h.lastFailureTime) > h.retryAfter {
+    // Only one worker may probe; the rest keep dropping until it resolves.
+    if time.Since(h.lastFailureTime) > h.retryAfter && !h.probing {
         // Allow one "probe" request to go through.
-        h.circuitOpen = false
+        h.probing = true
         return false
     }
     return true
@@ -217,6 +449,8 @@ func (h *HTTPHandler) tripCircuit() {
     h.mu.Lock()
     defer h.mu.Unlock()
     h.consecutiveFailures++
+    h.probing = false
+    h.retryAfter = h.defaultRetryAfter
     if h.consecutiveFailures >= h.failureThreshold {
         if !h.circuitOpen {
             slog.Warn("Circuit breaker tripped for log ingestion endpoint.", "url", h.url)
@@ -226,26 +460,82 @@ func (h *HTTPHandler) tripCircuit() {
     }
 }
 
+// backOff opens the circuit immediately for the server-requested delay, clamped to
+// the configured maximum, since the endpoint has told us it is overloaded.
+func (h *HTTPHandler) backOff(delay time.Duration) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    h.consecutiveFailures++
+    h.probing = false
+    h.retryAfter = min(delay, h.maxRetryAfter)
+    h.lastFailureTime = time.Now()
+    if !h.circuitOpen {
+        slog.Warn("Log ingestion endpoint throttled; pausing delivery.", "url", h.url, "retry_after", h.retryAfter)
+        h.circuitOpen = true
+    }
+}
+
 func (h *HTTPHandler) resetCircuit() {
     h.mu.Lock()
     defer h.mu.Unlock()
     if h.consecutiveFailures > 0 {
         slog.Info("Circuit breaker reset for log ingestion endpoint.")
     }
+    recovered := h.circuitOpen
     h.consecutiveFailures = 0
     h.circuitOpen = false
+    h.probing = false
+    h.retryAfter = h.defaultRetryAfter
+
+    if recovered && h.dlq != nil && h.dlqReplay && !h.replaying {
+        h.replaying = true
+        go h.replayDeadLetters()
+    }
+}
+
+// CircuitOpen reports whether the circuit breaker is currently rejecting logs.
+// Unlike isCircuitOpen it never moves the breaker into its probe state.
+func (h *HTTPHandler) CircuitOpen() bool {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    return h.circuitOpen && time.Since(h.lastFailureTime) <= h.retryAfter
 }
 
-// Close gracefully shuts down the HTTP handler worker.
+// IngestCircuitOpen reports whether log ingestion is enabled and any destination's
+// circuit breaker is open.
+func IngestCircuitOpen() bool {
+    for _, handler := range ingestHandlers {
+        if handler.CircuitOpen() {
+            return true
+        }
+    }
+    return false
+}
+
+// destinationDLQPath gives each destination its own dead-letter file, e.g. dlq.jsonl
+// becomes dlq.0.jsonl, so replay re-sends records only to the collector that missed them.
+func destinationDLQPath(path string, index int) string {
+    ext := filepath.Ext(path)
+    return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(path, ext), index, ext)
+}
+
+// Close gracefully shuts down the HTTP handler workers, waiting for queued logs to drain.
 func (h *HTTPHandler) Close() {
     close(h.logQueue)
     h.wg.Wait()
+    if h.dlq != nil {
+        h.dlq.Close()
+    }
 }
 
 // --- Unchanged Methods ---
 
 func (h *HTTPHandler) Enabled(_ context.Context, level slog.Level) bool {
-    return level >= h.opts.Level
+    minLevel := slog.LevelInfo
+    if h.opts.Level != nil {
+        minLevel = h.opts.Level.Level()
+    }
+    return level >= minLevel
 }
 
 func (h *HTTPHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
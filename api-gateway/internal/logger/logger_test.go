@@ -0,0 +1,404 @@
+package logger
+
+import (
+    "context"
+    "encoding/json"
+    "io"
+    "log/slog"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/config"
+)
+
+// collector is a mock ingestion endpoint that records every payload it accepts.
+type collector struct {
+    *httptest.Server
+    mu       sync.Mutex
+    payloads []map[string]any
+    headers  []http.Header
+    // respond, when set, handles the request instead of accepting it
+    respond func(w http.ResponseWriter, r *http.Request) bool
+    delay   time.Duration
+
+    inFlight     atomic.Int32
+    peakInFlight atomic.Int32
+}
+
+func newCollector(t *testing.T) *collector {
+    t.Helper()
+    c := &collector{}
+    c.Server = httptest.NewServer(http.HandlerFunc(c.serve))
+    t.Cleanup(c.Close)
+    return c
+}
+
+func (c *collector) serve(w http.ResponseWriter, r *http.Request) {
+    current := c.inFlight.Add(1)
+    defer c.inFlight.Add(-1)
+    for {
+        peak := c.peakInFlight.Load()
+        if current <= peak || c.peakInFlight.CompareAndSwap(peak, current) {
+            break
+        }
+    }
+    time.Sleep(c.delay)
+
+    c.mu.Lock()
+    respond := c.respond
+    c.mu.Unlock()
+    if respond != nil && respond(w, r) {
+        return
+    }
+
+    body, _ := io.ReadAll(r.Body)
+    var payload map[string]any
+    if err := json.Unmarshal(body, &payload); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    c.mu.Lock()
+    c.payloads = append(c.payloads, payload)
+    c.headers = append(c.headers, r.Header.Clone())
+    c.mu.Unlock()
+    w.WriteHeader(http.StatusAccepted)
+}
+
+func (c *collector) received() []map[string]any {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return append([]map[string]any(nil), c.payloads...)
+}
+
+func (c *collector) receivedHeaders() []http.Header {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return append([]http.Header(nil), c.headers...)
+}
+
+// testIngestConfig is the gateway's default log-ingestion configuration for url.
+func testIngestConfig(url string) config.Config {
+    return config.Config{
+        LogIngestEnabled:            true,
+        LogIngestURL:                url,
+        LogIngestURLs:               []string{url},
+        LogIngestTimeKey:            "time",
+        LogIngestLevelKey:           "level",
+        LogIngestMessageKey:         "msg",
+        LogIngestAuthHeader:         "Authorization",
+        LogIngestTimeoutMS:          2000,
+        LogIngestQueueSize:          1000,
+        LogIngestWorkers:            4,
+        LogIngestRetryAttempts:      3,
+        LogIngestLatencyThresholdMS: 1000,
+        LogIngestFailureThreshold:   5,
+        LogIngestMaxRetryAfterMS:    300000,
+        LogIngestDropPolicy:         "newest",
+        LogIngestSampleDebug:        1,
+        LogIngestSampleInfo:         1,
+        LogIngestDLQMaxBytes:        10485760,
+        LogIngestDLQReplayMax:       1000,
+    }
+}
+
+// newTestHandler starts an ingestion handler that accepts every level. The returned
+// close drains its queue; it runs at the end of the test if the test doesn't call it.
+func newTestHandler(t *testing.T, cfg config.Config) (*HTTPHandler, func()) {
+    t.Helper()
+    handler := NewHTTPHandler(cfg, &slog.HandlerOptions{Level: slog.LevelDebug})
+    var once sync.Once
+    closeHandler := func() { once.Do(handler.Close) }
+    t.Cleanup(closeHandler)
+    return handler, closeHandler
+}
+
+func record(level slog.Level, msg string, args ...any) slog.Record {
+    r := slog.NewRecord(time.Now(), level, msg, 0)
+    r.Add(args...)
+    return r
+}
+
+// eventually polls cond until it holds or the deadline passes.
+func eventually(t *testing.T, timeout time.Duration, cond func() bool) bool {
+    t.Helper()
+    deadline := time.Now().Add(timeout)
+    for time.Now().Before(deadline) {
+        if cond() {
+            return true
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+    return cond()
+}
+
+func TestWorkersDeliverConcurrentlyToSlowEndpoint(t *testing.T) {
+    const workers, records = 8, 32
+    endpoint := newCollector(t)
+    endpoint.delay = 100 * time.Millisecond
+
+    cfg := testIngestConfig(endpoint.URL)
+    cfg.LogIngestWorkers = workers
+    handler, closeHandler := newTestHandler(t, cfg)
+
+    // Handle never waits for the endpoint
+    start := time.Now()
+    for i := 0; i < records; i++ {
+        handler.Handle(context.Background(), record(slog.LevelInfo, "request handled", "n", i))
+    }
+    if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+        t.Errorf("queueing %d records took %s", records, elapsed)
+    }
+
+    closeHandler()
+    elapsed := time.Since(start)
+
+    if got := len(endpoint.received()); got != records {
+        t.Fatalf("endpoint received %d records, want %d", got, records)
+    }
+    // One worker would need records*delay = 3.2s; eight need about 400ms
+    if serial := records * endpoint.delay; elapsed >= serial/2 {
+        t.Errorf("delivery took %s, want well under the serial %s", elapsed, serial)
+    }
+    if peak := endpoint.peakInFlight.Load(); peak < 2 || peak > workers {
+        t.Errorf("peak concurrent POSTs = %d, want between 2 and %d", peak, workers)
+    }
+}
+
+func TestSamplingRates(t *testing.T) {
+    const trials = 20000
+    handler := &HTTPHandler{sampleDebug: 0.1, sampleInfo: 0.25}
+
+    tests := []struct {
+        level slog.Level
+        want  float64
+    }{
+        {slog.LevelDebug, 0.1},
+        {slog.LevelInfo, 0.25},
+        {slog.LevelWarn, 1},
+        {slog.LevelError, 1},
+    }
+    for _, tt := range tests {
+        kept := 0
+        for i := 0; i < trials; i++ {
+            if handler.sampled(tt.level) {
+                kept++
+            }
+        }
+        // Within about six standard deviations of the configured rate
+        if got := float64(kept) / trials; got < tt.want-0.02 || got > tt.want+0.02 {
+            t.Errorf("%s: kept %.3f of records, want %.2f", tt.level, got, tt.want)
+        }
+    }
+}
+
+func TestSamplingNeverDropsErrors(t *testing.T) {
+    endpoint := newCollector(t)
+    cfg := testIngestConfig(endpoint.URL)
+    cfg.LogIngestSampleDebug = 0
+    cfg.LogIngestSampleInfo = 0
+    handler, closeHandler := newTestHandler(t, cfg)
+
+    for i := 0; i < 50; i++ {
+        handler.Handle(context.Background(), record(slog.LevelDebug, "debug"))
+        handler.Handle(context.Background(), record(slog.LevelInfo, "info"))
+        handler.Handle(context.Background(), record(slog.LevelError, "error"))
+    }
+    handler.Handle(context.Background(), record(slog.LevelWarn, "warn"))
+    closeHandler()
+
+    counts := make(map[string]int)
+    for _, payload := range endpoint.received() {
+        counts[payload["level"].(string)]++
+    }
+    if counts["ERROR"] != 50 || counts["WARN"] != 1 || counts["INFO"] != 0 || counts["DEBUG"] != 0 {
+        t.Errorf("delivered levels = %v, want 50 ERROR, 1 WARN and nothing sampled out", counts)
+    }
+}
+
+func TestRetryAfterOpensCircuit(t *testing.T) {
+    endpoint := newCollector(t)
+    var throttled atomic.Int32
+    endpoint.respond = func(w http.ResponseWriter, r *http.Request) bool {
+        if throttled.Add(1) > 1 {
+            return false
+        }
+        w.Header().Set("Retry-After", "1")
+        w.WriteHeader(http.StatusTooManyRequests)
+        return true
+    }
+
+    cfg := testIngestConfig(endpoint.URL)
+    cfg.LogIngestWorkers = 1
+    handler, _ := newTestHandler(t, cfg)
+
+    handler.Handle(context.Background(), record(slog.LevelInfo, "throttled"))
+    if !eventually(t, time.Second, handler.CircuitOpen) {
+        t.Fatal("circuit did not open after a 429 with Retry-After")
+    }
+    // Throttling is not retried, and the circuit waits the requested second
+    if got := throttled.Load(); got != 1 {
+        t.Errorf("endpoint saw %d attempts, want 1", got)
+    }
+    handler.mu.Lock()
+    retryAfter := handler.retryAfter
+    handler.mu.Unlock()
+    if retryAfter != time.Second {
+        t.Errorf("retryAfter = %s, want 1s", retryAfter)
+    }
+
+    // While open, records are dropped without reaching the endpoint
+    handler.Handle(context.Background(), record(slog.LevelInfo, "dropped"))
+    time.Sleep(100 * time.Millisecond)
+    if got := throttled.Load(); got != 1 {
+        t.Errorf("endpoint saw %d attempts while the circuit was open, want 1", got)
+    }
+
+    // After the delay one probe goes through and closes the circuit
+    time.Sleep(time.Second)
+    handler.Handle(context.Background(), record(slog.LevelInfo, "probe"))
+    if !eventually(t, time.Second, func() bool { return len(endpoint.received()) == 1 }) {
+        t.Fatal("probe was not delivered after Retry-After elapsed")
+    }
+    if handler.CircuitOpen() {
+        t.Error("circuit still open after a successful probe")
+    }
+    if got := endpoint.received()[0]["msg"]; got != "probe" {
+        t.Errorf("delivered %v, want the probe", got)
+    }
+}
+
+func TestRetryAfterIsClampedToMaximum(t *testing.T) {
+    endpoint := newCollector(t)
+    endpoint.respond = func(w http.ResponseWriter, r *http.Request) bool {
+        w.Header().Set("Retry-After", "3600")
+        w.WriteHeader(http.StatusServiceUnavailable)
+        return true
+    }
+
+    cfg := testIngestConfig(endpoint.URL)
+    cfg.LogIngestWorkers = 1
+    cfg.LogIngestMaxRetryAfterMS = 60000
+    handler, _ := newTestHandler(t, cfg)
+
+    handler.Handle(context.Background(), record(slog.LevelError, "throttled"))
+    if !eventually(t, time.Second, handler.CircuitOpen) {
+        t.Fatal("circuit did not open after a 503 with Retry-After")
+    }
+    handler.mu.Lock()
+    defer handler.mu.Unlock()
+    if handler.retryAfter != time.Minute {
+        t.Errorf("retryAfter = %s, want the 1m maximum", handler.retryAfter)
+    }
+}
+
+func TestParseRetryAfter(t *testing.T) {
+    tests := []struct {
+        status int
+        value  string
+        want   time.Duration
+        ok     bool
+    }{
+        {http.StatusTooManyRequests, "5", 5 * time.Second, true},
+        {http.StatusServiceUnavailable, "0", 0, true},
+        {http.StatusTooManyRequests, time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+        {http.StatusTooManyRequests, "", 0, false},
+        {http.StatusTooManyRequests, "soon", 0, false},
+        {http.StatusTooManyRequests, "-1", 0, false},
+        // Only throttling statuses carry a delay
+        {http.StatusInternalServerError, "5", 0, false},
+    }
+    for _, tt := range tests {
+        resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+        if tt.value != "" {
+            resp.Header.Set("Retry-After", tt.value)
+        }
+        got, ok := parseRetryAfter(resp)
+        if got != tt.want || ok != tt.ok {
+            t.Errorf("%d Retry-After %q = %s, %t, want %s, %t", tt.status, tt.value, got, ok, tt.want, tt.ok)
+        }
+    }
+
+    // An HTTP date in the future is a delay until then
+    resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+    resp.Header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+    if got, ok := parseRetryAfter(resp); !ok || got < 58*time.Second || got > time.Minute {
+        t.Errorf("future date = %s, %t, want about 1m", got, ok)
+    }
+}
+
+func TestIngestCredentialHeader(t *testing.T) {
+    tests := []struct {
+        name   string
+        header string
+        token  string
+        want   string
+    }{
+        {"bare token becomes a bearer token", "Authorization", "secret-token", "Bearer secret-token"},
+        {"token with a scheme is sent as is", "Authorization", "Basic dXNlcjpwYXNz", "Basic dXNlcjpwYXNz"},
+        {"custom header carries the bare token", "X-Ingest-Key", "secret-token", "secret-token"},
+        {"no token sends no credential", "Authorization", "", ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            endpoint := newCollector(t)
+            cfg := testIngestConfig(endpoint.URL)
+            cfg.LogIngestAuthHeader = tt.header
+            cfg.LogIngestAuthToken = tt.token
+            handler, closeHandler := newTestHandler(t, cfg)
+
+            for i := 0; i < 3; i++ {
+                handler.Handle(context.Background(), record(slog.LevelInfo, "request served"))
+            }
+            closeHandler()
+
+            headers := endpoint.receivedHeaders()
+            if len(headers) != 3 {
+                t.Fatalf("collector received %d requests, want 3", len(headers))
+            }
+            for _, header := range headers {
+                if got := header.Get(tt.header); got != tt.want {
+                    t.Errorf("%s = %q, want %q", tt.header, got, tt.want)
+                }
+                if got := header.Get("Content-Type"); got != "application/json" {
+                    t.Errorf("Content-Type = %q, want application/json", got)
+                }
+            }
+        })
+    }
+}
+
+func TestIngestPayloadUsesRemappedKeys(t *testing.T) {
+    endpoint := newCollector(t)
+    cfg := testIngestConfig(endpoint.URL)
+    cfg.LogIngestTimeKey = "@timestamp"
+    cfg.LogIngestLevelKey = "severity"
+    cfg.LogIngestMessageKey = "message"
+    handler, closeHandler := newTestHandler(t, cfg)
+
+    at := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+    r := slog.NewRecord(at, slog.LevelWarn, "upstream slow", 0)
+    r.Add("path", "/api/jobs")
+    handler.Handle(context.Background(), r)
+    closeHandler()
+
+    payloads := endpoint.received()
+    if len(payloads) != 1 {
+        t.Fatalf("collector received %d records, want 1", len(payloads))
+    }
+    payload := payloads[0]
+    want := map[string]any{"@timestamp": "2024-03-01T09:30:00Z", "severity": "WARN", "message": "upstream slow", "path": "/api/jobs"}
+    for key, value := range want {
+        if payload[key] != value {
+            t.Errorf("%s = %v, want %v", key, payload[key], value)
+        }
+    }
+    for _, key := range []string{"time", "level", "msg"} {
+        if _, ok := payload[key]; ok {
+            t.Errorf("payload still has the default %q key: %v", key, payload)
+        }
+    }
+}
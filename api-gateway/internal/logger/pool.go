@@ -0,0 +1,51 @@
+package logger
+
+import (
+    "bytes"
+    "sync"
+)
+
+// maxPooledBufferBytes keeps an unusually large record's buffer from being pinned in the pool.
+const maxPooledBufferBytes = 64 * 1024
+
+var (
+    // recordFieldsPool reuses the maps records are flattened into before encoding.
+    recordFieldsPool = sync.Pool{
+        New: func() any { return make(map[string]interface{}, 16) },
+    }
+    // recordBufferPool reuses the buffers records are encoded into.
+    recordBufferPool = sync.Pool{
+        New: func() any { return new(bytes.Buffer) },
+    }
+)
+
+func getRecordBuffer() *bytes.Buffer {
+    buf := recordBufferPool.Get().(*bytes.Buffer)
+    buf.Reset()
+    return buf
+}
+
+func putRecordBuffer(buf *bytes.Buffer) {
+    if buf.Cap() > maxPooledBufferBytes {
+        return
+    }
+    buf.Reset()
+    recordBufferPool.Put(buf)
+}
+
+// pooledBody is a request body that returns its buffer to the pool when the HTTP
+// transport closes it, which may happen after Client.Do has returned.
+type pooledBody struct {
+    *bytes.Reader
+    buf  *bytes.Buffer
+    once sync.Once
+}
+
+func newPooledBody(buf *bytes.Buffer) *pooledBody {
+    return &pooledBody{Reader: bytes.NewReader(buf.Bytes()), buf: buf}
+}
+
+func (b *pooledBody) Close() error {
+    b.once.Do(func() { putRecordBuffer(b.buf) })
+    return nil
+}
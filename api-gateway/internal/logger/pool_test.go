@@ -0,0 +1,89 @@
+package logger
+
+import (
+    "bytes"
+    "encoding/json"
+    "log/slog"
+    "testing"
+)
+
+var testFields = recordFields{time: "time", level: "level", message: "msg"}
+
+func TestEncodeRecord(t *testing.T) {
+    buf := getRecordBuffer()
+    defer putRecordBuffer(buf)
+    if err := encodeRecord(record(slog.LevelWarn, "upstream slow", "path", "/api/jobs", "status", 504), buf, testFields); err != nil {
+        t.Fatal(err)
+    }
+
+    payload := buf.Bytes()
+    if bytes.HasSuffix(payload, []byte("\n")) {
+        t.Errorf("payload %q ends with a newline", payload)
+    }
+    var decoded map[string]any
+    if err := json.Unmarshal(payload, &decoded); err != nil {
+        t.Fatalf("payload %q is not JSON: %v", payload, err)
+    }
+    if decoded["level"] != "WARN" || decoded["msg"] != "upstream slow" || decoded["path"] != "/api/jobs" || decoded["status"] != float64(504) {
+        t.Errorf("payload = %v", decoded)
+    }
+
+    // The pooled field map is cleared, so the next record doesn't inherit attributes
+    buf.Reset()
+    if err := encodeRecord(record(slog.LevelInfo, "ok"), buf, testFields); err != nil {
+        t.Fatal(err)
+    }
+    decoded = nil
+    if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+        t.Fatal(err)
+    }
+    if _, leaked := decoded["path"]; leaked || len(decoded) != 3 {
+        t.Errorf("second payload = %v, want only time, level, and msg", decoded)
+    }
+}
+
+func TestPutRecordBufferDropsLargeBuffers(t *testing.T) {
+    buf := getRecordBuffer()
+    buf.Grow(2 * maxPooledBufferBytes)
+    putRecordBuffer(buf)
+    // sync.Pool may drop anything, so only a large buffer coming back is a failure
+    if again := getRecordBuffer(); again == buf {
+        t.Error("oversized buffer was returned to the pool")
+    }
+}
+
+// BenchmarkEncodeRecord compares allocations per record with the pooled field map and
+// buffer against allocating both for every record, e.g.
+//
+//    go test -run '^$' -bench EncodeRecord -benchmem ./internal/logger
+func BenchmarkEncodeRecord(b *testing.B) {
+    r := record(slog.LevelInfo, "request served", "method", "GET", "path", "/api/jobs", "status", 200, "duration_ms", 12.5)
+
+    b.Run("pooled", func(b *testing.B) {
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            buf := getRecordBuffer()
+            if err := encodeRecord(r, buf, testFields); err != nil {
+                b.Fatal(err)
+            }
+            putRecordBuffer(buf)
+        }
+    })
+
+    b.Run("unpooled", func(b *testing.B) {
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            data := make(map[string]interface{}, 16)
+            data[testFields.time] = r.Time
+            data[testFields.level] = r.Level.String()
+            data[testFields.message] = r.Message
+            r.Attrs(func(a slog.Attr) bool {
+                data[a.Key] = a.Value.Any()
+                return true
+            })
+            if err := json.NewEncoder(new(bytes.Buffer)).Encode(data); err != nil {
+                b.Fatal(err)
+            }
+        }
+    })
+}
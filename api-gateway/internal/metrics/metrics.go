@@ -0,0 +1,181 @@
+package metrics
+
+import (
+    "crypto/subtle"
+    "fmt"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the request duration histogram.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry collects gateway traffic metrics and renders them in the Prometheus
+// text exposition format.
+type Registry struct {
+    requestsTotal atomic.Uint64
+    inFlight      atomic.Int64
+
+    mu            sync.Mutex
+    statusCounts  map[int]uint64
+    bucketCounts  []uint64
+    durationSum   float64
+    durationCount uint64
+
+    // gauges are sampled at scrape time, e.g. the log-ingest circuit breaker state
+    gauges map[string]gauge
+}
+
+type gauge struct {
+    help  string
+    value func() float64
+}
+
+// NewRegistry creates an empty metrics registry.
+func NewRegistry() *Registry {
+    return &Registry{
+        statusCounts: make(map[int]uint64),
+        bucketCounts: make([]uint64, len(durationBuckets)),
+        gauges:       make(map[string]gauge),
+    }
+}
+
+// RegisterGauge adds a gauge whose value is read on every scrape.
+func (m *Registry) RegisterGauge(name, help string, value func() float64) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.gauges[name] = gauge{help: help, value: value}
+}
+
+// observe records a completed request.
+func (m *Registry) observe(status int, duration time.Duration) {
+    seconds := duration.Seconds()
+
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.statusCounts[status]++
+    for i, bound := range durationBuckets {
+        if seconds <= bound {
+            m.bucketCounts[i]++
+        }
+    }
+    m.durationSum += seconds
+    m.durationCount++
+}
+
+// Middleware counts requests, status codes, durations, and in-flight requests.
+// Requests to skipPath (the metrics endpoint itself) are not counted.
+func (m *Registry) Middleware(skipPath string, next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == skipPath {
+            next.ServeHTTP(w, r)
+            return
+        }
+
+        m.requestsTotal.Add(1)
+        m.inFlight.Add(1)
+        defer m.inFlight.Add(-1)
+
+        start := time.Now()
+        recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+        next.ServeHTTP(recorder, r)
+        m.observe(recorder.status, time.Since(start))
+    })
+}
+
+// Handler serves the metrics. When token is set, scrapers must send it as a
+// bearer token so traffic data isn't exposed publicly.
+func (m *Registry) Handler(token string) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodGet {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+        if token != "" {
+            provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+            if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+                http.Error(w, "Unauthorized", http.StatusUnauthorized)
+                return
+            }
+        }
+
+        w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+        fmt.Fprint(w, m.render())
+    }
+}
+
+// render writes all metrics in the Prometheus text format.
+func (m *Registry) render() string {
+    var b strings.Builder
+
+    b.WriteString("# HELP gateway_requests_total Total number of requests handled by the gateway.\n")
+    b.WriteString("# TYPE gateway_requests_total counter\n")
+    fmt.Fprintf(&b, "gateway_requests_total %d\n", m.requestsTotal.Load())
+
+    b.WriteString("# HELP gateway_requests_in_flight Requests currently being handled.\n")
+    b.WriteString("# TYPE gateway_requests_in_flight gauge\n")
+    fmt.Fprintf(&b, "gateway_requests_in_flight %d\n", m.inFlight.Load())
+
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    b.WriteString("# HELP gateway_responses_total Responses by HTTP status code.\n")
+    b.WriteString("# TYPE gateway_responses_total counter\n")
+    codes := make([]int, 0, len(m.statusCounts))
+    for code := range m.statusCounts {
+        codes = append(codes, code)
+    }
+    sort.Ints(codes)
+    for _, code := range codes {
+        fmt.Fprintf(&b, "gateway_responses_total{code=\"%d\"} %d\n", code, m.statusCounts[code])
+    }
+
+    b.WriteString("# HELP gateway_request_duration_seconds Request latency in seconds.\n")
+    b.WriteString("# TYPE gateway_request_duration_seconds histogram\n")
+    for i, bound := range durationBuckets {
+        fmt.Fprintf(&b, "gateway_request_duration_seconds_bucket{le=\"%s\"} %d\n",
+            strconv.FormatFloat(bound, 'g', -1, 64), m.bucketCounts[i])
+    }
+    fmt.Fprintf(&b, "gateway_request_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.durationCount)
+    fmt.Fprintf(&b, "gateway_request_duration_seconds_sum %g\n", m.durationSum)
+    fmt.Fprintf(&b, "gateway_request_duration_seconds_count %d\n", m.durationCount)
+
+    names := make([]string, 0, len(m.gauges))
+    for name := range m.gauges {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        g := m.gauges[name]
+        fmt.Fprintf(&b, "# HELP %s %s\n", name, g.help)
+        fmt.Fprintf(&b, "# TYPE %s gauge\n", name)
+        fmt.Fprintf(&b, "%s %g\n", name, g.value())
+    }
+
+    return b.String()
+}
+
+// statusRecorder captures the response status code for metrics.
+type statusRecorder struct {
+    http.ResponseWriter
+    status      int
+    wroteHeader bool
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+    if !sr.wroteHeader {
+        sr.wroteHeader = true
+        sr.status = status
+    }
+    sr.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer for flushing.
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+    return sr.ResponseWriter
+}
@@ -0,0 +1,105 @@
+package metrics_test
+
+import (
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "testing"
+
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/metrics"
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/proxy"
+)
+
+// newMeteredGateway serves /metrics and proxies everything else to a backend that
+// answers /missing with 404 and anything else with 200.
+func newMeteredGateway(t *testing.T, token string) (*httptest.Server, *metrics.Registry) {
+    t.Helper()
+    backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/missing" {
+            http.NotFound(w, r)
+            return
+        }
+        io.WriteString(w, "ok")
+    }))
+    t.Cleanup(backend.Close)
+
+    target, _ := url.Parse(backend.URL)
+    router, err := proxy.NewRouter(nil, target, proxy.Options{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    registry := metrics.NewRegistry()
+    mux := http.NewServeMux()
+    mux.HandleFunc("/metrics", registry.Handler(token))
+    mux.Handle("/", router)
+
+    gateway := httptest.NewServer(registry.Middleware("/metrics", mux))
+    t.Cleanup(gateway.Close)
+    return gateway, registry
+}
+
+func get(t *testing.T, url, token string) (int, string) {
+    t.Helper()
+    req, _ := http.NewRequest(http.MethodGet, url, nil)
+    if token != "" {
+        req.Header.Set("Authorization", "Bearer "+token)
+    }
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    body, _ := io.ReadAll(resp.Body)
+    return resp.StatusCode, string(body)
+}
+
+func TestMetricsAfterProxiedRequests(t *testing.T) {
+    gateway, registry := newMeteredGateway(t, "")
+    registry.RegisterGauge("gateway_test_gauge", "A gauge read at scrape time.", func() float64 { return 1.5 })
+
+    for _, path := range []string{"/jobs", "/jobs/1", "/missing"} {
+        get(t, gateway.URL+path, "")
+    }
+    status, body := get(t, gateway.URL+"/metrics", "")
+    if status != http.StatusOK {
+        t.Fatalf("scrape status = %d", status)
+    }
+
+    // The scrape itself is not counted
+    for _, line := range []string{
+        "gateway_requests_total 3",
+        "gateway_requests_in_flight 0",
+        `gateway_responses_total{code="200"} 2`,
+        `gateway_responses_total{code="404"} 1`,
+        `gateway_request_duration_seconds_bucket{le="+Inf"} 3`,
+        "gateway_request_duration_seconds_count 3",
+        "# TYPE gateway_test_gauge gauge",
+        "gateway_test_gauge 1.5",
+    } {
+        if !strings.Contains(body, line+"\n") {
+            t.Errorf("scrape is missing %q:\n%s", line, body)
+        }
+    }
+
+    // A second scrape still sees only the proxied requests
+    _, body = get(t, gateway.URL+"/metrics", "")
+    if !strings.Contains(body, "gateway_requests_total 3\n") {
+        t.Errorf("scrapes were counted:\n%s", body)
+    }
+}
+
+func TestMetricsToken(t *testing.T) {
+    gateway, _ := newMeteredGateway(t, "scrape-secret")
+
+    if status, _ := get(t, gateway.URL+"/metrics", ""); status != http.StatusUnauthorized {
+        t.Errorf("scrape without token: status = %d, want %d", status, http.StatusUnauthorized)
+    }
+    if status, _ := get(t, gateway.URL+"/metrics", "wrong"); status != http.StatusUnauthorized {
+        t.Errorf("scrape with wrong token: status = %d, want %d", status, http.StatusUnauthorized)
+    }
+    if status, _ := get(t, gateway.URL+"/metrics", "scrape-secret"); status != http.StatusOK {
+        t.Errorf("scrape with token: status = %d, want %d", status, http.StatusOK)
+    }
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+    "net/http"
+    "strconv"
+    "strings"
+)
+
+// CORSOptions configures which cross-origin requests the gateway allows.
+type CORSOptions struct {
+    // AllowedOrigins lists exact origins, "*" for any origin, or
+    // "https://*.example.com" style subdomain wildcards.
+    AllowedOrigins   []string
+    AllowedMethods   []string
+    AllowedHeaders   []string
+    AllowCredentials bool
+    MaxAgeSeconds    int
+}
+
+// CORS answers preflight requests at the gateway and adds Access-Control-* headers
+// to proxied responses, replacing any the backend sent. With no allowed origins
+// configured, requests pass through untouched.
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+    methods := strings.Join(opts.AllowedMethods, ", ")
+    headers := strings.Join(opts.AllowedHeaders, ", ")
+
+    return func(next http.Handler) http.Handler {
+        if len(opts.AllowedOrigins) == 0 {
+            return next
+        }
+
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            origin := r.Header.Get("Origin")
+            if origin == "" {
+                next.ServeHTTP(w, r)
+                return
+            }
+
+            allowed := opts.originAllowed(origin)
+            isPreflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+            if isPreflight {
+                if !allowed {
+                    w.WriteHeader(http.StatusForbidden)
+                    return
+                }
+                opts.setOriginHeaders(w.Header(), origin)
+                w.Header().Set("Access-Control-Allow-Methods", methods)
+                if headers != "" {
+                    w.Header().Set("Access-Control-Allow-Headers", headers)
+                } else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+                    w.Header().Set("Access-Control-Allow-Headers", requested)
+                }
+                if opts.MaxAgeSeconds > 0 {
+                    w.Header().Set("Access-Control-Max-Age", strconv.Itoa(opts.MaxAgeSeconds))
+                }
+                w.WriteHeader(http.StatusNoContent)
+                return
+            }
+
+            if !allowed {
+                next.ServeHTTP(w, r)
+                return
+            }
+            next.ServeHTTP(&corsResponseWriter{ResponseWriter: w, opts: &opts, origin: origin}, r)
+        })
+    }
+}
+
+// originAllowed reports whether origin matches the allowlist.
+func (opts *CORSOptions) originAllowed(origin string) bool {
+    for _, allowed := range opts.AllowedOrigins {
+        if allowed == "*" || strings.EqualFold(allowed, origin) {
+            return true
+        }
+        if scheme, domain, found := strings.Cut(allowed, "*."); found {
+            host := strings.TrimPrefix(origin, scheme)
+            if strings.HasPrefix(origin, scheme) && strings.HasSuffix(host, "."+domain) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// setOriginHeaders sets the allow-origin headers. Credentialed requests may not use
+// "*", so the request origin is echoed back instead.
+func (opts *CORSOptions) setOriginHeaders(h http.Header, origin string) {
+    h.Del("Access-Control-Allow-Origin")
+    h.Del("Access-Control-Allow-Credentials")
+    if opts.AllowCredentials || !opts.allowsAnyOrigin() {
+        h.Set("Access-Control-Allow-Origin", origin)
+        h.Add("Vary", "Origin")
+    } else {
+        h.Set("Access-Control-Allow-Origin", "*")
+    }
+    if opts.AllowCredentials {
+        h.Set("Access-Control-Allow-Credentials", "true")
+    }
+}
+
+func (opts *CORSOptions) allowsAnyOrigin() bool {
+    for _, allowed := range opts.AllowedOrigins {
+        if allowed == "*" {
+            return true
+        }
+    }
+    return false
+}
+
+// corsResponseWriter applies CORS headers just before the response is written,
+// after the reverse proxy has copied the backend's headers.
+type corsResponseWriter struct {
+    http.ResponseWriter
+    opts        *CORSOptions
+    origin      string
+    wroteHeader bool
+}
+
+func (cw *corsResponseWriter) WriteHeader(status int) {
+    if !cw.wroteHeader {
+        cw.wroteHeader = true
+        cw.opts.setOriginHeaders(cw.Header(), cw.origin)
+    }
+    cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *corsResponseWriter) Write(b []byte) (int, error) {
+    if !cw.wroteHeader {
+        cw.WriteHeader(http.StatusOK)
+    }
+    return cw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer for flushing.
+func (cw *corsResponseWriter) Unwrap() http.ResponseWriter {
+    return cw.ResponseWriter
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+// backendWithCORS stands in for a backend that sends its own CORS headers.
+var backendWithCORS = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Access-Control-Allow-Origin", "https://backend.example")
+    w.Write([]byte("ok"))
+})
+
+func corsRequest(handler http.Handler, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+    req := httptest.NewRequest(method, "/api/jobs", nil)
+    if origin != "" {
+        req.Header.Set("Origin", origin)
+    }
+    for name, value := range headers {
+        req.Header.Set(name, value)
+    }
+    rec := httptest.NewRecorder()
+    handler.ServeHTTP(rec, req)
+    return rec
+}
+
+func TestCORSPreflight(t *testing.T) {
+    handler := CORS(CORSOptions{
+        AllowedOrigins: []string{"https://app.example.com", "https://*.jobs.example"},
+        AllowedMethods: []string{"GET", "POST"},
+        AllowedHeaders: []string{"Authorization", "Content-Type"},
+        MaxAgeSeconds:  600,
+    })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        t.Error("preflight reached the backend")
+    }))
+    preflight := map[string]string{"Access-Control-Request-Method": "POST"}
+
+    for _, origin := range []string{"https://app.example.com", "https://eu.jobs.example"} {
+        rec := corsRequest(handler, http.MethodOptions, origin, preflight)
+        if rec.Code != http.StatusNoContent {
+            t.Fatalf("%s: status = %d, want %d", origin, rec.Code, http.StatusNoContent)
+        }
+        want := map[string]string{
+            "Access-Control-Allow-Origin":  origin,
+            "Access-Control-Allow-Methods": "GET, POST",
+            "Access-Control-Allow-Headers": "Authorization, Content-Type",
+            "Access-Control-Max-Age":       "600",
+            "Vary":                         "Origin",
+        }
+        for name, value := range want {
+            if got := rec.Header().Get(name); got != value {
+                t.Errorf("%s: %s = %q, want %q", origin, name, got, value)
+            }
+        }
+    }
+
+    rec := corsRequest(handler, http.MethodOptions, "https://evil.example", preflight)
+    if rec.Code != http.StatusForbidden {
+        t.Errorf("disallowed origin: status = %d, want %d", rec.Code, http.StatusForbidden)
+    }
+    if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+        t.Errorf("disallowed origin got Access-Control-Allow-Origin %q", got)
+    }
+}
+
+func TestCORSPreflightEchoesRequestedHeaders(t *testing.T) {
+    handler := CORS(CORSOptions{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}})(backendWithCORS)
+    rec := corsRequest(handler, http.MethodOptions, "https://app.example.com", map[string]string{
+        "Access-Control-Request-Method":  "GET",
+        "Access-Control-Request-Headers": "X-Custom",
+    })
+    if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "X-Custom" {
+        t.Errorf("Access-Control-Allow-Headers = %q, want X-Custom", got)
+    }
+    if got := rec.Header().Get("Access-Control-Max-Age"); got != "" {
+        t.Errorf("Access-Control-Max-Age = %q, want unset", got)
+    }
+}
+
+func TestCORSActualRequest(t *testing.T) {
+    tests := []struct {
+        name            string
+        opts            CORSOptions
+        origin          string
+        wantOrigin      string
+        wantCredentials string
+    }{
+        {
+            name:       "allowed origin replaces the backend's header",
+            opts:       CORSOptions{AllowedOrigins: []string{"https://app.example.com"}},
+            origin:     "https://app.example.com",
+            wantOrigin: "https://app.example.com",
+        },
+        {
+            name:       "wildcard",
+            opts:       CORSOptions{AllowedOrigins: []string{"*"}},
+            origin:     "https://app.example.com",
+            wantOrigin: "*",
+        },
+        {
+            name:            "credentials echo the origin instead of a wildcard",
+            opts:            CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+            origin:          "https://app.example.com",
+            wantOrigin:      "https://app.example.com",
+            wantCredentials: "true",
+        },
+        {
+            name:       "disallowed origin passes the backend's headers through",
+            opts:       CORSOptions{AllowedOrigins: []string{"https://app.example.com"}},
+            origin:     "https://evil.example",
+            wantOrigin: "https://backend.example",
+        },
+        {
+            name:       "no origin header",
+            opts:       CORSOptions{AllowedOrigins: []string{"*"}},
+            wantOrigin: "https://backend.example",
+        },
+        {
+            name:       "CORS disabled",
+            origin:     "https://app.example.com",
+            wantOrigin: "https://backend.example",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rec := corsRequest(CORS(tt.opts)(backendWithCORS), http.MethodGet, tt.origin, nil)
+            if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+                t.Fatalf("response = %d %q, want the backend's", rec.Code, rec.Body.String())
+            }
+            if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+                t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+            }
+            if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+                t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+            }
+        })
+    }
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+    "log/slog"
+    "net/http"
+)
+
+// MaxRequestBody limits request bodies to maxBytes. Requests that declare a larger
+// Content-Length are rejected with 413 up front; chunked bodies are capped by
+// http.MaxBytesReader and fail when the proxy reads past the limit.
+func MaxRequestBody(maxBytes int64) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            if maxBytes <= 0 {
+                next.ServeHTTP(w, r)
+                return
+            }
+
+            if r.ContentLength > maxBytes {
+                slog.Warn("Rejected oversized request",
+                    "request_id", RequestIDFromContext(r.Context()),
+                    "method", r.Method,
+                    "path", r.URL.Path,
+                    "content_length", r.ContentLength,
+                    "max_bytes", maxBytes,
+                )
+                http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+                return
+            }
+
+            r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+            next.ServeHTTP(w, r)
+        })
+    }
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "runtime/debug"
+)
+
+// Recover turns a panic in next into a logged 500 JSON error instead of a dropped
+// connection. It must run inside RequestID so the log line carries the request ID.
+// http.ErrAbortHandler is re-panicked, since it deliberately aborts the response.
+func Recover(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        tracked := &headerTracker{ResponseWriter: w}
+        defer func() {
+            recovered := recover()
+            if recovered == nil {
+                return
+            }
+            if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+                panic(recovered)
+            }
+
+            slog.Error("Recovered from handler panic",
+                "request_id", RequestIDFromContext(r.Context()),
+                "method", r.Method,
+                "path", r.URL.Path,
+                "panic", fmt.Sprint(recovered),
+                "stack", string(debug.Stack()),
+            )
+
+            // Once the response has started, the status can no longer be changed.
+            if tracked.wroteHeader {
+                return
+            }
+            w.Header().Set("Content-Type", "application/json")
+            w.WriteHeader(http.StatusInternalServerError)
+            if err := json.NewEncoder(w).Encode(map[string]string{
+                "error":  "internal_error",
+                "detail": "the gateway failed to handle the request",
+            }); err != nil {
+                slog.Error("Failed to encode panic error response", "error", err)
+            }
+        }()
+
+        next.ServeHTTP(tracked, r)
+    })
+}
+
+// headerTracker records whether the response status has been sent.
+type headerTracker struct {
+    http.ResponseWriter
+    wroteHeader bool
+}
+
+func (t *headerTracker) WriteHeader(status int) {
+    t.wroteHeader = true
+    t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *headerTracker) Write(b []byte) (int, error) {
+    t.wroteHeader = true
+    return t.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer for flushing.
+func (t *headerTracker) Unwrap() http.ResponseWriter {
+    return t.ResponseWriter
+}
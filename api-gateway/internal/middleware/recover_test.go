@@ -0,0 +1,156 @@
+package middleware
+
+import (
+    "bytes"
+    "encoding/json"
+    "io"
+    "log/slog"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+)
+
+// syncBuffer is a bytes.Buffer safe to log into from server goroutines.
+type syncBuffer struct {
+    mu  sync.Mutex
+    buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return b.buf.String()
+}
+
+// captureLogs sends the default slog output to a buffer for the rest of the test.
+func captureLogs(t *testing.T) *syncBuffer {
+    t.Helper()
+    logs := &syncBuffer{}
+    previous := slog.Default()
+    slog.SetDefault(slog.New(slog.NewJSONHandler(logs, nil)))
+    t.Cleanup(func() { slog.SetDefault(previous) })
+    return logs
+}
+
+// newPanicServer serves /panic, /partial and /abort, which panic, and /ok, which
+// does not, behind RequestID and Recover as main.go wires them.
+func newPanicServer(t *testing.T) *httptest.Server {
+    t.Helper()
+    mux := http.NewServeMux()
+    mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+        panic("nil map write")
+    })
+    mux.HandleFunc("/partial", func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusAccepted)
+        w.Write([]byte("started"))
+        panic("failed mid-response")
+    })
+    mux.HandleFunc("/abort", func(w http.ResponseWriter, r *http.Request) {
+        panic(http.ErrAbortHandler)
+    })
+    mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte("ok"))
+    })
+    server := httptest.NewServer(RequestID(Recover(mux)))
+    t.Cleanup(server.Close)
+    return server
+}
+
+func getPath(t *testing.T, server *httptest.Server, path string) (*http.Response, string) {
+    t.Helper()
+    resp, err := server.Client().Get(server.URL + path)
+    if err != nil {
+        t.Fatalf("GET %s: %v", path, err)
+    }
+    defer resp.Body.Close()
+    body, err := io.ReadAll(resp.Body)
+    if err != nil {
+        t.Fatalf("reading %s: %v", path, err)
+    }
+    return resp, string(body)
+}
+
+func TestRecoverReturns500AndKeepsServing(t *testing.T) {
+    logs := captureLogs(t)
+    server := newPanicServer(t)
+
+    resp, body := getPath(t, server, "/panic")
+    if resp.StatusCode != http.StatusInternalServerError {
+        t.Fatalf("status = %d, want 500", resp.StatusCode)
+    }
+    if got := resp.Header.Get("Content-Type"); got != "application/json" {
+        t.Errorf("Content-Type = %q, want application/json", got)
+    }
+    var payload map[string]string
+    if err := json.Unmarshal([]byte(body), &payload); err != nil {
+        t.Fatalf("body %q is not JSON: %v", body, err)
+    }
+    if payload["error"] != "internal_error" {
+        t.Errorf("error = %q, want internal_error", payload["error"])
+    }
+    requestID := resp.Header.Get(RequestIDHeader)
+    if requestID == "" {
+        t.Error("500 response has no request ID")
+    }
+
+    // The panic is logged with the request ID and a stack trace
+    var entry map[string]any
+    for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+        if strings.Contains(line, "Recovered from handler panic") {
+            if err := json.Unmarshal([]byte(line), &entry); err != nil {
+                t.Fatal(err)
+            }
+        }
+    }
+    if entry == nil {
+        t.Fatalf("panic was not logged:\n%s", logs.String())
+    }
+    if entry["request_id"] != requestID || entry["panic"] != "nil map write" || entry["path"] != "/panic" {
+        t.Errorf("log entry = %v, want request_id %s, panic and path", entry, requestID)
+    }
+    if stack, _ := entry["stack"].(string); !strings.Contains(stack, "recover_test.go") {
+        t.Errorf("log stack does not reach the panicking handler:\n%s", stack)
+    }
+
+    // The server survives and keeps serving other requests
+    for i := 0; i < 3; i++ {
+        if resp, body := getPath(t, server, "/ok"); resp.StatusCode != http.StatusOK || body != "ok" {
+            t.Errorf("after a panic, /ok = %d %q, want 200 ok", resp.StatusCode, body)
+        }
+    }
+}
+
+func TestRecoverKeepsStatusAlreadySent(t *testing.T) {
+    captureLogs(t)
+    server := newPanicServer(t)
+
+    resp, body := getPath(t, server, "/partial")
+    if resp.StatusCode != http.StatusAccepted || body != "started" {
+        t.Errorf("/partial = %d %q, want the 202 already sent and no error body", resp.StatusCode, body)
+    }
+}
+
+func TestRecoverRepanicsAbortHandler(t *testing.T) {
+    logs := captureLogs(t)
+    server := newPanicServer(t)
+
+    // net/http aborts the connection, so the client sees no response at all
+    if resp, err := server.Client().Get(server.URL + "/abort"); err == nil {
+        resp.Body.Close()
+        t.Fatalf("/abort returned %d, want the connection aborted", resp.StatusCode)
+    }
+    if strings.Contains(logs.String(), "Recovered from handler panic") {
+        t.Error("http.ErrAbortHandler was logged as a recovered panic")
+    }
+    if resp, _ := getPath(t, server, "/ok"); resp.StatusCode != http.StatusOK {
+        t.Errorf("after an abort, /ok = %d, want 200", resp.StatusCode)
+    }
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "net/http"
+)
+
+// RequestIDHeader is the header used to propagate request IDs to clients and backends.
+const RequestIDHeader = "X-Request-ID"
+
+type contextKey string
+
+const requestIDKey contextKey = "request_id"
+
+// RequestID ensures every request carries an ID, reusing the client's X-Request-ID when present.
+// The ID is stored in the request context and echoed back in the response headers.
+func RequestID(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        requestID := r.Header.Get(RequestIDHeader)
+        if requestID == "" {
+            requestID = newRequestID()
+            r.Header.Set(RequestIDHeader, requestID)
+        }
+
+        w.Header().Set(RequestIDHeader, requestID)
+        ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+        next.ServeHTTP(w, r.WithContext(ctx))
+    })
+}
+
+// RequestIDFromContext returns the request ID set by RequestID, or "" if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+    requestID, _ := ctx.Value(requestIDKey).(string)
+    return requestID
+}
+
+func newRequestID() string {
+    b := make([]byte, 16)
+    if _, err := rand.Read(b); err != nil {
+        return "unknown"
+    }
+    return hex.EncodeToString(b)
+}
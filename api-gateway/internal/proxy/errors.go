@@ -0,0 +1,110 @@
+package proxy
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net"
+    "net/http"
+    "syscall"
+
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/middleware"
+)
+
+// ErrorKind classifies why a request could not be proxied to the backend.
+type ErrorKind string
+
+const (
+    ErrorKindDNS               ErrorKind = "dns_failure"
+    ErrorKindConnectionRefused ErrorKind = "connection_refused"
+    ErrorKindTimeout           ErrorKind = "timeout"
+    ErrorKindCanceled          ErrorKind = "client_canceled"
+    ErrorKindRequestTooLarge   ErrorKind = "request_too_large"
+    ErrorKindUnknown           ErrorKind = "unknown"
+)
+
+// ErrorResponse is the JSON body returned to clients when proxying fails.
+type ErrorResponse struct {
+    Error  string `json:"error"`
+    Detail string `json:"detail"`
+}
+
+// ClassifyError determines the kind of a proxy transport error.
+func ClassifyError(err error) ErrorKind {
+    var dnsErr *net.DNSError
+    var netErr net.Error
+    var maxBytesErr *http.MaxBytesError
+
+    switch {
+    case errors.As(err, &maxBytesErr):
+        return ErrorKindRequestTooLarge
+    case errors.Is(err, context.Canceled):
+        return ErrorKindCanceled
+    case errors.Is(err, context.DeadlineExceeded):
+        return ErrorKindTimeout
+    case errors.As(err, &dnsErr):
+        if dnsErr.IsTimeout {
+            return ErrorKindTimeout
+        }
+        return ErrorKindDNS
+    case errors.Is(err, syscall.ECONNREFUSED):
+        return ErrorKindConnectionRefused
+    case errors.As(err, &netErr) && netErr.Timeout():
+        return ErrorKindTimeout
+    default:
+        return ErrorKindUnknown
+    }
+}
+
+// ErrorHandler is an httputil.ReverseProxy ErrorHandler that logs the failure with
+// its request ID and returns a JSON error body instead of a bare 502.
+func ErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+    kind := ClassifyError(err)
+
+    slog.Error("Proxy request failed",
+        "request_id", middleware.RequestIDFromContext(r.Context()),
+        "method", r.Method,
+        "path", r.URL.Path,
+        "kind", string(kind),
+        "error", err,
+    )
+
+    // The client is gone, so there is nobody to send a response to.
+    if kind == ErrorKindCanceled {
+        return
+    }
+
+    status := http.StatusBadGateway
+    response := ErrorResponse{Error: "bad_gateway", Detail: describeError(kind)}
+    switch kind {
+    case ErrorKindTimeout:
+        status = http.StatusGatewayTimeout
+        response.Error = "gateway_timeout"
+    case ErrorKindRequestTooLarge:
+        status = http.StatusRequestEntityTooLarge
+        response.Error = "request_too_large"
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    if err := json.NewEncoder(w).Encode(response); err != nil {
+        slog.Error("Failed to encode proxy error response", "error", err)
+    }
+}
+
+// describeError returns a client-safe description that does not leak backend addresses.
+func describeError(kind ErrorKind) string {
+    switch kind {
+    case ErrorKindDNS:
+        return "backend host could not be resolved"
+    case ErrorKindConnectionRefused:
+        return "backend refused the connection"
+    case ErrorKindTimeout:
+        return "backend did not respond in time"
+    case ErrorKindRequestTooLarge:
+        return "request body exceeds the gateway limit"
+    default:
+        return "backend request failed"
+    }
+}
@@ -0,0 +1,109 @@
+package proxy
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "os"
+    "syscall"
+    "testing"
+
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/middleware"
+)
+
+// closedServerURL returns the URL of a server that has stopped listening.
+func closedServerURL(t *testing.T) *url.URL {
+    t.Helper()
+    server := httptest.NewServer(http.NotFoundHandler())
+    target, err := url.Parse(server.URL)
+    if err != nil {
+        t.Fatal(err)
+    }
+    server.Close()
+    return target
+}
+
+func TestProxyConnectionRefused(t *testing.T) {
+    router, err := NewRouter(nil, closedServerURL(t), Options{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    handler := middleware.RequestID(router)
+
+    req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+    req.Header.Set(middleware.RequestIDHeader, "req-123")
+    rec := httptest.NewRecorder()
+    handler.ServeHTTP(rec, req)
+
+    if rec.Code != http.StatusBadGateway {
+        t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadGateway)
+    }
+    if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+        t.Errorf("Content-Type = %q, want application/json", contentType)
+    }
+    var body ErrorResponse
+    if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+        t.Fatalf("body %q is not JSON: %v", rec.Body.String(), err)
+    }
+    want := ErrorResponse{Error: "bad_gateway", Detail: "backend refused the connection"}
+    if body != want {
+        t.Errorf("body = %+v, want %+v", body, want)
+    }
+}
+
+func TestClassifyError(t *testing.T) {
+    timeout := &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}
+    tests := []struct {
+        name string
+        err  error
+        want ErrorKind
+    }{
+        {"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, ErrorKindConnectionRefused},
+        {"dns failure", &net.DNSError{Err: "no such host", Name: "backend.invalid", IsNotFound: true}, ErrorKindDNS},
+        {"dns timeout", &net.DNSError{Err: "timeout", Name: "backend", IsTimeout: true}, ErrorKindTimeout},
+        {"network timeout", timeout, ErrorKindTimeout},
+        {"deadline", fmt.Errorf("round trip: %w", context.DeadlineExceeded), ErrorKindTimeout},
+        {"client canceled", context.Canceled, ErrorKindCanceled},
+        {"request too large", &http.MaxBytesError{Limit: 10}, ErrorKindRequestTooLarge},
+        {"other", fmt.Errorf("tls: handshake failure"), ErrorKindUnknown},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := ClassifyError(tt.err); got != tt.want {
+                t.Errorf("ClassifyError(%v) = %s, want %s", tt.err, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestErrorHandlerStatuses(t *testing.T) {
+    tests := []struct {
+        err        error
+        wantStatus int
+        wantError  string
+    }{
+        {context.DeadlineExceeded, http.StatusGatewayTimeout, "gateway_timeout"},
+        {&http.MaxBytesError{Limit: 10}, http.StatusRequestEntityTooLarge, "request_too_large"},
+        {fmt.Errorf("unexpected"), http.StatusBadGateway, "bad_gateway"},
+    }
+    for _, tt := range tests {
+        rec := httptest.NewRecorder()
+        ErrorHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil), tt.err)
+        var body ErrorResponse
+        json.Unmarshal(rec.Body.Bytes(), &body)
+        if rec.Code != tt.wantStatus || body.Error != tt.wantError {
+            t.Errorf("%v: got %d %q, want %d %q", tt.err, rec.Code, body.Error, tt.wantStatus, tt.wantError)
+        }
+    }
+
+    // A canceled client gets no response at all
+    rec := httptest.NewRecorder()
+    ErrorHandler(rec, httptest.NewRequest(http.MethodGet, "/", nil), context.Canceled)
+    if rec.Body.Len() != 0 {
+        t.Errorf("canceled request got a body: %q", rec.Body.String())
+    }
+}
@@ -0,0 +1,31 @@
+package proxy
+
+import (
+    "net/http"
+)
+
+// HeaderRules adds and removes headers on proxied traffic. Request headers are removed
+// before RequestAdd is applied, so a rule can replace a client-supplied value.
+type HeaderRules struct {
+    RequestAdd     map[string]string
+    RequestRemove  []string
+    ResponseRemove []string
+}
+
+// applyRequest rewrites the headers sent to the backend.
+func (h HeaderRules) applyRequest(header http.Header) {
+    for _, name := range h.RequestRemove {
+        header.Del(name)
+    }
+    for name, value := range h.RequestAdd {
+        header.Set(name, value)
+    }
+}
+
+// applyResponse strips headers from the backend response before it reaches the client.
+func (h HeaderRules) applyResponse(resp *http.Response) error {
+    for _, name := range h.ResponseRemove {
+        resp.Header.Del(name)
+    }
+    return nil
+}
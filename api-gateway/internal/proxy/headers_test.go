@@ -0,0 +1,96 @@
+package proxy
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "testing"
+)
+
+// headerBackend answers with the request headers it received, as JSON, and sets a few
+// response headers of its own.
+func headerBackend(t *testing.T) *url.URL {
+    t.Helper()
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Server", "backend/1.2")
+        w.Header().Set("X-Powered-By", "Express")
+        w.Header().Set("X-Job-Count", "3")
+        json.NewEncoder(w).Encode(r.Header)
+    }))
+    t.Cleanup(server.Close)
+    target, _ := url.Parse(server.URL)
+    return target
+}
+
+func TestHeaderRules(t *testing.T) {
+    router, err := NewRouter(nil, headerBackend(t), Options{Headers: HeaderRules{
+        RequestAdd:     map[string]string{"X-Gateway": "api-gateway", "X-Tenant": "acme"},
+        RequestRemove:  []string{"x-internal-token", "X-Tenant"},
+        ResponseRemove: []string{"server", "X-Powered-By"},
+    }})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+    req.Header.Set("X-Internal-Token", "secret")
+    req.Header.Add("X-Tenant", "spoofed")
+    req.Header.Add("X-Tenant", "also-spoofed")
+    req.Header.Set("Accept", "application/json")
+    rec := httptest.NewRecorder()
+    router.ServeHTTP(rec, req)
+
+    var received http.Header
+    if err := json.Unmarshal(rec.Body.Bytes(), &received); err != nil {
+        t.Fatalf("backend response %q: %v", rec.Body.String(), err)
+    }
+
+    // Client to backend: removes match case-insensitively and run before adds, so an
+    // added header replaces every client-supplied value
+    if got := received.Values("X-Internal-Token"); len(got) != 0 {
+        t.Errorf("backend received removed X-Internal-Token %q", got)
+    }
+    if got := received.Values("X-Tenant"); len(got) != 1 || got[0] != "acme" {
+        t.Errorf("backend received X-Tenant %q, want only the gateway's acme", got)
+    }
+    if got := received.Get("X-Gateway"); got != "api-gateway" {
+        t.Errorf("backend received X-Gateway %q, want api-gateway", got)
+    }
+    if got := received.Get("Accept"); got != "application/json" {
+        t.Errorf("backend received Accept %q, want the client's header untouched", got)
+    }
+
+    // Backend to client: removed headers are stripped and the rest pass through
+    for _, name := range []string{"Server", "X-Powered-By"} {
+        if got := rec.Header().Get(name); got != "" {
+            t.Errorf("client received removed %s %q", name, got)
+        }
+    }
+    if got := rec.Header().Get("X-Job-Count"); got != "3" {
+        t.Errorf("client received X-Job-Count %q, want 3", got)
+    }
+}
+
+func TestNoHeaderRulesPassesHeadersThrough(t *testing.T) {
+    router, err := NewRouter(nil, headerBackend(t), Options{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+    req.Header.Set("X-Internal-Token", "secret")
+    rec := httptest.NewRecorder()
+    router.ServeHTTP(rec, req)
+
+    var received http.Header
+    if err := json.Unmarshal(rec.Body.Bytes(), &received); err != nil {
+        t.Fatal(err)
+    }
+    if received.Get("X-Internal-Token") != "secret" {
+        t.Error("request header was dropped without a rule")
+    }
+    if rec.Header().Get("Server") != "backend/1.2" || rec.Header().Get("X-Powered-By") != "Express" {
+        t.Error("response headers were dropped without a rule")
+    }
+}
@@ -0,0 +1,136 @@
+package proxy
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "io"
+    "log/slog"
+    "net/http"
+    "syscall"
+    "time"
+
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/middleware"
+)
+
+// RetryOptions configures retries of failed backend requests.
+type RetryOptions struct {
+    // Retries is how many times an idempotent request is retried; 0 disables retries.
+    Retries int
+    // Backoff is the delay before the first retry, doubled for each further one.
+    Backoff time.Duration
+    // MaxBodyBytes bounds the request body buffered for replay; larger requests are not retried.
+    MaxBodyBytes int64
+}
+
+// retryTransport retries idempotent requests that fail with a transient transport error
+// or a 502/503/504 from the backend. Routes have a single backend, so retries go to it.
+type retryTransport struct {
+    next http.RoundTripper
+    opts RetryOptions
+}
+
+func newRetryTransport(next http.RoundTripper, opts RetryOptions) http.RoundTripper {
+    if opts.Retries <= 0 {
+        return next
+    }
+    return &retryTransport{next: next, opts: opts}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    if !isIdempotent(req.Method) {
+        return t.next.RoundTrip(req)
+    }
+
+    body, replayable, err := bufferBody(req, t.opts.MaxBodyBytes)
+    if err != nil {
+        return nil, err
+    }
+    if !replayable {
+        return t.next.RoundTrip(req)
+    }
+
+    delay := t.opts.Backoff
+    for attempt := 0; ; attempt++ {
+        if body != nil {
+            req.Body = io.NopCloser(bytes.NewReader(body))
+        }
+        resp, err := t.next.RoundTrip(req)
+        if attempt >= t.opts.Retries || !shouldRetry(resp, err) {
+            return resp, err
+        }
+
+        reason := "transport error"
+        if err == nil {
+            reason = resp.Status
+            // Drain so the connection can be reused for the retry.
+            io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+            resp.Body.Close()
+        }
+        slog.Warn("Retrying backend request",
+            "request_id", middleware.RequestIDFromContext(req.Context()),
+            "method", req.Method,
+            "path", req.URL.Path,
+            "attempt", attempt+1,
+            "reason", reason,
+            "error", err,
+        )
+
+        select {
+        case <-req.Context().Done():
+            return nil, req.Context().Err()
+        case <-time.After(delay):
+        }
+        delay *= 2
+    }
+}
+
+// isIdempotent reports whether repeating a request has the same effect as sending it once.
+func isIdempotent(method string) bool {
+    switch method {
+    case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+        return true
+    }
+    return false
+}
+
+// bufferBody reads the request body so it can be replayed. It reports false, leaving the
+// body readable from the start, when the body is larger than maxBytes.
+func bufferBody(req *http.Request, maxBytes int64) ([]byte, bool, error) {
+    if req.Body == nil || req.Body == http.NoBody {
+        return nil, true, nil
+    }
+    if req.ContentLength > maxBytes {
+        return nil, false, nil
+    }
+
+    body, err := io.ReadAll(io.LimitReader(req.Body, maxBytes+1))
+    if err != nil {
+        return nil, false, err
+    }
+    if int64(len(body)) > maxBytes {
+        req.Body = struct {
+            io.Reader
+            io.Closer
+        }{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+        return nil, false, nil
+    }
+    req.Body.Close()
+    return body, true, nil
+}
+
+// shouldRetry reports whether a backend attempt failed transiently.
+func shouldRetry(resp *http.Response, err error) bool {
+    if err != nil {
+        if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+            return false
+        }
+        return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+            errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+    }
+    switch resp.StatusCode {
+    case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+        return true
+    }
+    return false
+}
@@ -0,0 +1,115 @@
+package proxy
+
+import (
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+// flakyBackend fails the first failures requests with 503, then answers with the
+// request body. It records the method and body of every attempt.
+type flakyBackend struct {
+    mu       sync.Mutex
+    failures int
+    attempts []string
+}
+
+func (b *flakyBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    body, _ := io.ReadAll(r.Body)
+    b.mu.Lock()
+    b.attempts = append(b.attempts, r.Method+" "+string(body))
+    failing := len(b.attempts) <= b.failures
+    b.mu.Unlock()
+    if failing {
+        http.Error(w, "warming up", http.StatusServiceUnavailable)
+        return
+    }
+    io.WriteString(w, "ok "+string(body))
+}
+
+func (b *flakyBackend) Attempts() []string {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return append([]string(nil), b.attempts...)
+}
+
+// newFlakyRouter proxies every path to a backend that fails failures times first.
+func newFlakyRouter(t *testing.T, failures int, retry RetryOptions) (*Router, *flakyBackend) {
+    t.Helper()
+    backend := &flakyBackend{failures: failures}
+    server := httptest.NewServer(backend)
+    t.Cleanup(server.Close)
+    target, _ := url.Parse(server.URL)
+    router, err := NewRouter(nil, target, Options{Retry: retry})
+    if err != nil {
+        t.Fatal(err)
+    }
+    return router, backend
+}
+
+var testRetries = RetryOptions{Retries: 2, Backoff: time.Millisecond, MaxBodyBytes: 1024}
+
+func TestRetrySucceedsOnSecondAttempt(t *testing.T) {
+    for _, method := range []string{http.MethodGet, http.MethodPut} {
+        t.Run(method, func(t *testing.T) {
+            router, backend := newFlakyRouter(t, 1, testRetries)
+            rec := httptest.NewRecorder()
+            router.ServeHTTP(rec, httptest.NewRequest(method, "/jobs/42", strings.NewReader("payload")))
+
+            if rec.Code != http.StatusOK || rec.Body.String() != "ok payload" {
+                t.Errorf("response = %d %q, want 200 from the second attempt", rec.Code, rec.Body.String())
+            }
+            // The body is replayed in full on the retry
+            attempts := backend.Attempts()
+            if len(attempts) != 2 || attempts[0] != method+" payload" || attempts[1] != method+" payload" {
+                t.Errorf("backend attempts = %q, want the same request twice", attempts)
+            }
+        })
+    }
+}
+
+func TestRetryGivesUpAfterRetries(t *testing.T) {
+    router, backend := newFlakyRouter(t, 5, testRetries)
+    rec := httptest.NewRecorder()
+    router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jobs", nil))
+
+    if rec.Code != http.StatusServiceUnavailable {
+        t.Errorf("status = %d, want the backend's last 503", rec.Code)
+    }
+    if attempts := len(backend.Attempts()); attempts != 3 {
+        t.Errorf("backend saw %d attempts, want 1 plus 2 retries", attempts)
+    }
+}
+
+func TestRetrySkipsUnsafeRequests(t *testing.T) {
+    tests := []struct {
+        name   string
+        method string
+        body   string
+        retry  RetryOptions
+    }{
+        {"non-idempotent method", http.MethodPost, "payload", testRetries},
+        {"body too large to replay", http.MethodPut, strings.Repeat("x", 2048), testRetries},
+        {"retries disabled", http.MethodGet, "", RetryOptions{}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            router, backend := newFlakyRouter(t, 1, tt.retry)
+            rec := httptest.NewRecorder()
+            router.ServeHTTP(rec, httptest.NewRequest(tt.method, "/jobs", strings.NewReader(tt.body)))
+
+            if rec.Code != http.StatusServiceUnavailable {
+                t.Errorf("status = %d, want the first attempt's 503", rec.Code)
+            }
+            attempts := backend.Attempts()
+            if len(attempts) != 1 || attempts[0] != tt.method+" "+tt.body {
+                t.Errorf("backend saw %d attempts, want the full request once", len(attempts))
+            }
+        })
+    }
+}
@@ -0,0 +1,120 @@
+package proxy
+
+import (
+    "fmt"
+    "net/http"
+    "net/http/httputil"
+    "net/url"
+    "sort"
+    "strings"
+
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/config"
+)
+
+// route is a configured prefix with its own reverse proxy.
+type route struct {
+    prefix      string
+    stripPrefix bool
+    proxy       *httputil.ReverseProxy
+}
+
+// Router dispatches requests to backends by longest path-prefix match,
+// falling back to the default backend when no route matches.
+type Router struct {
+    routes       []route
+    defaultProxy *httputil.ReverseProxy
+}
+
+// Options configures behaviour shared by every route's proxy.
+type Options struct {
+    Retry   RetryOptions
+    Headers HeaderRules
+}
+
+// NewRouter builds one reverse proxy per configured route plus the default backend.
+func NewRouter(routes []config.Route, defaultTarget *url.URL, opts Options) (*Router, error) {
+    router := &Router{defaultProxy: newReverseProxy(defaultTarget, opts)}
+
+    for _, r := range routes {
+        if !strings.HasPrefix(r.Prefix, "/") {
+            return nil, fmt.Errorf("route prefix %q must start with '/'", r.Prefix)
+        }
+        target, err := url.Parse(r.Target)
+        if err != nil || target.Scheme == "" || target.Host == "" {
+            return nil, fmt.Errorf("route %s has invalid target %q", r.Prefix, r.Target)
+        }
+
+        router.routes = append(router.routes, route{
+            prefix:      strings.TrimSuffix(r.Prefix, "/"),
+            stripPrefix: r.StripPrefix,
+            proxy:       newReverseProxy(target, opts),
+        })
+    }
+
+    // Longest prefix first so the first match is the most specific one.
+    sort.SliceStable(router.routes, func(i, j int) bool {
+        return len(router.routes[i].prefix) > len(router.routes[j].prefix)
+    })
+
+    return router, nil
+}
+
+// ServeHTTP proxies the request to the backend of the most specific matching route.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    for _, route := range rt.routes {
+        if !matchesPrefix(r.URL.Path, route.prefix) {
+            continue
+        }
+
+        if route.stripPrefix {
+            r = stripPrefix(r, route.prefix)
+        }
+        route.proxy.ServeHTTP(w, r)
+        return
+    }
+
+    rt.defaultProxy.ServeHTTP(w, r)
+}
+
+// matchesPrefix matches whole path segments, so "/api" matches "/api" and
+// "/api/users" but not "/apiary". An empty prefix (from "/") matches everything.
+func matchesPrefix(path, prefix string) bool {
+    if !strings.HasPrefix(path, prefix) {
+        return false
+    }
+    return len(path) == len(prefix) || path[len(prefix)] == '/' || prefix == ""
+}
+
+// stripPrefix returns a shallow copy of r with prefix removed from its path.
+func stripPrefix(r *http.Request, prefix string) *http.Request {
+    stripped := r.Clone(r.Context())
+    stripped.URL.Path = ensureLeadingSlash(strings.TrimPrefix(r.URL.Path, prefix))
+    if r.URL.RawPath != "" {
+        stripped.URL.RawPath = ensureLeadingSlash(strings.TrimPrefix(r.URL.RawPath, prefix))
+    }
+    return stripped
+}
+
+func ensureLeadingSlash(path string) string {
+    if !strings.HasPrefix(path, "/") {
+        return "/" + path
+    }
+    return path
+}
+
+// newReverseProxy creates a proxy for target that retries transient failures, applies
+// the header rules, and reports failures through ErrorHandler.
+func newReverseProxy(target *url.URL, opts Options) *httputil.ReverseProxy {
+    reverseProxy := httputil.NewSingleHostReverseProxy(target)
+    reverseProxy.Transport = newRetryTransport(http.DefaultTransport, opts.Retry)
+
+    director := reverseProxy.Director
+    reverseProxy.Director = func(req *http.Request) {
+        director(req)
+        opts.Headers.applyRequest(req.Header)
+    }
+    reverseProxy.ModifyResponse = opts.Headers.applyResponse
+    // Log backend failures and return a JSON error instead of a bare 502.
+    reverseProxy.ErrorHandler = ErrorHandler
+    return reverseProxy
+}
@@ -0,0 +1,92 @@
+package proxy
+
+import (
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "testing"
+
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/config"
+)
+
+// echoBackend answers every request with its name and the path it received.
+func echoBackend(t *testing.T, name string) string {
+    t.Helper()
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        io.WriteString(w, name+" "+r.URL.Path)
+    }))
+    t.Cleanup(server.Close)
+    return server.URL
+}
+
+func TestRouterPrefixMatching(t *testing.T) {
+    defaultTarget, _ := url.Parse(echoBackend(t, "default"))
+    router, err := NewRouter([]config.Route{
+        {Prefix: "/api", Target: echoBackend(t, "api")},
+        {Prefix: "/api/admin/", Target: echoBackend(t, "admin"), StripPrefix: true},
+        {Prefix: "/jobs", Target: echoBackend(t, "jobs"), StripPrefix: true},
+    }, defaultTarget, Options{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        path string
+        want string
+    }{
+        {"/api", "api /api"},
+        {"/api/users", "api /api/users"},
+        {"/api/admin", "admin /"},
+        {"/api/admin/users", "admin /users"},
+        {"/jobs/42", "jobs /42"},
+        // Prefixes match whole segments only
+        {"/apiary", "default /apiary"},
+        {"/jobsearch", "default /jobsearch"},
+        {"/", "default /"},
+        {"/other/path", "default /other/path"},
+    }
+    for _, tt := range tests {
+        rec := httptest.NewRecorder()
+        router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+        if rec.Code != http.StatusOK {
+            t.Errorf("%s: status = %d", tt.path, rec.Code)
+            continue
+        }
+        if got := rec.Body.String(); got != tt.want {
+            t.Errorf("%s: routed to %q, want %q", tt.path, got, tt.want)
+        }
+    }
+}
+
+func TestRouterRootRouteCatchesAll(t *testing.T) {
+    defaultTarget, _ := url.Parse(echoBackend(t, "default"))
+    router, err := NewRouter([]config.Route{
+        {Prefix: "/", Target: echoBackend(t, "root")},
+        {Prefix: "/api", Target: echoBackend(t, "api")},
+    }, defaultTarget, Options{})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    for path, want := range map[string]string{"/api/x": "api /api/x", "/anything": "root /anything"} {
+        rec := httptest.NewRecorder()
+        router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+        if got := rec.Body.String(); got != want {
+            t.Errorf("%s: routed to %q, want %q", path, got, want)
+        }
+    }
+}
+
+func TestNewRouterRejectsInvalidRoutes(t *testing.T) {
+    defaultTarget, _ := url.Parse("http://localhost:8048")
+    for _, r := range []config.Route{
+        {Prefix: "api", Target: "http://localhost:9000"},
+        {Prefix: "/api", Target: "localhost:9000"},
+        {Prefix: "/api", Target: "://bad"},
+    } {
+        if _, err := NewRouter([]config.Route{r}, defaultTarget, Options{}); err == nil {
+            t.Errorf("NewRouter accepted %+v", r)
+        }
+    }
+}
@@ -0,0 +1,177 @@
+package ratelimit
+
+import (
+    "container/list"
+    "log/slog"
+    "math"
+    "net"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "golang.org/x/time/rate"
+
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/middleware"
+)
+
+// Options configures per-client token-bucket rate limiting.
+type Options struct {
+    RequestsPerSecond float64
+    Burst             int
+    // MaxClients bounds how many per-client limiters are kept; the least
+    // recently seen client is evicted when the limit is reached.
+    MaxClients int
+    // TrustedProxies are CIDRs whose X-Forwarded-For entries are believed.
+    TrustedProxies []*net.IPNet
+}
+
+// entry is a client's limiter stored in the LRU list.
+type entry struct {
+    key     string
+    limiter *rate.Limiter
+}
+
+// Limiter rate-limits requests per client IP using an LRU of token buckets.
+type Limiter struct {
+    opts    Options
+    mu      sync.Mutex
+    clients map[string]*list.Element
+    lru     *list.List
+}
+
+// NewLimiter creates a per-client limiter.
+func NewLimiter(opts Options) *Limiter {
+    if opts.Burst < 1 {
+        opts.Burst = 1
+    }
+    if opts.MaxClients < 1 {
+        opts.MaxClients = 10000
+    }
+    return &Limiter{
+        opts:    opts,
+        clients: make(map[string]*list.Element),
+        lru:     list.New(),
+    }
+}
+
+// limiterFor returns the client's limiter, creating it and evicting the least
+// recently used client if needed.
+func (l *Limiter) limiterFor(key string) *rate.Limiter {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    if element, exists := l.clients[key]; exists {
+        l.lru.MoveToFront(element)
+        return element.Value.(*entry).limiter
+    }
+
+    if l.lru.Len() >= l.opts.MaxClients {
+        oldest := l.lru.Back()
+        l.lru.Remove(oldest)
+        delete(l.clients, oldest.Value.(*entry).key)
+    }
+
+    limiter := rate.NewLimiter(rate.Limit(l.opts.RequestsPerSecond), l.opts.Burst)
+    l.clients[key] = l.lru.PushFront(&entry{key: key, limiter: limiter})
+    return limiter
+}
+
+// Allow reports whether the client may proceed and, if not, how long until it may retry.
+func (l *Limiter) Allow(clientIP string) (bool, time.Duration) {
+    reservation := l.limiterFor(clientIP).Reserve()
+    delay := reservation.Delay()
+    if delay == 0 {
+        return true, 0
+    }
+    // Give the token back; a rejected request should not consume future capacity.
+    reservation.Cancel()
+    return false, delay
+}
+
+// Middleware rejects clients over their rate with 429 and a Retry-After header.
+// A non-positive RequestsPerSecond disables rate limiting.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+    if l.opts.RequestsPerSecond <= 0 {
+        return next
+    }
+
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        clientIP := ClientIP(r, l.opts.TrustedProxies)
+        allowed, retryAfter := l.Allow(clientIP)
+        if allowed {
+            next.ServeHTTP(w, r)
+            return
+        }
+
+        slog.Warn("Rate limit exceeded",
+            "request_id", middleware.RequestIDFromContext(r.Context()),
+            "client_ip", clientIP,
+            "method", r.Method,
+            "path", r.URL.Path,
+            "retry_after", retryAfter,
+        )
+        w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+        http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+    })
+}
+
+// ClientIP returns the originating client IP. X-Forwarded-For is only honored when
+// the direct peer is a trusted proxy, and is read right to left so clients cannot
+// spoof an address by prepending entries.
+func ClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+    remoteIP := r.RemoteAddr
+    if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+        remoteIP = host
+    }
+
+    if !isTrusted(remoteIP, trustedProxies) {
+        return remoteIP
+    }
+
+    forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+    for i := len(forwarded) - 1; i >= 0; i-- {
+        hop := strings.TrimSpace(forwarded[i])
+        if hop == "" {
+            continue
+        }
+        if !isTrusted(hop, trustedProxies) {
+            return hop
+        }
+    }
+    return remoteIP
+}
+
+func isTrusted(ip string, trustedProxies []*net.IPNet) bool {
+    parsed := net.ParseIP(ip)
+    if parsed == nil {
+        return false
+    }
+    for _, network := range trustedProxies {
+        if network.Contains(parsed) {
+            return true
+        }
+    }
+    return false
+}
+
+// ParseCIDRs parses trusted proxy entries, accepting bare IPs as single-host networks.
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+    var networks []*net.IPNet
+    for _, value := range values {
+        if !strings.Contains(value, "/") {
+            if ip := net.ParseIP(value); ip != nil && ip.To4() != nil {
+                value += "/32"
+            } else {
+                value += "/128"
+            }
+        }
+        _, network, err := net.ParseCIDR(value)
+        if err != nil {
+            return nil, err
+        }
+        networks = append(networks, network)
+    }
+    return networks, nil
+}
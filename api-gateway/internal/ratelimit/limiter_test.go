@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func limitedRequest(handler http.Handler, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+    req := httptest.NewRequest(http.MethodGet, "/api/jobs", nil)
+    req.RemoteAddr = remoteAddr
+    if forwardedFor != "" {
+        req.Header.Set("X-Forwarded-For", forwardedFor)
+    }
+    rec := httptest.NewRecorder()
+    handler.ServeHTTP(rec, req)
+    return rec
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    w.WriteHeader(http.StatusOK)
+})
+
+func TestMiddlewareRejectsOverLimit(t *testing.T) {
+    handler := NewLimiter(Options{RequestsPerSecond: 0.5, Burst: 2}).Middleware(okHandler)
+
+    for i := 0; i < 2; i++ {
+        if rec := limitedRequest(handler, "10.0.0.1:1234", ""); rec.Code != http.StatusOK {
+            t.Fatalf("request %d within burst: status = %d", i+1, rec.Code)
+        }
+    }
+
+    rec := limitedRequest(handler, "10.0.0.1:1234", "")
+    if rec.Code != http.StatusTooManyRequests {
+        t.Fatalf("request over burst: status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+    }
+    // One token every two seconds
+    if got := rec.Header().Get("Retry-After"); got != "2" {
+        t.Errorf("Retry-After = %q, want 2", got)
+    }
+
+    // Rejected requests don't consume capacity, and other clients have their own bucket
+    if rec := limitedRequest(handler, "10.0.0.1:1234", ""); rec.Code != http.StatusTooManyRequests {
+        t.Errorf("repeat request: status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+    }
+    if rec := limitedRequest(handler, "10.0.0.2:1234", ""); rec.Code != http.StatusOK {
+        t.Errorf("other client: status = %d, want %d", rec.Code, http.StatusOK)
+    }
+}
+
+func TestMiddlewareDisabled(t *testing.T) {
+    handler := NewLimiter(Options{RequestsPerSecond: 0, Burst: 1}).Middleware(okHandler)
+    for i := 0; i < 10; i++ {
+        if rec := limitedRequest(handler, "10.0.0.1:1234", ""); rec.Code != http.StatusOK {
+            t.Fatalf("request %d: status = %d", i+1, rec.Code)
+        }
+    }
+}
+
+func TestMiddlewareLimitsForwardedClients(t *testing.T) {
+    trusted, err := ParseCIDRs([]string{"10.0.0.0/8"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    handler := NewLimiter(Options{RequestsPerSecond: 0.5, Burst: 1, TrustedProxies: trusted}).Middleware(okHandler)
+
+    // Two clients behind the same trusted proxy are limited separately
+    if rec := limitedRequest(handler, "10.0.0.1:1234", "203.0.113.1"); rec.Code != http.StatusOK {
+        t.Fatalf("first client: status = %d", rec.Code)
+    }
+    if rec := limitedRequest(handler, "10.0.0.1:1234", "203.0.113.2"); rec.Code != http.StatusOK {
+        t.Fatalf("second client: status = %d", rec.Code)
+    }
+    if rec := limitedRequest(handler, "10.0.0.1:1234", "203.0.113.1"); rec.Code != http.StatusTooManyRequests {
+        t.Errorf("first client again: status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+    }
+}
+
+func TestClientIP(t *testing.T) {
+    trusted, err := ParseCIDRs([]string{"10.0.0.0/8", "192.168.1.5"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    tests := []struct {
+        remoteAddr   string
+        forwardedFor string
+        want         string
+    }{
+        {"203.0.113.9:4000", "", "203.0.113.9"},
+        // Untrusted peers can't claim another address
+        {"203.0.113.9:4000", "198.51.100.1", "203.0.113.9"},
+        {"10.0.0.1:4000", "198.51.100.1", "198.51.100.1"},
+        // Read right to left, skipping trusted hops; spoofed leading entries are ignored
+        {"10.0.0.1:4000", "1.2.3.4, 198.51.100.1, 192.168.1.5", "198.51.100.1"},
+        {"10.0.0.1:4000", "10.0.0.2", "10.0.0.1"},
+    }
+    for _, tt := range tests {
+        req := httptest.NewRequest(http.MethodGet, "/", nil)
+        req.RemoteAddr = tt.remoteAddr
+        if tt.forwardedFor != "" {
+            req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+        }
+        if got := ClientIP(req, trusted); got != tt.want {
+            t.Errorf("ClientIP(%s, %q) = %s, want %s", tt.remoteAddr, tt.forwardedFor, got, tt.want)
+        }
+    }
+}
+
+func TestLimiterEvictsLeastRecentlyUsed(t *testing.T) {
+    limiter := NewLimiter(Options{RequestsPerSecond: 0.001, Burst: 1, MaxClients: 2})
+    limiter.Allow("a")
+    limiter.Allow("b")
+    limiter.Allow("c")
+
+    if _, exists := limiter.clients["a"]; exists {
+        t.Error("least recently used client was not evicted")
+    }
+    // An evicted client starts with a fresh bucket
+    if allowed, _ := limiter.Allow("a"); !allowed {
+        t.Error("evicted client was still limited")
+    }
+}
@@ -3,30 +3,60 @@
 package main
 
 import (
+    "crypto/tls"
+    "errors"
     "fmt"
     "log"
+    "net"
     "net/http"
-    "net/http/httputil"
     "net/url"
+    "time"
 
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/auth"
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/cache"
     "gitea.wkav.cc/tony/jobapp/api-gateway/internal/config"
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/logger"
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/metrics"
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/middleware"
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/proxy"
+    "gitea.wkav.cc/tony/jobapp/api-gateway/internal/ratelimit"
     "gitea.wkav.cc/tony/jobapp/api-gateway/pkg/health"
 )
 
 func main() {
     // Load configuration using our dedicated package.
-    config.LoadEnv()
+    if err := config.LoadEnv(); err != nil {
+        log.Fatalf("❌ Invalid configuration: %v", err)
+    }
     // Get the populated configuration struct.
     cfg := config.Get()
 
+    // Set up structured logging before anything else logs through slog.
+    logger.Init(cfg)
+
     // Parse the backend URL from the config struct.
     backendUrl, err := url.Parse(cfg.BackendTarget)
     if err != nil {
         log.Fatalf("Failed to parse backend URL from config: %v", err)
     }
 
-    // Create the reverse proxy for all non-health-check requests.
-    proxy := httputil.NewSingleHostReverseProxy(backendUrl)
+    // Create the path-based router for all non-health-check requests.
+    // The backend target is the default route for unmatched paths.
+    proxyRouter, err := proxy.NewRouter(cfg.Routes, backendUrl, proxy.Options{
+        Retry: proxy.RetryOptions{
+            Retries:      cfg.ProxyRetries,
+            Backoff:      time.Duration(cfg.ProxyRetryBackoffMS) * time.Millisecond,
+            MaxBodyBytes: cfg.ProxyRetryMaxBodyBytes,
+        },
+        Headers: proxy.HeaderRules{
+            RequestAdd:     cfg.RequestHeadersAdd,
+            RequestRemove:  cfg.RequestHeadersRemove,
+            ResponseRemove: cfg.ResponseHeadersRemove,
+        },
+    })
+    if err != nil {
+        log.Fatalf("Failed to build routes from config: %v", err)
+    }
 
     // Create a new router (serve mux). This is better than using the default
     // http package router as it gives us more control.
@@ -34,23 +64,210 @@ func main() {
 
     // Register the health check handler.
     // This route will be handled directly by the gateway.
-    router.HandleFunc("/health", health.HealthCheckHandler)
+    healthTimeout := time.Duration(cfg.HealthTimeoutMS) * time.Millisecond
+    router.HandleFunc("/health", health.NewHandler(healthChecks(cfg, backendUrl), healthTimeout))
+
+    // Register the metrics endpoint, which is served by the gateway and never proxied.
+    metricsRegistry := metrics.NewRegistry()
+    metricsRegistry.RegisterGauge("gateway_log_ingest_circuit_open",
+        "Whether the log-ingestion circuit breaker is open (1) or closed (0).",
+        func() float64 {
+            if logger.IngestCircuitOpen() {
+                return 1
+            }
+            return 0
+        })
+    router.HandleFunc(cfg.MetricsPath, metricsRegistry.Handler(cfg.MetricsToken))
+
+    // Cache allowlisted GET/HEAD responses from the backend; health and metrics are never cached.
+    responseCache := cache.NewResponseCache(cache.Options{
+        Paths:             cfg.CachePaths,
+        TTL:               time.Duration(cfg.CacheTTLMS) * time.Millisecond,
+        MaxEntries:        cfg.CacheMaxEntries,
+        MaxBodyBytes:      cfg.CacheMaxBodyBytes,
+        CredentialHeaders: []string{cfg.AuthAPIKeyHeader},
+    })
+    metricsRegistry.RegisterGauge("gateway_response_cache_entries",
+        "Number of backend responses held in the gateway response cache.",
+        func() float64 { return float64(responseCache.Len()) })
 
     // Register the reverse proxy to handle all other requests.
     // The "/" pattern acts as a catch-all.
-    router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-        proxy.ServeHTTP(w, r)
-    })
+    router.Handle("/", responseCache.Middleware(proxyRouter))
 
     // Construct the port string for the server.
     listenAddr := fmt.Sprintf(":%s", cfg.GatewayPort)
 
     log.Printf("🚀 Starting API Gateway on %s", listenAddr)
-    log.Printf("🎯 Proxying all requests to: %s", cfg.BackendTarget)
+    for _, route := range cfg.Routes {
+        log.Printf("🔀 Routing %s to: %s (strip prefix: %t)", route.Prefix, route.Target, route.StripPrefix)
+    }
+    log.Printf("🎯 Proxying all other requests to: %s", cfg.BackendTarget)
+    if len(cfg.CachePaths) > 0 {
+        log.Printf("🗄️  Caching GET/HEAD responses under %v for %dms", cfg.CachePaths, cfg.CacheTTLMS)
+    }
     log.Printf("❤️  Health check available at: %s/health", listenAddr)
+    log.Printf("📊 Metrics available at: %s%s", listenAddr, cfg.MetricsPath)
+
+    // Wrap the router so every request gets an ID, a bounded body, and CORS
+    // preflights are answered here instead of being proxied to the backend.
+    // Panics anywhere below RequestID become a logged 500 instead of a dropped connection.
+    corsOptions := middleware.CORSOptions{
+        AllowedOrigins:   cfg.CORSOrigins,
+        AllowedMethods:   cfg.CORSMethods,
+        AllowedHeaders:   cfg.CORSHeaders,
+        AllowCredentials: cfg.CORSAllowCredentials,
+        MaxAgeSeconds:    cfg.CORSMaxAgeSeconds,
+    }
+    trustedProxies, err := ratelimit.ParseCIDRs(cfg.TrustedProxies)
+    if err != nil {
+        log.Fatalf("Failed to parse trusted proxies from config: %v", err)
+    }
+    limiter := ratelimit.NewLimiter(ratelimit.Options{
+        RequestsPerSecond: cfg.RateLimitRPS,
+        Burst:             cfg.RateLimitBurst,
+        MaxClients:        cfg.RateLimitMaxClients,
+        TrustedProxies:    trustedProxies,
+    })
+    // The metrics endpoint has its own bearer token, so it is never behind gateway auth.
+    // Only the path itself is exempt; paths beneath it are proxied and need credentials.
+    authenticator := auth.NewAuthenticator(auth.Options{
+        JWTSecret:        cfg.AuthJWTSecret,
+        JWKSURL:          cfg.AuthJWKSURL,
+        JWKSRefresh:      time.Duration(cfg.AuthJWKSRefreshMS) * time.Millisecond,
+        Issuer:           cfg.AuthIssuer,
+        Audience:         cfg.AuthAudience,
+        AllowNoExpiry:    cfg.AuthAllowNoExpiry,
+        APIKeys:          cfg.AuthAPIKeys,
+        APIKeyHeader:     cfg.AuthAPIKeyHeader,
+        ExemptPaths:      cfg.AuthExemptPaths,
+        ExemptExactPaths: []string{cfg.MetricsPath},
+    })
+    if authenticator.Enabled() {
+        log.Printf("🔐 Authentication required except under %v", cfg.AuthExemptPaths)
+    }
+    handler := metricsRegistry.Middleware(cfg.MetricsPath, middleware.RequestID(middleware.Recover(middleware.CORS(corsOptions)(limiter.Middleware(authenticator.Middleware(middleware.MaxRequestBody(cfg.GatewayMaxRequestBytes)(router)))))))
+
+    // Timeouts protect against slowloris-style clients holding connections open.
+    server := &http.Server{
+        Addr:              listenAddr,
+        Handler:           handler,
+        ReadHeaderTimeout: time.Duration(cfg.GatewayReadTimeoutMS) * time.Millisecond,
+        ReadTimeout:       time.Duration(cfg.GatewayReadTimeoutMS) * time.Millisecond,
+        WriteTimeout:      time.Duration(cfg.GatewayWriteTimeoutMS) * time.Millisecond,
+        IdleTimeout:       time.Duration(cfg.GatewayIdleTimeoutMS) * time.Millisecond,
+    }
+
+    // Validate TLS settings before listening so a bad cert fails fast.
+    tlsConfig, err := loadTLSConfig(cfg)
+    if err != nil {
+        log.Fatalf("❌ Invalid TLS configuration: %v", err)
+    }
+
+    if tlsConfig == nil {
+        if err := server.ListenAndServe(); err != nil {
+            log.Fatalf("❌ Failed to start gateway server: %v", err)
+        }
+        return
+    }
+
+    // HTTP/2 is negotiated automatically over TLS.
+    server.TLSConfig = tlsConfig
+
+    if cfg.HTTPRedirectPort != "" {
+        redirectAddr := fmt.Sprintf(":%s", cfg.HTTPRedirectPort)
+        log.Printf("↪️  Redirecting HTTP on %s to HTTPS", redirectAddr)
+        go func() {
+            redirectServer := &http.Server{
+                Addr:              redirectAddr,
+                Handler:           redirectToHTTPS(cfg.GatewayPort),
+                ReadHeaderTimeout: time.Duration(cfg.GatewayReadTimeoutMS) * time.Millisecond,
+            }
+            if err := redirectServer.ListenAndServe(); err != nil {
+                log.Fatalf("❌ Failed to start HTTP redirect server: %v", err)
+            }
+        }()
+    }
 
-    // Use our new router with the server.
-    if err := http.ListenAndServe(listenAddr, router); err != nil {
+    log.Printf("🔒 TLS enabled with certificate %s", cfg.TLSCertFile)
+    if err := server.ListenAndServeTLS("", ""); err != nil {
         log.Fatalf("❌ Failed to start gateway server: %v", err)
     }
 }
+
+// healthChecks builds the dependency checks reported by /health. The backend is
+// critical; log ingestion is not, so a down collector doesn't fail liveness.
+func healthChecks(cfg config.Config, backendUrl *url.URL) []health.Check {
+    checks := []health.Check{{
+        Name:     "backend",
+        Critical: true,
+        Run:      health.HTTPCheck(backendUrl.JoinPath(cfg.BackendHealthPath).String()),
+    }}
+
+    if cfg.LogIngestEnabled {
+        for i, destination := range cfg.LogIngestURLs {
+            ingestUrl, err := url.Parse(destination)
+            if err != nil {
+                continue
+            }
+            name := "log_ingest"
+            if len(cfg.LogIngestURLs) > 1 {
+                name = fmt.Sprintf("log_ingest_%d", i)
+            }
+            checks = append(checks, health.Check{
+                Name:     name,
+                Critical: false,
+                Run:      health.TCPCheck(hostWithPort(ingestUrl)),
+            })
+        }
+    }
+    return checks
+}
+
+// hostWithPort returns u's host:port, filling in the scheme's default port.
+func hostWithPort(u *url.URL) string {
+    if u.Port() != "" {
+        return u.Host
+    }
+    if u.Scheme == "https" {
+        return net.JoinHostPort(u.Hostname(), "443")
+    }
+    return net.JoinHostPort(u.Hostname(), "80")
+}
+
+// loadTLSConfig loads the configured certificate/key pair. It returns nil when TLS
+// is not configured, and an error if only one of the pair is set or they don't load.
+func loadTLSConfig(cfg config.Config) (*tls.Config, error) {
+    if cfg.TLSCertFile == "" && cfg.TLSKeyFile == "" {
+        return nil, nil
+    }
+    if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+        return nil, errors.New("GATEWAY_TLS_CERT and GATEWAY_TLS_KEY must both be set")
+    }
+
+    certificate, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+    if err != nil {
+        return nil, fmt.Errorf("failed to load certificate/key pair: %w", err)
+    }
+
+    return &tls.Config{
+        Certificates: []tls.Certificate{certificate},
+        MinVersion:   tls.VersionTLS12,
+    }, nil
+}
+
+// redirectToHTTPS permanently redirects plaintext requests to the HTTPS listener.
+func redirectToHTTPS(httpsPort string) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        host := r.Host
+        if h, _, err := net.SplitHostPort(r.Host); err == nil {
+            host = h
+        }
+        if httpsPort != "443" {
+            host = net.JoinHostPort(host, httpsPort)
+        }
+
+        target := url.URL{Scheme: "https", Host: host, Path: r.URL.Path, RawPath: r.URL.RawPath, RawQuery: r.URL.RawQuery}
+        http.Redirect(w, r, target.String(), http.StatusPermanentRedirect)
+    })
+}
@@ -0,0 +1,43 @@
+package health
+
+import (
+    "context"
+    "fmt"
+    "net"
+    "net/http"
+)
+
+// HTTPCheck probes url with a GET request. Any response below 500 counts as up,
+// since the dependency answered even if the path needs auth or doesn't exist.
+func HTTPCheck(url string) func(ctx context.Context) error {
+    return func(ctx context.Context) error {
+        req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+        if err != nil {
+            return err
+        }
+
+        resp, err := http.DefaultClient.Do(req)
+        if err != nil {
+            return err
+        }
+        defer resp.Body.Close()
+
+        if resp.StatusCode >= 500 {
+            return fmt.Errorf("unhealthy response: %s", resp.Status)
+        }
+        return nil
+    }
+}
+
+// TCPCheck verifies that address (host:port) accepts connections without sending
+// any application data, for dependencies that shouldn't receive probe requests.
+func TCPCheck(address string) func(ctx context.Context) error {
+    return func(ctx context.Context) error {
+        var dialer net.Dialer
+        conn, err := dialer.DialContext(ctx, "tcp", address)
+        if err != nil {
+            return err
+        }
+        return conn.Close()
+    }
+}
@@ -1,41 +1,124 @@
 package health
 
 import (
+    "context"
     "encoding/json"
     "log"
     "net/http"
+    "sync"
+    "time"
 )
 
 // HealthStatus represents the structure of our health check response.
 type HealthStatus struct {
-    Status  string `json:"status"`
-    Service string `json:"service"`
+    Status  string                 `json:"status"`
+    Service string                 `json:"service"`
+    Checks  map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the outcome of a single dependency check.
+type CheckResult struct {
+    Status    string `json:"status"`
+    Critical  bool   `json:"critical"`
+    LatencyMS int64  `json:"latency_ms"`
+    Error     string `json:"error,omitempty"`
+}
+
+// Check is a dependency probe. Critical checks fail the overall health status;
+// non-critical ones only mark it degraded.
+type Check struct {
+    Name     string
+    Critical bool
+    Run      func(ctx context.Context) error
 }
 
 // HealthCheckHandler is an http.Handler that responds with the service's health status.
 func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
-    // Ensure we only handle GET requests for this endpoint.
-    if r.Method != http.MethodGet {
-        http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-        return
-    }
+    NewHandler(nil, 0)(w, r)
+}
+
+// NewHandler returns a health handler that runs checks in parallel, each bounded by
+// timeout, and responds 200 only if every critical check passes.
+func NewHandler(checks []Check, timeout time.Duration) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        // Ensure we only handle GET requests for this endpoint.
+        if r.Method != http.MethodGet {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        // Create the response data.
+        status := HealthStatus{
+            Status:  "ok",
+            Service: "api-gateway",
+        }
+        httpStatus := http.StatusOK
 
-    // Create the response data.
-    status := HealthStatus{
-        Status:  "ok",
-        Service: "api-gateway",
+        if len(checks) > 0 {
+            status.Checks = runChecks(r.Context(), checks, timeout)
+            for _, result := range status.Checks {
+                if result.Status == "ok" {
+                    continue
+                }
+                if result.Critical {
+                    status.Status = "error"
+                    httpStatus = http.StatusServiceUnavailable
+                } else if status.Status == "ok" {
+                    status.Status = "degraded"
+                }
+            }
+        }
+
+        // Set the content type header to application/json.
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(httpStatus)
+
+        // Encode the status struct directly to the response writer.
+        // This is more efficient than marshalling to a byte slice first.
+        if err := json.NewEncoder(w).Encode(status); err != nil {
+            // If encoding fails, log the error. The headers are already sent,
+            // so we can't send a different status code.
+            log.Printf("Error encoding health check response: %v", err)
+        }
     }
+}
+
+// runChecks runs every check concurrently with its own timeout.
+func runChecks(ctx context.Context, checks []Check, timeout time.Duration) map[string]CheckResult {
+    results := make(map[string]CheckResult, len(checks))
+    var mu sync.Mutex
+    var wg sync.WaitGroup
 
-    // Set the content type header to application/json.
-    w.Header().Set("Content-Type", "application/json")
-    // Write the 200 OK status code.
-    w.WriteHeader(http.StatusOK)
-
-    // Encode the status struct directly to the response writer.
-    // This is more efficient than marshalling to a byte slice first.
-    if err := json.NewEncoder(w).Encode(status); err != nil {
-        // If encoding fails, log the error. The headers are already sent,
-        // so we can't send a different status code.
-        log.Printf("Error encoding health check response: %v", err)
+    for _, check := range checks {
+        wg.Add(1)
+        go func(check Check) {
+            defer wg.Done()
+
+            checkCtx := ctx
+            if timeout > 0 {
+                var cancel context.CancelFunc
+                checkCtx, cancel = context.WithTimeout(ctx, timeout)
+                defer cancel()
+            }
+
+            start := time.Now()
+            err := check.Run(checkCtx)
+            result := CheckResult{
+                Status:    "ok",
+                Critical:  check.Critical,
+                LatencyMS: time.Since(start).Milliseconds(),
+            }
+            if err != nil {
+                result.Status = "error"
+                result.Error = err.Error()
+            }
+
+            mu.Lock()
+            results[check.Name] = result
+            mu.Unlock()
+        }(check)
     }
+
+    wg.Wait()
+    return results
 }
@@ -0,0 +1,145 @@
+package health
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+// upServer is a dependency that answers its probes.
+func upServer(t *testing.T) *httptest.Server {
+    t.Helper()
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusNoContent)
+    }))
+    t.Cleanup(server.Close)
+    return server
+}
+
+// downURL is the address of a dependency that refuses connections.
+func downURL(t *testing.T) string {
+    t.Helper()
+    server := httptest.NewServer(http.NotFoundHandler())
+    server.Close()
+    return server.URL
+}
+
+func checkHealth(t *testing.T, checks []Check) (int, HealthStatus) {
+    t.Helper()
+    rec := httptest.NewRecorder()
+    NewHandler(checks, time.Second)(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+    var status HealthStatus
+    if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+        t.Fatalf("body %q is not JSON: %v", rec.Body.String(), err)
+    }
+    return rec.Code, status
+}
+
+func TestHealthStatuses(t *testing.T) {
+    backend := upServer(t)
+    collector := upServer(t)
+    collectorAddr := collector.Listener.Addr().String()
+
+    tests := []struct {
+        name       string
+        checks     []Check
+        wantCode   int
+        wantStatus string
+        wantFailed string
+    }{
+        {
+            name: "all up",
+            checks: []Check{
+                {Name: "backend", Critical: true, Run: HTTPCheck(backend.URL + "/health")},
+                {Name: "log_ingest", Run: TCPCheck(collectorAddr)},
+            },
+            wantCode:   http.StatusOK,
+            wantStatus: "ok",
+        },
+        {
+            name: "critical down",
+            checks: []Check{
+                {Name: "backend", Critical: true, Run: HTTPCheck(downURL(t))},
+                {Name: "log_ingest", Run: TCPCheck(collectorAddr)},
+            },
+            wantCode:   http.StatusServiceUnavailable,
+            wantStatus: "error",
+            wantFailed: "backend",
+        },
+        {
+            name: "noncritical down",
+            checks: []Check{
+                {Name: "backend", Critical: true, Run: HTTPCheck(backend.URL + "/health")},
+                {Name: "log_ingest", Run: TCPCheck(downURL(t)[len("http://"):])},
+            },
+            wantCode:   http.StatusOK,
+            wantStatus: "degraded",
+            wantFailed: "log_ingest",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            code, status := checkHealth(t, tt.checks)
+            if code != tt.wantCode || status.Status != tt.wantStatus {
+                t.Fatalf("got %d %q, want %d %q", code, status.Status, tt.wantCode, tt.wantStatus)
+            }
+            if len(status.Checks) != len(tt.checks) {
+                t.Fatalf("checks = %v, want %d results", status.Checks, len(tt.checks))
+            }
+            for name, result := range status.Checks {
+                failed := name == tt.wantFailed
+                if failed != (result.Status == "error") || failed != (result.Error != "") {
+                    t.Errorf("%s: %+v", name, result)
+                }
+            }
+        })
+    }
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+    slow := Check{Name: "backend", Critical: true, Run: func(ctx context.Context) error {
+        <-ctx.Done()
+        return ctx.Err()
+    }}
+    rec := httptest.NewRecorder()
+    start := time.Now()
+    NewHandler([]Check{slow}, 20*time.Millisecond)(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+    if elapsed := time.Since(start); elapsed > time.Second {
+        t.Errorf("health check took %s despite its timeout", elapsed)
+    }
+    if rec.Code != http.StatusServiceUnavailable {
+        t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+    }
+}
+
+func TestHTTPCheckServerError(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusBadGateway)
+    }))
+    defer server.Close()
+    if err := HTTPCheck(server.URL)(context.Background()); err == nil {
+        t.Error("HTTPCheck passed on a 502")
+    }
+
+    // Anything below 500 means the dependency answered
+    if err := HTTPCheck(upServer(t).URL)(context.Background()); err != nil {
+        t.Errorf("HTTPCheck failed on a 204: %v", err)
+    }
+}
+
+func TestHealthWithoutChecks(t *testing.T) {
+    rec := httptest.NewRecorder()
+    HealthCheckHandler(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+    if rec.Code != http.StatusOK {
+        t.Errorf("status = %d", rec.Code)
+    }
+    rec = httptest.NewRecorder()
+    HealthCheckHandler(rec, httptest.NewRequest(http.MethodPost, "/health", nil))
+    if rec.Code != http.StatusMethodNotAllowed {
+        t.Errorf("POST status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+    }
+}
+
@@ -1,11 +1,9 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 
 	"docs-cli/pkg/config"
@@ -13,126 +11,143 @@ import (
 
 // AnthropicProvider implements ModelProvider for Anthropic's API
 type AnthropicProvider struct {
-	apiKey string
-	cache  *EnterpriseCache
+	chatClient
+}
+
+// Anthropic API request/response structures
+type AnthropicRequest struct {
+	Model         string             `json:"model"`
+	MaxTokens     int                `json:"max_tokens"`
+	Temperature   float64            `json:"temperature"`
+	StopSequences []string           `json:"stop_sequences"`
+	System        string             `json:"system,omitempty"`
+	Messages      []AnthropicMessage `json:"messages"`
+}
+
+// AnthropicMessage content is a plain string, or []AnthropicContentBlock when part of it is
+// marked for prompt caching
+type AnthropicMessage struct {
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"`
+}
+
+// AnthropicContentBlock is a text block in a request message
+type AnthropicContentBlock struct {
+	Type         string                 `json:"type"`
+	Text         string                 `json:"text"`
+	CacheControl *AnthropicCacheControl `json:"cache_control,omitempty"`
+}
+
+// AnthropicCacheControl marks the end of a prompt prefix for Anthropic to cache
+type AnthropicCacheControl struct {
+	Type string `json:"type"`
+}
+
+type AnthropicResponse struct {
+	ID         string             `json:"id"`
+	Model      string             `json:"model"`
+	Content    []AnthropicContent `json:"content"`
+	StopReason string             `json:"stop_reason"` // "max_tokens" when the output token limit was hit
+	Usage      AnthropicUsage     `json:"usage"`
+}
+
+type AnthropicContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type AnthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
 }
 
 // NewAnthropicProvider creates a new Anthropic provider with enterprise caching
-func NewAnthropicProvider(apiKey string) *AnthropicProvider {
+func NewAnthropicProvider(apiKey string, providerConfig config.ProviderConfig, opts ...ProviderOption) *AnthropicProvider {
 	return &AnthropicProvider{
-		apiKey: apiKey,
-		cache:  GetProviderCache("anthropic"),
+		chatClient: newChatClient("anthropic", "Anthropic", "", apiKey, providerConfig, opts, anthropicAdapter{}),
 	}
 }
 
 // CallModel calls the Anthropic API with the given parameters
 func (p *AnthropicProvider) CallModel(ctx context.Context, prompt, model string, maxTokens int, temperature float64) (string, error) {
-	providerConfig := config.GetConfig().Providers.Anthropic
-	
-	// Validate input parameters
-	if prompt == "" {
-		return "", fmt.Errorf("prompt cannot be empty")
-	}
-	if temperature < providerConfig.TemperatureRange.Min || temperature > providerConfig.TemperatureRange.Max {
-		return "", fmt.Errorf("temperature must be between %.1f and %.1f", providerConfig.TemperatureRange.Min, providerConfig.TemperatureRange.Max)
-	}
-	if maxTokens <= 0 {
-		return "", fmt.Errorf("maxTokens must be positive")
-	}
-
-	// Generate cache key
-	cacheKey := GenerateCacheKey("anthropic", prompt, model, maxTokens, temperature)
+	return p.CallChat(ctx, userChatRequest(prompt, model, maxTokens, temperature))
+}
 
-	// Check cache first
-	if cached, found := p.cache.Get(cacheKey); found {
-		LogWithContext().WithField("cache_key", cacheKey[:8]+"...").Debug("Cache hit for API call")
-		return cached, nil
+// anthropicAdapter translates chat requests to Anthropic's Messages API, where the
+// system prompt is a top-level field rather than a message. With
+// providers.anthropic.prompt_caching, a message's cacheable prefix is sent as its own
+// block marked with cache_control, so calls sharing the source context reuse it.
+type anthropicAdapter struct{}
+
+func (anthropicAdapter) buildRequest(request ChatRequest, settings config.ProviderConfig) interface{} {
+	reqBody := AnthropicRequest{
+		Model:         request.Model,
+		MaxTokens:     request.MaxTokens,
+		Temperature:   request.Temperature,
+		StopSequences: settings.StopSequences,
 	}
-	
-	LogWithContext().WithField("cache_key", cacheKey[:8]+"...").Debug("Cache miss for API call")
-
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(ctx, providerConfig.Timeout)
-	defer cancel()
-
-	// Create request payload
-	reqBody := map[string]interface{}{
-		"model":          model,
-		"max_tokens":     maxTokens,
-		"temperature":    temperature,
-		"stop_sequences": providerConfig.StopSequences,
-		"messages": []map[string]interface{}{
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
+	for _, message := range request.Messages {
+		if message.Role == "system" {
+			reqBody.System = message.Content
+			continue
+		}
+		reqBody.Messages = append(reqBody.Messages, anthropicMessage(message, settings.PromptCaching))
 	}
+	return reqBody
+}
 
-	// Marshal request body
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request body: %w", err)
+// anthropicMessage splits a message with a cacheable prefix into a cached block and the
+// variable rest when prompt caching is enabled
+func anthropicMessage(message ChatMessage, promptCaching bool) AnthropicMessage {
+	if !promptCaching || message.CacheablePrefix <= 0 || message.CacheablePrefix > len(message.Content) {
+		return AnthropicMessage{Role: message.Role, Content: message.Content}
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", providerConfig.APIURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+	blocks := []AnthropicContentBlock{{
+		Type:         "text",
+		Text:         message.Content[:message.CacheablePrefix],
+		CacheControl: &AnthropicCacheControl{Type: "ephemeral"},
+	}}
+	if suffix := message.Content[message.CacheablePrefix:]; suffix != "" {
+		blocks = append(blocks, AnthropicContentBlock{Type: "text", Text: suffix})
 	}
+	return AnthropicMessage{Role: message.Role, Content: blocks}
+}
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-API-Key", p.apiKey)
-	req.Header.Set("Anthropic-Version", providerConfig.APIVersion)
-
-	// Send request
-	client := &http.Client{Timeout: providerConfig.Timeout}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("API request failed: %w", err)
-	}
-	defer resp.Body.Close()
+func (anthropicAdapter) setHeaders(header http.Header, apiKey string, settings config.ProviderConfig) {
+	header.Set("X-API-Key", apiKey)
+	header.Set("Anthropic-Version", settings.APIVersion)
+}
 
-	// Handle non-200 status
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API returned status %d: %s - %s", resp.StatusCode, resp.Status, string(body))
-	}
+func (anthropicAdapter) statusError(statusCode int, status string, body []byte) error {
+	return fmt.Errorf("API returned status %d: %s - %s", statusCode, status, redactedBody(body))
+}
 
-	// Parse response
-	var apiResp map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+func (anthropicAdapter) parseResponse(body []byte) (ChatResponse, error) {
+	var apiResp AnthropicResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return ChatResponse{}, fmt.Errorf("failed to decode Anthropic response: %w", err)
 	}
 
 	// Extract content from response
-	content, ok := apiResp["content"].([]interface{})
-	if !ok || len(content) == 0 {
-		return "", fmt.Errorf("invalid API response format")
-	}
-
-	firstContent, ok := content[0].(map[string]interface{})
-	if !ok {
-		return "", fmt.Errorf("invalid content format in API response")
+	if len(apiResp.Content) == 0 {
+		return ChatResponse{}, fmt.Errorf("invalid API response format")
 	}
-
-	text, ok := firstContent["text"].(string)
-	if !ok {
-		return "", fmt.Errorf("text field missing in API response")
-	}
-
-	// Cache the response
-	if p.cache.Set(cacheKey, text) {
-		LogWithContext().WithField("cache_key", cacheKey[:8]+"...").
-			WithField("response_length", len(text)).
-			Debug("Response cached successfully")
-	} else {
-		LogWithContext().WithField("cache_key", cacheKey[:8]+"...").
-			Warn("Failed to cache response (likely too large)")
+	if apiResp.Content[0].Text == "" {
+		return ChatResponse{}, fmt.Errorf("text field missing in API response")
 	}
 
-	return text, nil
+	return ChatResponse{
+		Content:          apiResp.Content[0].Text,
+		Model:            apiResp.Model,
+		PromptTokens:     apiResp.Usage.InputTokens,
+		CompletionTokens: apiResp.Usage.OutputTokens,
+		TotalTokens:      apiResp.Usage.InputTokens + apiResp.Usage.OutputTokens,
+		Truncated:        apiResp.StopReason == "max_tokens",
+
+		CacheCreationTokens: apiResp.Usage.CacheCreationInputTokens,
+		CacheReadTokens:     apiResp.Usage.CacheReadInputTokens,
+	}, nil
 }
-
-// Note: generateCacheKey function moved to cache.go as GenerateCacheKey
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sony/gobreaker"
+)
+
+// breakerStateFile records circuit breaker transitions next to the snapshots, so a breaker
+// opened by one run stays open for the next runs until its timeout, and the breaker
+// command can show and reset state from runs other than its own
+const breakerStateFile = ".docs-cli-breakers.json"
+
+// PersistedBreaker is a breaker's last state transition as recorded on disk
+type PersistedBreaker struct {
+	State     string           `json:"state"`
+	Counts    gobreaker.Counts `json:"counts"`
+	ChangedAt time.Time        `json:"changed_at"`
+}
+
+var (
+	// breakerStateMutex serializes reads and writes of the breaker state file in this process
+	breakerStateMutex sync.Mutex
+	// restoredBreakers holds the state file as read by the first call in this run; nil until loaded
+	restoredBreakers map[string]PersistedBreaker
+)
+
+func breakerStatePath() string {
+	return filepath.Join(projectRoot, breakerStateFile)
+}
+
+// readBreakerStates reads the breaker state file; callers must hold breakerStateMutex
+func readBreakerStates() map[string]PersistedBreaker {
+	states := make(map[string]PersistedBreaker)
+	data, err := os.ReadFile(breakerStatePath())
+	if err != nil {
+		return states
+	}
+	if err := json.Unmarshal(data, &states); err != nil {
+		LogWithContext().WithError(err).Warn("Ignoring unreadable circuit breaker state file")
+		return make(map[string]PersistedBreaker)
+	}
+	return states
+}
+
+// writeBreakerStates replaces the breaker state file; callers must hold breakerStateMutex
+func writeBreakerStates(states map[string]PersistedBreaker) error {
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal circuit breaker state: %w", err)
+	}
+	tmpPath := breakerStatePath() + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write circuit breaker state: %w", err)
+	}
+	return os.Rename(tmpPath, breakerStatePath())
+}
+
+// recordBreakerState persists a breaker transition; it runs from gobreaker's OnStateChange
+func recordBreakerState(name string, state gobreaker.State, counts gobreaker.Counts) {
+	breakerStateMutex.Lock()
+	defer breakerStateMutex.Unlock()
+
+	states := readBreakerStates()
+	states[name] = PersistedBreaker{State: state.String(), Counts: counts, ChangedAt: time.Now()}
+	if err := writeBreakerStates(states); err != nil {
+		LogWithContext().WithError(err).WithField("circuit_breaker", name).Warn("Failed to persist circuit breaker state")
+	}
+}
+
+// clearBreakerState removes a breaker's persisted state, for breaker reset
+func clearBreakerState(name string) error {
+	breakerStateMutex.Lock()
+	defer breakerStateMutex.Unlock()
+
+	states := readBreakerStates()
+	if _, exists := states[name]; !exists {
+		delete(restoredBreakers, name)
+		return nil
+	}
+	delete(states, name)
+	delete(restoredBreakers, name)
+	return writeBreakerStates(states)
+}
+
+// persistedOpenUntil reports whether an earlier run left the breaker open, and until when.
+// The state file is read once per run; this run's own transitions are tracked by gobreaker.
+func persistedOpenUntil(name string) (time.Time, bool) {
+	breakerStateMutex.Lock()
+	defer breakerStateMutex.Unlock()
+
+	if restoredBreakers == nil {
+		restoredBreakers = readBreakerStates()
+	}
+	persisted, exists := restoredBreakers[name]
+	if !exists || persisted.State != gobreaker.StateOpen.String() {
+		return time.Time{}, false
+	}
+	until := persisted.ChangedAt.Add(getResilienceConfig().CircuitBreaker.Timeout)
+	return until, time.Now().Before(until)
+}
+
+// forgetRestoredBreaker stops honoring an earlier run's open state once this run's breaker
+// has made its own transition
+func forgetRestoredBreaker(name string) {
+	breakerStateMutex.Lock()
+	defer breakerStateMutex.Unlock()
+	delete(restoredBreakers, name)
+}
+
+// PersistedBreakerStatus returns a breaker's state as last recorded by any run. An open
+// breaker whose timeout has passed is reported half-open, as gobreaker would treat it.
+func PersistedBreakerStatus(name string) (CircuitBreakerStatus, time.Time, bool) {
+	breakerStateMutex.Lock()
+	persisted, exists := readBreakerStates()[name]
+	breakerStateMutex.Unlock()
+	if !exists {
+		return CircuitBreakerStatus{}, time.Time{}, false
+	}
+
+	state := persisted.State
+	if state == gobreaker.StateOpen.String() && time.Since(persisted.ChangedAt) >= getResilienceConfig().CircuitBreaker.Timeout {
+		state = gobreaker.StateHalfOpen.String()
+	}
+	return CircuitBreakerStatus{Name: name, State: state, Counts: persisted.Counts}, persisted.ChangedAt, true
+}
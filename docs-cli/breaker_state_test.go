@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+)
+
+// resetBreakersForTest gives the test fresh breakers and forgets state restored by other tests
+func resetBreakersForTest(t *testing.T) {
+	t.Helper()
+	rebuildCircuitBreakers()
+	breakerStateMutex.Lock()
+	restoredBreakers = nil
+	breakerStateMutex.Unlock()
+	t.Cleanup(func() {
+		rebuildCircuitBreakers()
+		breakerStateMutex.Lock()
+		restoredBreakers = nil
+		breakerStateMutex.Unlock()
+	})
+}
+
+func TestBreakerStatePersistsAcrossRuns(t *testing.T) {
+	root := useTempProject(t)
+	resetBreakersForTest(t)
+
+	threshold := int(getResilienceConfig().CircuitBreaker.FailureThreshold)
+	failing := func() (interface{}, error) { return nil, errors.New("boom") }
+	breaker := GetCircuitBreaker("openai")
+	for i := 0; i < threshold; i++ {
+		CallWithCircuitBreaker(breaker, failing)
+	}
+	if breaker.State() != gobreaker.StateOpen {
+		t.Fatalf("breaker state = %s after %d failures, want open", breaker.State(), threshold)
+	}
+	if _, err := os.Stat(filepath.Join(root, breakerStateFile)); err != nil {
+		t.Fatalf("breaker state file not written: %v", err)
+	}
+
+	// A new run starts with closed in-process breakers but sees the persisted state
+	rebuildCircuitBreakers()
+	breakerStateMutex.Lock()
+	restoredBreakers = nil
+	breakerStateMutex.Unlock()
+
+	status, changedAt, ok := PersistedBreakerStatus("openai")
+	if !ok || status.State != "open" {
+		t.Fatalf("persisted status = %+v, %v; want open", status, ok)
+	}
+	if status.Counts.ConsecutiveFailures != uint32(threshold) {
+		t.Errorf("persisted consecutive failures = %d, want %d", status.Counts.ConsecutiveFailures, threshold)
+	}
+	if time.Since(changedAt) > time.Minute {
+		t.Errorf("changed_at = %s, want recent", changedAt)
+	}
+
+	calls := 0
+	_, err := ResilientAPICallWithConfig(context.Background(), "openai", func() (interface{}, error) {
+		calls++
+		return "ok", nil
+	}, RetryConfig{ShouldRetry: DefaultShouldRetry, Idempotent: true})
+	if !errors.Is(err, gobreaker.ErrOpenState) {
+		t.Fatalf("call through persisted open breaker: err = %v, want ErrOpenState", err)
+	}
+	if calls != 0 {
+		t.Errorf("function called %d times through an open breaker", calls)
+	}
+
+	// Other breakers are unaffected
+	if _, err := ResilientAPICallWithConfig(context.Background(), "anthropic", func() (interface{}, error) {
+		return "ok", nil
+	}, RetryConfig{ShouldRetry: DefaultShouldRetry, Idempotent: true}); err != nil {
+		t.Errorf("anthropic call: %v", err)
+	}
+
+	if err := ResetCircuitBreaker("openai"); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if _, _, ok := PersistedBreakerStatus("openai"); ok {
+		t.Error("persisted state still present after reset")
+	}
+	result, err := ResilientAPICallWithConfig(context.Background(), "openai", func() (interface{}, error) {
+		return "ok", nil
+	}, RetryConfig{ShouldRetry: DefaultShouldRetry, Idempotent: true})
+	if err != nil || result != "ok" {
+		t.Fatalf("call after reset = %v, %v", result, err)
+	}
+}
+
+func TestPersistedOpenBreakerExpiresToHalfOpen(t *testing.T) {
+	useTempProject(t)
+	resetBreakersForTest(t)
+
+	timeout := getResilienceConfig().CircuitBreaker.Timeout
+	breakerStateMutex.Lock()
+	err := writeBreakerStates(map[string]PersistedBreaker{
+		"anthropic": {State: gobreaker.StateOpen.String(), ChangedAt: time.Now().Add(-2 * timeout)},
+	})
+	breakerStateMutex.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, _, ok := PersistedBreakerStatus("anthropic")
+	if !ok || status.State != "half-open" {
+		t.Fatalf("status = %+v, %v; want half-open", status, ok)
+	}
+	if _, open := persistedOpenUntil("anthropic"); open {
+		t.Error("expired persisted open state still blocks calls")
+	}
+}
@@ -2,10 +2,12 @@ package main
 
 import (
 	"container/list"
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"sync"
 	"time"
+	"unsafe"
 
 	"docs-cli/pkg/config"
 )
@@ -15,6 +17,90 @@ func getCacheConfig() config.CacheConfig {
 	return config.GetConfig().Application.Cache
 }
 
+// Cache write policies for Set on a key that already holds an unexpired entry
+const (
+	// WritePolicyNewestWins overwrites the existing entry
+	WritePolicyNewestWins = "newest_wins"
+	// WritePolicyCoalesce keeps the existing entry and rejects the write
+	WritePolicyCoalesce = "coalesce"
+)
+
+// CachePolicy controls whether a model call reads from and writes to the response cache
+type CachePolicy struct {
+	// SkipRead ignores cached responses so the provider is always called (--no-cache)
+	SkipRead bool
+	// SkipWrite leaves the cache untouched by fresh responses (--no-cache-write)
+	SkipWrite bool
+	// TTL is how long a fresh response stays cached; zero uses the cache's default TTL
+	TTL time.Duration
+}
+
+// cachePolicyKey is the context key for a call's CachePolicy
+type cachePolicyKey struct{}
+
+// WithCachePolicy attaches a cache policy to ctx for the provider call path
+func WithCachePolicy(ctx context.Context, policy CachePolicy) context.Context {
+	return context.WithValue(ctx, cachePolicyKey{}, policy)
+}
+
+// cachePolicyFrom returns the cache policy carried by ctx, defaulting to normal caching
+func cachePolicyFrom(ctx context.Context) CachePolicy {
+	policy, _ := ctx.Value(cachePolicyKey{}).(CachePolicy)
+	return policy
+}
+
+// runCachePolicy returns the cache policy for a docType call, honoring --no-cache,
+// --no-cache-write, and cache.ttl_overrides
+func runCachePolicy(docType string) CachePolicy {
+	return CachePolicy{
+		SkipRead:  noCache || noCacheWrite,
+		SkipWrite: noCacheWrite,
+		TTL:       getCacheConfig().TTLOverrides[docType],
+	}
+}
+
+// cacheLookup is GetWithRefresh unless the context's policy skips cache reads. When a
+// stale entry is served under stale-while-revalidate, send re-fetches the value from the
+// provider through the guarded call the context carries; without one, or when the policy
+// skips cache writes, nothing is refreshed and an expired entry is a miss.
+func cacheLookup(ctx context.Context, cache *EnterpriseCache, key string, send func(ctx context.Context) (string, error)) (string, bool) {
+	policy := cachePolicyFrom(ctx)
+	if policy.SkipRead {
+		return "", false
+	}
+
+	var refresh func() (string, bool, error)
+	if guarded, ok := guardedCallFrom(ctx); ok && !policy.SkipWrite {
+		refresh = func() (string, bool, error) {
+			result, err := guarded(ctx, send)
+			if err != nil {
+				return "", false, err
+			}
+			// A truncated response is never cached, so a cache hit is always complete
+			return result.Content, !result.Truncated, nil
+		}
+	}
+	return cache.GetWithRefresh(key, refresh)
+}
+
+// guardedCallKey is the context key for the guarded call a background refresh goes through
+type guardedCallKey struct{}
+
+// guardedCall sends a provider call through the rate and spend limits, circuit breaker,
+// and retries of the call it was created for
+type guardedCall func(ctx context.Context, send func(ctx context.Context) (string, error)) (ModelResult, error)
+
+// withGuardedCall returns a context whose stale cache hits are refreshed through call
+func withGuardedCall(ctx context.Context, call guardedCall) context.Context {
+	return context.WithValue(ctx, guardedCallKey{}, call)
+}
+
+// guardedCallFrom returns the guarded call carried by ctx, if any
+func guardedCallFrom(ctx context.Context) (guardedCall, bool) {
+	call, ok := ctx.Value(guardedCallKey{}).(guardedCall)
+	return call, ok
+}
+
 // CacheEntry represents a cached item
 type CacheEntry struct {
 	Key        string
@@ -26,6 +112,16 @@ type CacheEntry struct {
 	AccessCount int64
 }
 
+// mapSlotOverhead approximates one slot of the entries map: the key's string header, the
+// element pointer, and the control byte and spare capacity each slot carries on average
+const mapSlotOverhead = int64(unsafe.Sizeof("") + unsafe.Sizeof((*list.Element)(nil)) + 8)
+
+// defaultEntryOverhead estimates the memory an entry takes beyond its key and value bytes:
+// the CacheEntry struct, its LRU list element, and its map slot, 192 bytes on 64-bit
+// platforms. The key's bytes are counted once because the map and entry share them.
+// Allocator size-class rounding is not included, so real usage runs slightly higher.
+var defaultEntryOverhead = int64(unsafe.Sizeof(CacheEntry{})+unsafe.Sizeof(list.Element{})) + mapSlotOverhead
+
 // CacheMetrics tracks cache performance
 type CacheMetrics struct {
 	Hits             int64   `json:"hits"`
@@ -33,6 +129,7 @@ type CacheMetrics struct {
 	Evictions        int64   `json:"evictions"`
 	TotalSize        int64   `json:"total_size_bytes"`
 	EntryCount       int      `json:"entry_count"`
+	StaleHits        int64   `json:"stale_hits"`
 	HitRatio         float64 `json:"hit_ratio"`
 	AverageEntrySize int64   `json:"average_entry_size_bytes"`
 }
@@ -43,10 +140,16 @@ type EnterpriseCache struct {
 	entries     map[string]*list.Element
 	lruList     *list.List
 	maxSize     int64
+	baseMaxSize int64
 	maxEntries  int
 	currentSize int64
+	// entryOverhead is the per-entry size added to len(key)+len(value) (cache.entry_overhead_bytes)
+	entryOverhead int64
 	ttl         time.Duration
-	metrics     CacheMetrics
+	writePolicy string
+	staleGrace  time.Duration   // stale-while-revalidate window past expiry; zero disables it
+	refreshing  map[string]bool // keys with a background refresh in flight
+	metrics     CacheMetrics // guarded by mutex; read only through GetMetrics
 	stopCleanup chan bool
 }
 
@@ -56,8 +159,12 @@ func NewEnterpriseCache(maxSize int64, maxEntries int, ttl time.Duration) *Enter
 		entries:     make(map[string]*list.Element),
 		lruList:     list.New(),
 		maxSize:     maxSize,
+		baseMaxSize: maxSize,
 		maxEntries:  maxEntries,
+		entryOverhead: defaultEntryOverhead,
 		ttl:         ttl,
+		writePolicy: WritePolicyNewestWins,
+		refreshing:  make(map[string]bool),
 		stopCleanup: make(chan bool),
 	}
 	
@@ -81,9 +188,11 @@ func (c *EnterpriseCache) Get(key string) (string, bool) {
 	
 	entry := element.Value.(*CacheEntry)
 	
-	// Check if expired
-	if time.Now().After(entry.ExpiresAt) {
-		c.removeElement(element)
+	// Check if expired; entries still inside the stale grace window are kept for GetWithRefresh
+	if now := time.Now(); now.After(entry.ExpiresAt) {
+		if now.After(entry.ExpiresAt.Add(c.staleGrace)) {
+			c.removeElement(element)
+		}
 		c.metrics.Misses++
 		c.updateHitRatio()
 		return "", false
@@ -100,12 +209,141 @@ func (c *EnterpriseCache) Get(key string) (string, bool) {
 	return entry.Value, true
 }
 
-// Set stores an item in cache
+// SetStaleWhileRevalidate enables serving expired entries for up to grace past expiry
+// from GetWithRefresh; a zero grace disables it
+func (c *EnterpriseCache) SetStaleWhileRevalidate(grace time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	
+	if grace < 0 {
+		grace = 0
+	}
+	c.staleGrace = grace
+}
+
+// GetWithRefresh is Get with stale-while-revalidate: an entry expired by less than the
+// stale grace window is returned immediately and refresh runs in the background to
+// replace it, when it reports its result cacheable. Only one refresh per key runs at a time.
+func (c *EnterpriseCache) GetWithRefresh(key string, refresh func() (string, bool, error)) (string, bool) {
+	c.mutex.Lock()
+	
+	element, exists := c.entries[key]
+	if !exists || c.staleGrace <= 0 || refresh == nil {
+		c.mutex.Unlock()
+		return c.Get(key)
+	}
+	
+	entry := element.Value.(*CacheEntry)
+	now := time.Now()
+	if !now.After(entry.ExpiresAt) || now.After(entry.ExpiresAt.Add(c.staleGrace)) {
+		c.mutex.Unlock()
+		return c.Get(key)
+	}
+	
+	entry.AccessedAt = now
+	entry.AccessCount++
+	c.lruList.MoveToFront(element)
+	c.metrics.Hits++
+	c.metrics.StaleHits++
+	c.updateHitRatio()
+	
+	value := entry.Value
+	ttl := entry.ExpiresAt.Sub(entry.CreatedAt)
+	startRefresh := !c.refreshing[key]
+	if startRefresh {
+		c.refreshing[key] = true
+	}
+	c.mutex.Unlock()
+	
+	if startRefresh {
+		go c.revalidate(key, ttl, refresh)
+	}
+	return value, true
+}
+
+// revalidate runs a background refresh for key and stores a cacheable result with the
+// entry's original TTL
+func (c *EnterpriseCache) revalidate(key string, ttl time.Duration, refresh func() (string, bool, error)) {
+	defer func() {
+		c.mutex.Lock()
+		delete(c.refreshing, key)
+		c.mutex.Unlock()
+	}()
+	
+	value, cacheable, err := refresh()
+	if err != nil {
+		LogWithContext().WithError(err).WithField("cache_key", key[:min(8, len(key))]+"...").
+			Warn("Background cache refresh failed, keeping stale entry")
+		return
+	}
+	if !cacheable {
+		LogWithContext().WithField("cache_key", key[:min(8, len(key))]+"...").
+			Debug("Background cache refresh not cacheable, keeping stale entry")
+		return
+	}
+	c.SetWithTTL(key, value, ttl)
+}
+
+// Contains reports whether an unexpired entry exists, without affecting metrics or LRU order
+func (c *EnterpriseCache) Contains(key string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	
+	element, exists := c.entries[key]
+	if !exists {
+		return false
+	}
+	
+	entry := element.Value.(*CacheEntry)
+	return time.Now().Before(entry.ExpiresAt)
+}
+
+// SetEntryOverhead sets the bytes each entry is charged beyond its key and value; zero or
+// less restores defaultEntryOverhead. Existing entries keep the size they were charged.
+func (c *EnterpriseCache) SetEntryOverhead(bytes int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	
+	if bytes <= 0 {
+		bytes = defaultEntryOverhead
+	}
+	c.entryOverhead = bytes
+}
+
+// SetWritePolicy sets how Set treats keys that already hold an unexpired entry
+func (c *EnterpriseCache) SetWritePolicy(policy string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	
+	if policy != WritePolicyCoalesce {
+		policy = WritePolicyNewestWins
+	}
+	c.writePolicy = policy
+}
+
+// Set stores an item in cache with the default TTL and reports whether the write happened
 func (c *EnterpriseCache) Set(key, value string) bool {
+	return c.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL stores an item that expires after ttl, or the cache's default TTL when ttl
+// is zero, and reports whether the write happened. Under the coalesce policy a key with
+// an unexpired entry keeps its first value.
+func (c *EnterpriseCache) SetWithTTL(key, value string, ttl time.Duration) bool {
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+	
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	
-	entrySize := int64(len(key) + len(value) + 200) // Approximate overhead
+	if c.writePolicy == WritePolicyCoalesce {
+		if element, exists := c.entries[key]; exists && time.Now().Before(element.Value.(*CacheEntry).ExpiresAt) {
+			return false
+		}
+	}
+	
+	entrySize := int64(len(key)+len(value)) + c.entryOverhead
 	
 	// Check if single entry is too large
 	if entrySize > c.maxSize {
@@ -131,7 +369,7 @@ func (c *EnterpriseCache) Set(key, value string) bool {
 		Value:       value,
 		Size:        entrySize,
 		CreatedAt:   time.Now(),
-		ExpiresAt:   time.Now().Add(c.ttl),
+		ExpiresAt:   time.Now().Add(ttl),
 		AccessedAt:  time.Now(),
 		AccessCount: 1,
 	}
@@ -147,7 +385,7 @@ func (c *EnterpriseCache) Set(key, value string) bool {
 	return true
 }
 
-// evictLRU removes the least recently used item
+// evictLRU removes the least recently used item; callers must hold the write lock
 func (c *EnterpriseCache) evictLRU() {
 	element := c.lruList.Back()
 	if element != nil {
@@ -156,7 +394,7 @@ func (c *EnterpriseCache) evictLRU() {
 	}
 }
 
-// removeElement removes an element from cache
+// removeElement removes an element from cache; callers must hold the write lock
 func (c *EnterpriseCache) removeElement(element *list.Element) {
 	if element == nil {
 		return
@@ -195,10 +433,10 @@ func (c *EnterpriseCache) cleanupExpired() {
 	now := time.Now()
 	var toRemove []*list.Element
 	
-	// Collect expired entries
+	// Collect expired entries, keeping those still servable as stale
 	for element := c.lruList.Back(); element != nil; element = element.Prev() {
 		entry := element.Value.(*CacheEntry)
-		if now.After(entry.ExpiresAt) {
+		if now.After(entry.ExpiresAt.Add(c.staleGrace)) {
 			toRemove = append(toRemove, element)
 		}
 	}
@@ -214,7 +452,7 @@ func (c *EnterpriseCache) cleanupExpired() {
 	}
 }
 
-// updateHitRatio calculates the current hit ratio
+// updateHitRatio calculates the current hit ratio; callers must hold the write lock
 func (c *EnterpriseCache) updateHitRatio() {
 	total := c.metrics.Hits + c.metrics.Misses
 	if total > 0 {
@@ -222,14 +460,17 @@ func (c *EnterpriseCache) updateHitRatio() {
 	}
 }
 
-// updateAverageEntrySize calculates average entry size
+// updateAverageEntrySize calculates average entry size; callers must hold the write lock
 func (c *EnterpriseCache) updateAverageEntrySize() {
 	if c.metrics.EntryCount > 0 {
 		c.metrics.AverageEntrySize = c.metrics.TotalSize / int64(c.metrics.EntryCount)
+	} else {
+		c.metrics.AverageEntrySize = 0
 	}
 }
 
-// GetMetrics returns current cache metrics
+// GetMetrics returns a consistent snapshot of the cache metrics, copied under the read lock
+// so counters such as Hits and Misses are never torn relative to each other
 func (c *EnterpriseCache) GetMetrics() CacheMetrics {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
@@ -247,6 +488,39 @@ func (c *EnterpriseCache) Clear() {
 	c.metrics = CacheMetrics{}
 }
 
+// SetMemoryPressure adjusts the effective size limit for the given memory pressure,
+// evicting least recently used entries until the cache fits
+func (c *EnterpriseCache) SetMemoryPressure(pressure MemoryPressure) {
+	warningRatio, criticalRatio, err := getCacheConfig().ResolvePressureRatios()
+	if err != nil {
+		LogWithContext().WithError(err).Warn("Ignoring memory pressure change; cache size unchanged")
+		return
+	}
+	
+	ratio := 1.0
+	switch pressure {
+	case MemoryPressureWarning:
+		ratio = warningRatio
+	case MemoryPressureCritical:
+		ratio = criticalRatio
+	}
+	
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	
+	c.maxSize = int64(float64(c.baseMaxSize) * ratio)
+	evicted := 0
+	for c.currentSize > c.maxSize && c.lruList.Len() > 0 {
+		c.evictLRU()
+		evicted++
+	}
+	
+	LogWithContext().WithField("pressure", pressure.String()).
+		WithField("max_size", c.maxSize).
+		WithField("evicted", evicted).
+		Info("Adjusted cache size for memory pressure")
+}
+
 // Close stops the cache cleanup goroutine
 func (c *EnterpriseCache) Close() {
 	close(c.stopCleanup)
@@ -265,6 +539,20 @@ func init() {
 	anthropicCache = NewEnterpriseCache(maxSizeBytes, cacheConfig.MaxEntries, cacheConfig.TTL)
 	openaiCache = NewEnterpriseCache(maxSizeBytes, cacheConfig.MaxEntries, cacheConfig.TTL)
 	defaultCache = NewEnterpriseCache(maxSizeBytes, cacheConfig.MaxEntries, cacheConfig.TTL)
+	
+	for _, cache := range []*EnterpriseCache{anthropicCache, openaiCache, defaultCache} {
+		cache.SetWritePolicy(cacheConfig.WritePolicy)
+		cache.SetEntryOverhead(cacheConfig.EntryOverheadBytes)
+		if cacheConfig.StaleWhileRevalidate {
+			cache.SetStaleWhileRevalidate(cacheConfig.StaleGrace)
+		}
+	}
+	
+	if cacheConfig.AdaptiveSizing {
+		for _, cache := range []*EnterpriseCache{anthropicCache, openaiCache, defaultCache} {
+			SubscribeMemoryPressure(cache.SetMemoryPressure)
+		}
+	}
 }
 
 // GetProviderCache returns the appropriate cache for a provider
@@ -279,10 +567,13 @@ func GetProviderCache(provider string) *EnterpriseCache {
 	}
 }
 
-// GenerateCacheKey creates a cache key for API calls
+// GenerateCacheKey creates a cache key for API calls, scoped to the --lang output language
 func GenerateCacheKey(provider, prompt, model string, maxTokens int, temperature float64) string {
 	// Use shorter hash for cache keys since we have size limits
-	input := fmt.Sprintf("%s|%s|%s|%d|%.2f", provider, model, prompt, maxTokens, temperature)
+	input := fmt.Sprintf("%s|%s|%s|%s|%d|%.2f", provider, model, docLanguage, prompt, maxTokens, temperature)
+	if seed := runSeed(); seed != nil {
+		input += fmt.Sprintf("|seed=%d", *seed)
+	}
 	hash := sha256.Sum256([]byte(input))
 	return fmt.Sprintf("%x", hash)[:16] // Use first 16 chars for shorter keys
 }
@@ -0,0 +1,558 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"docs-cli/pkg/config"
+)
+
+// newTestCache returns a cache whose entries are charged exactly entrySize bytes when their
+// key and value are built by fillCache
+func newTestCache(t *testing.T, maxSize int64, maxEntries int) *EnterpriseCache {
+	t.Helper()
+	cache := NewEnterpriseCache(maxSize, maxEntries, time.Hour)
+	cache.SetEntryOverhead(1)
+	t.Cleanup(cache.Close)
+	return cache
+}
+
+const entrySize = 100
+
+// fillCache stores n entries, key0 first, each charged entrySize bytes
+func fillCache(cache *EnterpriseCache, n int) {
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("key%d", i)
+		cache.Set(key, strings.Repeat("x", entrySize-len(key)-1))
+	}
+}
+
+// cachedKeys returns which of key0..key<n-1> are still cached
+func cachedKeys(cache *EnterpriseCache, n int) []string {
+	var keys []string
+	for i := 0; i < n; i++ {
+		if key := fmt.Sprintf("key%d", i); cache.Contains(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func TestSetMemoryPressureEvictsToRatio(t *testing.T) {
+	tests := []struct {
+		name                        string
+		warningRatio, criticalRatio float64
+		wantWarning, wantCritical   []string
+	}{
+		{
+			name:          "configured ratios",
+			warningRatio:  0.3,
+			criticalRatio: 0.2,
+			wantWarning:   []string{"key7", "key8", "key9"},
+			wantCritical:  []string{"key8", "key9"},
+		},
+		{
+			// Ratios missing from the YAML load as 0 and use 0.5 and 0.1
+			name:         "unset ratios use the defaults",
+			wantWarning:  []string{"key5", "key6", "key7", "key8", "key9"},
+			wantCritical: []string{"key9"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useEnterpriseConfig(t, func(c *config.EnterpriseConfig) {
+				c.Application.Cache.PressureWarningRatio = tt.warningRatio
+				c.Application.Cache.PressureCriticalRatio = tt.criticalRatio
+			})
+			cache := newTestCache(t, 10*entrySize, 100)
+			fillCache(cache, 10)
+
+			cache.SetMemoryPressure(MemoryPressureWarning)
+			if got := cachedKeys(cache, 10); strings.Join(got, ",") != strings.Join(tt.wantWarning, ",") {
+				t.Errorf("after warning: cached %v, want %v", got, tt.wantWarning)
+			}
+			cache.SetMemoryPressure(MemoryPressureCritical)
+			if got := cachedKeys(cache, 10); strings.Join(got, ",") != strings.Join(tt.wantCritical, ",") {
+				t.Errorf("after critical: cached %v, want %v", got, tt.wantCritical)
+			}
+			if got, want := cache.GetMetrics().Evictions, int64(10-len(tt.wantCritical)); got != want {
+				t.Errorf("evictions = %d, want %d", got, want)
+			}
+
+			// Back to normal the full size is available again
+			cache.SetMemoryPressure(MemoryPressureNormal)
+			fillCache(cache, 10)
+			if got := cachedKeys(cache, 10); len(got) != 10 {
+				t.Errorf("after normal: cached %v, want all 10 keys", got)
+			}
+		})
+	}
+}
+
+// setConcurrently has writers goroutines Set key at once, each with its own value, and
+// returns the values whose writes happened
+func setConcurrently(cache *EnterpriseCache, key string, writers int) []string {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		written []string
+		start   = make(chan struct{})
+	)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(value string) {
+			defer wg.Done()
+			<-start
+			if cache.Set(key, value) {
+				mu.Lock()
+				written = append(written, value)
+				mu.Unlock()
+			}
+		}(fmt.Sprintf("value-%02d", i))
+	}
+	close(start)
+	wg.Wait()
+	return written
+}
+
+func TestConcurrentSetWritePolicies(t *testing.T) {
+	const writers = 32
+
+	for round := 0; round < 20; round++ {
+		// Coalesce: exactly one writer wins and its value is the one cached
+		cache := newTestCache(t, 1<<20, 100)
+		cache.SetWritePolicy(WritePolicyCoalesce)
+		written := setConcurrently(cache, "doc", writers)
+		if len(written) != 1 {
+			t.Fatalf("coalesce: %d writes happened, want 1", len(written))
+		}
+		if value, _ := cache.Get("doc"); value != written[0] {
+			t.Fatalf("coalesce: cached %q, but the write that happened was %q", value, written[0])
+		}
+		// Later writes keep losing while the entry is unexpired
+		if cache.Set("doc", "late") {
+			t.Fatal("coalesce: a later Set overwrote the entry")
+		}
+
+		// Newest wins: every write happens and one of them is cached, counted once
+		cache = newTestCache(t, 1<<20, 100)
+		written = setConcurrently(cache, "doc", writers)
+		if len(written) != writers {
+			t.Fatalf("newest_wins: %d writes happened, want %d", len(written), writers)
+		}
+		value, _ := cache.Get("doc")
+		if !strings.HasPrefix(value, "value-") {
+			t.Fatalf("newest_wins: cached %q", value)
+		}
+		metrics := cache.GetMetrics()
+		if want := int64(len("doc") + len(value) + 1); metrics.EntryCount != 1 || metrics.TotalSize != want {
+			t.Fatalf("newest_wins: %d entries of %d bytes, want 1 of %d", metrics.EntryCount, metrics.TotalSize, want)
+		}
+	}
+}
+
+func TestCoalesceReplacesExpiredEntry(t *testing.T) {
+	cache := newTestCache(t, 1<<20, 100)
+	cache.SetWritePolicy(WritePolicyCoalesce)
+
+	cache.SetWithTTL("doc", "old", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if !cache.Set("doc", "new") {
+		t.Fatal("coalesce kept an expired entry")
+	}
+	if value, _ := cache.Get("doc"); value != "new" {
+		t.Errorf("cached %q, want new", value)
+	}
+}
+
+// TestCacheMetricsUnderConcurrency is meant for go test -race: it hammers every path that
+// reads or writes the metrics and checks each snapshot is internally consistent
+func TestCacheMetricsUnderConcurrency(t *testing.T) {
+	cache := newTestCache(t, 20*entrySize, 15)
+	cache.SetStaleWhileRevalidate(time.Minute)
+
+	const workers, iterations = 8, 500
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				key := fmt.Sprintf("key%d", (w*iterations+i)%40)
+				switch i % 5 {
+				case 0:
+					cache.Set(key, strings.Repeat("x", 10+i%50))
+				case 1:
+					cache.SetWithTTL(key, "short", time.Nanosecond)
+				case 2:
+					cache.GetWithRefresh(key, func() (string, bool, error) { return "refreshed", true, nil })
+				case 3:
+					cache.Contains(key)
+				default:
+					cache.Get(key)
+				}
+			}
+		}(w)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	var lookups int64
+	for done := false; !done; {
+		select {
+		case <-finished:
+			done = true
+		default:
+		}
+		metrics := cache.GetMetrics()
+		if metrics.Hits+metrics.Misses < lookups {
+			t.Fatalf("lookup count went backwards: %d after %d", metrics.Hits+metrics.Misses, lookups)
+		}
+		lookups = metrics.Hits + metrics.Misses
+		if lookups > 0 {
+			if want := float64(metrics.Hits) / float64(lookups); metrics.HitRatio != want {
+				t.Fatalf("torn metrics: hit ratio %g for %d hits and %d misses", metrics.HitRatio, metrics.Hits, metrics.Misses)
+			}
+		}
+		if metrics.StaleHits > metrics.Hits {
+			t.Fatalf("torn metrics: %d stale hits but %d hits", metrics.StaleHits, metrics.Hits)
+		}
+		if metrics.EntryCount > 15 || metrics.TotalSize > 20*entrySize {
+			t.Fatalf("cache over its limits: %d entries, %d bytes", metrics.EntryCount, metrics.TotalSize)
+		}
+	}
+
+	// Every Get and GetWithRefresh counted exactly one hit or miss
+	metrics := cache.GetMetrics()
+	if want := int64(workers * iterations * 2 / 5); metrics.Hits+metrics.Misses != want {
+		t.Errorf("%d lookups counted, want %d", metrics.Hits+metrics.Misses, want)
+	}
+}
+
+func TestTTLOverridesExpireOnTheirOwnSchedules(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	useEnterpriseConfig(t, func(cfg *config.EnterpriseConfig) {
+		cfg.Application.Cache.TTLOverrides = map[string]time.Duration{
+			"CHECKLIST": 20 * time.Millisecond,
+			"README":    200 * time.Millisecond,
+		}
+	})
+	cache := newTestCache(t, 1<<20, 100)
+	for _, docType := range []string{"CHECKLIST", "README", "SETUP"} {
+		cache.SetWithTTL(docType, docType+" response", runCachePolicy(docType).TTL)
+	}
+	if ttl := runCachePolicy("SETUP").TTL; ttl != 0 {
+		t.Fatalf("SETUP has no override but its policy TTL is %s, want 0 for the cache default", ttl)
+	}
+
+	cached := func() []string {
+		var docTypes []string
+		for _, docType := range []string{"CHECKLIST", "README", "SETUP"} {
+			if cache.Contains(docType) {
+				docTypes = append(docTypes, docType)
+			}
+		}
+		return docTypes
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if got := strings.Join(cached(), ","); got != "README,SETUP" {
+		t.Errorf("cached after 60ms = %s, want README,SETUP with CHECKLIST expired", got)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := strings.Join(cached(), ","); got != "SETUP" {
+		t.Errorf("cached after 260ms = %s, want only SETUP on the hour-long default TTL", got)
+	}
+}
+
+func TestStaleWhileRevalidateServesStaleAndRefreshesOnce(t *testing.T) {
+	cache := newTestCache(t, 1<<20, 100)
+	cache.SetStaleWhileRevalidate(time.Minute)
+	cache.SetWithTTL("doc", "old", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	var refreshes sync.WaitGroup
+	refreshes.Add(1)
+	release := make(chan struct{})
+	var calls int32
+	var mu sync.Mutex
+	refresh := func() (string, bool, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		<-release
+		defer refreshes.Done()
+		return "new", true, nil
+	}
+
+	// Every caller inside the grace window gets the stale value while one refresh runs
+	var readers sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			if value, found := cache.GetWithRefresh("doc", refresh); !found || value != "old" {
+				t.Errorf("GetWithRefresh in the grace window = %q, %v; want the stale value", value, found)
+			}
+		}()
+	}
+	readers.Wait()
+	close(release)
+	refreshes.Wait()
+
+	mu.Lock()
+	if calls != 1 {
+		t.Errorf("refresh ran %d times, want once for concurrent stale reads", calls)
+	}
+	mu.Unlock()
+	if value, found := cache.Get("doc"); !found || value != "new" {
+		t.Errorf("after the refresh Get = %q, %v; want the refreshed value", value, found)
+	}
+	if stale := cache.GetMetrics().StaleHits; stale != 10 {
+		t.Errorf("StaleHits = %d, want 10", stale)
+	}
+}
+
+func TestStaleWhileRevalidateGraceWindow(t *testing.T) {
+	refreshed := make(chan struct{}, 1)
+	refresh := func() (string, bool, error) {
+		refreshed <- struct{}{}
+		return "new", true, nil
+	}
+
+	// Past the grace window an entry is a plain miss and nothing is refreshed
+	cache := newTestCache(t, 1<<20, 100)
+	cache.SetStaleWhileRevalidate(10 * time.Millisecond)
+	cache.SetWithTTL("doc", "old", time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	if value, found := cache.GetWithRefresh("doc", refresh); found {
+		t.Errorf("GetWithRefresh past the grace window = %q, want a miss", value)
+	}
+	if cache.GetMetrics().EntryCount != 0 {
+		t.Error("an entry past the grace window was kept")
+	}
+
+	// Without a grace window an expired entry is a miss too
+	cache = newTestCache(t, 1<<20, 100)
+	cache.SetWithTTL("doc", "old", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if value, found := cache.GetWithRefresh("doc", refresh); found {
+		t.Errorf("GetWithRefresh with stale-while-revalidate off = %q, want a miss", value)
+	}
+
+	// A fresh entry is served as is without a refresh
+	cache.SetStaleWhileRevalidate(time.Minute)
+	cache.Set("doc", "fresh")
+	if value, found := cache.GetWithRefresh("doc", refresh); !found || value != "fresh" {
+		t.Errorf("GetWithRefresh on a fresh entry = %q, %v", value, found)
+	}
+
+	select {
+	case <-refreshed:
+		t.Error("refresh ran for an entry outside the grace window")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestStaleWhileRevalidateKeepsEntryWhenRefreshFails(t *testing.T) {
+	cache := newTestCache(t, 1<<20, 100)
+	cache.SetStaleWhileRevalidate(time.Minute)
+	cache.SetWithTTL("doc", "old", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	failed := make(chan struct{})
+	if value, found := cache.GetWithRefresh("doc", func() (string, bool, error) {
+		defer close(failed)
+		return "", false, fmt.Errorf("provider unavailable")
+	}); !found || value != "old" {
+		t.Fatalf("GetWithRefresh = %q, %v; want the stale value", value, found)
+	}
+	<-failed
+
+	// The failed refresh leaves the stale entry to serve, and a later read retries
+	retried := make(chan struct{})
+	var retry sync.Once
+	deadline := time.Now().Add(time.Second)
+	for {
+		value, found := cache.GetWithRefresh("doc", func() (string, bool, error) {
+			retry.Do(func() { close(retried) })
+			return "new", true, nil
+		})
+		if !found || value != "old" && value != "new" {
+			t.Fatalf("GetWithRefresh after a failed refresh = %q, %v; want the entry kept", value, found)
+		}
+		select {
+		case <-retried:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no refresh retried after the first one failed")
+		}
+	}
+}
+
+// checkSizeAccounting fails unless currentSize and the size metrics match the entries held
+func checkSizeAccounting(t *testing.T, cache *EnterpriseCache, step int) {
+	t.Helper()
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+
+	var total int64
+	for element := cache.lruList.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*CacheEntry)
+		if cache.entries[entry.Key] != element {
+			t.Fatalf("step %d: LRU entry %s is not the one in the map", step, entry.Key)
+		}
+		total += entry.Size
+	}
+	if cache.currentSize != total || cache.metrics.TotalSize != total {
+		t.Fatalf("step %d: currentSize %d, metrics %d, entries sum to %d", step, cache.currentSize, cache.metrics.TotalSize, total)
+	}
+	if len(cache.entries) != cache.lruList.Len() || cache.metrics.EntryCount != len(cache.entries) {
+		t.Fatalf("step %d: %d map entries, %d list elements, metrics %d", step, len(cache.entries), cache.lruList.Len(), cache.metrics.EntryCount)
+	}
+	if cache.currentSize > cache.maxSize || len(cache.entries) > cache.maxEntries {
+		t.Fatalf("step %d: %d bytes in %d entries exceeds %d bytes or %d entries", step, cache.currentSize, len(cache.entries), cache.maxSize, cache.maxEntries)
+	}
+}
+
+func TestCurrentSizeStaysConsistentAcrossSetEvictCycles(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	cache := newTestCache(t, 20_000, 64)
+	random := rand.New(rand.NewSource(1))
+	overheads := []int64{1, 0, 64, 500}
+
+	for step := 0; step < 20_000; step++ {
+		key := fmt.Sprintf("key%d", random.Intn(200))
+		value := strings.Repeat("x", random.Intn(2_000))
+		switch op := random.Intn(100); {
+		case op < 70:
+			cache.Set(key, value)
+		case op < 85:
+			cache.SetWithTTL(key, value, time.Nanosecond)
+		case op < 90:
+			cache.cleanupExpired()
+		case op < 95:
+			// Entries keep the size they were charged when the overhead changes
+			cache.SetEntryOverhead(overheads[random.Intn(len(overheads))])
+		case op < 98:
+			cache.SetMemoryPressure(MemoryPressure(random.Intn(3)))
+		default:
+			cache.Get(key)
+		}
+		checkSizeAccounting(t, cache, step)
+	}
+
+	if evictions := cache.GetMetrics().Evictions; evictions < 1_000 {
+		t.Errorf("only %d evictions; the cycles should keep the cache at its limits", evictions)
+	}
+	cache.Clear()
+	checkSizeAccounting(t, cache, -1)
+	if cache.currentSize != 0 {
+		t.Errorf("currentSize after Clear = %d, want 0", cache.currentSize)
+	}
+}
+
+func TestEntryOverhead(t *testing.T) {
+	cache := newTestCache(t, 1<<20, 10)
+	cache.Set("key", "value")
+	cache.SetEntryOverhead(0)
+	cache.Set("other", "value")
+
+	sizes := map[string]int64{}
+	for element := cache.lruList.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*CacheEntry)
+		sizes[entry.Key] = entry.Size
+	}
+	if sizes["key"] != int64(len("key")+len("value"))+1 {
+		t.Errorf("entry set with overhead 1 charged %d bytes", sizes["key"])
+	}
+	if sizes["other"] != int64(len("other")+len("value"))+defaultEntryOverhead {
+		t.Errorf("entry set after restoring the default charged %d bytes, want %d of overhead", sizes["other"], defaultEntryOverhead)
+	}
+	if defaultEntryOverhead <= mapSlotOverhead {
+		t.Errorf("defaultEntryOverhead = %d, want the entry and list element on top of the %d byte map slot", defaultEntryOverhead, mapSlotOverhead)
+	}
+}
+
+func TestStaleWhileRevalidateKeepsEntryWhenRefreshIsNotCacheable(t *testing.T) {
+	cache := newTestCache(t, 1<<20, 100)
+	cache.SetStaleWhileRevalidate(time.Minute)
+	cache.SetWithTTL("doc", "old", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	refreshed := make(chan struct{})
+	if value, found := cache.GetWithRefresh("doc", func() (string, bool, error) {
+		defer close(refreshed)
+		return "truncated", false, nil
+	}); !found || value != "old" {
+		t.Fatalf("GetWithRefresh = %q, %v; want the stale value", value, found)
+	}
+	<-refreshed
+
+	// Give revalidate the chance to store the result it must not store
+	time.Sleep(5 * time.Millisecond)
+	if value, found := cache.GetWithRefresh("doc", nil); found {
+		t.Errorf("uncacheable refresh was stored: %q", value)
+	}
+}
+
+func TestCacheLookupRefreshesOnlyThroughGuardedCalls(t *testing.T) {
+	tests := []struct {
+		name        string
+		guarded     bool
+		policy      CachePolicy
+		wantStale   bool
+		wantRefresh bool
+	}{
+		{"guarded call", true, CachePolicy{}, true, true},
+		// Nothing outside the guarded call path may reach the provider from the background
+		{"no guarded call", false, CachePolicy{}, false, false},
+		// A refresh could not store its result
+		{"skip write", true, CachePolicy{SkipWrite: true}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newTestCache(t, 1<<20, 100)
+			cache.SetStaleWhileRevalidate(time.Minute)
+			cache.SetWithTTL("doc", "old", time.Millisecond)
+			time.Sleep(5 * time.Millisecond)
+
+			var guardedCalls, sends atomic.Int32
+			ctx := WithCachePolicy(context.Background(), tt.policy)
+			if tt.guarded {
+				ctx = withGuardedCall(ctx, func(ctx context.Context, send func(ctx context.Context) (string, error)) (ModelResult, error) {
+					guardedCalls.Add(1)
+					content, err := send(ctx)
+					return ModelResult{Content: content}, err
+				})
+			}
+			value, found := cacheLookup(ctx, cache, "doc", func(ctx context.Context) (string, error) {
+				sends.Add(1)
+				return "new", nil
+			})
+			if found != tt.wantStale || tt.wantStale && value != "old" {
+				t.Errorf("cacheLookup = %q, %v; want stale served %v", value, found, tt.wantStale)
+			}
+
+			waitForRefreshes(t, cache)
+			if refreshed := sends.Load() > 0; refreshed != tt.wantRefresh {
+				t.Errorf("refreshed = %v, want %v", refreshed, tt.wantRefresh)
+			}
+			if sends.Load() != guardedCalls.Load() {
+				t.Errorf("%d sends but %d guarded calls; every refresh goes through the guarded call", sends.Load(), guardedCalls.Load())
+			}
+		})
+	}
+}
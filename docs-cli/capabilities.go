@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"strings"
+)
+
+// ProviderCapabilities describes what a provider (and, for thinking, a specific model) supports
+type ProviderCapabilities struct {
+	SupportsThinking  bool
+	SupportsStreaming bool
+	ReturnsCost       bool // response carries the billed cost
+	MaxContext        int  // context window in tokens
+}
+
+// CapabilityOverrides is a model-config.yaml capabilities block. Each field set replaces
+// the built-in default; fields left out keep it.
+type CapabilityOverrides struct {
+	SupportsThinking  *bool `yaml:"supports_thinking"`
+	SupportsStreaming *bool `yaml:"supports_streaming"`
+	ReturnsCost       *bool `yaml:"returns_cost"`
+	MaxContext        *int  `yaml:"max_context"`
+}
+
+// applyTo returns capabilities with the fields set in the overrides replaced
+func (o CapabilityOverrides) applyTo(capabilities ProviderCapabilities) ProviderCapabilities {
+	if o.SupportsThinking != nil {
+		capabilities.SupportsThinking = *o.SupportsThinking
+	}
+	if o.SupportsStreaming != nil {
+		capabilities.SupportsStreaming = *o.SupportsStreaming
+	}
+	if o.ReturnsCost != nil {
+		capabilities.ReturnsCost = *o.ReturnsCost
+	}
+	if o.MaxContext != nil {
+		capabilities.MaxContext = *o.MaxContext
+	}
+	return capabilities
+}
+
+// defaultProviderCapabilities apply to providers whose model-config.yaml entry has no capabilities block
+var defaultProviderCapabilities = map[string]ProviderCapabilities{
+	"anthropic":  {SupportsThinking: true, SupportsStreaming: true, MaxContext: 200000},
+	"openai":     {SupportsThinking: true, SupportsStreaming: true, MaxContext: 128000},
+	"openrouter": {SupportsThinking: true, SupportsStreaming: true, ReturnsCost: true, MaxContext: 128000},
+	"mock":       {ReturnsCost: true, MaxContext: 200000}, // its billed cost is always $0
+}
+
+// ThinkingModelProvider is implemented by providers that can send thinking parameters
+type ThinkingModelProvider interface {
+	ModelProvider
+	CallModelWithThinking(ctx context.Context, prompt, model string, maxTokens int, temperature float64, thinkingConfig ThinkingConfig) (string, error)
+}
+
+// providerSettings returns the model-config.yaml section for a provider
+func (c *ModelConfig) providerSettings(provider string) (ProviderConfig, bool) {
+	switch provider {
+	case "anthropic":
+		return c.Anthropic, true
+	case "openai":
+		return c.OpenAI, true
+	case "openrouter":
+		return c.OpenRouter, true
+	case "mock":
+		return c.Mock, true
+	default:
+		return ProviderConfig{}, false
+	}
+}
+
+// keyedProviders returns the providers that have an API key configured, in a fixed order
+func (c *ModelConfig) keyedProviders() []string {
+	var providers []string
+	for _, provider := range []string{"anthropic", "openai", "openrouter"} {
+		if settings, _ := c.providerSettings(provider); settings.APIKey != "" {
+			providers = append(providers, provider)
+		}
+	}
+	return providers
+}
+
+// GetProviderCapabilities returns the capabilities of provider for model. Provider-level
+// values are the built-in defaults with model-config.yaml's capabilities block merged over
+// them field by field; thinking also requires the model to be listed in the provider's
+// thinking_models.
+func GetProviderCapabilities(provider, model string) ProviderCapabilities {
+	capabilities := defaultProviderCapabilities[provider]
+
+	config, err := loadModelConfig()
+	if err != nil {
+		LogWithContext().WithError(err).Warn("Failed to load model config for capability lookup")
+		capabilities.SupportsThinking = false
+		return capabilities
+	}
+
+	providerConfig, known := config.providerSettings(provider)
+	if !known {
+		return ProviderCapabilities{}
+	}
+	if providerConfig.Capabilities != nil {
+		capabilities = providerConfig.Capabilities.applyTo(capabilities)
+	}
+
+	capabilities.SupportsThinking = capabilities.SupportsThinking && modelListed(providerConfig.ThinkingModels, model)
+	return capabilities
+}
+
+// modelListed reports whether model matches, or contains, any entry in models
+func modelListed(models []string, model string) bool {
+	for _, listed := range models {
+		if strings.Contains(model, listed) || listed == model {
+			return true
+		}
+	}
+	return false
+}
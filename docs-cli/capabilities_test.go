@@ -0,0 +1,85 @@
+package main
+
+import "testing"
+
+// useModelConfig writes model-config.yaml for the test project and drops the loaded one
+func useModelConfig(t *testing.T, project *testProject, content string) {
+	t.Helper()
+	project.WriteFile("cli/model-config.yaml", content)
+	modelConfig.Store(nil)
+}
+
+func TestGetProviderCapabilities(t *testing.T) {
+	project := newTestProject(t, "components: []\n")
+	useModelConfig(t, project, testMockModelConfig+`
+openai:
+  thinking_models: ["o1"]
+  capabilities:
+    max_context: 32000
+anthropic:
+  thinking_models: ["sonnet"]
+  capabilities:
+    supports_thinking: false
+    returns_cost: true
+openrouter:
+  thinking_models: ["deepseek-r1"]
+`)
+
+	tests := []struct {
+		name            string
+		provider, model string
+		want            ProviderCapabilities
+	}{
+		{
+			name:     "partial block keeps the unset defaults",
+			provider: "openai", model: "o1-preview",
+			want: ProviderCapabilities{SupportsThinking: true, SupportsStreaming: true, MaxContext: 32000},
+		},
+		{
+			name:     "thinking needs a listed model",
+			provider: "openai", model: "gpt-4.1",
+			want: ProviderCapabilities{SupportsStreaming: true, MaxContext: 32000},
+		},
+		{
+			name:     "block turns a default off and another on",
+			provider: "anthropic", model: "claude-sonnet-4",
+			want: ProviderCapabilities{SupportsStreaming: true, ReturnsCost: true, MaxContext: 200000},
+		},
+		{
+			name:     "no block uses the defaults",
+			provider: "openrouter", model: "deepseek-r1",
+			want: ProviderCapabilities{SupportsThinking: true, SupportsStreaming: true, ReturnsCost: true, MaxContext: 128000},
+		},
+		{
+			name:     "mock",
+			provider: "mock", model: "demo",
+			want: ProviderCapabilities{ReturnsCost: true, MaxContext: 200000},
+		},
+		{
+			name:     "unknown provider",
+			provider: "unknown", model: "model",
+			want: ProviderCapabilities{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetProviderCapabilities(tt.provider, tt.model); got != tt.want {
+				t.Errorf("GetProviderCapabilities(%s, %s) = %+v, want %+v", tt.provider, tt.model, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapabilityOverridesApplyTo(t *testing.T) {
+	base := ProviderCapabilities{SupportsThinking: true, SupportsStreaming: true, ReturnsCost: false, MaxContext: 100}
+	if got := (CapabilityOverrides{}).applyTo(base); got != base {
+		t.Errorf("empty overrides changed %+v to %+v", base, got)
+	}
+
+	off, on, maxContext := false, true, 500
+	got := CapabilityOverrides{SupportsStreaming: &off, ReturnsCost: &on, MaxContext: &maxContext}.applyTo(base)
+	want := ProviderCapabilities{SupportsThinking: true, SupportsStreaming: false, ReturnsCost: true, MaxContext: 500}
+	if got != want {
+		t.Errorf("applyTo = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"docs-cli/pkg/config"
+)
+
+// ChatMessage is one provider-neutral message in a completion request
+type ChatMessage struct {
+	Role    string
+	Content string
+	// CacheablePrefix is the length in bytes of the leading part of Content that stays the
+	// same between calls for a component (through the source context); providers with
+	// prompt caching cache the conversation up to that point
+	CacheablePrefix int
+}
+
+// ChatRequest is a provider-neutral completion request
+type ChatRequest struct {
+	Model       string
+	Messages    []ChatMessage
+	MaxTokens   int
+	Temperature float64
+	Thinking    ThinkingConfig
+	// Seed requests reproducible sampling from providers that support it (--seed)
+	Seed *int
+}
+
+// ChatResponse is a provider-neutral completion result
+type ChatResponse struct {
+	Content string
+	// Model is the model that served the request; routers may pick a different one
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	// TotalCost is the billed cost in USD, for providers that report it
+	TotalCost float64
+	// Truncated is set when the model stopped at the output token limit
+	Truncated bool
+	// CacheCreationTokens and CacheReadTokens are the prompt tokens written to and served
+	// from the provider's prompt cache
+	CacheCreationTokens int
+	CacheReadTokens     int
+}
+
+// ChatProvider is implemented by providers that accept a multi-turn message array;
+// other providers receive the conversation flattened into a single prompt
+type ChatProvider interface {
+	ModelProvider
+	CallChat(ctx context.Context, request ChatRequest) (string, error)
+}
+
+// chatAdapter translates chat requests and responses to and from one provider's wire format
+type chatAdapter interface {
+	// buildRequest returns the JSON request body for the provider's API
+	buildRequest(request ChatRequest, settings config.ProviderConfig) interface{}
+	// setHeaders adds authentication and provider-specific headers
+	setHeaders(header http.Header, apiKey string, settings config.ProviderConfig)
+	// statusError maps a non-200 response to an error
+	statusError(statusCode int, status string, body []byte) error
+	// parseResponse decodes a successful response body
+	parseResponse(body []byte) (ChatResponse, error)
+}
+
+// chatClient runs the completion flow shared by all providers:
+// validate → cache → build request → call → parse → cache
+type chatClient struct {
+	name         string // provider key used for caching, capabilities, and spend
+	label        string // provider name used in errors and logs
+	systemPrompt string // prepended to requests that carry no system message
+	apiKey       string
+	cache        *EnterpriseCache
+	settings     config.ProviderConfig
+	adapter      chatAdapter
+}
+
+// newChatClient creates the shared client for a provider with opts applied over its config
+func newChatClient(name, label, systemPrompt, apiKey string, providerConfig config.ProviderConfig, opts []ProviderOption, adapter chatAdapter) chatClient {
+	return chatClient{
+		name:         name,
+		label:        label,
+		systemPrompt: systemPrompt,
+		apiKey:       apiKey,
+		cache:        GetProviderCache(name),
+		settings:     applyProviderOptions(providerConfig, opts),
+		adapter:      adapter,
+	}
+}
+
+// userChatRequest builds a single-turn request for a prompt
+func userChatRequest(prompt, model string, maxTokens int, temperature float64) ChatRequest {
+	return ChatRequest{
+		Model:       model,
+		Messages:    []ChatMessage{{Role: "user", Content: prompt}},
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+	}
+}
+
+// finalPrompt returns the last user message, which is the prompt being answered
+func (r ChatRequest) finalPrompt() string {
+	for i := len(r.Messages) - 1; i >= 0; i-- {
+		if r.Messages[i].Role == "user" {
+			return r.Messages[i].Content
+		}
+	}
+	return ""
+}
+
+// flattenConversation renders messages as one prompt for providers without multi-turn
+// support and for cache keys. System messages are skipped, and a single turn is
+// returned unchanged so single-prompt calls keep their existing cache keys.
+func flattenConversation(messages []ChatMessage) string {
+	var turns []ChatMessage
+	for _, message := range messages {
+		if message.Role != "system" {
+			turns = append(turns, message)
+		}
+	}
+	if len(turns) == 1 {
+		return turns[0].Content
+	}
+
+	var flattened strings.Builder
+	for i, turn := range turns {
+		if i == len(turns)-1 {
+			flattened.WriteString(turn.Content)
+			break
+		}
+		flattened.WriteString(fmt.Sprintf("=== %s ===\n%s\n\n", strings.ToUpper(turn.Role), turn.Content))
+	}
+	return flattened.String()
+}
+
+// CallChat sends a multi-turn request, adding the provider's system prompt when the request has none
+func (c *chatClient) CallChat(ctx context.Context, request ChatRequest) (string, error) {
+	if c.systemPrompt != "" && (len(request.Messages) == 0 || request.Messages[0].Role != "system") {
+		request.Messages = append([]ChatMessage{{Role: "system", Content: c.systemPrompt}}, request.Messages...)
+	}
+	return c.complete(ctx, request)
+}
+
+// complete validates the request, serves it from cache when possible, and otherwise
+// calls the provider and caches the response
+func (c *chatClient) complete(ctx context.Context, request ChatRequest) (string, error) {
+	providerConfig := c.settings
+
+	// Validate input parameters
+	if request.finalPrompt() == "" {
+		return "", fmt.Errorf("prompt cannot be empty")
+	}
+	if request.Temperature < providerConfig.TemperatureRange.Min || request.Temperature > providerConfig.TemperatureRange.Max {
+		return "", fmt.Errorf("temperature must be between %.1f and %.1f for %s", providerConfig.TemperatureRange.Min, providerConfig.TemperatureRange.Max, c.label)
+	}
+	if request.MaxTokens <= 0 {
+		return "", fmt.Errorf("maxTokens must be positive")
+	}
+	if request.Seed == nil {
+		request.Seed = runSeed()
+	}
+
+	// Generate cache key
+	cacheKey := GenerateCacheKey(c.name, flattenConversation(request.Messages), request.Model, request.MaxTokens, request.Temperature)
+
+	// Check cache first
+	if cached, found := cacheLookup(ctx, c.cache, cacheKey, func(ctx context.Context) (string, error) {
+		response, err := c.fetch(ctx, request)
+		return response.Content, err
+	}); found {
+		LogFrom(ctx).WithField("cache_key", cacheKey[:8]+"...").Debugf("Cache hit for %s API call", c.label)
+		return cached, nil
+	}
+
+	LogFrom(ctx).WithField("cache_key", cacheKey[:8]+"...").Debugf("Cache miss for %s API call", c.label)
+
+	response, err := c.fetch(ctx, request)
+	if err != nil {
+		return "", err
+	}
+
+	// A truncated response is reported to the caller and never cached, so a cache hit is always complete
+	if response.Truncated {
+		LogFrom(ctx).WithField("cache_key", cacheKey[:8]+"...").
+			Debugf("%s response not cached (truncated at max_tokens)", c.label)
+		return response.Content, nil
+	}
+
+	// Cache the response unless --no-cache-write is set for this run
+	if cachePolicyFrom(ctx).SkipWrite {
+		LogFrom(ctx).WithField("cache_key", cacheKey[:8]+"...").
+			Debugf("%s response not cached (--no-cache-write)", c.label)
+	} else if c.cache.SetWithTTL(cacheKey, response.Content, cachePolicyFrom(ctx).TTL) {
+		LogFrom(ctx).WithField("cache_key", cacheKey[:8]+"...").
+			WithField("response_length", len(response.Content)).
+			Debugf("%s response cached successfully", c.label)
+	} else {
+		LogFrom(ctx).WithField("cache_key", cacheKey[:8]+"...").
+			Debugf("%s response not cached (too large, or existing entry kept by write policy)", c.label)
+	}
+
+	return response.Content, nil
+}
+
+// fetch sends a request to the provider, logs and records its usage, and reports its
+// completion tokens and any truncation through ctx
+func (c *chatClient) fetch(ctx context.Context, request ChatRequest) (ChatResponse, error) {
+	response, err := c.send(ctx, request)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+
+	// Log usage for cost tracking; providers that bill per response also record actual spend
+	returnsCost := GetProviderCapabilities(c.name, request.Model).ReturnsCost
+	entry := LogFrom(ctx).WithField("provider", c.name).
+		WithField("model", request.Model).
+		WithField("prompt_tokens", response.PromptTokens).
+		WithField("completion_tokens", response.CompletionTokens).
+		WithField("total_tokens", response.TotalTokens)
+	if response.Model != "" && response.Model != request.Model {
+		entry = entry.WithField("actual_model", response.Model)
+	}
+	if returnsCost {
+		entry = entry.WithField("total_cost_usd", response.TotalCost)
+	}
+	if response.CacheCreationTokens > 0 || response.CacheReadTokens > 0 {
+		entry = entry.WithField("cache_creation_input_tokens", response.CacheCreationTokens).
+			WithField("cache_read_input_tokens", response.CacheReadTokens)
+	}
+	entry.Infof("%s API call completed", c.label)
+	if returnsCost {
+		RecordSpend(c.name, response.TotalCost)
+	}
+	reportCompletionTokens(ctx, response.CompletionTokens)
+	if response.Truncated {
+		markTruncated(ctx)
+	}
+	return response, nil
+}
+
+// send performs the HTTP round trip for a request through the provider's adapter
+func (c *chatClient) send(ctx context.Context, request ChatRequest) (response ChatResponse, err error) {
+	providerConfig := c.settings
+
+	ctx, span := startSpan(ctx, "POST "+c.name, trace.SpanKindClient)
+	span.SetAttribute("provider", c.name)
+	span.SetAttribute("model", request.Model)
+	defer func() {
+		span.SetAttribute("prompt_tokens", response.PromptTokens)
+		span.SetAttribute("completion_tokens", response.CompletionTokens)
+		if response.TotalCost > 0 {
+			span.SetAttribute("cost_usd", response.TotalCost)
+		}
+		span.RecordError(err)
+		span.End()
+	}()
+
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(ctx, providerConfig.Timeout)
+	defer cancel()
+
+	// Marshal request body
+	jsonBody, err := json.Marshal(c.adapter.buildRequest(request, providerConfig))
+	if err != nil {
+		return ChatResponse{}, fmt.Errorf("failed to marshal %s request body: %w", c.label, err)
+	}
+
+	// Create HTTP request
+	req, err := http.NewRequestWithContext(ctx, "POST", providerConfig.APIURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return ChatResponse{}, fmt.Errorf("failed to create %s request: %w", c.label, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.adapter.setHeaders(req.Header, c.apiKey, providerConfig)
+	injectTraceContext(ctx, req.Header)
+
+	// Send request
+	resp, err := providerHTTPClient(c.name, providerConfig).Do(req)
+	if err != nil {
+		return ChatResponse{}, fmt.Errorf("%s API request failed: %w", c.label, err)
+	}
+	defer resp.Body.Close()
+
+	// Read response body within the configured size limit
+	body, err := readLimitedBody(resp.Body, providerConfig.MaxResponseBytes)
+	if err != nil {
+		return ChatResponse{}, fmt.Errorf("failed to read %s response: %w", c.label, err)
+	}
+
+	span.SetAttribute("http.status_code", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return ChatResponse{}, &APIStatusError{StatusCode: resp.StatusCode, Err: c.adapter.statusError(resp.StatusCode, resp.Status, body)}
+	}
+
+	return c.adapter.parseResponse(body)
+}
+
+// APIStatusError carries the HTTP status of a failed provider call so retry decisions
+// do not depend on the wording of the provider's error message
+type APIStatusError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *APIStatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *APIStatusError) Unwrap() error {
+	return e.Err
+}
+
+// chatStatusError maps the status codes shared by OpenAI-compatible APIs to errors
+func chatStatusError(label string, statusCode int, body []byte) error {
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		LogWithContext().Warnf("%s rate limit exceeded", label)
+		return fmt.Errorf("%s rate limit exceeded, please try again later", label)
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%s authentication failed - check API key", label)
+	case http.StatusBadRequest:
+		return fmt.Errorf("%s bad request: %s", label, redactedBody(body))
+	default:
+		return fmt.Errorf("%s API returned status %d: %s", label, statusCode, redactedBody(body))
+	}
+}
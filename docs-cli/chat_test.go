@@ -0,0 +1,459 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"docs-cli/pkg/config"
+)
+
+// providerServer is an httptest API endpoint that records each request and answers
+// with a fixed status and body
+type providerServer struct {
+	*httptest.Server
+	status int
+	body   string
+
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+type recordedRequest struct {
+	header http.Header
+	body   map[string]interface{}
+}
+
+func newProviderServer(t *testing.T, status int, body string) *providerServer {
+	t.Helper()
+	server := &providerServer{status: status, body: body}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Errorf("request body is not JSON: %v", err)
+		}
+		server.mu.Lock()
+		server.requests = append(server.requests, recordedRequest{header: r.Header.Clone(), body: decoded})
+		server.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(server.status)
+		w.Write([]byte(server.body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Requests returns the requests received so far
+func (s *providerServer) Requests() []recordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]recordedRequest(nil), s.requests...)
+}
+
+// chatCompletion is an OpenAI-compatible response body carrying content
+func chatCompletion(content, finishReason string) string {
+	data, _ := json.Marshal(OpenAIResponse{
+		Model:   "served-model",
+		Choices: []OpenAIChoice{{Message: OpenAIMessage{Role: "assistant", Content: content}, FinishReason: finishReason}},
+		Usage:   OpenAIUsage{PromptTokens: 12, CompletionTokens: 34, TotalTokens: 46},
+	})
+	return string(data)
+}
+
+// newHTTPProviders creates each HTTP provider pointed at url, keyed by provider name
+func newHTTPProviders(url string) map[string]ChatProvider {
+	providers := config.GetConfig().Providers
+	return map[string]ChatProvider{
+		"anthropic":  NewAnthropicProvider("test-key", providers.Anthropic, WithBaseURL(url)),
+		"openai":     NewOpenAIProvider("test-key", providers.OpenAI, WithBaseURL(url)),
+		"openrouter": NewOpenRouterProvider("test-key", providers.OpenRouter, WithBaseURL(url)),
+	}
+}
+
+func TestChatClientRequestShape(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	const anthropicBody = `{"model":"served-model","content":[{"type":"text","text":"generated"}],"usage":{"input_tokens":12,"output_tokens":34}}`
+
+	tests := []struct {
+		provider string
+		response string
+		headers  map[string]string
+		system   string
+	}{
+		{
+			provider: "anthropic",
+			response: anthropicBody,
+			headers:  map[string]string{"X-Api-Key": "test-key", "Anthropic-Version": config.GetConfig().Providers.Anthropic.APIVersion},
+		},
+		{
+			provider: "openai",
+			response: chatCompletion("generated", "stop"),
+			headers:  map[string]string{"Authorization": "Bearer test-key"},
+			system:   openAISystemPrompt,
+		},
+		{
+			provider: "openrouter",
+			response: chatCompletion("generated", "stop"),
+			headers:  map[string]string{"Authorization": "Bearer test-key"},
+			system:   openRouterSystemPrompt,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			server := newProviderServer(t, http.StatusOK, tt.response)
+			provider := newHTTPProviders(server.URL)[tt.provider]
+
+			content, err := provider.CallModel(context.Background(), "document the "+tt.provider+" service", "test-model", 500, 0.3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if content != "generated" {
+				t.Errorf("content = %q, want %q", content, "generated")
+			}
+
+			requests := server.Requests()
+			if len(requests) != 1 {
+				t.Fatalf("server received %d requests, want 1", len(requests))
+			}
+			request := requests[0]
+			if got := request.header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			for name, want := range tt.headers {
+				if got := request.header.Get(name); got != want {
+					t.Errorf("header %s = %q, want %q", name, got, want)
+				}
+			}
+			if request.body["model"] != "test-model" || request.body["max_tokens"] != float64(500) || request.body["temperature"] != 0.3 {
+				t.Errorf("body model, max_tokens, temperature = %v, %v, %v; want test-model, 500, 0.3",
+					request.body["model"], request.body["max_tokens"], request.body["temperature"])
+			}
+
+			messages, _ := request.body["messages"].([]interface{})
+			var roles []string
+			for _, message := range messages {
+				roles = append(roles, message.(map[string]interface{})["role"].(string))
+			}
+			wantRoles := []string{"user"}
+			if tt.system != "" {
+				wantRoles = []string{"system", "user"}
+				if content := messages[0].(map[string]interface{})["content"]; content != tt.system {
+					t.Errorf("system message = %v, want the provider's system prompt", content)
+				}
+			}
+			if strings.Join(roles, ",") != strings.Join(wantRoles, ",") {
+				t.Errorf("message roles = %v, want %v", roles, wantRoles)
+			}
+		})
+	}
+}
+
+func TestChatClientStatusErrors(t *testing.T) {
+	tests := []struct {
+		status    int
+		message   string
+		retryable bool
+	}{
+		{http.StatusTooManyRequests, "OpenRouter rate limit exceeded", true},
+		{http.StatusUnauthorized, "OpenRouter authentication failed", false},
+		{http.StatusBadRequest, "OpenRouter bad request", false},
+		{http.StatusPaymentRequired, "OpenRouter insufficient credits", false},
+		{http.StatusServiceUnavailable, "OpenRouter model unavailable", true},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			newTestProject(t, "components: []\n")
+			server := newProviderServer(t, tt.status, `{"error":{"message":"upstream says no"}}`)
+			provider := newHTTPProviders(server.URL)["openrouter"]
+
+			_, err := provider.CallModel(context.Background(), "prompt", "test-model", 100, 0)
+			var statusErr *APIStatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.status {
+				t.Fatalf("err = %v, want an APIStatusError with status %d", err, tt.status)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("err = %q, want it to contain %q", err, tt.message)
+			}
+			if got := DefaultShouldRetry(err); got != tt.retryable {
+				t.Errorf("DefaultShouldRetry = %v, want %v", got, tt.retryable)
+			}
+			if entries := GetProviderCache("openrouter").GetMetrics().EntryCount; entries != 0 {
+				t.Errorf("cache holds %d entries after a failed call, want 0", entries)
+			}
+		})
+	}
+}
+
+func TestChatClientEmptyChoices(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	for _, provider := range []string{"openai", "openrouter"} {
+		t.Run(provider, func(t *testing.T) {
+			server := newProviderServer(t, http.StatusOK, `{"model":"served-model","choices":[]}`)
+			_, err := newHTTPProviders(server.URL)[provider].CallModel(context.Background(), "prompt", "test-model", 100, 0)
+			if err == nil || !strings.Contains(err.Error(), "returned no choices") {
+				t.Errorf("err = %v, want a no choices error", err)
+			}
+		})
+	}
+}
+
+func TestChatClientCachesResponses(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	server := newProviderServer(t, http.StatusOK, chatCompletion("generated once", "stop"))
+	provider := newHTTPProviders(server.URL)["openai"]
+
+	for i := 0; i < 2; i++ {
+		content, err := provider.CallModel(context.Background(), "cache me", "test-model", 100, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content != "generated once" {
+			t.Errorf("call %d content = %q, want %q", i+1, content, "generated once")
+		}
+	}
+	if requests := len(server.Requests()); requests != 1 {
+		t.Errorf("server received %d requests, want 1 with the repeat served from cache", requests)
+	}
+
+	request := userChatRequest("cache me", "test-model", 100, 0)
+	request.Messages = append([]ChatMessage{{Role: "system", Content: openAISystemPrompt}}, request.Messages...)
+	key := GenerateCacheKey("openai", flattenConversation(request.Messages), "test-model", 100, 0)
+	if cached, found := GetProviderCache("openai").Get(key); !found || cached != "generated once" {
+		t.Errorf("cache entry = %q, %v; want the response under the request's key", cached, found)
+	}
+}
+
+func TestChatClientDoesNotCacheTruncatedResponses(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	server := newProviderServer(t, http.StatusOK, chatCompletion("cut off", "length"))
+	provider := newHTTPProviders(server.URL)["openai"]
+
+	for i := 0; i < 2; i++ {
+		if _, err := provider.CallModel(context.Background(), "long answer", "test-model", 100, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests := len(server.Requests()); requests != 2 {
+		t.Errorf("server received %d requests, want 2 since truncated responses are not cached", requests)
+	}
+}
+
+// wireRequest decodes the request body of any of the chat adapters: Anthropic sends the
+// system prompt as a field and message content as a string or as text blocks
+type wireRequest struct {
+	Model       string  `json:"model"`
+	System      string  `json:"system"`
+	MaxTokens   int     `json:"max_tokens"`
+	Temperature float64 `json:"temperature"`
+	Seed        *int    `json:"seed"`
+	Messages    []struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"messages"`
+}
+
+// chatMessages converts the decoded body back to provider-neutral messages
+func (r wireRequest) chatMessages(t *testing.T) []ChatMessage {
+	t.Helper()
+	var messages []ChatMessage
+	if r.System != "" {
+		messages = append(messages, ChatMessage{Role: "system", Content: r.System})
+	}
+	for _, message := range r.Messages {
+		var text string
+		if err := json.Unmarshal(message.Content, &text); err != nil {
+			var blocks []AnthropicContentBlock
+			if err := json.Unmarshal(message.Content, &blocks); err != nil {
+				t.Fatalf("message content is neither a string nor text blocks: %s", message.Content)
+			}
+			for _, block := range blocks {
+				text += block.Text
+			}
+		}
+		messages = append(messages, ChatMessage{Role: message.Role, Content: text})
+	}
+	return messages
+}
+
+func TestChatAdaptersRoundTrip(t *testing.T) {
+	seed := 7
+	request := ChatRequest{
+		Model: "test-model",
+		Messages: []ChatMessage{
+			{Role: "system", Content: "You write documentation."},
+			{Role: "user", Content: "Source context for svc"},
+			{Role: "assistant", Content: "# svc architecture"},
+			{Role: "user", Content: "Source context for svc\n\nWrite the README.", CacheablePrefix: len("Source context for svc")},
+		},
+		MaxTokens:   1200,
+		Temperature: 0.2,
+		Seed:        &seed,
+	}
+	response := ChatResponse{Content: "# svc", Model: "served-model", PromptTokens: 120, CompletionTokens: 30, TotalTokens: 150, Truncated: true}
+
+	tests := []struct {
+		name     string
+		adapter  chatAdapter
+		settings config.ProviderConfig
+		wantSeed bool
+		// encode renders response in the provider's wire format
+		encode func(ChatResponse) interface{}
+	}{
+		{
+			name:     "openai",
+			adapter:  openAIAdapter{},
+			wantSeed: true,
+			encode: func(r ChatResponse) interface{} {
+				return OpenAIResponse{
+					Model:   r.Model,
+					Choices: []OpenAIChoice{{Message: OpenAIMessage{Role: "assistant", Content: r.Content}, FinishReason: "length"}},
+					Usage:   OpenAIUsage{PromptTokens: r.PromptTokens, CompletionTokens: r.CompletionTokens, TotalTokens: r.TotalTokens},
+				}
+			},
+		},
+		{
+			name:     "openrouter",
+			adapter:  openRouterAdapter{},
+			wantSeed: true,
+			encode: func(r ChatResponse) interface{} {
+				return OpenRouterResponse{
+					Model:   r.Model,
+					Choices: []OpenRouterChoice{{Message: OpenRouterMessage{Role: "assistant", Content: r.Content}, FinishReason: "length"}},
+					Usage:   OpenRouterUsage{PromptTokens: r.PromptTokens, CompletionTokens: r.CompletionTokens, TotalTokens: r.TotalTokens, TotalCost: r.TotalCost},
+				}
+			},
+		},
+		{
+			name:     "anthropic",
+			adapter:  anthropicAdapter{},
+			settings: config.ProviderConfig{PromptCaching: true},
+			encode: func(r ChatResponse) interface{} {
+				return AnthropicResponse{
+					Model:      r.Model,
+					Content:    []AnthropicContent{{Type: "text", Text: r.Content}},
+					StopReason: "max_tokens",
+					Usage:      AnthropicUsage{InputTokens: r.PromptTokens, OutputTokens: r.CompletionTokens},
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.adapter.buildRequest(request, tt.settings))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded wireRequest
+			if err := json.Unmarshal(body, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if decoded.Model != request.Model || decoded.MaxTokens != request.MaxTokens || decoded.Temperature != request.Temperature {
+				t.Errorf("model, max_tokens, temperature = %s, %d, %g; want %s, %d, %g",
+					decoded.Model, decoded.MaxTokens, decoded.Temperature, request.Model, request.MaxTokens, request.Temperature)
+			}
+			if gotSeed := decoded.Seed != nil && *decoded.Seed == seed; gotSeed != tt.wantSeed {
+				t.Errorf("seed = %v, want it sent: %v", decoded.Seed, tt.wantSeed)
+			}
+			got := decoded.chatMessages(t)
+			if len(got) != len(request.Messages) {
+				t.Fatalf("request carries %d messages, want %d: %s", len(got), len(request.Messages), body)
+			}
+			for i, message := range request.Messages {
+				if got[i].Role != message.Role || got[i].Content != message.Content {
+					t.Errorf("message %d = %s %q, want %s %q", i, got[i].Role, got[i].Content, message.Role, message.Content)
+				}
+			}
+
+			wire, err := json.Marshal(tt.encode(response))
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := tt.adapter.parseResponse(wire)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed != response {
+				t.Errorf("parseResponse = %+v, want %+v", parsed, response)
+			}
+		})
+	}
+}
+
+func TestCallPathPreservesOpenAITurnStructure(t *testing.T) {
+	project := newTestProject(t, "components: []\n")
+	useModelConfig(t, project, `default:
+  provider: "openai"
+  model: "gpt-4o"
+  max_tokens: 1000
+  temperature: 0.5
+openai:
+  api_key: "test-key"
+  models:
+    gpt-4o: "gpt-4o"
+`)
+	server := newProviderServer(t, http.StatusOK, chatCompletion("# svc README", "stop"))
+	previous := newModelProvider
+	newModelProvider = func(providerName, apiKey string) ModelProvider {
+		return newHTTPProviders(server.URL)[providerName]
+	}
+	t.Cleanup(func() { newModelProvider = previous })
+
+	conversation := []ChatMessage{
+		{Role: "user", Content: "Here is the source of svc."},
+		{Role: "assistant", Content: "# svc architecture"},
+		{Role: "user", Content: "Write the README for svc."},
+	}
+	content, err := callModelAPIWithConversation(context.Background(), conversation, "README", "service", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "# svc README" {
+		t.Errorf("content = %q, want the provider's answer", content)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("server received %d requests, want 1", len(requests))
+	}
+	// Prior documents stay assistant turns rather than being flattened into one prompt
+	want := append([]ChatMessage{{Role: "system", Content: openAISystemPrompt}}, conversation...)
+	messages, _ := requests[0].body["messages"].([]interface{})
+	if len(messages) != len(want) {
+		t.Fatalf("request carries %d messages, want %d: %v", len(messages), len(want), messages)
+	}
+	for i, raw := range messages {
+		message := raw.(map[string]interface{})
+		if message["role"] != want[i].Role || message["content"] != want[i].Content {
+			t.Errorf("message %d = %v %q, want %s %q", i, message["role"], message["content"], want[i].Role, want[i].Content)
+		}
+	}
+}
+
+func TestChatClientKeepsCallerSystemPrompt(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	server := newProviderServer(t, http.StatusOK, chatCompletion("ok", "stop"))
+	provider := newHTTPProviders(server.URL)["openai"]
+
+	request := ChatRequest{
+		Model: "test-model",
+		Messages: []ChatMessage{
+			{Role: "system", Content: "Answer in French."},
+			{Role: "user", Content: "Describe svc."},
+		},
+		MaxTokens: 100,
+	}
+	if _, err := provider.CallChat(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	messages, _ := server.Requests()[0].body["messages"].([]interface{})
+	if len(messages) != 2 || messages[0].(map[string]interface{})["content"] != "Answer in French." {
+		t.Errorf("messages = %v, want the caller's system prompt alone ahead of the user turn", messages)
+	}
+}
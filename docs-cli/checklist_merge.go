@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChecklistMergeReport summarizes how a generated checklist was merged into an existing one
+type ChecklistMergeReport struct {
+	Preserved []string `json:"preserved"` // tasks whose human-set status was kept
+	Added     []string `json:"added"`     // tasks new in the generated checklist
+	Removed   []string `json:"removed"`   // tasks missing from the generated checklist, kept and flagged
+}
+
+// MergeChecklists merges a newly generated checklist into an existing one. Tasks are
+// matched by name across categories: matched tasks take the generated content but keep
+// the existing status, new tasks are added, and tasks the generation dropped are kept
+// with Removed set rather than deleted.
+func MergeChecklists(existing, generated Checklist) (Checklist, ChecklistMergeReport) {
+	var report ChecklistMergeReport
+
+	existingTasks := make(map[string]Task)
+	for _, category := range existing.Categories {
+		for _, task := range category.Tasks {
+			existingTasks[task.Name] = task
+		}
+	}
+
+	merged := Checklist{ProjectName: generated.ProjectName}
+	if merged.ProjectName == "" {
+		merged.ProjectName = existing.ProjectName
+	}
+
+	generatedNames := make(map[string]bool)
+	categoryIndex := make(map[string]int)
+	for _, category := range generated.Categories {
+		mergedCategory := Category{Name: category.Name}
+		for _, task := range category.Tasks {
+			generatedNames[task.Name] = true
+			if previous, found := existingTasks[task.Name]; found {
+				task.Status = previous.Status
+				report.Preserved = append(report.Preserved, task.Name)
+			} else {
+				report.Added = append(report.Added, task.Name)
+			}
+			mergedCategory.Tasks = append(mergedCategory.Tasks, task)
+		}
+		categoryIndex[category.Name] = len(merged.Categories)
+		merged.Categories = append(merged.Categories, mergedCategory)
+	}
+
+	// Keep dropped tasks in their original category so manual additions survive
+	for _, category := range existing.Categories {
+		for _, task := range category.Tasks {
+			if generatedNames[task.Name] {
+				continue
+			}
+
+			task.Removed = true
+			report.Removed = append(report.Removed, task.Name)
+
+			index, found := categoryIndex[category.Name]
+			if !found {
+				index = len(merged.Categories)
+				categoryIndex[category.Name] = index
+				merged.Categories = append(merged.Categories, Category{Name: category.Name})
+			}
+			merged.Categories[index].Tasks = append(merged.Categories[index].Tasks, task)
+		}
+	}
+
+	return merged, report
+}
+
+// MergeChecklistYAML merges generated CHECKLIST.yaml content into existing content and
+// returns the merged YAML only if it passes checklist validation
+func MergeChecklistYAML(existingYAML, generatedYAML string) (string, ChecklistMergeReport, error) {
+	var existing, generated Checklist
+	if err := yaml.Unmarshal([]byte(existingYAML), &existing); err != nil {
+		return "", ChecklistMergeReport{}, fmt.Errorf("failed to parse existing checklist: %w", err)
+	}
+	if err := yaml.Unmarshal([]byte(generatedYAML), &generated); err != nil {
+		return "", ChecklistMergeReport{}, fmt.Errorf("failed to parse generated checklist: %w", err)
+	}
+
+	merged, report := MergeChecklists(existing, generated)
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return "", report, fmt.Errorf("failed to marshal merged checklist: %w", err)
+	}
+
+	if err := validateChecklistYAML(string(data)); err != nil {
+		return "", report, fmt.Errorf("merged checklist is invalid: %w", err)
+	}
+
+	LogWithContext().WithField("preserved", len(report.Preserved)).
+		WithField("added", len(report.Added)).
+		WithField("removed", len(report.Removed)).
+		Info("Merged checklist")
+
+	return string(data), report, nil
+}
+
+// mergeExistingChecklist merges a generated checklist into the CHECKLIST.yaml at outputPath
+// for --merge; with no existing file the generated checklist is returned unchanged
+func mergeExistingChecklist(outputPath, generated string) (string, error) {
+	existing, err := os.ReadFile(outputPath)
+	if os.IsNotExist(err) {
+		return generated, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read existing checklist: %w", err)
+	}
+
+	merged, report, err := MergeChecklistYAML(string(existing), generated)
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("🔀 Merged %s: %d statuses kept, %d tasks added, %d flagged removed\n",
+		outputPath, len(report.Preserved), len(report.Added), len(report.Removed))
+	return merged, nil
+}
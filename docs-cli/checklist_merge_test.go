@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// checklistTasks indexes a checklist's tasks by name
+func checklistTasks(checklist Checklist) map[string]Task {
+	tasks := make(map[string]Task)
+	for _, category := range checklist.Categories {
+		for _, task := range category.Tasks {
+			tasks[task.Name] = task
+		}
+	}
+	return tasks
+}
+
+func TestMergeChecklists(t *testing.T) {
+	existing := Checklist{
+		ProjectName: "svc",
+		Categories: []Category{
+			{Name: "Testing", Tasks: []Task{
+				{Name: "Add unit tests", Status: "completed", Priority: "high", Description: "old description"},
+				{Name: "Load test", Status: "in_progress", Priority: "low", Description: "added by hand"},
+			}},
+			{Name: "Operations", Tasks: []Task{
+				{Name: "Write runbook", Status: "completed", Priority: "medium", Description: "runbook"},
+			}},
+		},
+	}
+	generated := Checklist{
+		ProjectName: "svc",
+		Categories: []Category{
+			{Name: "Testing", Tasks: []Task{
+				{Name: "Add unit tests", Status: "planned", Priority: "high", Description: "new description"},
+				{Name: "Add fuzz tests", Status: "planned", Priority: "medium", Description: "fuzz the parser"},
+			}},
+		},
+	}
+
+	merged, report := MergeChecklists(existing, generated)
+
+	if !reflect.DeepEqual(report.Preserved, []string{"Add unit tests"}) {
+		t.Errorf("Preserved = %v, want [Add unit tests]", report.Preserved)
+	}
+	if !reflect.DeepEqual(report.Added, []string{"Add fuzz tests"}) {
+		t.Errorf("Added = %v, want [Add fuzz tests]", report.Added)
+	}
+	if !reflect.DeepEqual(report.Removed, []string{"Load test", "Write runbook"}) {
+		t.Errorf("Removed = %v, want [Load test Write runbook]", report.Removed)
+	}
+
+	tasks := checklistTasks(merged)
+	if task := tasks["Add unit tests"]; task.Status != "completed" || task.Description != "new description" || task.Removed {
+		t.Errorf("matched task = %+v, want the existing status with the generated content", task)
+	}
+	if task := tasks["Add fuzz tests"]; task.Status != "planned" || task.Removed {
+		t.Errorf("new task = %+v, want it added as generated", task)
+	}
+	for name, status := range map[string]string{"Load test": "in_progress", "Write runbook": "completed"} {
+		if task, found := tasks[name]; !found || !task.Removed || task.Status != status {
+			t.Errorf("dropped task %q = %+v, %v; want it kept with status %s and removed set", name, task, found, status)
+		}
+	}
+
+	var categories []string
+	for _, category := range merged.Categories {
+		categories = append(categories, category.Name)
+	}
+	if !reflect.DeepEqual(categories, []string{"Testing", "Operations"}) {
+		t.Errorf("categories = %v, want generated categories first, then ones only the existing checklist had", categories)
+	}
+}
+
+func TestMergeChecklistYAMLRejectsInvalidInput(t *testing.T) {
+	valid := "project_name: svc\ncategories:\n  - name: Testing\n    tasks:\n      - name: Add unit tests\n        status: planned\n        priority: high\n        description: tests\n"
+	if _, _, err := MergeChecklistYAML("categories: [", valid); err == nil || !strings.Contains(err.Error(), "existing checklist") {
+		t.Errorf("unparseable existing checklist: err = %v", err)
+	}
+	if _, _, err := MergeChecklistYAML(valid, "categories: ["); err == nil || !strings.Contains(err.Error(), "generated checklist") {
+		t.Errorf("unparseable generated checklist: err = %v", err)
+	}
+}
+
+// writeTrackedChecklist writes a CHECKLIST.yaml for svc whose tasks have been worked on by
+// hand. The mock provider generates one "Replace mock documentation" task.
+func writeTrackedChecklist(t *testing.T, project *testProject) {
+	t.Helper()
+	existing, err := yaml.Marshal(Checklist{
+		ProjectName: "svc",
+		Categories: []Category{{Name: "Documentation", Tasks: []Task{
+			{Name: "Replace mock documentation", Status: "completed", Priority: "medium", Description: "done by hand"},
+			{Name: "Document the API", Status: "in_progress", Priority: "high", Description: "added by hand"},
+		}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	project.WriteFile("svc/docs/CHECKLIST.yaml", string(existing))
+}
+
+// checkTrackedChecklistMerged fails unless svc's CHECKLIST.yaml kept the statuses
+// writeTrackedChecklist gave it
+func checkTrackedChecklistMerged(t *testing.T, project *testProject) {
+	t.Helper()
+	var merged Checklist
+	if err := yaml.Unmarshal([]byte(project.ReadFile("svc/docs/CHECKLIST.yaml")), &merged); err != nil {
+		t.Fatal(err)
+	}
+	tasks := checklistTasks(merged)
+	if task := tasks["Replace mock documentation"]; task.Status != "completed" || task.Removed {
+		t.Errorf("regenerated task = %+v, want its completed status kept", task)
+	}
+	if task := tasks["Document the API"]; task.Status != "in_progress" || !task.Removed {
+		t.Errorf("hand-added task = %+v, want it kept with its status and flagged removed", task)
+	}
+}
+
+func TestRegenerateChecklistWithMerge(t *testing.T) {
+	project, svc := singleServiceProject(t)
+	mergeChecklist = true
+	t.Cleanup(func() { mergeChecklist = false })
+
+	writeTrackedChecklist(t, project)
+	if err := regenerate(t, NewSnapshotManager(), svc, "CHECKLIST"); err != nil {
+		t.Fatal(err)
+	}
+	checkTrackedChecklistMerged(t, project)
+
+	// Without --merge the generated checklist replaces the file
+	mergeChecklist = false
+	if err := regenerate(t, NewSnapshotManager(), svc, "CHECKLIST"); err != nil {
+		t.Fatal(err)
+	}
+	var replaced Checklist
+	if err := yaml.Unmarshal([]byte(project.ReadFile("svc/docs/CHECKLIST.yaml")), &replaced); err != nil {
+		t.Fatal(err)
+	}
+	if tasks := checklistTasks(replaced); len(tasks) != 1 || tasks["Replace mock documentation"].Status != "planned" {
+		t.Errorf("checklist without --merge = %+v, want only the generated task", tasks)
+	}
+}
+
+func TestCommandsMergeChecklist(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"update", func(t *testing.T) { runUpdate(t, context.Background()) }},
+		// create leaves other existing documents alone, but --merge regenerates the checklist
+		{"create", func(t *testing.T) {
+			runCommand(t, context.Background(), createDocumentation, "CHECKLIST", "svc")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project, _ := singleServiceProject(t)
+			mergeChecklist = true
+			t.Cleanup(func() { mergeChecklist = false })
+
+			writeTrackedChecklist(t, project)
+			output := captureStdout(t, func() { tt.run(t) })
+			if !strings.Contains(output, "Updated svc/CHECKLIST") {
+				t.Fatalf("%s did not regenerate the checklist:\n%s", tt.name, output)
+			}
+			checkTrackedChecklistMerged(t, project)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// cliArgsEnv holds the JSON-encoded arguments TestCLIHelperProcess runs docs-cli with
+const cliArgsEnv = "DOCS_CLI_TEST_ARGS"
+
+// TestCLIHelperProcess is not a test: run by runCLI in a child process, it runs docs-cli
+// with the arguments in cliArgsEnv from the working directory, so commands that exit the
+// process can be checked for their exit status
+func TestCLIHelperProcess(t *testing.T) {
+	encoded := os.Getenv(cliArgsEnv)
+	if encoded == "" {
+		t.Skip("helper process for runCLI")
+	}
+	var args []string
+	if err := json.Unmarshal([]byte(encoded), &args); err != nil {
+		os.Stdout.WriteString("error: " + err.Error() + "\n")
+		os.Exit(2)
+	}
+	wd, _ := os.Getwd()
+	projectRoot = filepath.Dir(wd)
+	os.Args = append([]string{"docs-cli"}, args...)
+	main()
+	os.Exit(0)
+}
+
+// runCLI runs docs-cli with args in a child process from project's cli directory, with the
+// offline mock provider, and returns its output and exit code
+func runCLI(t *testing.T, project *testProject, args ...string) (output string, exitCode int) {
+	t.Helper()
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestCLIHelperProcess$")
+	cmd.Dir = project.CLI
+	cmd.Env = append(os.Environ(), cliArgsEnv+"="+string(encoded))
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), 0
+}
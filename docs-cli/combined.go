@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"docs-cli/pkg/scanner"
+)
+
+// combinedOutputPath is the --combined file holding every document of a component
+func combinedOutputPath(component scanner.Component) string {
+	return localizedPath(filepath.Join(component.Dir(), "docs", component.Name+".docs.md"), docLanguage)
+}
+
+// renderCombinedDocument joins the component's documents on disk into one, a "## " section
+// per doc type in chain order, with CHECKLIST.yaml as a fenced yaml block. It returns ""
+// when the component has no documents yet.
+func renderCombinedDocument(component scanner.Component) string {
+	var sections strings.Builder
+	for _, docType := range chainOrder() {
+		content, err := os.ReadFile(docOutputPath(component, docType))
+		if err != nil {
+			continue
+		}
+
+		sections.WriteString(fmt.Sprintf("## %s\n\n", docType))
+		if docType == "CHECKLIST" {
+			sections.WriteString("```yaml\n" + strings.TrimRight(string(content), "\n") + "\n```\n\n")
+		} else {
+			sections.WriteString(strings.TrimRight(string(content), "\n") + "\n\n")
+		}
+	}
+	if sections.Len() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("# %s Documentation\n\n", component.Name) + sections.String()
+}
+
+// writeCombinedDocument refreshes the component's --combined file from its documents. The
+// per-type files stay in place, since context chaining and incremental updates read them.
+func writeCombinedDocument(component scanner.Component) error {
+	content := renderCombinedDocument(component)
+	if content == "" {
+		return nil
+	}
+
+	outputPath := combinedOutputPath(component)
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	err := os.WriteFile(outputPath, []byte(content), 0644)
+	LogFileOperation("write", outputPath, int64(len(content)), err)
+	if err != nil {
+		return fmt.Errorf("failed to write combined documentation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"docs-cli/pkg/config"
+)
+
+// compareDir is where compare writes each model's version of the document, relative to the
+// working directory, so the real document is never touched
+const compareDir = "compare"
+
+// compareModels lists the models compare generates with, as model aliases or provider/model
+var compareModels []string
+
+// pinnedModelKey is the context key for a model that a generation must use as is
+type pinnedModelKey struct{}
+
+// withPinnedModel returns a context whose generation uses model instead of the configured
+// or cost-optimized one; the provider is passed to the generation separately
+func withPinnedModel(ctx context.Context, model string) context.Context {
+	return context.WithValue(ctx, pinnedModelKey{}, model)
+}
+
+// pinnedModelFrom returns the model pinned on ctx, if any
+func pinnedModelFrom(ctx context.Context) (string, bool) {
+	model, ok := ctx.Value(pinnedModelKey{}).(string)
+	return model, ok
+}
+
+// generationStatsKey is the context key for the stats recordGeneration reports into
+type generationStatsKey struct{}
+
+// GenerationStats is what recordGeneration saw for the generation made with a context
+type GenerationStats struct {
+	Provider     string
+	Model        string
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+	Cached       bool
+}
+
+// withGenerationStats returns a context whose generation reports into the returned stats
+func withGenerationStats(ctx context.Context) (context.Context, *GenerationStats) {
+	stats := new(GenerationStats)
+	return context.WithValue(ctx, generationStatsKey{}, stats), stats
+}
+
+// parseCompareModel splits "provider/model" into its parts; anything else is a model alias for
+// the doc type's configured provider. OpenRouter IDs keep their own slash, e.g.
+// openrouter/anthropic/claude-3.5-sonnet.
+func parseCompareModel(spec string) (provider, model string) {
+	if prefix, rest, ok := strings.Cut(spec, "/"); ok && rest != "" {
+		switch prefix {
+		case "anthropic", "openai", "openrouter", "mock":
+			return prefix, rest
+		}
+	}
+	return "", spec
+}
+
+// compareOutputPath is the file one model's version of the document is written to
+func compareOutputPath(spec string) string {
+	name := strings.NewReplacer("/", "_", ":", "_", "\\", "_").Replace(spec)
+	return filepath.Join(compareDir, name+".md")
+}
+
+// compareResult is one row of the comparison table
+type compareResult struct {
+	spec    string
+	stats   GenerationStats
+	latency time.Duration
+	length  int
+	path    string
+	err     error
+}
+
+func compareDocumentModels(cmd *cobra.Command, args []string) {
+	componentName, docType := args[0], strings.ToUpper(args[1])
+	if err := validateDocType(docType); err != nil || docType == "all" {
+		fmt.Printf("❌ Invalid document type: %s\n", args[1])
+		return
+	}
+	if len(compareModels) == 0 {
+		fmt.Println("❌ --models must list at least one model")
+		return
+	}
+
+	configManager := config.NewConfigManager()
+	if _, err := configManager.LoadConfig(); err != nil {
+		fmt.Printf("❌ Configuration error: %v\n", err)
+		return
+	}
+
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		fmt.Printf("❌ Error opening source: %v\n", err)
+		return
+	}
+	component, err := findComponentByName(fileScanner, componentName)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	// Every model answers the same conversation
+	messages, err := BuildConversation(configManager, fileScanner, component, docType)
+	if err != nil {
+		fmt.Printf("❌ Failed to build prompt: %v\n", err)
+		return
+	}
+	if err := os.MkdirAll(compareDir, 0755); err != nil {
+		fmt.Printf("❌ Failed to create %s: %v\n", compareDir, err)
+		return
+	}
+
+	ctx := WithLogFields(cmd.Context(), logrus.Fields{"component": component.Key()})
+	var results []compareResult
+	for _, spec := range compareModels {
+		if ctx.Err() != nil {
+			break
+		}
+		fmt.Printf("🔍 Generating %s/%s with %s\n", component.Key(), docType, spec)
+		results = append(results, compareModel(ctx, messages, docType, component.Type, spec))
+	}
+	printCompareResults(results)
+}
+
+// compareModel generates the document with one model and writes it under compareDir
+func compareModel(ctx context.Context, messages []ChatMessage, docType, componentType, spec string) compareResult {
+	provider, model := parseCompareModel(spec)
+	ctx, stats := withGenerationStats(withPinnedModel(ctx, model))
+
+	start := time.Now()
+	content, err := callModelAPIWithConversation(ctx, messages, docType, componentType, provider)
+	result := compareResult{spec: spec, stats: *stats, latency: time.Since(start), length: len(content), err: err}
+	if err != nil {
+		return result
+	}
+
+	result.path = compareOutputPath(spec)
+	if err := os.WriteFile(result.path, []byte(content), 0644); err != nil {
+		result.err = fmt.Errorf("failed to write %s: %w", result.path, err)
+	}
+	return result
+}
+
+// printCompareResults prints the cost, token, latency, and length table for a comparison
+func printCompareResults(results []compareResult) {
+	fmt.Println("\n📊 Model comparison (estimated cost and tokens)")
+	fmt.Printf("%-36s %10s %10s %10s %10s %8s  %s\n", "MODEL", "COST", "IN TOKENS", "OUT TOKENS", "LATENCY", "LENGTH", "OUTPUT")
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Printf("%-36s ❌ %v\n", result.spec, result.err)
+			continue
+		}
+		output := result.path
+		if result.stats.Cached {
+			output += " (cached)"
+		}
+		fmt.Printf("%-36s %10s %10d %10d %10s %8d  %s\n", result.spec, fmt.Sprintf("$%.4f", result.stats.Cost),
+			result.stats.InputTokens, result.stats.OutputTokens, result.latency.Round(time.Millisecond), result.length, output)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// modelEchoProvider answers with a document naming the model it was asked for, as many
+// output tokens as the model name is long, and fails for the model "broken"
+type modelEchoProvider struct {
+	provider string
+	calls    *[]string
+	mu       *sync.Mutex
+}
+
+func (p modelEchoProvider) CallModel(ctx context.Context, prompt, model string, maxTokens int, temperature float64) (string, error) {
+	return p.CallChat(ctx, ChatRequest{Model: model, Messages: []ChatMessage{{Role: "user", Content: prompt}}, MaxTokens: maxTokens})
+}
+
+func (p modelEchoProvider) CallChat(ctx context.Context, request ChatRequest) (string, error) {
+	p.mu.Lock()
+	*p.calls = append(*p.calls, p.provider+"/"+request.Model)
+	p.mu.Unlock()
+	if request.Model == "broken" {
+		return "", errors.New("model unavailable")
+	}
+	reportCompletionTokens(ctx, len(request.Model))
+	return fmt.Sprintf("# svc\n\nWritten by %s.\n", request.Model), nil
+}
+
+// useModelEchoProviders routes every model call through a modelEchoProvider and returns
+// the provider/model of each call made
+func useModelEchoProviders(t *testing.T) func() []string {
+	t.Helper()
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	previous := newModelProvider
+	newModelProvider = func(providerName, apiKey string) ModelProvider {
+		return modelEchoProvider{provider: providerName, calls: &calls, mu: &mu}
+	}
+	t.Cleanup(func() { newModelProvider = previous })
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), calls...)
+	}
+}
+
+func TestCompareModels(t *testing.T) {
+	project, svc := singleServiceProject(t)
+	project.WriteFile("cli/model-config.yaml", testMockModelConfig+`anthropic:
+  api_key: "test-key"
+  models:
+    sonnett-4: "claude-sonnet-4-20250514"
+`)
+	const realReadme = "# svc\n\nThe real README.\n"
+	project.WriteFile("svc/README.md", realReadme)
+	useFastRetries(t, 1)
+	calls := useModelEchoProviders(t)
+	previousModels := compareModels
+	compareModels = []string{"demo", "anthropic/sonnett-4", "mock/broken"}
+	t.Cleanup(func() { compareModels = previousModels })
+
+	output := captureStdout(t, func() { runCommand(t, context.Background(), compareDocumentModels, "svc", "readme") })
+
+	// Each model is called with its own provider and the pinned model, untouched by cost
+	// optimization; the failing one may be retried
+	got := calls()
+	if len(got) < 3 || got[0] != "mock/demo" || got[1] != "anthropic/claude-sonnet-4-20250514" {
+		t.Errorf("calls = %v, want mock/demo, anthropic/claude-sonnet-4-20250514, then mock/broken", got)
+	}
+	for _, call := range got[min(len(got), 2):] {
+		if call != "mock/broken" {
+			t.Errorf("calls = %v, want only mock/broken after the first two", got)
+		}
+	}
+
+	for spec, model := range map[string]string{"demo": "demo", "anthropic/sonnett-4": "claude-sonnet-4-20250514"} {
+		path := compareOutputPath(spec)
+		content, err := os.ReadFile(filepath.Join(project.CLI, path))
+		if err != nil {
+			t.Fatalf("%s was not written: %v", path, err)
+		}
+		if !strings.Contains(string(content), "Written by "+model+".") {
+			t.Errorf("%s = %q, want %s's version", path, content, model)
+		}
+
+		row := rowFor(output, spec)
+		length := fmt.Sprintf(" %d ", len(content))
+		outTokens := fmt.Sprintf(" %d ", len(model))
+		if row == "" || !strings.Contains(row, "$") || !strings.Contains(row, length) || !strings.Contains(row, outTokens) || !strings.HasSuffix(row, path) {
+			t.Errorf("table row for %s = %q, want its cost, %d output tokens, length %d, and output path", spec, row, len(model), len(content))
+		}
+	}
+	if row := rowFor(output, "mock/broken"); !strings.Contains(row, "❌") || !strings.Contains(row, "model unavailable") {
+		t.Errorf("table row for the failing model = %q, want its error", row)
+	}
+	if _, err := os.Stat(filepath.Join(project.CLI, compareOutputPath("mock/broken"))); !os.IsNotExist(err) {
+		t.Errorf("the failing model left an output file: %v", err)
+	}
+
+	if got := project.ReadFile("svc/README.md"); got != realReadme {
+		t.Errorf("compare overwrote the real README: %q", got)
+	}
+	if _, ok := NewSnapshotManager().snapshots[svc.Key()]; ok {
+		t.Error("compare recorded a snapshot for the component")
+	}
+}
+
+// rowFor returns the line of the comparison table for spec, or "" if there is none
+func rowFor(output, spec string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, spec+" ") {
+			return line
+		}
+	}
+	return ""
+}
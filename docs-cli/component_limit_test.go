@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"docs-cli/pkg/config"
+)
+
+// useComponentLimitFlags sets --max-components, as if given when set is true, and --confirm
+// for the rest of the test
+func useComponentLimitFlags(t *testing.T, limit int, set, confirm bool) {
+	t.Helper()
+	previousLimit, previousSet, previousConfirm := maxComponents, maxComponentsSet, confirmRun
+	maxComponents, maxComponentsSet, confirmRun = limit, set, confirm
+	t.Cleanup(func() { maxComponents, maxComponentsSet, confirmRun = previousLimit, previousSet, previousConfirm })
+}
+
+func TestCheckComponentLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured int
+		flag       int
+		flagSet    bool
+		confirm    bool
+		count      int
+		wantErr    string
+	}{
+		{name: "unset config defaults to 100", configured: 0, count: 100},
+		{name: "unset config refuses 101", configured: 0, count: 101, wantErr: "101 components selected, more than the limit of 100"},
+		{name: "configured limit", configured: 5, count: 6, wantErr: "limit of 5"},
+		{name: "-1 disables the guard", configured: -1, count: 5000},
+		{name: "flag overrides config", configured: 5, flag: 10, flagSet: true, count: 8},
+		{name: "flag -1 disables the guard", configured: 5, flag: -1, flagSet: true, count: 5000},
+		{name: "confirm allows the run", configured: 5, confirm: true, count: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useEnterpriseConfig(t, func(c *config.EnterpriseConfig) { c.CostOpt.MaxComponents = tt.configured })
+			useComponentLimitFlags(t, tt.flag, tt.flagSet, tt.confirm)
+
+			err := checkComponentLimit(tt.count)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkComponentLimit(%d) = %v", tt.count, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "--confirm") {
+				t.Errorf("checkComponentLimit(%d) = %v, want %q and a --confirm hint", tt.count, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateComponentLimit(t *testing.T) {
+	project := newTestProject(t, `components:
+  - name: "api"
+    path: "api"
+    type: "service"
+  - name: "web"
+    path: "web"
+    type: "frontend"
+  - name: "worker"
+    path: "worker"
+    type: "service"
+`)
+	for _, name := range []string{"api", "web", "worker"} {
+		project.WriteFile(name+"/main.go", "package main\n\nfunc main() {}\n")
+	}
+	readmes := func() int {
+		written := 0
+		for _, name := range []string{"api", "web", "worker"} {
+			if _, err := os.Stat(filepath.Join(project.Root, name, "README.md")); err == nil {
+				written++
+			}
+		}
+		return written
+	}
+
+	for _, args := range [][]string{{"--max-components", "0"}, {"--max-components", "-2"}} {
+		output, code := runCLI(t, project, append([]string{"update"}, args...)...)
+		if code == 0 || !strings.Contains(output, "or -1 to disable the guard") {
+			t.Errorf("update %v exited %d, want it rejected:\n%s", args, code, output)
+		}
+	}
+
+	output, code := runCLI(t, project, "update", "--max-components", "2")
+	if code == 0 || !strings.Contains(output, "3 components selected, more than the limit of 2") {
+		t.Fatalf("update over the limit exited %d, want non-zero:\n%s", code, output)
+	}
+	if written := readmes(); written != 0 {
+		t.Fatalf("update over the limit wrote %d READMEs, want none", written)
+	}
+
+	output, code = runCLI(t, project, "update", "--max-components", "2", "--confirm")
+	if code != 0 {
+		t.Fatalf("update with --confirm exited %d:\n%s", code, output)
+	}
+	if written := readmes(); written != 3 {
+		t.Errorf("update with --confirm wrote %d READMEs, want 3:\n%s", written, output)
+	}
+}
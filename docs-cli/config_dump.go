@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"docs-cli/pkg/config"
+)
+
+func dumpEffectiveConfig(cmd *cobra.Command, args []string) {
+	source := "enterprise-config.yaml"
+	if _, err := config.LoadEnterpriseConfig(); err != nil {
+		source = fmt.Sprintf("built-in defaults (%v)", err)
+	}
+	if err := printConfigYAML("enterprise config", source, config.GetConfig()); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	modelConfig, err := loadModelConfig()
+	if err != nil {
+		fmt.Printf("\n# model config: %v\n", err)
+		return
+	}
+	source = "model-config.yaml"
+	if modelConfig.builtIn {
+		source = "built-in defaults (model-config.yaml not found)"
+	}
+	if err := printConfigYAML("model config", source, redactedModelConfig(*modelConfig)); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// printConfigYAML prints a resolved config as a YAML document with secrets scrubbed
+func printConfigYAML(name, source string, value interface{}) error {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	fmt.Printf("---\n# %s, from %s\n%s", name, source, RedactSecrets(string(data)))
+	return nil
+}
+
+// redactedModelConfig returns a copy of the model config with API keys masked; an unset
+// key stays empty so it is visible in the dump
+func redactedModelConfig(modelConfig ModelConfig) ModelConfig {
+	for _, provider := range []*ProviderConfig{&modelConfig.OpenAI, &modelConfig.Anthropic, &modelConfig.OpenRouter} {
+		if provider.APIKey != "" {
+			provider.APIKey = redactedPlaceholder
+		}
+	}
+	return modelConfig
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"docs-cli/pkg/config"
+)
+
+func TestConfigDumpPrintsTheMergedConfig(t *testing.T) {
+	project := newTestProject(t, "components: []\n")
+	t.Setenv("DOCS_CLI_TEST_ANTHROPIC_KEY", fakeAnthropicKey)
+	t.Setenv("DOCS_CLI_TEST_REFERER", "https://docs.example.com")
+
+	// The enterprise config is the file's values with its environment references expanded
+	want := *config.GetConfig()
+	want.Providers.OpenRouter.APIURL = "https://openrouter.example.com/v1"
+	want.Providers.OpenRouter.Headers = map[string]string{"http_referer": "https://docs.example.com", "x_title": "docs-cli"}
+	useEnterpriseConfig(t, func(c *config.EnterpriseConfig) {
+		c.Providers.OpenRouter.APIURL = "${DOCS_CLI_TEST_UNSET_URL:-https://openrouter.example.com/v1}"
+		c.Providers.OpenRouter.Headers = map[string]string{"http_referer": "${DOCS_CLI_TEST_REFERER}", "x_title": "docs-cli"}
+	})
+
+	useModelConfig(t, project, `default:
+  provider: "anthropic"
+  model: "sonnet-4"
+  max_tokens: 4000
+  temperature: 0.7
+anthropic:
+  api_key: "${DOCS_CLI_TEST_ANTHROPIC_KEY}"
+  models:
+    sonnet-4: "claude-sonnet-4-20250514"
+openai:
+  api_key: ""
+  context_overflow_model: "gpt-4.1"
+document_types:
+  README:
+    provider: "openai"
+    model: "gpt-4o"
+    max_tokens: 2000
+    temperature: 0.3
+`)
+	// Keys are masked, and an unset key stays empty so it shows as missing
+	wantModels := ModelConfig{
+		Default:   ModelSettings{Provider: "anthropic", Model: "sonnet-4", MaxTokens: 4000, Temperature: 0.7},
+		Anthropic: ProviderConfig{APIKey: redactedPlaceholder, Models: map[string]string{"sonnet-4": "claude-sonnet-4-20250514"}},
+		OpenAI:    ProviderConfig{ContextOverflowModel: "gpt-4.1"},
+		DocumentTypes: map[string]ModelSettings{
+			"README": {Provider: "openai", Model: "gpt-4o", MaxTokens: 2000, Temperature: 0.3},
+		},
+	}
+
+	output := captureStdout(t, func() { dumpEffectiveConfig(nil, nil) })
+	if strings.Contains(output, fakeAnthropicKey) {
+		t.Fatalf("config dump leaks the API key:\n%s", output)
+	}
+	for _, header := range []string{"# enterprise config, from enterprise-config.yaml\n", "# model config, from model-config.yaml\n"} {
+		if !strings.Contains(output, header) {
+			t.Errorf("config dump is missing %q", header)
+		}
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(output))
+	var gotEnterprise config.EnterpriseConfig
+	var gotModels ModelConfig
+	if err := decoder.Decode(&gotEnterprise); err != nil {
+		t.Fatalf("decoding the enterprise config document: %v", err)
+	}
+	if err := decoder.Decode(&gotModels); err != nil {
+		t.Fatalf("decoding the model config document: %v", err)
+	}
+	assertSameYAML(t, "enterprise config", &gotEnterprise, &want)
+	assertSameYAML(t, "model config", &gotModels, &wantModels)
+}
+
+func TestConfigDumpWithoutModelConfig(t *testing.T) {
+	project := newTestProject(t, "components: []\n")
+	if err := os.Remove(filepath.Join(project.CLI, "model-config.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	modelConfig.Store(nil)
+	t.Setenv("ANTHROPIC_API_KEY", fakeAnthropicKey)
+	t.Setenv("OPENAI_API_KEY", "")
+
+	output := captureStdout(t, func() { dumpEffectiveConfig(nil, nil) })
+	if !strings.Contains(output, "# model config, from built-in defaults (model-config.yaml not found)\n") {
+		t.Errorf("config dump does not say the model config is built in:\n%s", output)
+	}
+	if strings.Contains(output, fakeAnthropicKey) {
+		t.Errorf("config dump leaks the API key:\n%s", output)
+	}
+}
+
+// assertSameYAML compares got and want by their YAML encodings, where a nil map and an
+// empty one are the same
+func assertSameYAML(t *testing.T, name string, got, want interface{}) {
+	t.Helper()
+	gotYAML, err := yaml.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantYAML, err := yaml.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotYAML, wantYAML) {
+		t.Errorf("%s dump =\n%s\nwant\n%s", name, gotYAML, wantYAML)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"docs-cli/pkg/config"
+)
+
+// reloadOnSIGHUP re-reads the configuration whenever the process receives SIGHUP, for
+// long-running commands such as watch. A failed reload keeps the previous configuration.
+func reloadOnSIGHUP() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	onShutdown(func() { signal.Stop(signals) })
+
+	go func() {
+		for range signals {
+			if err := reloadConfig(); err != nil {
+				LogFrom(runContext()).WithError(err).Error("Configuration reload failed, keeping the previous configuration")
+				fmt.Printf("❌ Configuration reload failed, keeping the previous configuration: %v\n", err)
+				continue
+			}
+			LogFrom(runContext()).Info("Configuration reloaded")
+			fmt.Println("✅ Configuration reloaded")
+		}
+	}()
+}
+
+// reloadConfig validates enterprise-config.yaml and model-config.yaml and swaps both in, then
+// rebuilds the circuit breakers and HTTP clients derived from them. Both files are read and
+// validated before either is swapped, so a bad file leaves the running configuration untouched.
+func reloadConfig() error {
+	previous := config.GetConfig()
+	models, err := readModelConfig()
+	if err != nil {
+		return err
+	}
+	if err := models.validate(); err != nil {
+		return err
+	}
+	reloaded, err := config.ReloadEnterpriseConfig()
+	if err != nil {
+		return err
+	}
+	modelConfig.Store(models)
+
+	if previous.Application.Resilience.CircuitBreaker != reloaded.Application.Resilience.CircuitBreaker {
+		rebuildCircuitBreakers()
+	}
+	resetHTTPClients()
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"gopkg.in/yaml.v3"
+
+	"docs-cli/pkg/config"
+)
+
+// writeEnterpriseConfig rewrites the project's enterprise-config.yaml as the loaded config
+// with edit applied, without loading it
+func writeEnterpriseConfig(t *testing.T, project *testProject, edit func(*config.EnterpriseConfig)) {
+	t.Helper()
+	edited := *config.GetConfig()
+	edit(&edited)
+	data, err := yaml.Marshal(&edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project.WriteFile("cli/enterprise-config.yaml", string(data))
+}
+
+// breakerFor returns the current circuit breaker for provider
+func breakerFor(name string) *gobreaker.CircuitBreaker {
+	breakersMutex.Lock()
+	defer breakersMutex.Unlock()
+	return breakers[name]
+}
+
+func TestReloadConfig(t *testing.T) {
+	const reloadedModels = `default:
+  provider: "mock"
+  model: "reloaded"
+  max_tokens: 2000
+  temperature: 0.2
+`
+	tests := []struct {
+		name        string
+		models      string
+		edit        func(*config.EnterpriseConfig)
+		wantErr     string
+		wantApplied bool
+	}{
+		{
+			name:   "valid",
+			models: reloadedModels,
+			edit: func(c *config.EnterpriseConfig) {
+				c.Application.Cache.MaxEntries = 1234
+				c.Application.Resilience.CircuitBreaker.FailureThreshold++
+			},
+			wantApplied: true,
+		},
+		{
+			name:    "invalid enterprise config",
+			models:  reloadedModels,
+			edit:    func(c *config.EnterpriseConfig) { c.Providers.Mock.ErrorRate = 2 },
+			wantErr: "providers.mock.error_rate must be between 0 and 1",
+		},
+		{
+			name:    "invalid model config",
+			models:  "default:\n  provider: \"acme\"\n  model: \"x\"\n",
+			edit:    func(c *config.EnterpriseConfig) { c.Application.Cache.MaxEntries = 1234 },
+			wantErr: `unsupported provider "acme"`,
+		},
+		{
+			name:    "unparseable model config",
+			models:  "default: [\n",
+			edit:    func(c *config.EnterpriseConfig) { c.Application.Cache.MaxEntries = 1234 },
+			wantErr: "model-config.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := newTestProject(t, "components: []\n")
+			before := config.GetConfig()
+			beforeModels, err := loadModelConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			beforeBreaker := breakerFor("anthropic")
+			beforeClient := providerHTTPClient("anthropic", before.Providers.Anthropic)
+			t.Cleanup(resetHTTPClients)
+
+			writeEnterpriseConfig(t, project, tt.edit)
+			project.WriteFile("cli/model-config.yaml", tt.models)
+			err = reloadConfig()
+
+			afterModels, loadErr := loadModelConfig()
+			if loadErr != nil {
+				t.Fatal(loadErr)
+			}
+			if !tt.wantApplied {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("reloadConfig() = %v, want an error containing %q", err, tt.wantErr)
+				}
+				// Neither file is swapped in, even the valid one
+				if config.GetConfig() != before {
+					t.Error("a failed reload replaced the enterprise config")
+				}
+				if afterModels != beforeModels {
+					t.Error("a failed reload replaced the model config")
+				}
+				if breakerFor("anthropic") != beforeBreaker {
+					t.Error("a failed reload rebuilt the circuit breakers")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := config.GetConfig().Application.Cache.MaxEntries; got != 1234 {
+				t.Errorf("cache.max_entries = %d after reload, want 1234", got)
+			}
+			if afterModels.Default.Model != "reloaded" {
+				t.Errorf("default model = %q after reload, want reloaded", afterModels.Default.Model)
+			}
+			if breakerFor("anthropic") == beforeBreaker {
+				t.Error("circuit breakers were not rebuilt for the new settings")
+			}
+			if providerHTTPClient("anthropic", config.GetConfig().Providers.Anthropic) == beforeClient {
+				t.Error("HTTP clients were not rebuilt")
+			}
+		})
+	}
+}
+
+func TestSIGHUPReloadsConfig(t *testing.T) {
+	project := newTestProject(t, "components: []\n")
+	shutdownMutex.Lock()
+	registered := len(shutdownHooks)
+	shutdownMutex.Unlock()
+
+	output := captureStdout(t, func() {
+		reloadOnSIGHUP()
+		hangUp := func() {
+			t.Helper()
+			process, err := os.FindProcess(os.Getpid())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := process.Signal(syscall.SIGHUP); err != nil {
+				t.Skipf("cannot signal this process: %v", err)
+			}
+		}
+
+		// An invalid file is reported and the running config kept
+		before := config.GetConfig()
+		writeEnterpriseConfig(t, project, func(c *config.EnterpriseConfig) { c.Providers.Mock.ErrorRate = 2 })
+		hangUp()
+		time.Sleep(100 * time.Millisecond)
+		if config.GetConfig() != before {
+			t.Error("SIGHUP swapped in an invalid config")
+		}
+
+		writeEnterpriseConfig(t, project, func(c *config.EnterpriseConfig) { c.Application.Cache.MaxEntries = 4321 })
+		hangUp()
+		deadline := time.Now().Add(5 * time.Second)
+		for config.GetConfig().Application.Cache.MaxEntries != 4321 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := config.GetConfig().Application.Cache.MaxEntries; got != 4321 {
+			t.Errorf("cache.max_entries = %d after SIGHUP, want 4321", got)
+		}
+
+		shutdownMutex.Lock()
+		hooks := append([]func(){}, shutdownHooks[registered:]...)
+		shutdownHooks = shutdownHooks[:registered]
+		shutdownMutex.Unlock()
+		for _, hook := range hooks {
+			hook()
+		}
+	})
+
+	if !strings.Contains(output, "❌ Configuration reload failed, keeping the previous configuration") {
+		t.Errorf("invalid reload not reported:\n%s", output)
+	}
+	if !strings.Contains(output, "✅ Configuration reloaded") {
+		t.Errorf("valid reload not reported:\n%s", output)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/sha256"
+	"strings"
+)
+
+// minDedupeParagraphLength keeps short paragraphs such as headings and list labels,
+// which repeat legitimately and carry a document's structure
+const minDedupeParagraphLength = 40
+
+// dedupeParagraphs removes from each document the paragraphs already present in an
+// earlier one, so chained context only carries novel content forward. Paragraphs are
+// compared by a hash of their whitespace-normalized text.
+func dedupeParagraphs(documents []string) []string {
+	seen := make(map[[sha256.Size]byte]bool)
+	deduped := make([]string, len(documents))
+
+	for i, document := range documents {
+		var kept []string
+		for _, paragraph := range splitParagraphs(document) {
+			normalized := strings.Join(strings.Fields(paragraph), " ")
+			if len(normalized) < minDedupeParagraphLength {
+				kept = append(kept, paragraph)
+				continue
+			}
+
+			hash := sha256.Sum256([]byte(normalized))
+			if seen[hash] {
+				continue
+			}
+			seen[hash] = true
+			kept = append(kept, paragraph)
+		}
+		deduped[i] = strings.Join(kept, "\n\n")
+	}
+
+	return deduped
+}
+
+// splitParagraphs splits a document on blank lines, dropping empty paragraphs
+func splitParagraphs(document string) []string {
+	var paragraphs []string
+	var current []string
+
+	flush := func() {
+		if len(current) > 0 {
+			paragraphs = append(paragraphs, strings.Join(current, "\n"))
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(document, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+
+	return paragraphs
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDedupeParagraphsOverlappingDocuments(t *testing.T) {
+	const (
+		overview  = "The billing service computes invoices from metered usage every night."
+		storage   = "Invoices are stored in Postgres and exported to S3 for the finance team."
+		readmeNew = "Run make dev to start the service with a local database and fake payment provider."
+		archNew   = "A scheduler enqueues one job per customer; workers pull jobs and write invoices."
+		setupNew  = "Copy .env.example to .env and set STRIPE_KEY before the first run."
+	)
+	readme := "# Billing\n\n" + overview + "\n\n" + storage + "\n\n" + readmeNew
+	// ARCHITECTURE repeats the overview reflowed over two lines with extra spacing
+	architecture := "# Architecture\n\nThe billing  service computes invoices\nfrom metered usage every night.\n\n" + archNew + "\n\n" + storage
+	setup := "# Setup\n\r\n" + setupNew + "\r\n\r\n" + overview
+
+	got := dedupeParagraphs([]string{readme, architecture, setup})
+	want := []string{
+		readme,
+		"# Architecture\n\n" + archNew,
+		"# Setup\n\n" + setupNew,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dedupeParagraphs:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestDedupeParagraphsKeepsShortAndRepeatedWithinDocument(t *testing.T) {
+	const long = "Every paragraph this long is checked for repeats across the chained documents."
+	documents := []string{
+		"## Overview\n\n" + long + "\n\n" + long,
+		"## Overview\n\n- yes\n\n" + long,
+	}
+	got := dedupeParagraphs(documents)
+	want := []string{
+		// A repeat inside the first document is dropped too, once it has been seen
+		"## Overview\n\n" + long,
+		"## Overview\n\n- yes",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dedupeParagraphs:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestSplitParagraphs(t *testing.T) {
+	got := splitParagraphs("\n\nfirst line\nsecond line\n \n\t\nthird\r\n\r\nfourth\n")
+	want := []string{"first line\nsecond line", "third", "fourth"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitParagraphs = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"docs-cli/pkg/scanner"
+)
+
+// errContextLength marks a prompt that exceeded the model's context window even after
+// any context_overflow_model fallback; callers can trim the prompt and try once more
+var errContextLength = errors.New("prompt exceeds the model's context window")
+
+// contextLengthMarkers are the phrases providers use when rejecting an oversized prompt
+var contextLengthMarkers = []string{
+	"context_length_exceeded",
+	"context length",
+	"context window",
+	"maximum context",
+	"prompt is too long",
+	"input is too long",
+	"too many tokens",
+}
+
+// isContextLengthError reports whether a provider rejected the call because the prompt
+// was too long. Retrying the same model cannot succeed, so these are not retried.
+func isContextLengthError(err error) bool {
+	var statusErr *APIStatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+
+	switch statusErr.StatusCode {
+	case http.StatusRequestEntityTooLarge:
+		return true
+	case http.StatusBadRequest:
+		message := strings.ToLower(statusErr.Error())
+		for _, marker := range contextLengthMarkers {
+			if strings.Contains(message, marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dropLowPriorityFiles keeps the higher-priority half of the files the component would send
+// as source context, returning false when there is nothing left to drop
+func dropLowPriorityFiles(fileScanner scanner.FileScanner, component scanner.Component) (scanner.Component, bool) {
+	files := prioritizedSourceFiles(fileScanner, component)
+	if len(files) <= 1 {
+		return component, false
+	}
+
+	trimmed := component
+	trimmed.Files = files[:len(files)/2]
+	return trimmed, true
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestIsContextLengthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"413", &APIStatusError{StatusCode: http.StatusRequestEntityTooLarge, Err: errors.New("request too large")}, true},
+		{"openai 400", &APIStatusError{StatusCode: http.StatusBadRequest, Err: errors.New(`{"code":"context_length_exceeded"}`)}, true},
+		{"anthropic 400", &APIStatusError{StatusCode: http.StatusBadRequest, Err: errors.New("prompt is too long: 210000 tokens > 200000 maximum")}, true},
+		{"wrapped", fmt.Errorf("call: %w", &APIStatusError{StatusCode: http.StatusBadRequest, Err: errors.New("This model's maximum context length is 8192 tokens")}), true},
+		{"other 400", &APIStatusError{StatusCode: http.StatusBadRequest, Err: errors.New("invalid temperature")}, false},
+		{"marker on a retryable status", &APIStatusError{StatusCode: http.StatusServiceUnavailable, Err: errors.New("context window busy")}, false},
+		{"no status", errors.New("context_length_exceeded"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isContextLengthError(tt.err); got != tt.want {
+				t.Errorf("isContextLengthError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// overflowProvider rejects with a context-length error any call to a model other than
+// roomyModel whose conversation contains oversized, and records each call's model
+type overflowProvider struct {
+	roomyModel string
+	oversized  string
+
+	mu       sync.Mutex
+	models   []string
+	contents []string
+}
+
+func (p *overflowProvider) CallModel(ctx context.Context, prompt, model string, maxTokens int, temperature float64) (string, error) {
+	return p.CallChat(ctx, userChatRequest(prompt, model, maxTokens, temperature))
+}
+
+func (p *overflowProvider) CallChat(ctx context.Context, request ChatRequest) (string, error) {
+	conversation := flattenConversation(request.Messages)
+	p.mu.Lock()
+	p.models = append(p.models, request.Model)
+	p.contents = append(p.contents, conversation)
+	p.mu.Unlock()
+	if request.Model != p.roomyModel && strings.Contains(conversation, p.oversized) {
+		return "", &APIStatusError{StatusCode: http.StatusBadRequest, Err: errors.New("context_length_exceeded: reduce the length of the messages")}
+	}
+	return "# svc\n\nGenerated with model " + request.Model + ".\n", nil
+}
+
+// useOverflowProvider routes model calls to an overflowProvider and makes any retry
+// of a rejected call visible in its call count
+func useOverflowProvider(t *testing.T, roomyModel, oversized string) *overflowProvider {
+	t.Helper()
+	useFastRetries(t, 2)
+	useCacheFlags(t, true, true)
+	provider := &overflowProvider{roomyModel: roomyModel, oversized: oversized}
+	previous := newModelProvider
+	newModelProvider = func(providerName, apiKey string) ModelProvider { return provider }
+	t.Cleanup(func() { newModelProvider = previous })
+	return provider
+}
+
+func TestContextLengthFallsBackToOverflowModel(t *testing.T) {
+	project := newTestProject(t, "components: []\n")
+	useModelConfig(t, project, testMockModelConfig+`mock:
+  models:
+    demo: "demo-8k"
+    roomy: "demo-200k"
+  context_overflow_model: "roomy"
+`)
+	provider := useOverflowProvider(t, "demo-200k", "Document")
+
+	content, err := generateReadme("Document the billing service.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"demo-8k", "demo-200k"}; strings.Join(provider.models, ",") != strings.Join(want, ",") {
+		t.Errorf("models called = %v, want %v: one rejected call, not retried, then the overflow model", provider.models, want)
+	}
+	if !strings.Contains(content, "demo-200k") {
+		t.Errorf("content = %q, want the overflow model's answer", content)
+	}
+}
+
+func TestContextLengthWithoutOverflowModel(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	provider := useOverflowProvider(t, "", "Document")
+
+	_, err := generateReadme("Document the billing service.")
+	if !errors.Is(err, errContextLength) {
+		t.Fatalf("err = %v, want errContextLength", err)
+	}
+	if len(provider.models) != 1 {
+		t.Errorf("provider called %d times, want 1 since context-length errors are not retried", len(provider.models))
+	}
+}
+
+func TestContextLengthRetriesWithHigherPriorityFiles(t *testing.T) {
+	const lowPriority = "LOW_PRIORITY_CONTEXT"
+	for _, tt := range []struct {
+		name      string
+		oversized string
+		wantErr   bool
+	}{
+		{name: "trimmed prompt fits", oversized: lowPriority},
+		{name: "trimmed prompt still too long", oversized: "HIGH_PRIORITY_CONTEXT", wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			project, _ := singleServiceProject(t)
+			project.WriteFile("svc/jobs.go", "package main\n\n// HIGH_PRIORITY_CONTEXT\nfunc jobs() {}\n")
+			project.WriteFile("svc/NOTES.md", "# Notes\n\n"+lowPriority+"\n")
+			project.WriteFile("svc/fixtures.json", `{"note": "`+lowPriority+`"}`)
+			svc := project.Component("svc")
+			provider := useOverflowProvider(t, "", tt.oversized)
+
+			err := regenerate(t, NewSnapshotManager(), svc, "README")
+			if tt.wantErr {
+				if !errors.Is(err, errContextLength) {
+					t.Fatalf("err = %v, want errContextLength", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(provider.contents) != 2 {
+				t.Fatalf("provider called %d times, want the full prompt then one trimmed retry", len(provider.contents))
+			}
+			full, trimmed := provider.contents[0], provider.contents[1]
+			if !strings.Contains(full, lowPriority) {
+				t.Error("first prompt is missing the low-priority files")
+			}
+			if strings.Contains(trimmed, lowPriority) {
+				t.Error("retry still sends the low-priority files")
+			}
+			if !strings.Contains(trimmed, "HIGH_PRIORITY_CONTEXT") {
+				t.Error("retry dropped the high-priority source file")
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// costExportFormat is the cost-export output format; only csv is supported
+	costExportFormat string
+	// costExportOut is the cost-export output file, or "-" for stdout
+	costExportOut string
+)
+
+// costExportHeader is the CSV column order
+var costExportHeader = []string{"timestamp", "component", "doc_type", "provider", "model", "input_tokens", "output_tokens", "cost"}
+
+func exportCosts(cmd *cobra.Command, args []string) {
+	if costExportFormat != "csv" {
+		fmt.Printf("❌ Unsupported --format %q (supported: csv)\n", costExportFormat)
+		return
+	}
+
+	records, err := readUsageRecords(usageLogPath())
+	if os.IsNotExist(err) {
+		fmt.Println("📊 No usage recorded yet; API calls are logged to .docs-cli-usage.jsonl as documents are generated")
+		return
+	}
+	if err != nil {
+		fmt.Printf("❌ Failed to read usage log: %v\n", err)
+		return
+	}
+
+	out := io.Writer(os.Stdout)
+	if costExportOut != "-" {
+		file, err := os.Create(costExportOut)
+		if err != nil {
+			fmt.Printf("❌ Failed to create %s: %v\n", costExportOut, err)
+			return
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := writeUsageCSV(out, records); err != nil {
+		fmt.Printf("❌ Failed to write CSV: %v\n", err)
+		return
+	}
+	if costExportOut != "-" {
+		fmt.Printf("✅ Exported %d API calls to %s\n", len(records), costExportOut)
+	}
+}
+
+// writeUsageCSV writes one row per API call followed by a TOTAL row
+func writeUsageCSV(w io.Writer, records []UsageRecord) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(costExportHeader); err != nil {
+		return err
+	}
+
+	var inputTokens, outputTokens int
+	var cost float64
+	for _, record := range records {
+		row := []string{
+			record.Timestamp.Format(time.RFC3339),
+			record.Component,
+			record.DocType,
+			record.Provider,
+			record.Model,
+			strconv.Itoa(record.InputTokens),
+			strconv.Itoa(record.OutputTokens),
+			strconv.FormatFloat(record.Cost, 'f', 6, 64),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+		inputTokens += record.InputTokens
+		outputTokens += record.OutputTokens
+		cost += record.Cost
+	}
+
+	total := []string{"TOTAL", "", "", "", "", strconv.Itoa(inputTokens), strconv.Itoa(outputTokens), strconv.FormatFloat(cost, 'f', 6, 64)}
+	if err := writer.Write(total); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// exportCSV runs cost-export into a file and parses what it wrote
+func exportCSV(t *testing.T) [][]string {
+	t.Helper()
+	previousFormat, previousOut := costExportFormat, costExportOut
+	costExportFormat, costExportOut = "csv", filepath.Join(t.TempDir(), "spend.csv")
+	t.Cleanup(func() { costExportFormat, costExportOut = previousFormat, previousOut })
+
+	output := captureStdout(t, func() { exportCosts(nil, nil) })
+	file, err := os.Open(costExportOut)
+	if err != nil {
+		t.Fatalf("cost-export wrote no file: %v\n%s", err, output)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("cost-export wrote invalid CSV: %v", err)
+	}
+	return rows
+}
+
+func TestCostExportCSVRoundTrip(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	at := time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC)
+	records := []UsageRecord{
+		{Timestamp: at, DocType: "README", Provider: "anthropic", Model: "claude-sonnet-4-20250514", InputTokens: 1200, OutputTokens: 800, Cost: 0.078},
+		// Commas, quotes, and newlines survive CSV quoting
+		{Timestamp: at.Add(time.Minute), Component: `jobs/"worker", v2`, DocType: "ARCHITECTURE", Provider: "openai", Model: "gpt-4o", InputTokens: 3000, OutputTokens: 1500, Cost: 0.0375},
+		{Timestamp: at.Add(2 * time.Minute), Component: "web\nui", DocType: "SETUP", Provider: "openrouter", Model: "anthropic/claude-3.5-haiku", InputTokens: 10, OutputTokens: 5, Cost: 0.000001},
+	}
+	// The first record takes its component from the generation's log fields
+	appendUsageRecord(WithLogFields(context.Background(), logrus.Fields{"component": "api"}), records[0])
+	records[0].Component = "api"
+	for _, record := range records[1:] {
+		appendUsageRecord(context.Background(), record)
+	}
+
+	rows := exportCSV(t)
+	if len(rows) != len(records)+2 {
+		t.Fatalf("CSV has %d rows, want a header, %d calls, and a total:\n%q", len(rows), len(records), rows)
+	}
+	if !reflect.DeepEqual(rows[0], costExportHeader) {
+		t.Errorf("header = %q, want %q", rows[0], costExportHeader)
+	}
+
+	var inputTokens, outputTokens int
+	var cost float64
+	for i, row := range rows[1 : len(rows)-1] {
+		timestamp, err := time.Parse(time.RFC3339, row[0])
+		if err != nil {
+			t.Fatalf("row %d timestamp: %v", i, err)
+		}
+		in, _ := strconv.Atoi(row[5])
+		out, _ := strconv.Atoi(row[6])
+		rowCost, _ := strconv.ParseFloat(row[7], 64)
+		got := UsageRecord{Timestamp: timestamp, Component: row[1], DocType: row[2], Provider: row[3], Model: row[4], InputTokens: in, OutputTokens: out, Cost: rowCost}
+		if !got.Timestamp.Equal(records[i].Timestamp) {
+			t.Errorf("row %d timestamp = %s, want %s", i, got.Timestamp, records[i].Timestamp)
+		}
+		got.Timestamp = records[i].Timestamp
+		if got != records[i] {
+			t.Errorf("row %d = %+v, want %+v", i, got, records[i])
+		}
+		inputTokens, outputTokens, cost = inputTokens+in, outputTokens+out, cost+rowCost
+	}
+
+	want := []string{"TOTAL", "", "", "", "", strconv.Itoa(inputTokens), strconv.Itoa(outputTokens), strconv.FormatFloat(cost, 'f', 6, 64)}
+	if total := rows[len(rows)-1]; !reflect.DeepEqual(total, want) {
+		t.Errorf("total row = %q, want %q", total, want)
+	}
+	if want := "0.115501"; rows[len(rows)-1][7] != want {
+		t.Errorf("total cost = %s, want %s", rows[len(rows)-1][7], want)
+	}
+}
+
+func TestCostExportRecordsEachGeneration(t *testing.T) {
+	singleServiceProject(t)
+	useCacheFlags(t, true, true)
+	provider, _ := useScriptedProvider(t)
+	runUpdate(t, context.Background())
+
+	rows := exportCSV(t)
+	calls := int(provider.calls.Load())
+	if len(rows) != calls+2 {
+		t.Fatalf("CSV has %d rows for %d API calls:\n%q", len(rows), calls, rows)
+	}
+	docTypes := make(map[string]bool)
+	for _, row := range rows[1 : len(rows)-1] {
+		if row[1] != "svc" || row[3] != "mock" {
+			t.Errorf("row = %q, want component svc and provider mock", row)
+		}
+		docTypes[row[2]] = true
+	}
+	for _, docType := range orderedUpdateDocTypes() {
+		if !docTypes[docType] {
+			t.Errorf("no row for %s", docType)
+		}
+	}
+}
+
+func TestCostExportWithoutUsage(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	previousFormat, previousOut := costExportFormat, costExportOut
+	costExportFormat, costExportOut = "csv", "-"
+	t.Cleanup(func() { costExportFormat, costExportOut = previousFormat, previousOut })
+
+	output := captureStdout(t, func() { exportCosts(nil, nil) })
+	if !strings.Contains(output, "No usage recorded yet") {
+		t.Errorf("output = %q, want a note that nothing was recorded", output)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"regexp"
 	"strings"
+	"sync"
 	"unicode"
 
 	"docs-cli/pkg/config"
@@ -107,65 +108,134 @@ func SelectOptimalModel(complexity TaskComplexity, provider string) string {
 	return "sonnett-4"
 }
 
+// providerModelTiers lists the models SelectOptimalModel picks from for each provider with
+// pricing, each with the most complex task it is trusted with, cheapest first
+var providerModelTiers = map[string][]struct {
+	model string
+	tier  TaskComplexity
+}{
+	"anthropic": {{"haiku-3.5", SimpleTask}, {"sonnett-4", MediumTask}, {"opus-4", ComplexTask}},
+	"openai":    {{"gpt-3.5-turbo", SimpleTask}, {"gpt-4o", ComplexTask}},
+}
+
+// SelectCrossProviderModel returns the lowest estimated cost among the models of providers
+// whose tier meets complexity. Only providers listed in providerModelTiers can be chosen; ties
+// go to the provider listed first. It returns false when no provider qualifies.
+func SelectCrossProviderModel(complexity TaskComplexity, prompt string, outputTokens int, providers []string) (CostEstimate, bool) {
+	var best CostEstimate
+	found := false
+	for _, provider := range providers {
+		for _, candidate := range providerModelTiers[provider] {
+			if candidate.tier < complexity {
+				continue
+			}
+			estimate := EstimateCost(provider, candidate.model, prompt, outputTokens)
+			if !found || estimate.TotalEstimatedCost < best.TotalEstimatedCost {
+				best, found = estimate, true
+			}
+		}
+	}
+	return best, found
+}
+
 // CompressPrompt reduces prompt size while preserving essential information
 func CompressPrompt(prompt string) string {
 	// Start with the original prompt
 	compressed := prompt
 	originalSize := len(compressed)
 	
-	// Step 1: Remove excessive whitespace
-	compressed = regexp.MustCompile(`\s+`).ReplaceAllString(compressed, " ")
-	compressed = strings.TrimSpace(compressed)
-	
-	// Step 2: Remove comments and metadata that don't affect generation
-	compressed = regexp.MustCompile(`(?m)^#.*$`).ReplaceAllString(compressed, "")
-	compressed = regexp.MustCompile(`(?m)^\s*//.*$`).ReplaceAllString(compressed, "")
-	
-	// Step 3: Compress repeated patterns
-	compressed = regexp.MustCompile(`\n\s*\n\s*\n+`).ReplaceAllString(compressed, "\n\n")
-	
-	// Step 4: Remove redundant file extensions in listings
-	compressed = regexp.MustCompile(`\.(py|go|ts|tsx|js|jsx|md|yaml|yml|json)`).ReplaceAllString(compressed, "")
-	
-	// Step 5: Compress common programming patterns
-	replacements := map[string]string{
-		"import ": "imp ",
-		"export ": "exp ",
-		"function ": "fn ",
-		"interface ": "int ",
-		"component ": "comp ",
-		"const ": "c ",
-		"return ": "ret ",
+	// Apply the configured substitutions in order (cost_optimization.compression.rules)
+	costConfig := getCostOptConfig()
+	rules, err := costConfig.Compression.ResolveRules()
+	if err != nil {
+		LogWithContext().WithError(err).Warn("Invalid compression rules, sending prompt uncompressed")
+		return prompt
 	}
+	compressed = applyCompressionRules(compressed, rules)
 	
-	for old, new := range replacements {
-		compressed = strings.ReplaceAll(compressed, old, new)
+	// Cost is driven by tokens, so judge the compression by its token delta
+	originalTokens := EstimateTokens(prompt)
+	compressedTokens := EstimateTokens(compressed)
+	if originalTokens == 0 {
+		return prompt
 	}
-	
-	// Step 6: Remove file paths prefixes for brevity
-	compressed = regexp.MustCompile(`/[a-zA-Z0-9_-]+/[a-zA-Z0-9_-]+/`).ReplaceAllString(compressed, "")
+	tokenRatio := float64(compressedTokens) / float64(originalTokens)
+	entry := LogWithContext().WithField("original_size", originalSize).
+		WithField("compressed_size", len(compressed)).
+		WithField("bytes_saved", originalSize-len(compressed)).
+		WithField("original_tokens", originalTokens).
+		WithField("compressed_tokens", compressedTokens).
+		WithField("tokens_saved", originalTokens-compressedTokens).
+		WithField("token_ratio", tokenRatio)
 	
 	// Don't compress too aggressively
-	costConfig := getCostOptConfig()
-	if len(compressed) < int(float64(originalSize)*costConfig.Compression.MaxRatio) {
-		LogWithContext().WithField("original_size", originalSize).
-			WithField("compressed_size", len(compressed)).
-			WithField("ratio", float64(len(compressed))/float64(originalSize)).
-			Warn("Compression too aggressive, reverting")
+	if tokenRatio < costConfig.Compression.MaxRatio {
+		entry.Warn("Compression too aggressive, reverting")
 		return prompt
 	}
 	
-	compressionRatio := float64(len(compressed)) / float64(originalSize)
+	// Rewritten text is harder to read; only keep it when it saves enough tokens
+	if compressedTokens >= originalTokens || 1-tokenRatio < costConfig.Compression.MinTokenSavings {
+		entry.WithField("min_token_savings", costConfig.Compression.MinTokenSavings).
+			Debug("Compression saved too few tokens, reverting")
+		return prompt
+	}
 	
-	LogWithContext().WithField("original_size", originalSize).
-		WithField("compressed_size", len(compressed)).
-		WithField("compression_ratio", compressionRatio).
-		WithField("tokens_saved", EstimateTokens(prompt)-EstimateTokens(compressed)).
-		Info("Prompt compressed successfully")
+	entry.Debug("Prompt compressed successfully")
 	
 	return compressed
 }
 
+// compressMessage compresses a prompt message. A cacheable prefix is compressed on its own,
+// apart from the rest, so it stays byte-identical between calls that share it; its trailing
+// whitespace is kept so it doesn't run into the rest.
+func compressMessage(message ChatMessage) ChatMessage {
+	if message.CacheablePrefix <= 0 || message.CacheablePrefix > len(message.Content) {
+		return ChatMessage{Role: message.Role, Content: CompressPrompt(message.Content)}
+	}
+	original := message.Content[:message.CacheablePrefix]
+	trimmed := strings.TrimRightFunc(original, unicode.IsSpace)
+	prefix := strings.TrimRightFunc(CompressPrompt(original), unicode.IsSpace) + original[len(trimmed):]
+	rest := message.Content[message.CacheablePrefix:]
+	if rest != "" {
+		rest = CompressPrompt(rest)
+	}
+	return ChatMessage{Role: message.Role, Content: prefix + rest, CacheablePrefix: len(prefix)}
+}
+
+// optimizeConversation applies the call path's cost optimization to a conversation ending
+// in the prompt: the model and cost estimate come from the whole flattened conversation, and
+// only the prompt turn is compressed, so earlier turns and cacheable prefixes are sent as built
+func optimizeConversation(messages []ChatMessage, docType, componentType, provider string) ([]ChatMessage, string, CostEstimate) {
+	history := messages[:len(messages)-1]
+	_, optimalModel, costEstimate := OptimizeForCost(flattenConversation(messages), docType, componentType, provider)
+	optimized := append(history[:len(history):len(history)], compressMessage(messages[len(messages)-1]))
+	return optimized, optimalModel, costEstimate
+}
+
+// compiledCompressionPatterns caches compression rule regexps by pattern
+var compiledCompressionPatterns sync.Map
+
+// applyCompressionRules applies each enabled rule in order; rules must come from ResolveRules
+func applyCompressionRules(text string, rules []config.CompressionRule) string {
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		if rule.Literal {
+			text = strings.ReplaceAll(text, rule.Pattern, rule.Replacement)
+			continue
+		}
+
+		compiled, ok := compiledCompressionPatterns.Load(rule.Pattern)
+		if !ok {
+			compiled, _ = compiledCompressionPatterns.LoadOrStore(rule.Pattern, regexp.MustCompile(rule.Pattern))
+		}
+		text = compiled.(*regexp.Regexp).ReplaceAllString(text, rule.Replacement)
+	}
+	return text
+}
+
 // EstimateCost calculates the estimated cost for an API call
 func EstimateCost(provider, model, prompt string, estimatedOutputTokens int) CostEstimate {
 	inputTokens := EstimateTokens(prompt)
@@ -174,6 +244,8 @@ func EstimateCost(provider, model, prompt string, estimatedOutputTokens int) Cos
 	var inputCostPer1K, outputCostPer1K float64
 	
 	switch provider {
+	case "mock":
+		// Offline calls cost nothing
 	case "anthropic":
 		switch model {
 		case "opus-4", "claude-opus-4-20250514":
@@ -210,12 +282,23 @@ func EstimateCost(provider, model, prompt string, estimatedOutputTokens int) Cos
 			}
 		}
 	case "openai":
-		if pricing, exists := costConfig.Pricing.OpenAI["gpt4"]; exists {
-			inputCostPer1K = pricing.InputCost
-			outputCostPer1K = pricing.OutputCost
-		} else {
-			inputCostPer1K = 0.005
-			outputCostPer1K = 0.015
+		switch model {
+		case "gpt-3.5-turbo":
+			if pricing, exists := costConfig.Pricing.OpenAI["gpt35"]; exists {
+				inputCostPer1K = pricing.InputCost
+				outputCostPer1K = pricing.OutputCost
+			} else {
+				inputCostPer1K = 0.0005
+				outputCostPer1K = 0.0015
+			}
+		default:
+			if pricing, exists := costConfig.Pricing.OpenAI["gpt4"]; exists {
+				inputCostPer1K = pricing.InputCost
+				outputCostPer1K = pricing.OutputCost
+			} else {
+				inputCostPer1K = 0.005
+				outputCostPer1K = 0.015
+			}
 		}
 	default:
 		if pricing, exists := costConfig.Pricing.Anthropic["sonnet4"]; exists {
@@ -241,8 +324,14 @@ func EstimateCost(provider, model, prompt string, estimatedOutputTokens int) Cos
 	}
 }
 
-// EstimateOutputTokens predicts output length based on document type and input
+// EstimateOutputTokens predicts output length, blending the document type's completion
+// history with the fixed-fraction heuristic when history exists
 func EstimateOutputTokens(docType string, inputTokens int) int {
+	return getTokenHistory().Blend(docType, heuristicOutputTokens(docType, inputTokens))
+}
+
+// heuristicOutputTokens predicts output length as a fixed fraction of input by document type
+func heuristicOutputTokens(docType string, inputTokens int) int {
 	switch docType {
 	case "README":
 		// READMEs are typically comprehensive
@@ -274,6 +363,9 @@ func OptimizeForCost(prompt, docType, componentType, provider string) (string, s
 		return OptimizeForOpenAI(prompt, docType, complexity)
 	case "openrouter":
 		return OptimizeForOpenRouter(prompt, docType, complexity)
+	case "mock":
+		// The mock provider is free; keep the prompt and model as configured
+		return prompt, "", EstimateCost("mock", "", prompt, EstimateOutputTokens(docType, EstimateTokens(prompt)))
 	default:
 		// Fallback to Anthropic optimization
 		return OptimizeForAnthropic(prompt, docType, complexity)
@@ -293,7 +385,7 @@ func OptimizeForAnthropic(prompt, docType string, complexity TaskComplexity) (st
 		WithField("complexity", complexity).
 		WithField("selected_model", optimalModel).
 		WithField("cost_estimate", costEstimate).
-		Info("Anthropic-specific cost optimization completed")
+		Debug("Anthropic-specific cost optimization completed")
 	
 	return optimizedPrompt, optimalModel, costEstimate
 }
@@ -311,7 +403,7 @@ func OptimizeForOpenAI(prompt, docType string, complexity TaskComplexity) (strin
 		WithField("complexity", complexity).
 		WithField("selected_model", optimalModel).
 		WithField("cost_estimate", costEstimate).
-		Info("OpenAI-specific cost optimization completed")
+		Debug("OpenAI-specific cost optimization completed")
 	
 	return optimizedPrompt, optimalModel, costEstimate
 }
@@ -329,7 +421,7 @@ func OptimizeForOpenRouter(prompt, docType string, complexity TaskComplexity) (s
 		WithField("complexity", complexity).
 		WithField("selected_model", optimalModel).
 		WithField("cost_estimate", costEstimate).
-		Info("OpenRouter-specific cost optimization completed")
+		Debug("OpenRouter-specific cost optimization completed")
 	
 	return optimizedPrompt, optimalModel, costEstimate
 }
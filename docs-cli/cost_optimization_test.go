@@ -0,0 +1,218 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"docs-cli/pkg/config"
+)
+
+// compressionSample is code-heavy text the default rules shorten
+const compressionSample = `import { render } from "./render.tsx"
+import { load } from "./load.ts"
+
+export function main() {
+  const config = load()
+  return render(config)
+}
+
+export function helper() {
+  return null
+}
+`
+
+// useCompressionRules configures CompressPrompt with rules and keeps any result it produces
+func useCompressionRules(t *testing.T, rules []config.CompressionRule) {
+	t.Helper()
+	useEnterpriseConfig(t, func(c *config.EnterpriseConfig) {
+		c.CostOpt.Compression = config.CompressionConfig{Rules: rules}
+	})
+}
+
+// withRule returns the default rules with edit applied to the rule called name
+func withRule(t *testing.T, name string, edit func(*config.CompressionRule)) []config.CompressionRule {
+	t.Helper()
+	rules := append([]config.CompressionRule(nil), config.DefaultCompressionRules...)
+	for i := range rules {
+		if rules[i].Name == name {
+			edit(&rules[i])
+			return rules
+		}
+	}
+	t.Fatalf("no default compression rule %q", name)
+	return nil
+}
+
+func TestCompressPromptDefaultRules(t *testing.T) {
+	useCompressionRules(t, nil)
+	compressed := CompressPrompt(compressionSample)
+	for _, want := range []string{`imp { render } from "./render"`, "exp fn main()", "c config = load()", "ret render(config)"} {
+		if !strings.Contains(compressed, want) {
+			t.Errorf("compressed prompt is missing %q:\n%s", want, compressed)
+		}
+	}
+}
+
+func TestCompressPromptSkipsDisabledRule(t *testing.T) {
+	useCompressionRules(t, withRule(t, "abbreviate_import", func(rule *config.CompressionRule) { rule.Disabled = true }))
+	compressed := CompressPrompt(compressionSample)
+	if !strings.Contains(compressed, `import { render }`) || strings.Contains(compressed, "imp {") {
+		t.Errorf("disabled import rule was applied:\n%s", compressed)
+	}
+	// The other rules still run
+	if !strings.Contains(compressed, "exp fn main()") {
+		t.Errorf("enabled rules were not applied:\n%s", compressed)
+	}
+}
+
+func TestCompressPromptAppliesCustomRule(t *testing.T) {
+	rules := append(append([]config.CompressionRule(nil), config.DefaultCompressionRules...),
+		config.CompressionRule{Name: "abbreviate_render", Pattern: `\brender\b`, Replacement: "rdr"},
+		config.CompressionRule{Name: "abbreviate_null", Pattern: "null", Replacement: "nil", Literal: true},
+	)
+	useCompressionRules(t, rules)
+	compressed := CompressPrompt(compressionSample)
+	for _, want := range []string{"imp { rdr }", "ret rdr(config)", "ret nil"} {
+		if !strings.Contains(compressed, want) {
+			t.Errorf("compressed prompt is missing %q:\n%s", want, compressed)
+		}
+	}
+	if strings.Contains(compressed, "render") {
+		t.Errorf("custom rule left a match:\n%s", compressed)
+	}
+}
+
+func TestCompressPromptRevertThreshold(t *testing.T) {
+	// Erasing every line compresses the prompt to almost nothing
+	rules := []config.CompressionRule{{Name: "erase", Pattern: `(?m)^.*$`, Replacement: ""}}
+	for _, tt := range []struct {
+		name       string
+		maxRatio   float64
+		wantRevert bool
+	}{
+		{"below max_ratio reverts", 0.3, true},
+		{"no max_ratio keeps it", 0, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			useEnterpriseConfig(t, func(c *config.EnterpriseConfig) {
+				c.CostOpt.Compression = config.CompressionConfig{MaxRatio: tt.maxRatio, Rules: rules}
+			})
+			compressed := CompressPrompt(compressionSample)
+			if reverted := compressed == compressionSample; reverted != tt.wantRevert {
+				t.Errorf("reverted = %v, want %v (result %q)", reverted, tt.wantRevert, compressed)
+			}
+		})
+	}
+}
+
+// logRecorder keeps the entries logged at or above its level
+type logRecorder struct {
+	mu      sync.Mutex
+	entries []*logrus.Entry
+}
+
+func (r *logRecorder) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (r *logRecorder) Fire(entry *logrus.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+// Message returns the last entry logged with message, or nil
+func (r *logRecorder) Message(message string) *logrus.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if r.entries[i].Message == message {
+			return r.entries[i]
+		}
+	}
+	return nil
+}
+
+// recordLogs captures debug and higher log entries for the rest of the test
+func recordLogs(t *testing.T) *logRecorder {
+	t.Helper()
+	recorder := &logRecorder{}
+	level, output := logger.GetLevel(), logger.Out
+	hooks := logger.ReplaceHooks(logrus.LevelHooks{})
+	logger.AddHook(recorder)
+	logger.SetLevel(logrus.DebugLevel)
+	logger.SetOutput(io.Discard)
+	t.Cleanup(func() {
+		logger.ReplaceHooks(hooks)
+		logger.SetLevel(level)
+		logger.SetOutput(output)
+	})
+	return recorder
+}
+
+func TestCompressPromptJudgesTokensNotBytes(t *testing.T) {
+	// EstimateTokens ignores runs of whitespace, so the byte and token deltas of these
+	// compressions diverge
+	padding := strings.Repeat(" ", 1000)
+	tests := []struct {
+		name        string
+		prompt      string
+		rule        config.CompressionRule
+		wantMessage string
+		wantKept    bool
+	}{
+		{
+			// Most bytes go, but no tokens, so there is nothing worth the rewrite
+			name:        "bytes saved without tokens",
+			prompt:      "Document" + padding + "the billing service.",
+			rule:        config.CompressionRule{Name: "collapse_whitespace", Pattern: `\s+`, Replacement: " "},
+			wantMessage: "Compression saved too few tokens, reverting",
+		},
+		{
+			// Few bytes go, but nearly every token does, which is too aggressive
+			name:        "tokens gutted by a small byte change",
+			prompt:      padding + "keep " + strings.Repeat("drop", 30),
+			rule:        config.CompressionRule{Name: "drop", Pattern: "drop", Replacement: "", Literal: true},
+			wantMessage: "Compression too aggressive, reverting",
+		},
+		{
+			// A modest share of the bytes, but a worthwhile share of the tokens
+			name:        "tokens saved by a small byte change",
+			prompt:      padding + "keep these words " + strings.Repeat("drop", 5),
+			rule:        config.CompressionRule{Name: "drop", Pattern: "drop", Replacement: "", Literal: true},
+			wantMessage: "Prompt compressed successfully",
+			wantKept:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useEnterpriseConfig(t, func(c *config.EnterpriseConfig) {
+				c.CostOpt.Compression = config.CompressionConfig{MaxRatio: 0.3, MinTokenSavings: 0.05, Rules: []config.CompressionRule{tt.rule}}
+			})
+			logs := recordLogs(t)
+
+			compressed := CompressPrompt(tt.prompt)
+			if kept := compressed != tt.prompt; kept != tt.wantKept {
+				t.Errorf("kept compression = %v, want %v", kept, tt.wantKept)
+			}
+			entry := logs.Message(tt.wantMessage)
+			if entry == nil {
+				t.Fatalf("no %q log entry", tt.wantMessage)
+			}
+			// Both deltas are logged
+			for _, field := range []string{"original_size", "compressed_size", "bytes_saved", "original_tokens", "compressed_tokens", "tokens_saved", "token_ratio"} {
+				if _, ok := entry.Data[field]; !ok {
+					t.Errorf("log entry is missing %s: %v", field, entry.Data)
+				}
+			}
+			bytesShare := float64(entry.Data["bytes_saved"].(int)) / float64(entry.Data["original_size"].(int))
+			tokensShare := float64(entry.Data["tokens_saved"].(int)) / float64(entry.Data["original_tokens"].(int))
+			if diff := bytesShare - tokensShare; diff > -0.3 && diff < 0.3 {
+				t.Errorf("byte share saved %.2f and token share saved %.2f do not diverge", bytesShare, tokensShare)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// costSummaryKey groups generations by the provider and model that served them
+type costSummaryKey struct {
+	provider string
+	model    string
+}
+
+// costSummaryRow accumulates the generations served by one provider/model pair
+type costSummaryRow struct {
+	calls        int
+	cacheHits    int
+	inputTokens  int
+	outputTokens int
+	cost         float64
+}
+
+// CostSummary aggregates estimated generation costs across a run for --cost-summary
+type CostSummary struct {
+	mutex sync.Mutex
+	rows  map[costSummaryKey]*costSummaryRow
+}
+
+var runCostSummary = &CostSummary{rows: make(map[costSummaryKey]*costSummaryRow)}
+
+// Record adds one generation to the summary; cache hits count as calls with no cost
+func (s *CostSummary) Record(provider, model string, inputTokens, outputTokens int, cost float64, cached bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := costSummaryKey{provider: provider, model: model}
+	row, exists := s.rows[key]
+	if !exists {
+		row = &costSummaryRow{}
+		s.rows[key] = row
+	}
+
+	row.calls++
+	if cached {
+		row.cacheHits++
+		return
+	}
+	row.inputTokens += inputTokens
+	row.outputTokens += outputTokens
+	row.cost += cost
+}
+
+// TotalCost returns the estimated spend recorded so far
+func (s *CostSummary) TotalCost() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var total float64
+	for _, row := range s.rows {
+		total += row.cost
+	}
+	return total
+}
+
+// recordGeneration logs the one Info line per generation, tags the current trace span, and
+// feeds the run's cost summary. The per-call optimization breakdown stays at Debug.
+func recordGeneration(ctx context.Context, provider, model, docType string, inputTokens, outputTokens int, cost float64, cached bool) {
+	LogFrom(ctx).WithField("provider", provider).
+		WithField("model", model).
+		WithField("doc_type", docType).
+		WithField("input_tokens", inputTokens).
+		WithField("output_tokens", outputTokens).
+		WithField("estimated_cost", cost).
+		WithField("cached", cached).
+		Info("Generation completed")
+
+	span := SpanFrom(ctx)
+	span.SetAttribute("provider", provider)
+	span.SetAttribute("model", model)
+	span.SetAttribute("input_tokens", inputTokens)
+	span.SetAttribute("output_tokens", outputTokens)
+	span.SetAttribute("estimated_cost_usd", cost)
+	span.SetAttribute("cached", cached)
+
+	if stats, ok := ctx.Value(generationStatsKey{}).(*GenerationStats); ok {
+		*stats = GenerationStats{Provider: provider, Model: model, InputTokens: inputTokens, OutputTokens: outputTokens, Cost: cost, Cached: cached}
+	}
+
+	runCostSummary.Record(provider, model, inputTokens, outputTokens, cost, cached)
+	if !cached {
+		appendUsageRecord(ctx, UsageRecord{
+			Timestamp:    time.Now().UTC(),
+			DocType:      docType,
+			Provider:     provider,
+			Model:        model,
+			InputTokens:  inputTokens,
+			OutputTokens: outputTokens,
+			Cost:         cost,
+		})
+	}
+}
+
+// printCostSummary prints the aggregate cost table at the end of a --cost-summary run
+func printCostSummary() {
+	runCostSummary.mutex.Lock()
+	defer runCostSummary.mutex.Unlock()
+
+	if len(runCostSummary.rows) == 0 {
+		fmt.Println("\n📊 Cost summary: no model calls were made")
+		return
+	}
+
+	keys := make([]costSummaryKey, 0, len(runCostSummary.rows))
+	for key := range runCostSummary.rows {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].provider != keys[j].provider {
+			return keys[i].provider < keys[j].provider
+		}
+		return keys[i].model < keys[j].model
+	})
+
+	fmt.Println("\n📊 Cost summary (estimated)")
+	fmt.Printf("%-12s %-36s %6s %6s %10s %10s %10s\n", "PROVIDER", "MODEL", "CALLS", "CACHED", "IN TOKENS", "OUT TOKENS", "COST")
+
+	var total costSummaryRow
+	for _, key := range keys {
+		row := runCostSummary.rows[key]
+		fmt.Printf("%-12s %-36s %6d %6d %10d %10d %10s\n", key.provider, key.model, row.calls, row.cacheHits, row.inputTokens, row.outputTokens, fmt.Sprintf("$%.4f", row.cost))
+
+		total.calls += row.calls
+		total.cacheHits += row.cacheHits
+		total.inputTokens += row.inputTokens
+		total.outputTokens += row.outputTokens
+		total.cost += row.cost
+	}
+	fmt.Printf("%-12s %-36s %6d %6d %10d %10d %10s\n", "TOTAL", "", total.calls, total.cacheHits, total.inputTokens, total.outputTokens, fmt.Sprintf("$%.4f", total.cost))
+}
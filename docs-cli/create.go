@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"docs-cli/pkg/config"
+	"docs-cli/pkg/scanner"
+)
+
+// createDocumentation generates one document type, or every type in chain order for "all",
+// for one component, or for every component selected by --components and --tags for "all".
+// Documents already on disk are left alone, though they still feed the context of the
+// documents generated after them, unless --force is set; with --merge an existing
+// CHECKLIST.yaml is regenerated and merged.
+func createDocumentation(cmd *cobra.Command, args []string) {
+	docType := args[0]
+	componentName := args[1]
+
+	// Validate inputs
+	if err := ValidateInput(docType, "doc_type"); err != nil {
+		fmt.Printf("❌ Invalid document type: %v\n", err)
+		os.Exit(1)
+	}
+
+	if componentName != "all" {
+		if err := ValidateInput(componentName, "component_name"); err != nil {
+			fmt.Printf("❌ Invalid component name: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if explain {
+		if docType == "all" || componentName == "all" {
+			fmt.Println("❌ --explain requires a single document type and component")
+			os.Exit(1)
+		}
+
+		explanation, err := ExplainPrompt(docType, componentName)
+		if err != nil {
+			fmt.Printf("❌ Failed to explain prompt: %v\n", err)
+			os.Exit(1)
+		}
+		if err := printPromptExplanation(explanation, explainOut); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Serialize snapshot writers; the lock is released on exit, signal, or timeout
+	lock, err := acquireRunLock(cmd.Context(), false)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	onShutdown(lock.Release)
+
+	configManager := config.NewConfigManager()
+	if _, err := configManager.LoadConfig(); err != nil {
+		exitCreate("Configuration error: %v", err)
+	}
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		exitCreate("Error opening source: %v", err)
+	}
+	components, err := createTargets(fileScanner, componentName)
+	if err != nil {
+		exitCreate("%v", err)
+	}
+	if err := checkComponentLimit(len(components)); err != nil {
+		exitCreate("%v", err)
+	}
+
+	docTypes := []string{docType}
+	if docType == "all" {
+		docTypes = chainOrder()
+	}
+	existing := 0
+	for _, component := range components {
+		for _, docType := range docTypes {
+			if keepExistingDoc(component, docType) {
+				existing++
+			}
+		}
+	}
+
+	snapshotManager := NewSnapshotManager()
+	onShutdown(snapshotManager.Flush)
+
+	ctx := cmd.Context()
+	progress := NewProgressReporter(len(components), len(components)*len(docTypes)-existing)
+	var counts generationCounts
+	for _, component := range components {
+		componentCtx := WithLogFields(ctx, logrus.Fields{"component": component.Key()})
+		for _, docType := range docTypes {
+			if ctx.Err() != nil {
+				progress.Finish()
+				exitCreate("Generation cancelled after %d documents: %v", counts.generated, context.Cause(ctx))
+			}
+			if keepExistingDoc(component, docType) {
+				continue
+			}
+			counts.generate(componentCtx, configManager, fileScanner, snapshotManager, progress, component, docType)
+		}
+		progress.ComponentDone()
+	}
+	progress.Finish()
+
+	if counts.failed > 0 {
+		exitCreate("Created %d documents, %d failed", counts.generated, counts.failed)
+	}
+	summary := []string{fmt.Sprintf("Created %d documents", counts.generated)}
+	if existing > 0 {
+		summary = append(summary, fmt.Sprintf("left %d existing (use --force to regenerate them)", existing))
+	}
+	if counts.skipped > 0 {
+		summary = append(summary, fmt.Sprintf("skipped %d over --max-cost-per-doc", counts.skipped))
+	}
+	if counts.kept > 0 {
+		summary = append(summary, fmt.Sprintf("kept %d existing after --show-diff", counts.kept))
+	}
+	fmt.Printf("✅ %s\n", strings.Join(summary, ", "))
+}
+
+// createTargets returns the named component, or for "all" every component selected by
+// --components and --tags
+func createTargets(fileScanner scanner.FileScanner, componentName string) ([]scanner.Component, error) {
+	if componentName != "all" {
+		component, err := findComponentByName(fileScanner, componentName)
+		if err != nil {
+			return nil, err
+		}
+		return []scanner.Component{component}, nil
+	}
+
+	components, err := fileScanner.ScanComponents(projectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("error scanning components: %w", err)
+	}
+	components, err = selectComponents(components)
+	if err != nil {
+		return nil, err
+	}
+	if len(components) == 0 {
+		return nil, fmt.Errorf("no components match --components %q and --tags %q", componentFilter, tagFilter)
+	}
+	return components, nil
+}
+
+// keepExistingDoc reports whether create leaves the document on disk as it is: it exists,
+// and neither --force nor, for a checklist, --merge asks for it to be regenerated
+func keepExistingDoc(component scanner.Component, docType string) bool {
+	if force || (docType == "CHECKLIST" && mergeChecklist) {
+		return false
+	}
+	_, err := os.Stat(docOutputPath(component, docType))
+	return err == nil
+}
+
+// exitCreate prints a create failure, releases the run lock, and exits non-zero
+func exitCreate(format string, args ...interface{}) {
+	fmt.Printf("❌ "+format+"\n", args...)
+	runShutdown()
+	os.Exit(1)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// twoComponentProject has an api service and a web frontend, each with a source file
+func twoComponentProject(t *testing.T) *testProject {
+	t.Helper()
+	project := newTestProject(t, `components:
+  - name: "api"
+    path: "api"
+    type: "service"
+    tags: ["backend"]
+  - name: "web"
+    path: "web"
+    type: "frontend"
+    tags: ["frontend"]
+`)
+	project.WriteFile("api/main.go", "package main\n\nfunc main() {}\n")
+	project.WriteFile("web/main.go", "package main\n\nfunc main() {}\n")
+	return project
+}
+
+// useForce sets --force for the rest of the test
+func useForce(t *testing.T, enabled bool) {
+	t.Helper()
+	previous := force
+	force = enabled
+	t.Cleanup(func() { force = previous })
+}
+
+// writtenDocs returns which of the chained documents exist for each component, as component/docType
+func writtenDocs(t *testing.T, project *testProject) []string {
+	t.Helper()
+	var written []string
+	for _, component := range project.Components() {
+		for _, docType := range chainOrder() {
+			if _, err := os.Stat(docOutputPath(component, docType)); err == nil {
+				written = append(written, component.Key()+"/"+docType)
+			}
+		}
+	}
+	return written
+}
+
+func TestCreateGeneratesOneDocument(t *testing.T) {
+	project := twoComponentProject(t)
+
+	output := captureStdout(t, func() { runCommand(t, context.Background(), createDocumentation, "README", "api") })
+
+	if got := writtenDocs(t, project); strings.Join(got, ",") != "api/README" {
+		t.Errorf("create README api wrote %v, want api/README only", got)
+	}
+	if !strings.Contains(output, "✅ Created 1 documents") {
+		t.Errorf("output does not report the document:\n%s", output)
+	}
+	if _, ok := NewSnapshotManager().snapshots["api"].DocsGenerated["README"]; !ok {
+		t.Error("create did not record the generated README in the snapshot")
+	}
+}
+
+func TestCreateAllForSelectedComponents(t *testing.T) {
+	project := twoComponentProject(t)
+	previous := componentFilter
+	componentFilter = "api"
+	t.Cleanup(func() { componentFilter = previous })
+
+	captureStdout(t, func() { runCommand(t, context.Background(), createDocumentation, "all", "all") })
+
+	var want []string
+	for _, docType := range chainOrder() {
+		want = append(want, "api/"+docType)
+	}
+	if got := writtenDocs(t, project); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("create all all --components api wrote %v, want %v", got, want)
+	}
+}
+
+func TestCreateKeepsExistingDocumentsUnlessForced(t *testing.T) {
+	project := twoComponentProject(t)
+	const handWritten = "# api\n\nWritten by hand.\n"
+	project.WriteFile("api/README.md", handWritten)
+
+	output := captureStdout(t, func() { runCommand(t, context.Background(), createDocumentation, "README", "api") })
+	if got := project.ReadFile("api/README.md"); got != handWritten {
+		t.Errorf("create without --force overwrote README.md: %q", got)
+	}
+	if !strings.Contains(output, "✅ Created 0 documents, left 1 existing (use --force to regenerate them)") {
+		t.Errorf("output does not report the existing document:\n%s", output)
+	}
+
+	useForce(t, true)
+	captureStdout(t, func() { runCommand(t, context.Background(), createDocumentation, "README", "api") })
+	if got := project.ReadFile("api/README.md"); got == handWritten {
+		t.Error("create --force left README.md as it was")
+	}
+}
+
+func TestCreateFailuresExitNonZero(t *testing.T) {
+	project := twoComponentProject(t)
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"unknown component", []string{"create", "README", "billing"}, "component 'billing' not found"},
+		{"no component matches", []string{"create", "README", "all", "--components", "billing-*"}, "matched no components"},
+		{"no component carries the tags", []string{"create", "README", "all", "--tags", "billing"}, "no components match"},
+		{"over the component limit", []string{"create", "README", "all", "--max-components", "1"}, "2 components selected, more than the limit of 1"},
+		{"invalid document type", []string{"create", "API", "api"}, "Invalid document type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, code := runCLI(t, project, tt.args...)
+			if code == 0 || !strings.Contains(output, tt.want) {
+				t.Errorf("%v exited %d, want non-zero with %q:\n%s", tt.args, code, tt.want, output)
+			}
+			if _, err := os.Stat(filepath.Join(project.Root, "api", "README.md")); !os.IsNotExist(err) {
+				t.Errorf("%v wrote a document", tt.args)
+			}
+		})
+	}
+}
+
+func TestCreateFiltersByTags(t *testing.T) {
+	tests := []struct {
+		tags, match string
+		want        string
+	}{
+		{"backend", "all", "api/README"},
+		{"frontend", "all", "web/README"},
+		{"backend,frontend", "all", ""},
+		{"backend,frontend", "any", "api/README,web/README"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tags+" "+tt.match, func(t *testing.T) {
+			project := twoComponentProject(t)
+			previousTags, previousMatch := tagFilter, tagMatch
+			tagFilter, tagMatch = tt.tags, tt.match
+			t.Cleanup(func() { tagFilter, tagMatch = previousTags, previousMatch })
+
+			output := captureStdout(t, func() {
+				if tt.want == "" {
+					// No component carries both tags, which create reports as an error
+					output, code := runCLI(t, project, "create", "README", "all", "--tags", tt.tags)
+					if code == 0 || !strings.Contains(output, "no components match") {
+						t.Errorf("create with no tagged components exited %d:\n%s", code, output)
+					}
+					return
+				}
+				runCommand(t, context.Background(), createDocumentation, "README", "all")
+			})
+			if got := writtenDocs(t, project); strings.Join(got, ",") != tt.want {
+				t.Errorf("create README all --tags %s --tags-match %s wrote %v, want %s:\n%s", tt.tags, tt.match, got, tt.want, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"docs-cli/pkg/config"
+)
+
+// cheapAnthropicSmallModels makes Anthropic's haiku the cheapest model overall, but its
+// larger models dearer than gpt-4o
+var cheapAnthropicSmallModels = config.PricingConfig{
+	Anthropic: map[string]config.ModelPricing{
+		"haiku":   {InputCost: 0.0001, OutputCost: 0.0005},
+		"sonnet4": {InputCost: 0.02, OutputCost: 0.08},
+	},
+	OpenAI: map[string]config.ModelPricing{
+		"gpt35": {InputCost: 0.001, OutputCost: 0.002},
+		"gpt4":  {InputCost: 0.003, OutputCost: 0.01},
+	},
+}
+
+// cheapAnthropicLargeModels reverses that: gpt-3.5-turbo is the cheapest small model, and
+// Anthropic's larger models undercut gpt-4o
+var cheapAnthropicLargeModels = config.PricingConfig{
+	Anthropic: map[string]config.ModelPricing{
+		"haiku":   {InputCost: 0.002, OutputCost: 0.008},
+		"sonnet4": {InputCost: 0.001, OutputCost: 0.004},
+	},
+	OpenAI: map[string]config.ModelPricing{
+		"gpt35": {InputCost: 0.0005, OutputCost: 0.0015},
+		"gpt4":  {InputCost: 0.01, OutputCost: 0.03},
+	},
+}
+
+// usePricing replaces the cost_optimization pricing table for the rest of the test
+func usePricing(t *testing.T, pricing config.PricingConfig, crossProvider bool) {
+	t.Helper()
+	useEnterpriseConfig(t, func(c *config.EnterpriseConfig) {
+		c.CostOpt.Pricing = pricing
+		c.CostOpt.CrossProviderSelection = crossProvider
+	})
+}
+
+func TestSelectCrossProviderModel(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	keyed := []string{"anthropic", "openai"}
+
+	tests := []struct {
+		name         string
+		pricing      config.PricingConfig
+		complexity   TaskComplexity
+		providers    []string
+		wantProvider string
+		wantModel    string
+	}{
+		{"simple task, cheap haiku", cheapAnthropicSmallModels, SimpleTask, keyed, "anthropic", "haiku-3.5"},
+		{"medium task, gpt-4o under sonnet", cheapAnthropicSmallModels, MediumTask, keyed, "openai", "gpt-4o"},
+		{"complex task, gpt-4o under opus", cheapAnthropicSmallModels, ComplexTask, keyed, "openai", "gpt-4o"},
+		{"simple task, cheap gpt-3.5-turbo", cheapAnthropicLargeModels, SimpleTask, keyed, "openai", "gpt-3.5-turbo"},
+		{"medium task, sonnet under gpt-4o", cheapAnthropicLargeModels, MediumTask, keyed, "anthropic", "sonnett-4"},
+		{"complex task, opus under gpt-4o", cheapAnthropicLargeModels, ComplexTask, keyed, "anthropic", "opus-4"},
+		// Only providers with a key are considered, however cheap the others are
+		{"cheaper provider has no key", cheapAnthropicSmallModels, MediumTask, []string{"anthropic"}, "anthropic", "sonnett-4"},
+		{"order of keyed providers does not matter", cheapAnthropicSmallModels, SimpleTask, []string{"openai", "anthropic"}, "anthropic", "haiku-3.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usePricing(t, tt.pricing, true)
+			choice, ok := SelectCrossProviderModel(tt.complexity, budgetPrompt, 1000, tt.providers)
+			if !ok {
+				t.Fatal("no provider selected")
+			}
+			if choice.Provider != tt.wantProvider || choice.Model != tt.wantModel {
+				t.Errorf("selected %s/%s, want %s/%s", choice.Provider, choice.Model, tt.wantProvider, tt.wantModel)
+			}
+			// The choice is the cheapest estimate of every qualifying candidate
+			for _, provider := range tt.providers {
+				for _, candidate := range providerModelTiers[provider] {
+					if candidate.tier < tt.complexity {
+						continue
+					}
+					if other := EstimateCost(provider, candidate.model, budgetPrompt, 1000); other.TotalEstimatedCost < choice.TotalEstimatedCost {
+						t.Errorf("%s/%s costs $%.4f, less than the selected $%.4f", provider, candidate.model, other.TotalEstimatedCost, choice.TotalEstimatedCost)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSelectCrossProviderModelWithoutPricedProviders(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	for _, providers := range [][]string{nil, {"openrouter"}, {"mock"}} {
+		if choice, ok := SelectCrossProviderModel(SimpleTask, budgetPrompt, 1000, providers); ok {
+			t.Errorf("providers %v selected %s/%s, want none", providers, choice.Provider, choice.Model)
+		}
+	}
+}
+
+// recordingFactory answers through the mock provider and records which provider each call
+// was sent to
+type recordingFactory struct {
+	mu        sync.Mutex
+	providers []string
+}
+
+func (f *recordingFactory) New(providerName, apiKey string) ModelProvider {
+	f.mu.Lock()
+	f.providers = append(f.providers, providerName)
+	f.mu.Unlock()
+	return &scriptedProvider{mock: NewMockProvider(config.GetConfig().Providers.Mock)}
+}
+
+func TestCallPathCrossProviderSelection(t *testing.T) {
+	const keyedModelConfig = `default:
+  provider: "anthropic"
+  model: "sonnett-4"
+  max_tokens: 1000
+  temperature: 0.5
+anthropic:
+  api_key: "test-key"
+  models:
+    sonnett-4: "claude-sonnet-4-20250514"
+openai:
+  api_key: "test-key"
+  models:
+    gpt-4o: "gpt-4o"
+`
+	tests := []struct {
+		name          string
+		modelConfig   string
+		crossProvider bool
+		want          string
+	}{
+		{"cheaper keyed provider wins", keyedModelConfig, true, "openai"},
+		{"disabled keeps the configured provider", keyedModelConfig, false, "anthropic"},
+		{"cheaper provider without a key is skipped", strings.Replace(keyedModelConfig, `  api_key: "test-key"
+  models:
+    gpt-4o`, `  models:
+    gpt-4o`, 1), true, "anthropic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := newTestProject(t, "components: []\n")
+			useModelConfig(t, project, tt.modelConfig)
+			useCacheFlags(t, true, true)
+			// OpenAI is cheaper than Anthropic for every task
+			usePricing(t, config.PricingConfig{
+				Anthropic: map[string]config.ModelPricing{"haiku": {InputCost: 0.01, OutputCost: 0.05}, "sonnet4": {InputCost: 0.02, OutputCost: 0.08}},
+				OpenAI:    map[string]config.ModelPricing{"gpt35": {InputCost: 0.0005, OutputCost: 0.0015}, "gpt4": {InputCost: 0.001, OutputCost: 0.002}},
+			}, tt.crossProvider)
+			factory := &recordingFactory{}
+			previous := newModelProvider
+			newModelProvider = factory.New
+			t.Cleanup(func() { newModelProvider = previous })
+			logs := recordLogs(t)
+
+			conversation := []ChatMessage{{Role: "user", Content: "Document the billing service."}}
+			if _, err := callModelAPIWithConversation(context.Background(), conversation, "README", "service", ""); err != nil {
+				t.Fatal(err)
+			}
+			if len(factory.providers) != 1 || factory.providers[0] != tt.want {
+				t.Errorf("call sent to %v, want %s", factory.providers, tt.want)
+			}
+			if entry := logs.Message("API call completed successfully"); entry == nil || entry.Data["provider"] != tt.want {
+				t.Errorf("API call logged as %v, want provider %s", entry, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"docs-cli/pkg/scanner"
+)
+
+// dependencyDocTypes are the documents of a dependency included as context with --with-deps
+var dependencyDocTypes = []string{"README", "ARCHITECTURE"}
+
+var (
+	dependencyGraphOnce sync.Once
+	dependencyGraph     map[string][]string
+	dependencyIndex     map[string]scanner.Component
+)
+
+// componentDependencies returns the components that component imports. The graph is built
+// once per run from every scanned component's source files.
+func componentDependencies(fileScanner scanner.FileScanner, component scanner.Component) []scanner.Component {
+	dependencyGraphOnce.Do(func() {
+		components, err := fileScanner.ScanComponents(projectRoot)
+		if err != nil {
+			LogWithContext().WithError(err).Warn("Failed to scan components for the dependency graph")
+			return
+		}
+
+		dependencyGraph = scanner.BuildDependencyGraph(components, MemoryAwareFileReader)
+		dependencyIndex = make(map[string]scanner.Component, len(components))
+		for _, scanned := range components {
+			dependencyIndex[scanned.Key()] = scanned
+		}
+	})
+
+	var dependencies []scanner.Component
+	for _, key := range dependencyGraph[component.Key()] {
+		dependencies = append(dependencies, dependencyIndex[key])
+	}
+	return dependencies
+}
+
+// buildDependencyContext renders the existing README and ARCHITECTURE of the component's
+// dependencies, or "" without --with-deps or when none have been generated
+func buildDependencyContext(fileScanner scanner.FileScanner, component scanner.Component) string {
+	if !withDeps {
+		return ""
+	}
+
+	var dependencyContext strings.Builder
+	for _, dependency := range componentDependencies(fileScanner, component) {
+		for _, docType := range dependencyDocTypes {
+			content, err := os.ReadFile(docOutputPath(dependency, docType))
+			if err != nil {
+				continue
+			}
+			dependencyContext.WriteString(fmt.Sprintf("=== %s %s ===\n%s\n\n", dependency.Key(), docType, content))
+		}
+	}
+	return dependencyContext.String()
+}
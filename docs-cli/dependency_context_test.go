@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"docs-cli/pkg/config"
+)
+
+func TestDependencyDocsInjectedWithDeps(t *testing.T) {
+	project := newTestProject(t, `components:
+  - name: "api"
+    path: "services/api"
+    type: "backend"
+  - name: "core"
+    path: "core"
+    type: "library"
+  - name: "web"
+    path: "web"
+    type: "frontend"
+`)
+	project.WriteFile("services/api/main.go", "package main\n\nimport \"example.com/repo/core\"\n\nfunc main() { core.Run() }\n")
+	project.WriteFile("core/core.go", "package core\n\nfunc Run() {}\n")
+	project.WriteFile("core/README.md", "CORE-README-MARKER\n")
+	project.WriteFile("core/docs/ARCHITECTURE.md", "CORE-ARCHITECTURE-MARKER\n")
+	project.WriteFile("web/index.js", "console.log('standalone')\n")
+	project.WriteFile("web/README.md", "WEB-README-MARKER\n")
+
+	configManager := config.NewConfigManager()
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := project.Component("api")
+
+	render := func() string {
+		t.Helper()
+		prompt, err := renderPrompt(configManager, fileScanner, api, "README", "", "", buildSourceContext(fileScanner, api))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return prompt
+	}
+
+	if prompt := render(); strings.Contains(prompt, "CORE-README-MARKER") {
+		t.Error("dependency docs injected without --with-deps")
+	}
+
+	withDeps = true
+	t.Cleanup(func() { withDeps = false })
+	prompt := render()
+	for _, want := range []string{"Dependency Documentation", "=== core README ===", "CORE-README-MARKER", "CORE-ARCHITECTURE-MARKER"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt with --with-deps is missing %q", want)
+		}
+	}
+	if strings.Contains(prompt, "WEB-README-MARKER") {
+		t.Error("docs of a component api does not import were injected")
+	}
+}
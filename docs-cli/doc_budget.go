@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// errDocOverBudget marks a document skipped because even the cheapest model's estimate
+// exceeds --max-cost-per-doc
+var errDocOverBudget = errors.New("estimated cost exceeds --max-cost-per-doc")
+
+// fitModelToDocBudget returns model if its estimated cost for prompt fits maxCost, otherwise the
+// most expensive cheaper tier that fits. A maxCost of zero disables the check. Providers
+// SelectOptimalModel has one tier for, such as openrouter, cannot downgrade, only skip.
+func fitModelToDocBudget(provider, model, prompt, docType string, maxCost float64) (string, error) {
+	if maxCost <= 0 {
+		return model, nil
+	}
+
+	outputTokens := EstimateOutputTokens(docType, EstimateTokens(prompt))
+	current := EstimateCost(provider, model, prompt, outputTokens)
+	if current.TotalEstimatedCost <= maxCost {
+		return model, nil
+	}
+
+	// The tiers SelectOptimalModel picks from, cheapest last
+	var tiers []CostEstimate
+	seen := map[string]bool{model: true}
+	for _, complexity := range []TaskComplexity{ComplexTask, MediumTask, SimpleTask} {
+		tier := SelectOptimalModel(complexity, provider)
+		if seen[tier] {
+			continue
+		}
+		seen[tier] = true
+		tiers = append(tiers, EstimateCost(provider, tier, prompt, outputTokens))
+	}
+	sort.SliceStable(tiers, func(i, j int) bool {
+		return tiers[i].TotalEstimatedCost > tiers[j].TotalEstimatedCost
+	})
+
+	cheapest := current
+	for _, tier := range tiers {
+		if tier.TotalEstimatedCost >= current.TotalEstimatedCost {
+			continue
+		}
+		if tier.TotalEstimatedCost <= maxCost {
+			LogWithContext().WithField("model", model).
+				WithField("downgraded_model", tier.Model).
+				WithField("estimated_cost", current.TotalEstimatedCost).
+				WithField("downgraded_cost", tier.TotalEstimatedCost).
+				WithField("max_cost_per_doc", maxCost).
+				Warn("Downgrading model to fit --max-cost-per-doc")
+			return tier.Model, nil
+		}
+		cheapest = tier
+	}
+
+	return "", fmt.Errorf("%w: $%.4f with %s is over $%.4f", errDocOverBudget, cheapest.TotalEstimatedCost, cheapest.Model, maxCost)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// budgetPrompt is long enough that model tiers differ in estimated cost
+var budgetPrompt = strings.Repeat("Describe how the billing service settles invoices. ", 200)
+
+// docCost is the estimated cost of a README for budgetPrompt with model
+func docCost(provider, model string) float64 {
+	outputTokens := EstimateOutputTokens("README", EstimateTokens(budgetPrompt))
+	return EstimateCost(provider, model, budgetPrompt, outputTokens).TotalEstimatedCost
+}
+
+func TestEstimateCostPricesEachOpenAIModel(t *testing.T) {
+	if gpt4o, gpt35 := docCost("openai", "gpt-4o"), docCost("openai", "gpt-3.5-turbo"); gpt35 >= gpt4o {
+		t.Errorf("gpt-3.5-turbo estimate $%.4f is not below gpt-4o $%.4f", gpt35, gpt4o)
+	}
+}
+
+func TestFitModelToDocBudget(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		model    string
+		maxCost  float64
+		want     string
+		wantSkip bool
+	}{
+		{name: "disabled", provider: "anthropic", model: "opus-4", maxCost: 0, want: "opus-4"},
+		{name: "fits", provider: "anthropic", model: "opus-4", maxCost: docCost("anthropic", "opus-4"), want: "opus-4"},
+		{name: "anthropic downgrades", provider: "anthropic", model: "opus-4", maxCost: docCost("anthropic", "haiku-3.5"), want: "haiku-3.5"},
+		{name: "openai downgrades", provider: "openai", model: "gpt-4o", maxCost: docCost("openai", "gpt-3.5-turbo"), want: "gpt-3.5-turbo"},
+		{name: "anthropic skips under the cheapest tier", provider: "anthropic", model: "opus-4", maxCost: docCost("anthropic", "haiku-3.5") / 2, wantSkip: true},
+		{name: "openai skips under the cheapest tier", provider: "openai", model: "gpt-4o", maxCost: docCost("openai", "gpt-3.5-turbo") / 2, wantSkip: true},
+		// OpenRouter has a single tier, so there is nothing to downgrade to
+		{name: "openrouter skips", provider: "openrouter", model: "sonnett-4", maxCost: docCost("openrouter", "sonnett-4") / 2, wantSkip: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fitModelToDocBudget(tt.provider, tt.model, budgetPrompt, "README", tt.maxCost)
+			if tt.wantSkip {
+				if !errors.Is(err, errDocOverBudget) {
+					t.Errorf("fitModelToDocBudget = %q, %v; want errDocOverBudget", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("fitModelToDocBudget = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateSkipsDocOverMaxCostPerDoc(t *testing.T) {
+	project, _ := singleServiceProject(t)
+	useModelConfig(t, project, `default:
+  provider: "openai"
+  model: "gpt-4o"
+  max_tokens: 1000
+  temperature: 0.5
+openai:
+  api_key: "test-key"
+  models:
+    gpt-4o: "gpt-4o"
+    gpt-3.5-turbo: "gpt-3.5-turbo"
+`)
+	useCacheFlags(t, true, true)
+	previousDocTypes := updateDocTypes
+	updateDocTypes = []string{"README"}
+	maxCostPerDoc = 0.000001
+	t.Cleanup(func() { updateDocTypes, maxCostPerDoc = previousDocTypes, 0 })
+	provider, _ := useScriptedProvider(t)
+
+	output := captureStdout(t, func() { runUpdate(t, context.Background()) })
+	if calls := provider.calls.Load(); calls != 0 {
+		t.Errorf("provider called %d times for a document over --max-cost-per-doc", calls)
+	}
+	if _, err := os.Stat(filepath.Join(project.Root, "svc", "README.md")); !os.IsNotExist(err) {
+		t.Errorf("README written despite being over budget: %v", err)
+	}
+	if !strings.Contains(output, "skipped 1 over --max-cost-per-doc") {
+		t.Errorf("summary does not report the skip:\n%s", output)
+	}
+}
+
+func TestCallDowngradesToFitMaxCostPerDoc(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		maxCost   float64
+		wantModel string
+	}{
+		{"no cap", 0, "gpt-4o"},
+		// Enough for gpt-3.5-turbo but not gpt-4o
+		{"cap between tiers", (docCost("openai", "gpt-3.5-turbo") + docCost("openai", "gpt-4o")) / 2, "gpt-3.5-turbo"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			project := newTestProject(t, "components: []\n")
+			useModelConfig(t, project, `default:
+  provider: "openai"
+  model: "gpt-4o"
+  max_tokens: 1000
+  temperature: 0.5
+openai:
+  api_key: "test-key"
+  models:
+    gpt-4o: "gpt-4o"
+    gpt-3.5-turbo: "gpt-3.5-turbo"
+`)
+			useCacheFlags(t, true, true)
+			server := newProviderServer(t, http.StatusOK, chatCompletion("# svc README", "stop"))
+			previous := newModelProvider
+			newModelProvider = func(providerName, apiKey string) ModelProvider {
+				return newHTTPProviders(server.URL)[providerName]
+			}
+			t.Cleanup(func() { newModelProvider = previous })
+			maxCostPerDoc = tt.maxCost
+			t.Cleanup(func() { maxCostPerDoc = 0 })
+
+			conversation := []ChatMessage{{Role: "user", Content: budgetPrompt}}
+			if _, err := callModelAPIWithConversation(context.Background(), conversation, "README", "service", ""); err != nil {
+				t.Fatal(err)
+			}
+			requests := server.Requests()
+			if len(requests) != 1 {
+				t.Fatalf("server received %d requests, want 1", len(requests))
+			}
+			if model := requests[0].body["model"]; model != tt.wantModel {
+				t.Errorf("request model = %v, want %s", model, tt.wantModel)
+			}
+		})
+	}
+}
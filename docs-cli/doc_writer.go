@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// diffContextLines is the number of unchanged lines --show-diff prints around each change
+const diffContextLines = 3
+
+// errOverwriteDeclined is returned when the user answers no to a --show-diff confirmation
+var errOverwriteDeclined = errors.New("kept the existing document")
+
+var (
+	// overwriteInput and overwriteOutput carry --show-diff diffs and confirmations
+	overwriteInput  io.Reader = os.Stdin
+	overwriteOutput io.Writer = os.Stdout
+	// confirmOverwrites reports whether to ask before overwriting; only a terminal can answer
+	confirmOverwrites = stdinIsTerminal
+)
+
+// stdinIsTerminal reports whether stdin is a terminal that can answer prompts
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// unifiedDiff renders a unified diff between existing and generated document content
+func unifiedDiff(path, existing, generated string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(existing),
+		B:        difflib.SplitLines(generated),
+		FromFile: path + " (existing)",
+		ToFile:   path + " (generated)",
+		Context:  diffContextLines,
+	})
+}
+
+// confirmOverwrite asks whether to overwrite path; anything but y or yes is a no
+func confirmOverwrite(path string) bool {
+	fmt.Fprintf(overwriteOutput, "Overwrite %s? [y/N]: ", path)
+	answer, err := bufio.NewReader(overwriteInput).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// backupDocument copies an existing document to a timestamped .bak file
+func backupDocument(path string, content []byte) (string, error) {
+	backupPath := fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backupPath, content, 0644); err != nil {
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+	return backupPath, nil
+}
+
+// writeGeneratedDocument writes a generated document over outputPath. With --show-diff the
+// changes to an existing file are printed first and, on a terminal, confirmed, returning
+// errOverwriteDeclined if refused; an unchanged document is not rewritten. With --backup the
+// existing file is copied to a timestamped .bak before it is overwritten.
+func writeGeneratedDocument(outputPath, content string) error {
+	existing, err := os.ReadFile(outputPath)
+	if err == nil {
+		if showDiff {
+			diff, err := unifiedDiff(outputPath, string(existing), content)
+			if err != nil {
+				return fmt.Errorf("failed to diff %s: %w", outputPath, err)
+			}
+			if diff == "" {
+				fmt.Fprintf(overwriteOutput, "📄 No changes for %s\n", outputPath)
+				return nil
+			}
+			fmt.Fprint(overwriteOutput, diff)
+
+			if confirmOverwrites() && !confirmOverwrite(outputPath) {
+				return errOverwriteDeclined
+			}
+		}
+
+		if backup {
+			backupPath, err := backupDocument(outputPath, existing)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(overwriteOutput, "💾 Backed up %s to %s\n", outputPath, backupPath)
+		}
+	}
+
+	err = os.WriteFile(outputPath, []byte(content), 0644)
+	LogFileOperation("write", outputPath, int64(len(content)), err)
+	if err != nil {
+		return fmt.Errorf("failed to write documentation: %w", err)
+	}
+	return nil
+}
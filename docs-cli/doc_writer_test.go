@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useOverwritePrompt answers --show-diff confirmations with answer (or never asks when
+// answer is "") and returns what the writer printed
+func useOverwritePrompt(t *testing.T, answer string) *bytes.Buffer {
+	t.Helper()
+	var output bytes.Buffer
+	previousInput, previousOutput, previousConfirm := overwriteInput, overwriteOutput, confirmOverwrites
+	overwriteInput = strings.NewReader(answer)
+	overwriteOutput = &output
+	confirmOverwrites = func() bool { return answer != "" }
+	t.Cleanup(func() {
+		overwriteInput, overwriteOutput, confirmOverwrites = previousInput, previousOutput, previousConfirm
+	})
+	return &output
+}
+
+func setFlag(t *testing.T, flag *bool, value bool) {
+	t.Helper()
+	previous := *flag
+	*flag = value
+	t.Cleanup(func() { *flag = previous })
+}
+
+func writeExisting(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "README.md")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readString(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestShowDiffPrintsUnifiedDiff(t *testing.T) {
+	setFlag(t, &showDiff, true)
+	output := useOverwritePrompt(t, "")
+	path := writeExisting(t, "# Title\nold line\nsame\n")
+
+	if err := writeGeneratedDocument(path, "# Title\nnew line\nsame\n"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"--- " + path + " (existing)", "+++ " + path + " (generated)", "-old line", "+new line", " same"} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("diff output is missing %q:\n%s", want, output)
+		}
+	}
+	if got := readString(t, path); got != "# Title\nnew line\nsame\n" {
+		t.Errorf("without a terminal the document should be written, got %q", got)
+	}
+}
+
+func TestShowDiffConfirmation(t *testing.T) {
+	tests := []struct {
+		answer  string
+		written bool
+	}{
+		{"y\n", true},
+		{"yes\n", true},
+		{"n\n", false},
+		{"\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.answer), func(t *testing.T) {
+			setFlag(t, &showDiff, true)
+			output := useOverwritePrompt(t, tt.answer)
+			path := writeExisting(t, "old\n")
+
+			err := writeGeneratedDocument(path, "new\n")
+			if tt.written {
+				if err != nil || readString(t, path) != "new\n" {
+					t.Errorf("answer %q: err = %v, content %q; want the document written", tt.answer, err, readString(t, path))
+				}
+			} else if !errors.Is(err, errOverwriteDeclined) || readString(t, path) != "old\n" {
+				t.Errorf("answer %q: err = %v, content %q; want the existing document kept", tt.answer, err, readString(t, path))
+			}
+			if !strings.Contains(output.String(), "Overwrite "+path+"? [y/N]: ") {
+				t.Errorf("no confirmation prompt in output:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestShowDiffUnchangedDocument(t *testing.T) {
+	setFlag(t, &showDiff, true)
+	setFlag(t, &backup, true)
+	output := useOverwritePrompt(t, "n\n")
+	path := writeExisting(t, "same\n")
+
+	if err := writeGeneratedDocument(path, "same\n"); err != nil {
+		t.Fatalf("unchanged document: %v", err)
+	}
+	if !strings.Contains(output.String(), "No changes for "+path) || strings.Contains(output.String(), "Overwrite") {
+		t.Errorf("unchanged document output:\n%s", output)
+	}
+	if backups, _ := filepath.Glob(path + ".*.bak"); len(backups) != 0 {
+		t.Errorf("unchanged document was backed up: %v", backups)
+	}
+}
+
+func TestBackupBeforeOverwrite(t *testing.T) {
+	setFlag(t, &backup, true)
+	useOverwritePrompt(t, "")
+	path := writeExisting(t, "previous version\n")
+
+	if err := writeGeneratedDocument(path, "next version\n"); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := filepath.Glob(path + ".*.bak")
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("backups = %v, %v; want exactly one", backups, err)
+	}
+	if got := readString(t, backups[0]); got != "previous version\n" {
+		t.Errorf("backup holds %q, want the previous version", got)
+	}
+	if got := readString(t, path); got != "next version\n" {
+		t.Errorf("document holds %q, want the new version", got)
+	}
+
+	// A new document has nothing to back up
+	fresh := filepath.Join(filepath.Dir(path), "SETUP.md")
+	if err := writeGeneratedDocument(fresh, "setup\n"); err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ := filepath.Glob(fresh + ".*.bak"); len(backups) != 0 {
+		t.Errorf("new document was backed up: %v", backups)
+	}
+}
+
+func TestRegenerateDocumentKeepsDeclinedDocument(t *testing.T) {
+	project, svc := singleServiceProject(t)
+	setFlag(t, &showDiff, true)
+	useOverwritePrompt(t, "n\n")
+	project.WriteFile("svc/README.md", "hand-written readme\n")
+
+	snapshotManager := NewSnapshotManager()
+	err := regenerate(t, snapshotManager, svc, "README")
+	if !errors.Is(err, errOverwriteDeclined) {
+		t.Fatalf("err = %v, want errOverwriteDeclined", err)
+	}
+	if got := project.ReadFile("svc/README.md"); got != "hand-written readme\n" {
+		t.Errorf("declined document was overwritten with %q", got)
+	}
+	if regenerate, _ := snapshotManager.ShouldRegenerateDoc(svc, "README"); !regenerate {
+		t.Error("a declined document should stay due for regeneration")
+	}
+}
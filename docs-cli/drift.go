@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"docs-cli/pkg/config"
+)
+
+// driftJSON prints the drift report as JSON for CI
+var driftJSON bool
+
+// DriftReport is the drift command's --json output
+type DriftReport struct {
+	Components int        `json:"components"`
+	Modified   int        `json:"modified"`
+	Missing    int        `json:"missing"`
+	Stale      int        `json:"stale"`
+	Docs       []DocDrift `json:"docs"`
+}
+
+func reportDocDrift(cmd *cobra.Command, args []string) {
+	configManager := config.NewConfigManager()
+	if _, err := configManager.LoadConfig(); err != nil {
+		fmt.Printf("❌ Configuration error: %v\n", err)
+		return
+	}
+
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		fmt.Printf("❌ Error opening source: %v\n", err)
+		return
+	}
+	components, err := fileScanner.ScanComponents(projectRoot)
+	if err != nil {
+		fmt.Printf("❌ Error scanning components: %v\n", err)
+		return
+	}
+	components, err = selectComponents(components)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	docTypes := append([]string{"EXECUTIVE_SUMMARY"}, config.DefaultChainOrder...)
+	report := DriftReport{Components: len(components), Docs: NewSnapshotManager().GetDocDrift(components, docTypes)}
+	for _, doc := range report.Docs {
+		switch doc.Status {
+		case DocModified:
+			report.Modified++
+		case DocMissing:
+			report.Missing++
+		}
+		if doc.Stale && doc.Status != DocUntracked {
+			report.Stale++
+		}
+	}
+
+	if driftJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Printf("❌ Failed to encode report: %v\n", err)
+		}
+		return
+	}
+
+	if report.Modified == 0 && report.Missing == 0 && report.Stale == 0 {
+		fmt.Printf("✅ Generated docs for %d components match their snapshots and sources\n", len(components))
+		return
+	}
+
+	fmt.Printf("🔍 %d modified outside docs-cli, %d missing, %d stale relative to source:\n\n", report.Modified, report.Missing, report.Stale)
+	for _, doc := range report.Docs {
+		var notes []string
+		if doc.Status == DocModified || doc.Status == DocMissing {
+			notes = append(notes, doc.Status)
+		}
+		if doc.Stale && doc.Status != DocUntracked {
+			notes = append(notes, "stale: "+strings.Join(doc.StaleReasons, ", "))
+		}
+		if len(notes) == 0 {
+			continue
+		}
+		fmt.Printf("• %s/%s (%s)\n", doc.Component, doc.DocType, doc.Path)
+		fmt.Printf("  %s\n", strings.Join(notes, "; "))
+	}
+}
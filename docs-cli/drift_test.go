@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// docDrift returns the drift report of the project's components for README and SETUP,
+// read back from the snapshots on disk, keyed by doc type
+func docDrift(t *testing.T, project *testProject) map[string]DocDrift {
+	t.Helper()
+	byDocType := map[string]DocDrift{}
+	for _, doc := range NewSnapshotManager().GetDocDrift(project.Components(), []string{"README", "SETUP"}) {
+		byDocType[doc.DocType] = doc
+	}
+	return byDocType
+}
+
+// generatedServiceProject is singleServiceProject with its README generated and snapshotted
+func generatedServiceProject(t *testing.T) *testProject {
+	t.Helper()
+	project, svc := singleServiceProject(t)
+	snapshotManager := NewSnapshotManager()
+	if err := regenerate(t, snapshotManager, svc, "README"); err != nil {
+		t.Fatal(err)
+	}
+	snapshotManager.Flush()
+	return project
+}
+
+func TestDocDriftMatchingHash(t *testing.T) {
+	project := generatedServiceProject(t)
+
+	drift := docDrift(t, project)
+	readme, ok := drift["README"]
+	if !ok || readme.Status != DocUnchanged || readme.Stale {
+		t.Errorf("README drift = %+v, want unchanged and not stale", readme)
+	}
+	if _, ok := drift["SETUP"]; ok {
+		t.Errorf("SETUP was never generated nor written but is reported: %+v", drift["SETUP"])
+	}
+}
+
+func TestDocDriftDriftedHash(t *testing.T) {
+	tests := []struct {
+		name       string
+		change     func(project *testProject)
+		wantDoc    string
+		wantStatus string
+		wantStale  bool
+	}{
+		{
+			name:       "edited by hand",
+			change:     func(project *testProject) { project.WriteFile("svc/README.md", "# svc\n\nEdited by hand.\n") },
+			wantDoc:    "README",
+			wantStatus: DocModified,
+		},
+		{
+			name: "deleted",
+			change: func(project *testProject) {
+				if err := os.Remove(filepath.Join(project.Root, "svc", "README.md")); err != nil {
+					project.t.Fatal(err)
+				}
+			},
+			wantDoc:    "README",
+			wantStatus: DocMissing,
+		},
+		{
+			name:       "written outside docs-cli",
+			change:     func(project *testProject) { project.WriteFile("svc/docs/SETUP.md", "# Setup\n") },
+			wantDoc:    "SETUP",
+			wantStatus: DocUntracked,
+		},
+		{
+			name: "source changed",
+			change: func(project *testProject) {
+				project.WriteFile("svc/main.go", "package main\n\nfunc main() { run() }\n")
+			},
+			wantDoc:    "README",
+			wantStatus: DocUnchanged,
+			wantStale:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := generatedServiceProject(t)
+			tt.change(project)
+
+			doc, ok := docDrift(t, project)[tt.wantDoc]
+			if !ok || doc.Status != tt.wantStatus || doc.Stale != tt.wantStale {
+				t.Fatalf("%s drift = %+v, want status %s and stale %t", tt.wantDoc, doc, tt.wantStatus, tt.wantStale)
+			}
+			if tt.wantStale && (len(doc.StaleReasons) != 1 || !strings.HasPrefix(doc.StaleReasons[0], "modified file: ")) {
+				t.Errorf("stale reasons = %q, want the modified source file only", doc.StaleReasons)
+			}
+		})
+	}
+}
+
+func TestDriftCommandJSON(t *testing.T) {
+	project := generatedServiceProject(t)
+	driftJSON = true
+	t.Cleanup(func() { driftJSON = false })
+
+	var report DriftReport
+	decode := func() {
+		t.Helper()
+		output := captureStdout(t, func() { runCommand(t, context.Background(), reportDocDrift) })
+		if err := json.Unmarshal([]byte(output), &report); err != nil {
+			t.Fatalf("drift --json printed %q: %v", output, err)
+		}
+	}
+
+	decode()
+	if report.Components != 1 || report.Modified != 0 || report.Missing != 0 || report.Stale != 0 {
+		t.Errorf("report for freshly generated docs = %+v, want no drift", report)
+	}
+
+	project.WriteFile("svc/README.md", "# svc\n\nEdited by hand.\n")
+	decode()
+	if report.Modified != 1 || report.Missing != 0 || report.Stale != 0 {
+		t.Errorf("report after editing README = %+v, want 1 modified", report)
+	}
+}
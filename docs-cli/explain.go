@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"docs-cli/pkg/config"
+)
+
+// PromptExplanation describes exactly what would be sent to the model for one document
+type PromptExplanation struct {
+	Component      string       `json:"component"`
+	DocType        string       `json:"doc_type"`
+	Provider       string       `json:"provider"`
+	Model          string       `json:"model"`
+	ModelID        string       `json:"model_id"`
+	OriginalTokens int          `json:"original_tokens"`
+	Prompt         string       `json:"prompt"`
+	CostEstimate   CostEstimate `json:"cost_estimate"`
+}
+
+// ExplainPrompt builds the final prompt for a component and document type without calling the API
+func ExplainPrompt(docType, componentName string) (PromptExplanation, error) {
+	configManager := config.NewConfigManager()
+	if _, err := configManager.LoadConfig(); err != nil {
+		return PromptExplanation{}, fmt.Errorf("configuration error: %w", err)
+	}
+
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		return PromptExplanation{}, fmt.Errorf("error opening source: %w", err)
+	}
+	component, err := findComponentByName(fileScanner, componentName)
+	if err != nil {
+		return PromptExplanation{}, err
+	}
+
+	messages, err := BuildConversation(configManager, fileScanner, component, docType)
+	if err != nil {
+		return PromptExplanation{}, fmt.Errorf("failed to build prompt: %w", err)
+	}
+
+	settings, err := getModelSettingsForDocType(docType)
+	if err != nil {
+		return PromptExplanation{}, fmt.Errorf("error getting model settings: %w", err)
+	}
+
+	modelCfg, err := loadModelConfig()
+	if err != nil {
+		return PromptExplanation{}, fmt.Errorf("error loading model config: %w", err)
+	}
+
+	// Mirror callModelAPIWithConversation so the explanation matches what would be sent
+	optimizedMessages, optimalModel, costEstimate := optimizeConversation(messages, docType, component.Type, settings.Provider)
+	if optimalModel != "" {
+		settings.Model = optimalModel
+	}
+
+	return PromptExplanation{
+		Component:      component.Name,
+		DocType:        docType,
+		Provider:       settings.Provider,
+		Model:          settings.Model,
+		ModelID:        resolveModelID(modelCfg, settings.Provider, settings.Model),
+		OriginalTokens: EstimateTokens(flattenConversation(messages)),
+		Prompt:         flattenConversation(optimizedMessages),
+		CostEstimate:   costEstimate,
+	}, nil
+}
+
+// printPromptExplanation prints the explanation summary and writes the prompt to stdout or outputPath
+func printPromptExplanation(explanation PromptExplanation, outputPath string) error {
+	fmt.Printf("🔍 Prompt for %s/%s (no API call made)\n", explanation.Component, explanation.DocType)
+	fmt.Printf("  Provider: %s\n", explanation.Provider)
+	fmt.Printf("  Model: %s (%s)\n", explanation.Model, explanation.ModelID)
+	fmt.Printf("  Input tokens: %d (before compression: %d)\n", explanation.CostEstimate.InputTokens, explanation.OriginalTokens)
+	fmt.Printf("  Estimated output tokens: %d\n", explanation.CostEstimate.EstimatedOutputTokens)
+	fmt.Printf("  Estimated cost: $%.4f\n", explanation.CostEstimate.TotalEstimatedCost)
+
+	if outputPath != "" {
+		if err := os.WriteFile(outputPath, []byte(explanation.Prompt), 0644); err != nil {
+			return fmt.Errorf("failed to write prompt: %w", err)
+		}
+		fmt.Printf("📝 Prompt written to %s\n", outputPath)
+		return nil
+	}
+
+	fmt.Println("\n=== PROMPT ===")
+	fmt.Println(explanation.Prompt)
+	fmt.Println("=== END PROMPT ===")
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"docs-cli/pkg/config"
+)
+
+// conversationRecorder answers through the offline MockProvider and keeps the messages of
+// the last chat request it received
+type conversationRecorder struct {
+	mock *MockProvider
+
+	mu   sync.Mutex
+	last []ChatMessage
+}
+
+func (r *conversationRecorder) CallModel(ctx context.Context, prompt, model string, maxTokens int, temperature float64) (string, error) {
+	return r.CallChat(ctx, ChatRequest{Model: model, Messages: []ChatMessage{{Role: "user", Content: prompt}}, MaxTokens: maxTokens, Temperature: temperature})
+}
+
+func (r *conversationRecorder) CallChat(ctx context.Context, request ChatRequest) (string, error) {
+	r.mu.Lock()
+	r.last = request.Messages
+	r.mu.Unlock()
+	return r.mock.CallChat(ctx, request)
+}
+
+func TestExplainMatchesTheConversationSent(t *testing.T) {
+	project, svc := singleServiceProject(t)
+	useCacheFlags(t, true, true)
+	recorder := &conversationRecorder{mock: NewMockProvider(config.GetConfig().Providers.Mock)}
+	previous := newModelProvider
+	newModelProvider = func(providerName, apiKey string) ModelProvider { return recorder }
+	t.Cleanup(func() { newModelProvider = previous })
+
+	// An existing document becomes an earlier turn, which is sent as written
+	architecture := docOutputPath(svc, "ARCHITECTURE")
+	if err := os.MkdirAll(filepath.Dir(architecture), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(architecture, []byte(compressionSample), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc = project.Component("svc")
+
+	explanation, err := ExplainPrompt("README", "svc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := regenerate(t, NewSnapshotManager(), svc, "README"); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder.mu.Lock()
+	sent := flattenConversation(recorder.last)
+	recorder.mu.Unlock()
+	if explanation.Prompt != sent {
+		t.Errorf("explained prompt differs from the conversation sent:\n--- explained\n%s\n--- sent\n%s", explanation.Prompt, sent)
+	}
+	if !strings.Contains(explanation.Prompt, compressionSample) {
+		t.Errorf("explained prompt compressed the earlier turns:\n%s", explanation.Prompt)
+	}
+	if len(recorder.last) < 3 {
+		t.Errorf("conversation sent as %d turns, want the existing document as an earlier turn", len(recorder.last))
+	}
+
+	// The TUI prices a document the way --explain does
+	configManager := config.NewConfigManager()
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Price the README as --explain saw it, before it existed
+	if err := os.Remove(docOutputPath(svc, "README")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := estimateDocumentCost(configManager, fileScanner, svc, "README"), explanation.CostEstimate.TotalEstimatedCost; got != want {
+		t.Errorf("TUI estimate $%.6f, want the --explain estimate $%.6f", got, want)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"docs-cli/pkg/config"
+	"docs-cli/pkg/scanner"
+)
+
+// exportPromptsOut is the export-prompts output file, or "-" for stdout
+var exportPromptsOut string
+
+// PromptExample is one line of the export-prompts JSONL dataset
+type PromptExample struct {
+	Prompt     string         `json:"prompt"`
+	Completion string         `json:"completion"`
+	Metadata   PromptMetadata `json:"metadata"`
+}
+
+// PromptMetadata identifies where a dataset example came from
+type PromptMetadata struct {
+	Component        string `json:"component"`
+	ComponentType    string `json:"component_type"`
+	DocType          string `json:"doc_type"`
+	DocPath          string `json:"doc_path"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+}
+
+// promptExportStats counts what export-prompts wrote and why it skipped the rest
+type promptExportStats struct {
+	exported    int
+	missingDocs int
+	invalid     int
+}
+
+func exportPrompts(cmd *cobra.Command, args []string) {
+	configManager := config.NewConfigManager()
+	if _, err := configManager.LoadConfig(); err != nil {
+		fmt.Printf("❌ Configuration error: %v\n", err)
+		return
+	}
+
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		fmt.Printf("❌ Error opening source: %v\n", err)
+		return
+	}
+	components, err := fileScanner.ScanComponents(projectRoot)
+	if err != nil {
+		fmt.Printf("❌ Error scanning components: %v\n", err)
+		return
+	}
+	components, err = selectComponents(components)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	out := io.Writer(os.Stdout)
+	if exportPromptsOut != "-" {
+		file, err := os.Create(exportPromptsOut)
+		if err != nil {
+			fmt.Printf("❌ Failed to create %s: %v\n", exportPromptsOut, err)
+			return
+		}
+		defer file.Close()
+		out = file
+	}
+
+	stats, err := writePromptDataset(out, configManager, fileScanner, components, chainOrder())
+	if err != nil {
+		fmt.Printf("❌ Failed to write dataset: %v\n", err)
+		return
+	}
+	if exportPromptsOut != "-" {
+		fmt.Printf("✅ Exported %d prompt/completion pairs to %s (%d documents not generated yet, %d prompts rejected by validation)\n",
+			stats.exported, exportPromptsOut, stats.missingDocs, stats.invalid)
+	}
+}
+
+// writePromptDataset writes one JSONL example per component and doc type whose document
+// exists on disk. Prompts are built as for generation but without the document itself,
+// either as existing content or as a source file, so the completion is never part of its
+// prompt. Secrets are redacted from both, and prompts that fail validation, including the
+// length limit, are skipped.
+func writePromptDataset(w io.Writer, configManager config.ConfigManager, fileScanner scanner.FileScanner, components []scanner.Component, docTypes []string) (promptExportStats, error) {
+	var stats promptExportStats
+	encoder := json.NewEncoder(w)
+	for _, component := range components {
+		for _, docType := range docTypes {
+			docPath := docOutputPath(component, docType)
+			completion, err := os.ReadFile(docPath)
+			if err != nil {
+				stats.missingDocs++
+				continue
+			}
+
+			source := withoutFile(component, docPath)
+			prompt, err := renderPrompt(configManager, fileScanner, source, docType, buildConversationContext(component, docType), "", buildSourceContext(fileScanner, source))
+			if err == nil {
+				err = ValidateInput(prompt, "prompt")
+			}
+			if err != nil {
+				LogWithContext().WithError(err).
+					WithField("component", component.Key()).
+					WithField("doc_type", docType).
+					Warn("Skipping prompt in dataset export")
+				stats.invalid++
+				continue
+			}
+
+			example := PromptExample{
+				Prompt:     RedactSecrets(prompt),
+				Completion: RedactSecrets(string(completion)),
+				Metadata: PromptMetadata{
+					Component:     component.Key(),
+					ComponentType: component.Type,
+					DocType:       docType,
+					DocPath:       docPath,
+				},
+			}
+			example.Metadata.PromptTokens = EstimateTokens(example.Prompt)
+			example.Metadata.CompletionTokens = EstimateTokens(example.Completion)
+			if err := encoder.Encode(example); err != nil {
+				return stats, err
+			}
+			stats.exported++
+		}
+	}
+	return stats, nil
+}
+
+// withoutFile returns component with path dropped from its source files
+func withoutFile(component scanner.Component, path string) scanner.Component {
+	files := make([]string, 0, len(component.Files))
+	for _, file := range component.Files {
+		if filepath.Clean(file) != filepath.Clean(path) {
+			files = append(files, file)
+		}
+	}
+	component.Files = files
+	return component
+}
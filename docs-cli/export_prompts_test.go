@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// datasetProject has two components with some documents generated: api's README, which
+// leaks a key, and SETUP; web's ARCHITECTURE, too long to fit in any other web prompt, and
+// its README
+func datasetProject(t *testing.T) *testProject {
+	t.Helper()
+	project := newTestProject(t, `components:
+  - name: "api"
+    path: "api"
+    type: "service"
+  - name: "web"
+    path: "web"
+    type: "frontend"
+`)
+	project.WriteFile("api/main.go", "package main\n\n// API-SOURCE-MARKER\nconst upstreamKey = \""+fakeAnthropicKey+"\"\n\nfunc main() {}\n")
+	project.WriteFile("api/README.md", "# api\n\nAPI-README-MARKER. Export OPENAI_API_KEY="+fakeOpenAIKey+" first.\n")
+	project.WriteFile("api/docs/SETUP.md", "# Setup\n\nAPI-SETUP-MARKER\n")
+	project.WriteFile("web/main.go", "package main\n\nfunc main() {}\n")
+	project.WriteFile("web/docs/ARCHITECTURE.md", "# Architecture\n\n"+strings.Repeat("web architecture ", MaxPromptLength/16))
+	project.WriteFile("web/README.md", "# web\n")
+	return project
+}
+
+// readDataset decodes a JSONL dataset, keyed by component/docType
+func readDataset(t *testing.T, path string) map[string]PromptExample {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	examples := map[string]PromptExample{}
+	lines := bufio.NewScanner(file)
+	lines.Buffer(nil, 4*MaxPromptLength)
+	for lines.Scan() {
+		var example PromptExample
+		if err := json.Unmarshal(lines.Bytes(), &example); err != nil {
+			t.Fatalf("dataset line is not an example: %v", err)
+		}
+		examples[example.Metadata.Component+"/"+example.Metadata.DocType] = example
+	}
+	if err := lines.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return examples
+}
+
+func TestExportPromptsSmallDataset(t *testing.T) {
+	project := datasetProject(t)
+	out := filepath.Join(t.TempDir(), "dataset.jsonl")
+	previous := exportPromptsOut
+	exportPromptsOut = out
+	t.Cleanup(func() { exportPromptsOut = previous })
+
+	output := captureStdout(t, func() { runCommand(t, context.Background(), exportPrompts) })
+
+	missing := 2*len(chainOrder()) - 4
+	want := fmt.Sprintf("Exported 3 prompt/completion pairs to %s (%d documents not generated yet, 1 prompts rejected by validation)", out, missing)
+	if !strings.Contains(output, want) {
+		t.Errorf("output does not report %q:\n%s", want, output)
+	}
+
+	examples := readDataset(t, out)
+	if len(examples) != 3 {
+		t.Fatalf("dataset has %d examples, want 3", len(examples))
+	}
+	for _, key := range []string{"api/README", "api/SETUP", "web/ARCHITECTURE"} {
+		if _, ok := examples[key]; !ok {
+			t.Errorf("dataset is missing %s", key)
+		}
+	}
+	if _, ok := examples["web/README"]; ok {
+		t.Error("web/README's prompt is over the length limit but was exported")
+	}
+
+	readme := examples["api/README"]
+	if !strings.Contains(readme.Completion, "API-README-MARKER") {
+		t.Errorf("api/README completion = %q, want the document on disk", readme.Completion)
+	}
+	if !strings.Contains(readme.Prompt, "API-SOURCE-MARKER") || !strings.Contains(readme.Prompt, "API-SETUP-MARKER") {
+		t.Error("api/README prompt should carry the source and the other generated documents")
+	}
+	if strings.Contains(readme.Prompt, "API-README-MARKER") {
+		t.Error("api/README prompt contains its own completion")
+	}
+	for key, example := range examples {
+		for _, secret := range []string{fakeAnthropicKey, fakeOpenAIKey} {
+			if strings.Contains(example.Prompt, secret) || strings.Contains(example.Completion, secret) {
+				t.Errorf("%s leaks a key", key)
+			}
+		}
+		if len(example.Prompt) > MaxPromptLength {
+			t.Errorf("%s prompt is %d bytes, over the limit", key, len(example.Prompt))
+		}
+		if example.Metadata.PromptTokens == 0 || example.Metadata.CompletionTokens == 0 || example.Metadata.DocPath == "" {
+			t.Errorf("%s metadata = %+v, want token counts and the document path", key, example.Metadata)
+		}
+	}
+	if !strings.Contains(readme.Completion, redactedPlaceholder) || !strings.Contains(examples["api/SETUP"].Prompt, redactedPlaceholder) {
+		t.Error("secrets should be replaced with the redaction placeholder in prompts and completions")
+	}
+	if readme.Metadata.ComponentType != "service" || examples["web/ARCHITECTURE"].Metadata.ComponentType != "frontend" {
+		t.Errorf("component types = %q and %q", readme.Metadata.ComponentType, examples["web/ARCHITECTURE"].Metadata.ComponentType)
+	}
+	if got := project.ReadFile("api/README.md"); !strings.Contains(got, fakeOpenAIKey) {
+		t.Error("export-prompts rewrote a document on disk")
+	}
+}
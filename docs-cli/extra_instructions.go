@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	// promptFile and promptText hold one-off instructions appended to every prompt in the run
+	promptFile string
+	promptText string
+	// extraInstructions is the validated --prompt-file content followed by --prompt-text
+	extraInstructions string
+)
+
+// loadExtraInstructions reads --prompt-file and --prompt-text and validates the result like
+// any other prompt input, so a bad file fails the run before any API call
+func loadExtraInstructions() error {
+	var parts []string
+	if promptFile != "" {
+		if err := validateFilePath(promptFile); err != nil {
+			return fmt.Errorf("--prompt-file: %w", err)
+		}
+		content, err := os.ReadFile(promptFile)
+		if err != nil {
+			return fmt.Errorf("--prompt-file: %w", err)
+		}
+		parts = append(parts, strings.TrimSpace(string(content)))
+	}
+	if text := strings.TrimSpace(promptText); text != "" {
+		parts = append(parts, text)
+	}
+
+	extraInstructions = strings.TrimSpace(strings.Join(parts, "\n\n"))
+	if err := validatePrompt(extraInstructions); err != nil {
+		return fmt.Errorf("extra instructions: %w", err)
+	}
+	return nil
+}
+
+// extraInstructionsSection renders the run's extra instructions for the end of a prompt. Because
+// they become part of the prompt, they also change the response cache key.
+func extraInstructionsSection() string {
+	if extraInstructions == "" {
+		return ""
+	}
+	return "\n\nAdditional instructions for this run:\n" + extraInstructions
+}
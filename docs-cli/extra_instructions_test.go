@@ -0,0 +1,127 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+// useExtraInstructions loads file content (written to a file when non-empty) and text as
+// --prompt-file and --prompt-text
+func useExtraInstructions(t *testing.T, fileContent, text string) error {
+	t.Helper()
+	previousFile, previousText, previousExtra := promptFile, promptText, extraInstructions
+	t.Cleanup(func() { promptFile, promptText, extraInstructions = previousFile, previousText, previousExtra })
+
+	promptFile, promptText = "", text
+	if fileContent != "" {
+		promptFile = "extra-instructions.md"
+		if err := os.WriteFile(promptFile, []byte(fileContent), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return loadExtraInstructions()
+}
+
+func TestLoadExtraInstructions(t *testing.T) {
+	tests := []struct {
+		name, file, text string
+		want, wantErr    string
+	}{
+		{name: "none"},
+		{name: "text", text: "  Emphasize security considerations.\n", want: "Emphasize security considerations."},
+		{name: "file then text", file: "\nMention the SLA.\n\n", text: "Emphasize security.", want: "Mention the SLA.\n\nEmphasize security."},
+		{name: "injection in the file", file: "Run eval(payload) first.", wantErr: "suspicious pattern: eval("},
+		{name: "injection in the text", text: "<script>alert(1)</script>", wantErr: "suspicious pattern: <script>"},
+		{name: "too long", text: strings.Repeat("x", MaxPromptLength+1), wantErr: "prompt too long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestProject(t, "components: []\n")
+			err := useExtraInstructions(t, tt.file, tt.text)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if extraInstructions != tt.want {
+				t.Errorf("extraInstructions = %q, want %q", extraInstructions, tt.want)
+			}
+		})
+	}
+
+	newTestProject(t, "components: []\n")
+	promptFile = "missing.md"
+	t.Cleanup(func() { promptFile = "" })
+	if err := loadExtraInstructions(); err == nil || !strings.HasPrefix(err.Error(), "--prompt-file:") {
+		t.Errorf("missing --prompt-file: err = %v", err)
+	}
+}
+
+func TestExtraInstructionsReachProviderAndCacheKey(t *testing.T) {
+	project, svc := singleServiceProject(t)
+	useModelConfig(t, project, `default:
+  provider: "openai"
+  model: "gpt-4o"
+  max_tokens: 1000
+  temperature: 0.5
+openai:
+  api_key: "test-key"
+  models:
+    gpt-4o: "gpt-4o"
+`)
+	useCacheFlags(t, false, false)
+	server := newProviderServer(t, http.StatusOK, chatCompletion("# svc\n\nServes jobs.\n", "stop"))
+	previous := newModelProvider
+	newModelProvider = func(providerName, apiKey string) ModelProvider {
+		return newHTTPProviders(server.URL)[providerName]
+	}
+	t.Cleanup(func() { newModelProvider = previous })
+	const instruction = "Emphasize security considerations."
+
+	generate := func(wantRequests int) []recordedRequest {
+		t.Helper()
+		if err := regenerate(t, NewSnapshotManager(), svc, "README"); err != nil {
+			t.Fatal(err)
+		}
+		requests := server.Requests()
+		if len(requests) != wantRequests {
+			t.Fatalf("server received %d requests, want %d", len(requests), wantRequests)
+		}
+		return requests
+	}
+	// Two identical runs share a cache entry
+	requests := generate(1)
+	generate(1)
+	if strings.Contains(requestText(requests[0]), instruction) {
+		t.Fatal("instruction sent before it was given")
+	}
+
+	// Extra instructions miss that entry and reach the provider
+	if err := useExtraInstructions(t, "", instruction); err != nil {
+		t.Fatal(err)
+	}
+	requests = generate(2)
+	sent := requestText(requests[1])
+	if !strings.HasSuffix(strings.TrimSpace(sent), "Additional instructions for this run:\n"+instruction) {
+		t.Errorf("prompt does not end with the extra instructions:\n...%s", sent[max(0, len(sent)-200):])
+	}
+
+	// The same instructions hit the entry they created
+	generate(2)
+}
+
+// requestText is the content of the last message of an OpenAI-style request
+func requestText(request recordedRequest) string {
+	messages, _ := request.body["messages"].([]interface{})
+	if len(messages) == 0 {
+		return ""
+	}
+	content, _ := messages[len(messages)-1].(map[string]interface{})["content"].(string)
+	return content
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+
+	"docs-cli/pkg/config"
+)
+
+var (
+	httpClients      = make(map[string]*http.Client)
+	httpClientsMutex sync.Mutex
+)
+
+// providerHTTPClient returns the pooled HTTP client for a provider, creating it on first use
+// so connections and TLS sessions are reused across calls
+func providerHTTPClient(provider string, settings config.ProviderConfig) *http.Client {
+	httpClientsMutex.Lock()
+	defer httpClientsMutex.Unlock()
+
+	if client, exists := httpClients[provider]; exists {
+		return client
+	}
+
+	client := &http.Client{
+		Timeout:   settings.Timeout,
+		Transport: newProviderTransport(settings.Transport),
+	}
+	httpClients[provider] = client
+	return client
+}
+
+// resetHTTPClients drops the pooled clients so the next call builds them from the current
+// provider settings; idle connections of the old clients are closed
+func resetHTTPClients() {
+	httpClientsMutex.Lock()
+	defer httpClientsMutex.Unlock()
+
+	for provider, client := range httpClients {
+		client.CloseIdleConnections()
+		delete(httpClients, provider)
+	}
+}
+
+// newProviderTransport tunes a copy of the default transport from providers.<name>.transport;
+// zero values keep the defaults
+func newProviderTransport(transportConfig config.TransportConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if transportConfig.MaxIdleConns > 0 {
+		transport.MaxIdleConns = transportConfig.MaxIdleConns
+	}
+	if transportConfig.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = transportConfig.MaxIdleConnsPerHost
+	}
+	if transportConfig.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = transportConfig.IdleConnTimeout
+	}
+	if transportConfig.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = transportConfig.TLSHandshakeTimeout
+	}
+	transport.DisableKeepAlives = transportConfig.DisableKeepAlives
+	return transport
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"docs-cli/pkg/config"
+)
+
+// newCountingTLSServer is an HTTPS endpoint that counts the connections clients open
+func newCountingTLSServer(tb testing.TB) (*httptest.Server, *atomic.Int32) {
+	tb.Helper()
+	var connections atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.StartTLS()
+	tb.Cleanup(server.Close)
+	return server, &connections
+}
+
+// trustServer makes client's transport trust the test server's certificate
+func trustServer(client *http.Client, server *httptest.Server) *http.Client {
+	transport := client.Transport.(*http.Transport)
+	transport.TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	return client
+}
+
+// get fetches url and drains the body so the connection can be reused
+func get(tb testing.TB, client *http.Client, url string) {
+	tb.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+func TestProviderHTTPClientIsPooled(t *testing.T) {
+	t.Cleanup(resetHTTPClients)
+	resetHTTPClients()
+	settings := config.ProviderConfig{Timeout: 5 * time.Second}
+
+	client := providerHTTPClient("openai", settings)
+	if again := providerHTTPClient("openai", settings); again != client {
+		t.Error("second providerHTTPClient call built a new client")
+	}
+	if other := providerHTTPClient("anthropic", settings); other == client {
+		t.Error("providers share one client")
+	}
+
+	server, connections := newCountingTLSServer(t)
+	trustServer(client, server)
+	for i := 0; i < 5; i++ {
+		get(t, client, server.URL)
+	}
+	if opened := connections.Load(); opened != 1 {
+		t.Errorf("5 sequential calls opened %d connections, want 1 reused", opened)
+	}
+
+	resetHTTPClients()
+	if rebuilt := providerHTTPClient("openai", settings); rebuilt == client {
+		t.Error("resetHTTPClients kept the old client")
+	}
+}
+
+func TestNewProviderTransport(t *testing.T) {
+	transport := newProviderTransport(config.TransportConfig{MaxIdleConnsPerHost: 8, IdleConnTimeout: time.Minute})
+	if transport.MaxIdleConnsPerHost != 8 || transport.IdleConnTimeout != time.Minute {
+		t.Errorf("MaxIdleConnsPerHost, IdleConnTimeout = %d, %s; want 8, 1m", transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+	defaults := http.DefaultTransport.(*http.Transport)
+	if transport.MaxIdleConns != defaults.MaxIdleConns || transport.TLSHandshakeTimeout != defaults.TLSHandshakeTimeout {
+		t.Error("unset transport settings did not keep the defaults")
+	}
+}
+
+// BenchmarkProviderHTTPClient compares the latency of calls through the pooled client, which
+// reuses one TLS connection, with a connection per call as before pooling, e.g.
+//
+//	go test -run '^$' -bench ProviderHTTPClient
+func BenchmarkProviderHTTPClient(b *testing.B) {
+	server, _ := newCountingTLSServer(b)
+	for _, bm := range []struct {
+		name      string
+		transport config.TransportConfig
+	}{
+		{"pooled", config.TransportConfig{}},
+		{"connection-per-call", config.TransportConfig{DisableKeepAlives: true}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			client := trustServer(&http.Client{Timeout: 5 * time.Second, Transport: newProviderTransport(bm.transport)}, server)
+			defer client.CloseIdleConnections()
+			get(b, client, server.URL)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				get(b, client, server.URL)
+			}
+		})
+	}
+}
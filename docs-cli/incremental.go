@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"docs-cli/pkg/config"
@@ -24,9 +26,11 @@ type ComponentSnapshot struct {
 	TotalSize     int64             `json:"total_size"`
 }
 
-// SnapshotManager manages component snapshots for incremental updates
+// SnapshotManager manages component snapshots for incremental updates. It is safe for
+// concurrent use: workers update snapshots while shutdown may flush them.
 type SnapshotManager struct {
 	snapshotsPath string
+	mutex         sync.Mutex
 	snapshots     map[string]ComponentSnapshot
 }
 
@@ -62,7 +66,15 @@ func (sm *SnapshotManager) loadSnapshots() {
 	LogWithContext().WithField("snapshot_count", len(snapshots)).Info("Loaded component snapshots")
 }
 
-// saveSnapshots saves current snapshots to disk
+// snapshot returns the recorded snapshot for a component key
+func (sm *SnapshotManager) snapshot(key string) (ComponentSnapshot, bool) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	snapshot, exists := sm.snapshots[key]
+	return snapshot, exists
+}
+
+// saveSnapshots saves current snapshots to disk; callers must hold the lock
 func (sm *SnapshotManager) saveSnapshots() error {
 	data, err := json.MarshalIndent(sm.snapshots, "", "  ")
 	if err != nil {
@@ -76,6 +88,16 @@ func (sm *SnapshotManager) saveSnapshots() error {
 	return nil
 }
 
+// Flush writes the current snapshots to disk, logging rather than returning failures
+// so it can run as shutdown cleanup
+func (sm *SnapshotManager) Flush() {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	if err := sm.saveSnapshots(); err != nil {
+		LogWithContext().WithError(err).Warn("Failed to flush snapshots")
+	}
+}
+
 // CreateSnapshot creates a snapshot of the current component state
 func (sm *SnapshotManager) CreateSnapshot(component scanner.Component) ComponentSnapshot {
 	snapshot := ComponentSnapshot{
@@ -90,11 +112,10 @@ func (sm *SnapshotManager) CreateSnapshot(component scanner.Component) Component
 	// Calculate file hashes
 	var totalSize int64
 	for _, filePath := range component.Files {
-		fullPath := filepath.Join(projectRoot, filePath)
-		if content, err := MemoryAwareFileReader(fullPath); err == nil {
-			hash := fmt.Sprintf("%x", md5.Sum(content))
+		// Scanned file paths are already resolved against the component's workspace root
+		if hash, size, err := MemoryAwareFileHasher(filePath); err == nil {
 			snapshot.FileHashes[filePath] = hash
-			totalSize += int64(len(content))
+			totalSize += size
 		} else {
 			LogWithContext().WithError(err).WithField("file", filePath).Warn("Failed to hash file")
 		}
@@ -106,7 +127,7 @@ func (sm *SnapshotManager) CreateSnapshot(component scanner.Component) Component
 
 // HasComponentChanged checks if a component has changed since the last snapshot
 func (sm *SnapshotManager) HasComponentChanged(component scanner.Component) (bool, []string) {
-	lastSnapshot, exists := sm.snapshots[component.Name]
+	lastSnapshot, exists := sm.snapshot(component.Key())
 	if !exists {
 		return true, []string{"component never documented"}
 	}
@@ -148,26 +169,17 @@ func (sm *SnapshotManager) ShouldRegenerateDoc(component scanner.Component, docT
 	}
 	
 	// Check if this document type was never generated
-	lastSnapshot, exists := sm.snapshots[component.Name]
+	lastSnapshot, exists := sm.snapshot(component.Key())
 	if !exists {
 		return true, "no previous snapshot"
 	}
 	
-	if _, docExists := lastSnapshot.DocsGenerated[docType]; !docExists {
+	if _, docExists := lastSnapshot.DocsGenerated[localizedDocType(docType, docLanguage)]; !docExists {
 		return true, "document type never generated"
 	}
 	
 	// Check if the existing documentation file is missing
-	var docPath string
-	if docType == "README" {
-		docPath = filepath.Join(projectRoot, component.Path, "README.md")
-	} else if docType == "CHECKLIST" {
-		docPath = filepath.Join(projectRoot, component.Path, "docs", "CHECKLIST.yaml")
-	} else {
-		docPath = filepath.Join(projectRoot, component.Path, "docs", docType+".md")
-	}
-	
-	if _, err := os.Stat(docPath); os.IsNotExist(err) {
+	if _, err := os.Stat(docOutputPath(component, docType)); os.IsNotExist(err) {
 		return true, "documentation file missing"
 	}
 	
@@ -179,24 +191,29 @@ func (sm *SnapshotManager) UpdateSnapshot(component scanner.Component, docType,
 	snapshot := sm.CreateSnapshot(component)
 	
 	// Store hash of generated content
+	// Docs generated in different --lang languages are tracked separately
+	docKey := localizedDocType(docType, docLanguage)
 	contentHash := fmt.Sprintf("%x", md5.Sum([]byte(generatedContent)))
-	snapshot.DocsGenerated[docType] = contentHash
+	snapshot.DocsGenerated[docKey] = contentHash
+	
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
 	
 	// Merge with existing docs generated
-	if existingSnapshot, exists := sm.snapshots[component.Name]; exists {
+	if existingSnapshot, exists := sm.snapshots[component.Key()]; exists {
 		for existingDocType, existingHash := range existingSnapshot.DocsGenerated {
-			if existingDocType != docType {
+			if existingDocType != docKey {
 				snapshot.DocsGenerated[existingDocType] = existingHash
 			}
 		}
 	}
 	
-	sm.snapshots[component.Name] = snapshot
+	sm.snapshots[component.Key()] = snapshot
 	
 	if err := sm.saveSnapshots(); err != nil {
 		LogWithContext().WithError(err).Warn("Failed to save updated snapshots")
 	} else {
-		LogWithContext().WithField("component", component.Name).
+		LogWithContext().WithField("component", component.Key()).
 			WithField("doc_type", docType).
 			Debug("Updated component snapshot")
 	}
@@ -209,13 +226,177 @@ func (sm *SnapshotManager) GetChangesSummary(components []scanner.Component) map
 	for _, component := range components {
 		changed, changes := sm.HasComponentChanged(component)
 		if changed {
-			summary[component.Name] = changes
+			summary[component.Key()] = changes
 		}
 	}
 	
 	return summary
 }
 
+// Document drift statuses reported by GetDocDrift
+const (
+	DocUnchanged = "unchanged" // on-disk content matches the hash recorded at generation
+	DocModified  = "modified"  // edited outside docs-cli since it was generated
+	DocMissing   = "missing"   // generated, then deleted
+	DocUntracked = "untracked" // on disk but never generated by docs-cli
+)
+
+// DocDrift compares one document on disk with the hash recorded when docs-cli generated it
+type DocDrift struct {
+	Component    string   `json:"component"`
+	DocType      string   `json:"doc_type"`
+	Path         string   `json:"path"`
+	Status       string   `json:"status"`
+	Stale        bool     `json:"stale"` // the component's sources changed since its last snapshot
+	StaleReasons []string `json:"stale_reasons,omitempty"`
+}
+
+// GetDocDrift reports, for each component and doc type in the current --lang, whether the
+// document on disk still matches the snapshot's DocsGenerated hash, and whether the component's
+// other files changed since. Doc types never generated and absent from disk are left out.
+func (sm *SnapshotManager) GetDocDrift(components []scanner.Component, docTypes []string) []DocDrift {
+	var drift []DocDrift
+	for _, component := range components {
+		lastSnapshot, _ := sm.snapshot(component.Key())
+		recorded := lastSnapshot.DocsGenerated
+		outputs := make(map[string]bool, len(docTypes))
+		for _, docType := range docTypes {
+			outputs[docOutputPath(component, docType)] = true
+		}
+		reasons := sm.sourceChanges(component, outputs)
+		stale := len(reasons) > 0
+
+		for _, docType := range docTypes {
+			path := docOutputPath(component, docType)
+			hash, generated := recorded[localizedDocType(docType, docLanguage)]
+			content, err := os.ReadFile(path)
+
+			var status string
+			switch {
+			case !generated && err != nil:
+				continue
+			case !generated:
+				status = DocUntracked
+			case err != nil:
+				status = DocMissing
+			case fmt.Sprintf("%x", md5.Sum(content)) == hash:
+				status = DocUnchanged
+			default:
+				status = DocModified
+			}
+
+			entry := DocDrift{Component: component.Key(), DocType: docType, Path: path, Status: status, Stale: stale}
+			if stale {
+				entry.StaleReasons = reasons
+			}
+			drift = append(drift, entry)
+		}
+	}
+	return drift
+}
+
+// sourceChanges lists the component's files added, modified, or deleted since its last snapshot,
+// leaving out outputs, the documents docs-cli writes into the component itself
+func (sm *SnapshotManager) sourceChanges(component scanner.Component, outputs map[string]bool) []string {
+	lastSnapshot, exists := sm.snapshot(component.Key())
+	if !exists {
+		return nil
+	}
+
+	var changes []string
+	current := make(map[string]bool, len(component.Files))
+	for _, filePath := range component.Files {
+		if outputs[filePath] {
+			continue
+		}
+		current[filePath] = true
+		hash, _, err := MemoryAwareFileHasher(filePath)
+		if err != nil {
+			continue
+		}
+		if lastHash, exists := lastSnapshot.FileHashes[filePath]; !exists {
+			changes = append(changes, fmt.Sprintf("new file: %s", filePath))
+		} else if hash != lastHash {
+			changes = append(changes, fmt.Sprintf("modified file: %s", filePath))
+		}
+	}
+	for filePath := range lastSnapshot.FileHashes {
+		if !outputs[filePath] && !current[filePath] {
+			changes = append(changes, fmt.Sprintf("deleted file: %s", filePath))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// StaleComponent describes a component whose documentation may have rotted
+type StaleComponent struct {
+	Key          string    `json:"key"`
+	LastUpdated  time.Time `json:"last_updated"`  // zero when the component was never documented
+	LastModified time.Time `json:"last_modified"` // newest source file mtime
+	Reasons      []string  `json:"reasons"`
+}
+
+// GetStaleComponents returns components whose snapshot is older than olderThan, or whose files
+// were modified after changedSince. A zero olderThan or changedSince disables that check.
+func (sm *SnapshotManager) GetStaleComponents(components []scanner.Component, olderThan time.Duration, changedSince, now time.Time) []StaleComponent {
+	var stale []StaleComponent
+	
+	for _, component := range components {
+		entry := StaleComponent{
+			Key:          component.Key(),
+			LastModified: latestModTime(component.Files),
+		}
+		
+		snapshot, exists := sm.snapshot(component.Key())
+		if exists {
+			entry.LastUpdated = snapshot.LastUpdated
+		}
+		
+		if olderThan > 0 {
+			if !exists {
+				entry.Reasons = append(entry.Reasons, "component never documented")
+			} else if age := now.Sub(snapshot.LastUpdated); age > olderThan {
+				entry.Reasons = append(entry.Reasons, fmt.Sprintf("snapshot is %s old", formatAge(age)))
+			}
+		}
+		
+		if !changedSince.IsZero() && entry.LastModified.After(changedSince) {
+			entry.Reasons = append(entry.Reasons, fmt.Sprintf("files modified %s", entry.LastModified.Format(time.RFC3339)))
+		}
+		
+		if len(entry.Reasons) > 0 {
+			stale = append(stale, entry)
+		}
+	}
+	
+	return stale
+}
+
+// latestModTime returns the newest modification time among files, skipping files that cannot be stat'ed
+func latestModTime(files []string) time.Time {
+	var latest time.Time
+	for _, filePath := range files {
+		info, err := statSourceFile(filePath)
+		if err != nil {
+			LogWithContext().WithError(err).WithField("file", filePath).Debug("Failed to stat file")
+			continue
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// formatAge renders a duration in whole days, falling back to hours below a day
+func formatAge(age time.Duration) string {
+	if age >= 24*time.Hour {
+		return fmt.Sprintf("%dd", int(age/(24*time.Hour)))
+	}
+	return fmt.Sprintf("%dh", int(age/time.Hour))
+}
+
 // GetCostSavingsEstimate estimates cost savings from incremental updates
 func (sm *SnapshotManager) GetCostSavingsEstimate(components []scanner.Component, docTypes []string) CostSavingsReport {
 	report := CostSavingsReport{
@@ -273,6 +454,8 @@ type CostSavingsReport struct {
 
 // ForceRefresh clears all snapshots to force full regeneration
 func (sm *SnapshotManager) ForceRefresh() error {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
 	sm.snapshots = make(map[string]ComponentSnapshot)
 	return sm.saveSnapshots()
 }
@@ -284,6 +467,9 @@ func (sm *SnapshotManager) CleanupStaleSnapshots(activeComponents []scanner.Comp
 		activeNames[comp.Name] = true
 	}
 	
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	
 	var removedCount int
 	for name := range sm.snapshots {
 		if !activeNames[name] {
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"crypto/md5"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"docs-cli/pkg/scanner"
+)
+
+// allocatedDuring returns the bytes the process allocated while fn ran
+func allocatedDuring(fn func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	fn()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+func TestCreateSnapshotStreamsLargeFiles(t *testing.T) {
+	useTempProject(t)
+	dir := t.TempDir()
+
+	// Just under MaxFileSize, so it is hashed rather than rejected
+	large := make([]byte, MaxFileSize-1)
+	for i := range large {
+		large[i] = byte(i)
+	}
+	largePath := filepath.Join(dir, "large.bin")
+	if err := os.WriteFile(largePath, large, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tooLargePath := filepath.Join(dir, "too-large.bin")
+	if err := os.WriteFile(tooLargePath, make([]byte, MaxFileSize+1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("%x", md5.Sum(large))
+	large = nil
+
+	sm := NewSnapshotManager()
+	component := scanner.Component{Name: "svc", Path: dir, Files: []string{largePath, tooLargePath}}
+	var snapshot ComponentSnapshot
+	allocated := allocatedDuring(func() { snapshot = sm.CreateSnapshot(component) })
+
+	if got := snapshot.FileHashes[largePath]; got != want {
+		t.Errorf("hash = %s, want %s", got, want)
+	}
+	if snapshot.TotalSize != MaxFileSize-1 {
+		t.Errorf("TotalSize = %d, want %d", snapshot.TotalSize, MaxFileSize-1)
+	}
+	// The oversized file is rejected by its size before it is opened
+	if _, hashed := snapshot.FileHashes[tooLargePath]; hashed {
+		t.Errorf("file over MaxFileSize was hashed")
+	}
+	// Reading the file into memory would allocate at least its 10MB
+	if allocated > 1<<20 {
+		t.Errorf("hashing a %d byte file allocated %d bytes", MaxFileSize-1, allocated)
+	}
+}
+
+func TestGetStaleComponents(t *testing.T) {
+	useTempProject(t)
+	dir := t.TempDir()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// touch writes a source file last modified at mtime
+	touch := func(name string, mtime time.Time) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("package svc\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	oldFile := touch("old.go", now.AddDate(0, -6, 0))
+	recentFile := touch("recent.go", now.AddDate(0, 0, -2))
+
+	components := []scanner.Component{
+		{Name: "fresh", Files: []string{oldFile}},
+		{Name: "rotting", Files: []string{oldFile}},
+		{Name: "edited", Files: []string{oldFile, recentFile}},
+		{Name: "undocumented", Files: []string{oldFile}},
+	}
+	sm := NewSnapshotManager()
+	sm.snapshots = map[string]ComponentSnapshot{
+		"fresh":   {ComponentName: "fresh", LastUpdated: now.AddDate(0, 0, -10)},
+		"rotting": {ComponentName: "rotting", LastUpdated: now.AddDate(0, 0, -120)},
+		"edited":  {ComponentName: "edited", LastUpdated: now.AddDate(0, 0, -30)},
+	}
+
+	staleKeys := func(stale []StaleComponent) []string {
+		var keys []string
+		for _, entry := range stale {
+			keys = append(keys, entry.Key+": "+strings.Join(entry.Reasons, ", "))
+		}
+		return keys
+	}
+
+	tests := []struct {
+		name         string
+		olderThan    time.Duration
+		changedSince time.Time
+		want         []string
+	}{
+		{
+			name:      "older than 90 days",
+			olderThan: 90 * 24 * time.Hour,
+			want:      []string{"rotting: snapshot is 120d old", "undocumented: component never documented"},
+		},
+		{
+			name:      "older than 20 days",
+			olderThan: 20 * 24 * time.Hour,
+			want:      []string{"rotting: snapshot is 120d old", "edited: snapshot is 30d old", "undocumented: component never documented"},
+		},
+		{
+			name:         "changed in the last week",
+			changedSince: now.AddDate(0, 0, -7),
+			want:         []string{"edited: files modified " + now.AddDate(0, 0, -2).Format(time.RFC3339)},
+		},
+		{
+			name:         "both checks",
+			olderThan:    90 * 24 * time.Hour,
+			changedSince: now.AddDate(0, 0, -7),
+			want: []string{
+				"rotting: snapshot is 120d old",
+				"edited: files modified " + now.AddDate(0, 0, -2).Format(time.RFC3339),
+				"undocumented: component never documented",
+			},
+		},
+		{
+			name:      "nothing that old",
+			olderThan: 365 * 24 * time.Hour,
+			want:      []string{"undocumented: component never documented"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stale := sm.GetStaleComponents(components, tt.olderThan, tt.changedSince, now)
+			if got := staleKeys(stale); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stale = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	stale := sm.GetStaleComponents(components[2:3], 0, now.AddDate(0, 0, -7), now)
+	if len(stale) != 1 || !stale[0].LastModified.Equal(now.AddDate(0, 0, -2)) || !stale[0].LastUpdated.Equal(now.AddDate(0, 0, -30)) {
+		t.Errorf("edited = %+v, want its newest mtime and snapshot time", stale)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"90d": 90 * 24 * time.Hour,
+		"0d":  0,
+		"36h": 36 * time.Hour,
+		"90m": 90 * time.Minute,
+	}
+	for value, want := range tests {
+		if got, err := parseAge(value); err != nil || got != want {
+			t.Errorf("parseAge(%q) = %s, %v, want %s", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "d", "-1d", "1.5d", "-2h", "ninety days"} {
+		if _, err := parseAge(value); err == nil {
+			t.Errorf("parseAge(%q) accepted", value)
+		}
+	}
+
+	if got, err := parseSinceTime("2026-05-01"); err != nil || !got.Equal(time.Date(2026, 5, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("parseSinceTime(date) = %s, %v", got, err)
+	}
+	if got, err := parseSinceTime("2026-05-01T10:00:00Z"); err != nil || !got.Equal(time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseSinceTime(RFC3339) = %s, %v", got, err)
+	}
+	if _, err := parseSinceTime("May 1"); err == nil {
+		t.Error("parseSinceTime accepted May 1")
+	}
+}
+
+func TestSnapshotManagerConcurrentUpdatesAndFlush(t *testing.T) {
+	project := newTestProject(t, "components: []\n")
+	var components []scanner.Component
+	for i := 0; i < 4; i++ {
+		name := fmt.Sprintf("svc%d", i)
+		file := project.WriteFile(name+"/main.go", "package main\n")
+		components = append(components, scanner.Component{Name: name, Path: name, Root: project.Root, Files: []string{file}})
+	}
+
+	// Workers record generated docs while shutdown flushes and reports read snapshots,
+	// as runShutdown can during an update; run with -race
+	sm := NewSnapshotManager()
+	docTypes := []string{"README", "SETUP", "ARCHITECTURE"}
+	var wg sync.WaitGroup
+	for _, component := range components {
+		wg.Add(1)
+		go func(component scanner.Component) {
+			defer wg.Done()
+			for _, docType := range docTypes {
+				sm.UpdateSnapshot(component, docType, docType+" for "+component.Name)
+			}
+		}(component)
+	}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			sm.Flush()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			sm.GetDocDrift(components, docTypes)
+			sm.GetStaleComponents(components, time.Hour, time.Time{}, time.Now())
+			sm.ShouldRegenerateDoc(components[0], "README")
+		}
+	}()
+	wg.Wait()
+	sm.Flush()
+
+	reloaded := NewSnapshotManager()
+	for _, component := range components {
+		snapshot, exists := reloaded.snapshot(component.Key())
+		if !exists || len(snapshot.DocsGenerated) != len(docTypes) {
+			t.Errorf("flushed snapshot for %s = %+v, want all %d doc types recorded", component.Key(), snapshot, len(docTypes))
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"docs-cli/pkg/config"
+)
+
+// validateLanguage checks --lang against the configured templates.languages
+func validateLanguage(code string) error {
+	if code == "" {
+		return nil
+	}
+
+	languages := config.GetConfig().Templates.Languages
+	if _, ok := languages[code]; ok {
+		return nil
+	}
+
+	supported := make([]string, 0, len(languages))
+	for supportedCode := range languages {
+		supported = append(supported, supportedCode)
+	}
+	sort.Strings(supported)
+	return fmt.Errorf("unsupported --lang %q (supported: %s)", code, strings.Join(supported, ", "))
+}
+
+// languageInstruction returns the prompt suffix asking for output in the --lang language
+func languageInstruction(code string) string {
+	if code == "" {
+		return ""
+	}
+
+	name := config.GetConfig().Templates.Languages[code]
+	if name == "" {
+		name = code
+	}
+	return fmt.Sprintf("\n\nWrite the documentation in %s.", name)
+}
+
+// localizedPath inserts a language code before a file's extension, e.g. README.md -> README.de.md
+func localizedPath(path, code string) string {
+	if code == "" {
+		return path
+	}
+
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + code + ext
+}
+
+// localizedDocType keys per-language document state, e.g. README -> README.de
+func localizedDocType(docType, code string) string {
+	if code == "" {
+		return docType
+	}
+	return docType + "." + code
+}
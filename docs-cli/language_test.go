@@ -0,0 +1,98 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"docs-cli/pkg/scanner"
+)
+
+// useDocLanguage sets --lang for the rest of the test
+func useDocLanguage(t *testing.T, code string) {
+	t.Helper()
+	previous := docLanguage
+	docLanguage = code
+	t.Cleanup(func() { docLanguage = previous })
+}
+
+func TestLocalizedPath(t *testing.T) {
+	tests := []struct {
+		path, code, want string
+	}{
+		{"README.md", "", "README.md"},
+		{"README.md", "de", "README.de.md"},
+		{filepath.Join("svc", "docs", "CHECKLIST.yaml"), "de", filepath.Join("svc", "docs", "CHECKLIST.de.yaml")},
+		{filepath.Join("svc", "docs", "svc.docs.md"), "en", filepath.Join("svc", "docs", "svc.docs.en.md")},
+	}
+	for _, tt := range tests {
+		if got := localizedPath(tt.path, tt.code); got != tt.want {
+			t.Errorf("localizedPath(%q, %q) = %q, want %q", tt.path, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestDocOutputPathPerLanguage(t *testing.T) {
+	component := scanner.Component{Name: "svc", Path: "svc", Root: "/project"}
+	tests := []struct {
+		docType, lang, want string
+	}{
+		{"README", "", "/project/svc/README.md"},
+		{"README", "de", "/project/svc/README.de.md"},
+		{"ARCHITECTURE", "de", "/project/svc/docs/ARCHITECTURE.de.md"},
+		{"CHECKLIST", "de", "/project/svc/docs/CHECKLIST.de.yaml"},
+		{"EXECUTIVE_SUMMARY", "en", "/project/svc/docs/executive_summary.en.md"},
+	}
+	for _, tt := range tests {
+		useDocLanguage(t, tt.lang)
+		if got := docOutputPath(component, tt.docType); got != filepath.FromSlash(tt.want) {
+			t.Errorf("docOutputPath(%s) with --lang %q = %q, want %q", tt.docType, tt.lang, got, tt.want)
+		}
+	}
+
+	useDocLanguage(t, "de")
+	if got, want := combinedOutputPath(component), filepath.FromSlash("/project/svc/docs/svc.docs.de.md"); got != want {
+		t.Errorf("combinedOutputPath with --lang de = %q, want %q", got, want)
+	}
+}
+
+func TestCacheKeysPerLanguage(t *testing.T) {
+	keys := make(map[string]string)
+	for _, lang := range []string{"", "en", "de"} {
+		useDocLanguage(t, lang)
+		key := GenerateCacheKey("openai", "prompt", "gpt-4", 1000, 0.5)
+		if again := GenerateCacheKey("openai", "prompt", "gpt-4", 1000, 0.5); again != key {
+			t.Errorf("cache key for --lang %q is not stable: %s then %s", lang, key, again)
+		}
+		for otherLang, otherKey := range keys {
+			if otherKey == key {
+				t.Errorf("--lang %q and --lang %q share cache key %s", lang, otherLang, key)
+			}
+		}
+		keys[lang] = key
+	}
+}
+
+func TestLocalizedDocType(t *testing.T) {
+	if got := localizedDocType("README", ""); got != "README" {
+		t.Errorf("localizedDocType without --lang = %q, want README", got)
+	}
+	if got := localizedDocType("README", "de"); got != "README.de" {
+		t.Errorf("localizedDocType with --lang de = %q, want README.de", got)
+	}
+}
+
+func TestValidateLanguage(t *testing.T) {
+	for _, code := range []string{"", "en", "de"} {
+		if err := validateLanguage(code); err != nil {
+			t.Errorf("validateLanguage(%q) = %v, want nil", code, err)
+		}
+	}
+	err := validateLanguage("xx")
+	if err == nil || !strings.Contains(err.Error(), "supported: de, en") {
+		t.Errorf("validateLanguage(xx) = %v, want an error listing the supported languages", err)
+	}
+	if got := languageInstruction("de"); !strings.Contains(got, "Write the documentation in German.") {
+		t.Errorf("languageInstruction(de) = %q, want the configured language name", got)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockPollInterval is how often --wait retries a held run lock
+const lockPollInterval = 500 * time.Millisecond
+
+// errLockHeld reports that another docs-cli process holds the run lock
+var errLockHeld = errors.New("another docs-cli run holds the lock")
+
+// RunLock is an advisory lock that serializes docs-cli runs which write snapshots
+type RunLock struct {
+	file *os.File
+}
+
+// acquireRunLock takes the project's .docs-cli.lock, failing fast when it is held unless
+// wait is set, in which case it retries until the lock frees or ctx is cancelled
+func acquireRunLock(ctx context.Context, wait bool) (*RunLock, error) {
+	lockPath := filepath.Join(projectRoot, ".docs-cli.lock")
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	for {
+		err := tryLockFile(file)
+		if err == nil {
+			LogWithContext().WithField("path", lockPath).Debug("Acquired run lock")
+			return &RunLock{file: file}, nil
+		}
+		if !errors.Is(err, errLockHeld) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", lockPath, err)
+		}
+		if !wait {
+			file.Close()
+			return nil, fmt.Errorf("%w (%s); rerun with --wait to queue behind it", errLockHeld, lockPath)
+		}
+
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, fmt.Errorf("gave up waiting for %s: %w", lockPath, context.Cause(ctx))
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// Release unlocks and closes the lock file; it is safe to call more than once
+func (l *RunLock) Release() {
+	if l.file == nil {
+		return
+	}
+	unlockFile(l.file)
+	l.file.Close()
+	l.file = nil
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// tryLockFile is a no-op where flock is unavailable; runs are not serialized
+func tryLockFile(file *os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op where flock is unavailable
+func unlockFile(file *os.File) {}
@@ -0,0 +1,116 @@
+//go:build unix
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// lockHolderRootEnv tells the helper process which project's run lock to hold
+const lockHolderRootEnv = "DOCS_CLI_TEST_LOCK_ROOT"
+
+// TestRunLockHelperProcess is not a test: run by startLockHolder in a child process, it
+// holds the run lock of the project in lockHolderRootEnv until its stdin is closed
+func TestRunLockHelperProcess(t *testing.T) {
+	root := os.Getenv(lockHolderRootEnv)
+	if root == "" {
+		t.Skip("helper process for TestRunLockBlocksSecondProcess")
+	}
+	projectRoot = root
+	lock, err := acquireRunLock(context.Background(), false)
+	if err != nil {
+		os.Stdout.WriteString("error: " + err.Error() + "\n")
+		os.Exit(1)
+	}
+	os.Stdout.WriteString("locked\n")
+	io.Copy(io.Discard, os.Stdin)
+	lock.Release()
+	os.Exit(0)
+}
+
+// startLockHolder starts a child process holding root's run lock and returns once it holds
+// it; closing the returned writer makes the child release the lock and exit
+func startLockHolder(t *testing.T, root string) (release io.WriteCloser, exited <-chan error) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRunLockHelperProcess$")
+	cmd.Env = append(os.Environ(), lockHolderRootEnv+"="+root)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	t.Cleanup(func() {
+		stdin.Close()
+		cmd.Process.Kill()
+		<-done
+	})
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	go func() {
+		done <- cmd.Wait()
+		close(done)
+	}()
+	if err != nil || line != "locked\n" {
+		t.Fatalf("lock holder did not take the lock: %q, %v", line, err)
+	}
+	return stdin, done
+}
+
+func TestRunLockBlocksSecondProcess(t *testing.T) {
+	root := useTempProject(t)
+	release, exited := startLockHolder(t, root)
+
+	if lock, err := acquireRunLock(context.Background(), false); !errors.Is(err, errLockHeld) {
+		if lock != nil {
+			lock.Release()
+		}
+		t.Fatalf("acquireRunLock while another process holds it = %v, want errLockHeld", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := acquireRunLock(ctx, true); err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquireRunLock --wait past its deadline = %v, want it to give up", err)
+	}
+
+	// A waiting run takes the lock once the holder exits
+	acquired := make(chan error, 1)
+	go func() {
+		lock, err := acquireRunLock(context.Background(), true)
+		if err == nil {
+			lock.Release()
+		}
+		acquired <- err
+	}()
+	select {
+	case err := <-acquired:
+		t.Fatalf("acquireRunLock --wait returned %v while the lock was still held", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	release.Close()
+	if err := <-exited; err != nil {
+		t.Fatalf("lock holder failed: %v", err)
+	}
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("acquireRunLock --wait after release = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("acquireRunLock --wait did not take the released lock")
+	}
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"os"
 	"runtime"
+	"strconv"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -16,15 +20,15 @@ func init() {
 	// Set output to stdout for containerized environments
 	logger.SetOutput(os.Stdout)
 	
-	// JSON formatter for structured logging
-	logger.SetFormatter(&logrus.JSONFormatter{
+	// JSON formatter for structured logging, with credentials scrubbed from every entry
+	logger.SetFormatter(&redactingFormatter{inner: &logrus.JSONFormatter{
 		TimestampFormat: time.RFC3339,
 		FieldMap: logrus.FieldMap{
 			logrus.FieldKeyTime:  "timestamp",
 			logrus.FieldKeyLevel: "level",
 			logrus.FieldKeyMsg:   "message",
 		},
-	})
+	}})
 	
 	// Set log level from environment or default to Info
 	level := os.Getenv("LOG_LEVEL")
@@ -52,6 +56,48 @@ func LogWithContext() *logrus.Entry {
 	})
 }
 
+// logFieldsKey is the context key for fields added with WithLogFields
+type logFieldsKey struct{}
+
+// WithLogFields returns a context whose LogFrom entries carry fields in addition to
+// any already attached, e.g. a run_id for the run and a component for each component
+func WithLogFields(ctx context.Context, fields logrus.Fields) context.Context {
+	merged := logrus.Fields{}
+	if existing, ok := ctx.Value(logFieldsKey{}).(logrus.Fields); ok {
+		for key, value := range existing {
+			merged[key] = value
+		}
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, logFieldsKey{}, merged)
+}
+
+// LogFieldsFrom returns the fields attached to ctx with WithLogFields
+func LogFieldsFrom(ctx context.Context) logrus.Fields {
+	fields, _ := ctx.Value(logFieldsKey{}).(logrus.Fields)
+	return fields
+}
+
+// LogFrom creates a logger with the common context fields plus those attached to ctx
+func LogFrom(ctx context.Context) *logrus.Entry {
+	entry := LogWithContext()
+	if fields := LogFieldsFrom(ctx); fields != nil {
+		entry = entry.WithFields(fields)
+	}
+	return entry
+}
+
+// newRunID returns a short random identifier for correlating one run's logs
+func newRunID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(id)
+}
+
 // LogAPICall logs API call details
 func LogAPICall(provider, model string, tokensUsed int, duration time.Duration, err error) {
 	entry := LogWithContext().WithFields(logrus.Fields{
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"docs-cli/pkg/config"
+)
+
+// useMockSettings configures the mock provider for the rest of the test
+func useMockSettings(t *testing.T, settings config.MockConfig) {
+	t.Helper()
+	useEnterpriseConfig(t, func(c *config.EnterpriseConfig) {
+		c.Providers.Mock = settings
+	})
+}
+
+func TestMockProviderPlaceholders(t *testing.T) {
+	newTestProject(t, "components: []\n")
+
+	readme, err := callModelAPIWithContext("Document the billing service.", "README", "service", "mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(readme, "# component: README") || !strings.Contains(readme, "no model was called") {
+		t.Errorf("README = %q, want the placeholder", readme)
+	}
+	for _, section := range config.GetConfig().Templates.RequiredSections["README"] {
+		if !strings.Contains(readme, "## "+section) {
+			t.Errorf("README placeholder is missing required section %q", section)
+		}
+	}
+
+	checklist, err := callModelAPIWithContext("Plan the billing service.", "CHECKLIST", "service", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed Checklist
+	if err := yaml.Unmarshal([]byte(checklist), &parsed); err != nil || len(parsed.Categories) == 0 {
+		t.Errorf("CHECKLIST placeholder is not a checklist (%v):\n%s", err, checklist)
+	}
+}
+
+func TestMockProviderFixtureFile(t *testing.T) {
+	project := newTestProject(t, "components: []\n")
+	const fixture = "# Billing\n\nCanned documentation for demos.\n"
+	project.WriteFile("fixtures/readme.md", fixture)
+	useMockSettings(t, config.MockConfig{FixtureFile: filepath.Join(project.Root, "fixtures", "readme.md")})
+
+	for _, docType := range []string{"README", "SETUP"} {
+		content, err := callModelAPIWithContext("Document the billing service for "+docType+".", docType, "service", "mock")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content != fixture {
+			t.Errorf("%s = %q, want the fixture", docType, content)
+		}
+	}
+
+	useMockSettings(t, config.MockConfig{FixtureFile: filepath.Join(project.Root, "fixtures", "missing.md")})
+	if _, err := callModelAPIWithContext("Document the payments service.", "README", "service", "mock"); err == nil || !strings.Contains(err.Error(), "mock fixture") {
+		t.Errorf("error = %v, want the missing fixture reported", err)
+	}
+}
+
+func TestMockProviderLatencyAndCache(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	const latency = 100 * time.Millisecond
+	useMockSettings(t, config.MockConfig{Latency: latency})
+	const prompt = "Document the billing service."
+
+	start := time.Now()
+	first, err := callModelAPIWithContext(prompt, "README", "service", "mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < latency {
+		t.Errorf("first call took %s, want at least the %s latency", elapsed, latency)
+	}
+
+	// The repeat is served from the cache without waiting out the latency
+	start = time.Now()
+	second, err := callModelAPIWithContext(prompt, "README", "service", "mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= latency {
+		t.Errorf("cached call took %s, want it to skip the %s latency", elapsed, latency)
+	}
+	if second != first {
+		t.Error("cached content differs from the first response")
+	}
+	if hits := GetProviderCache("mock").GetMetrics().Hits; hits != 1 {
+		t.Errorf("cache served %d hits, want 1", hits)
+	}
+}
+
+func TestMockProviderInjectedErrors(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	useMockSettings(t, config.MockConfig{ErrorRate: 1, ErrorStatus: http.StatusInternalServerError})
+	useFastRetries(t, 2)
+	const prompt = "Document the billing service."
+
+	_, err := callModelAPIWithContext(prompt, "README", "service", "mock")
+	var statusErr *APIStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("error = %v, want the injected 500", err)
+	}
+	if entries := GetProviderCache("mock").GetMetrics().EntryCount; entries != 0 {
+		t.Errorf("cache holds %d entries after a failed call, want 0", entries)
+	}
+
+	// Once failures stop, the same prompt is answered rather than a cached failure
+	useMockSettings(t, config.MockConfig{})
+	rebuildCircuitBreakers()
+	if content, err := callModelAPIWithContext(prompt, "README", "service", "mock"); err != nil || !strings.Contains(content, "## Overview") {
+		t.Errorf("after failures stop = %q, %v; want the placeholder", content, err)
+	}
+}
@@ -2,11 +2,17 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
+
+	"docs-cli/pkg/config"
 )
 
 type ModelConfig struct {
@@ -14,7 +20,11 @@ type ModelConfig struct {
 	OpenAI        ProviderConfig           `yaml:"openai"`
 	Anthropic     ProviderConfig           `yaml:"anthropic"`
 	OpenRouter    ProviderConfig           `yaml:"openrouter"`
+	Mock          ProviderConfig           `yaml:"mock"`
 	DocumentTypes map[string]ModelSettings `yaml:"document_types"`
+
+	// builtIn is set on builtInModelConfig, used when model-config.yaml is missing
+	builtIn bool
 }
 
 type ProviderConfig struct {
@@ -23,6 +33,10 @@ type ProviderConfig struct {
 	MaxTokens     int               `yaml:"max_tokens"`
 	Temperature   float64           `yaml:"temperature"`
 	ThinkingModels []string         `yaml:"thinking_models"`
+	// Capabilities overrides built-in capability defaults for this provider, field by field
+	Capabilities *CapabilityOverrides `yaml:"capabilities,omitempty"`
+	// ContextOverflowModel is tried once, instead of retrying, when a prompt exceeds the model's context window
+	ContextOverflowModel string `yaml:"context_overflow_model,omitempty"`
 }
 
 type ModelSettings struct {
@@ -35,16 +49,62 @@ type ModelSettings struct {
 	ThinkingLevel   string  `yaml:"thinking_level"`
 }
 
-var modelConfig *ModelConfig
+// modelConfig is swapped atomically so a reload never exposes a half-updated configuration
+var modelConfig atomic.Pointer[ModelConfig]
+
+// errModelConfigMissing reports that model-config.yaml does not exist
+var errModelConfigMissing = errors.New("model-config.yaml not found")
 
+// loadModelConfig returns the loaded model configuration, reading model-config.yaml on first
+// use. Without the file it falls back to builtInModelConfig, so commands that only scan
+// components work; model calls then fail on the missing API key instead.
 func loadModelConfig() (*ModelConfig, error) {
-	if modelConfig != nil {
-		return modelConfig, nil
+	if loaded := modelConfig.Load(); loaded != nil {
+		return loaded, nil
+	}
+
+	loaded, err := readModelConfig()
+	if errors.Is(err, errModelConfigMissing) {
+		LogWithContext().Debug("model-config.yaml not found, using built-in model defaults")
+		loaded, err = builtInModelConfig(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	modelConfig.CompareAndSwap(nil, loaded)
+	return modelConfig.Load(), nil
+}
+
+// validate checks that the default and every document type name a supported provider and a model
+func (c *ModelConfig) validate() error {
+	check := func(name string, settings ModelSettings) error {
+		switch settings.Provider {
+		case "anthropic", "openai", "openrouter", "mock":
+		default:
+			return fmt.Errorf("model-config.yaml: %s: unsupported provider %q", name, settings.Provider)
+		}
+		if settings.Model == "" {
+			return fmt.Errorf("model-config.yaml: %s: model is required", name)
+		}
+		return nil
 	}
 
+	if err := check("default", c.Default); err != nil {
+		return err
+	}
+	for docType, settings := range c.DocumentTypes {
+		if err := check("document_types."+docType, settings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readModelConfig parses model-config.yaml without touching the loaded configuration
+func readModelConfig() (*ModelConfig, error) {
 	configPath := "model-config.yaml"
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("model-config.yaml not found")
+		return nil, errModelConfigMissing
 	}
 
 	data, err := os.ReadFile(configPath)
@@ -52,14 +112,62 @@ func loadModelConfig() (*ModelConfig, error) {
 		return nil, fmt.Errorf("error reading model-config.yaml: %w", err)
 	}
 
-	var config ModelConfig
-	err = yaml.Unmarshal(data, &config)
+	var loaded ModelConfig
+	err = yaml.Unmarshal(data, &loaded)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing model-config.yaml: %w", err)
 	}
 
-	modelConfig = &config
-	return modelConfig, nil
+	// API keys may reference the environment, e.g. api_key: "${ANTHROPIC_API_KEY}"
+	for _, provider := range []*ProviderConfig{&loaded.OpenAI, &loaded.Anthropic, &loaded.OpenRouter} {
+		provider.APIKey = config.ExpandEnv(provider.APIKey)
+	}
+
+	return &loaded, nil
+}
+
+// builtInModelConfig mirrors model-config.yaml.example for running without the file: every
+// document type uses Claude Sonnet 4, and API keys come from ANTHROPIC_API_KEY,
+// OPENAI_API_KEY, and OPENROUTER_API_KEY
+func builtInModelConfig() *ModelConfig {
+	return &ModelConfig{
+		Default: ModelSettings{Provider: "anthropic", Model: "claude-sonnet-4-20250514", MaxTokens: 4000, Temperature: 0.7},
+		OpenAI: ProviderConfig{
+			APIKey: os.Getenv("OPENAI_API_KEY"),
+			Models: map[string]string{
+				"gpt-4o":        "gpt-4o",
+				"gpt-4o-mini":   "gpt-4o-mini",
+				"gpt-3.5-turbo": "gpt-3.5-turbo",
+			},
+			MaxTokens:   4000,
+			Temperature: 0.7,
+		},
+		Anthropic: ProviderConfig{
+			APIKey: os.Getenv("ANTHROPIC_API_KEY"),
+			Models: map[string]string{
+				"opus-4":     "claude-opus-4-20250514",
+				"sonnett-4":  "claude-sonnet-4-20250514",
+				"sonnet-3.5": "claude-3-5-sonnet-20241022",
+				"haiku-3.5":  "claude-3-5-haiku-20241022",
+			},
+			MaxTokens:   4000,
+			Temperature: 0.7,
+		},
+		OpenRouter: ProviderConfig{
+			APIKey:      os.Getenv("OPENROUTER_API_KEY"),
+			MaxTokens:   4000,
+			Temperature: 0.7,
+		},
+		builtIn: true,
+	}
+}
+
+// missingKeyError explains a provider call made without an API key
+func (c *ModelConfig) missingKeyError(provider string) error {
+	if c.builtIn {
+		return fmt.Errorf("model-config.yaml not found and %s_API_KEY is not set; copy model-config.yaml.example to model-config.yaml to configure models", strings.ToUpper(provider))
+	}
+	return fmt.Errorf("%s API key not set in model-config.yaml", provider)
 }
 
 func getModelSettingsForDocType(docType string) (ModelSettings, error) {
@@ -69,12 +177,37 @@ func getModelSettingsForDocType(docType string) (ModelSettings, error) {
 	}
 
 	// Check if there's a specific config for this document type
-	if settings, exists := config.DocumentTypes[docType]; exists {
-		return settings, nil
+	settings, exists := config.DocumentTypes[docType]
+	if !exists {
+		// Fall back to default
+		settings = config.Default
+	}
+
+	// --seed asks for reproducible output, which needs greedy sampling everywhere
+	if runSeed() != nil {
+		settings.Temperature = 0
+	}
+	return settings, nil
+}
+
+// resolveModelID maps a model alias to the provider's model ID, if one is configured
+func resolveModelID(config *ModelConfig, provider, model string) string {
+	var modelMap map[string]string
+	switch provider {
+	case "anthropic":
+		modelMap = config.Anthropic.Models
+	case "openai":
+		modelMap = config.OpenAI.Models
+	case "openrouter":
+		modelMap = config.OpenRouter.Models
+	case "mock":
+		modelMap = config.Mock.Models
 	}
 
-	// Fall back to default
-	return config.Default, nil
+	if modelID, exists := modelMap[model]; exists {
+		return modelID
+	}
+	return model
 }
 
 func callModelAPI(prompt, docType string) (string, error) {
@@ -82,6 +215,17 @@ func callModelAPI(prompt, docType string) (string, error) {
 }
 
 func callModelAPIWithContext(prompt, docType, componentType, provider string) (string, error) {
+	return callModelAPIWithConversation(runContext(), []ChatMessage{{Role: "user", Content: prompt}}, docType, componentType, provider)
+}
+
+// callModelAPIWithConversation sends prior turns plus a final user prompt as one multi-turn
+// request; providers without multi-turn support receive the conversation flattened
+func callModelAPIWithConversation(ctx context.Context, messages []ChatMessage, docType, componentType, provider string) (string, error) {
+	if len(messages) == 0 || messages[len(messages)-1].Role != "user" {
+		return "", fmt.Errorf("conversation must end with a user prompt")
+	}
+	prompt := messages[len(messages)-1].Content
+
 	// Input validation
 	if err := ValidateInput(prompt, "prompt"); err != nil {
 		return "", fmt.Errorf("invalid prompt: %w", err)
@@ -90,33 +234,18 @@ func callModelAPIWithContext(prompt, docType, componentType, provider string) (s
 	if err := ValidateInput(docType, "doc_type"); err != nil {
 		return "", fmt.Errorf("invalid document type: %w", err)
 	}
+	ctx = WithLogFields(ctx, logrus.Fields{"doc_type": docType})
 	
 	// Check memory usage before processing
 	if err := LimitMemoryUsage("api_call"); err != nil {
 		return "", err
 	}
 	
-	// Cost optimization: compress prompt and select optimal model
-	optimizedPrompt, optimalModel, costEstimate := OptimizeForCost(prompt, docType, componentType, provider)
-	
-	LogWithContext().WithField("cost_estimate", costEstimate).
-		WithField("original_tokens", EstimateTokens(prompt)).
-		WithField("optimized_tokens", EstimateTokens(optimizedPrompt)).
-		Info("Cost optimization applied")
-	
 	settings, err := getModelSettingsForDocType(docType)
 	if err != nil {
 		return "", fmt.Errorf("error getting model settings: %w", err)
 	}
 	
-	// Override with optimized model if different
-	if optimalModel != settings.Model && optimalModel != "" {
-		LogWithContext().WithField("original_model", settings.Model).
-			WithField("optimal_model", optimalModel).
-			Info("Using cost-optimized model selection")
-		settings.Model = optimalModel
-	}
-
 	config, err := loadModelConfig()
 	if err != nil {
 		return "", fmt.Errorf("error loading model config: %w", err)
@@ -127,10 +256,82 @@ func callModelAPIWithContext(prompt, docType, componentType, provider string) (s
 		provider = settings.Provider
 	}
 	
+	// Cost optimization: compress the prompt and select an optimal model for the whole
+	// conversation (applied only on cache miss) for the resolved provider
+	conversation := flattenConversation(messages)
+	optimizedMessages, optimalModel, costEstimate := optimizeConversation(messages, docType, componentType, provider)
+	optimizedPrompt := flattenConversation(optimizedMessages)
+	
+	LogFrom(ctx).WithField("cost_estimate", costEstimate).
+		WithField("original_tokens", EstimateTokens(conversation)).
+		WithField("optimized_tokens", EstimateTokens(optimizedPrompt)).
+		WithField("turns", len(optimizedMessages)).
+		Debug("Cost optimization applied")
+	
+	// A model pinned by compare is used as is, without cost-optimized substitutions
+	pinnedModel, isPinned := pinnedModelFrom(ctx)
+	if isPinned {
+		settings.Model = pinnedModel
+	}
+	
+	// Prefer a cached response from the originally requested model; only pay-per-call
+	// misses are worth downgrading to a cheaper model
+	requestedModel := resolveModelID(config, provider, settings.Model)
+	requestedKey := GenerateCacheKey(provider, optimizedPrompt, requestedModel, settings.MaxTokens, settings.Temperature)
+	providerCache := GetProviderCache(provider)
+	ctx = WithCachePolicy(ctx, runCachePolicy(docType))
+	if !cachePolicyFrom(ctx).SkipRead && providerCache.Contains(requestedKey) {
+		if cached, found := providerCache.Get(requestedKey); found {
+			LogFrom(ctx).WithField("model", requestedModel).
+				WithField("cache_key", requestedKey[:8]+"...").
+				Debug("Cache hit for requested model, skipping cost-optimized downgrade")
+			recordGeneration(ctx, provider, requestedModel, docType, 0, 0, 0, true)
+			return cached, nil
+		}
+	}
+	
+	// Override with optimized model if different
+	if optimalModel != settings.Model && optimalModel != "" && !isPinned {
+		LogFrom(ctx).WithField("original_model", settings.Model).
+			WithField("optimal_model", optimalModel).
+			Debug("Using cost-optimized model selection")
+		settings.Model = optimalModel
+	}
+	
+	// cost_optimization.cross_provider_selection may move the call to a cheaper provider with a
+	// key configured; the offline mock provider is never swapped for a paid one
+	if getCostOptConfig().CrossProviderSelection && provider != "mock" && !isPinned {
+		complexity := AnalyzeTaskComplexity(conversation, docType, componentType)
+		outputTokens := EstimateOutputTokens(docType, EstimateTokens(optimizedPrompt))
+		if choice, ok := SelectCrossProviderModel(complexity, optimizedPrompt, outputTokens, config.keyedProviders()); ok {
+			LogFrom(ctx).WithField("original_provider", provider).
+				WithField("original_model", settings.Model).
+				WithField("provider", choice.Provider).
+				WithField("model", choice.Model).
+				WithField("estimated_cost", choice.TotalEstimatedCost).
+				Debug("Using cross-provider model selection")
+			provider, settings.Model, costEstimate = choice.Provider, choice.Model, choice
+		}
+	}
+	
+	// Downgrade, or refuse, a document whose estimate exceeds --max-cost-per-doc; a pinned
+	// model is refused rather than swapped
+	budgetModel, err := fitModelToDocBudget(provider, settings.Model, optimizedPrompt, docType, maxCostPerDoc)
+	if err != nil {
+		return "", err
+	}
+	if isPinned && budgetModel != settings.Model {
+		return "", fmt.Errorf("%s exceeds --max-cost-per-doc $%.2f", settings.Model, maxCostPerDoc)
+	}
+	settings.Model = budgetModel
+	
 	// Check provider-specific rate limit
 	if err := CheckRateLimit(provider); err != nil {
 		return "", err
 	}
+	if err := CheckSpendRate(provider); err != nil {
+		return "", err
+	}
 
 	// Get API key based on provider
 	var apiKey string
@@ -141,57 +342,134 @@ func callModelAPIWithContext(prompt, docType, componentType, provider string) (s
 		apiKey = config.OpenAI.APIKey
 	case "openrouter":
 		apiKey = config.OpenRouter.APIKey
+	case "mock":
+		// The mock provider never leaves the machine, so it needs no key
+		apiKey = config.Mock.APIKey
 	default:
 		return "", fmt.Errorf("unsupported provider: %s", provider)
 	}
 
-	if apiKey == "" {
-		return "", fmt.Errorf("%s API key not set in model-config.yaml", provider)
+	if apiKey == "" && provider != "mock" {
+		return "", config.missingKeyError(provider)
 	}
 
 	// Resolve model name using the models mapping
-	actualModel := settings.Model
-	var modelMap map[string]string
-	switch provider {
-	case "anthropic":
-		modelMap = config.Anthropic.Models
-	case "openai":
-		modelMap = config.OpenAI.Models
-	case "openrouter":
-		modelMap = config.OpenRouter.Models
-	}
-
-	if modelID, exists := modelMap[settings.Model]; exists {
-		actualModel = modelID
-	}
+	actualModel := resolveModelID(config, provider, settings.Model)
 
 	// Get provider and call model with resilience features
-	providerInstance := ProviderFactory(provider, apiKey)
+	providerInstance := newModelProvider(provider, apiKey)
 	if providerInstance == nil {
 		return "", fmt.Errorf("no provider found for: %s", provider)
 	}
 
+	// A stale cache entry served under stale-while-revalidate is refreshed in the background
+	// through the same rate and spend limits, circuit breaker, and retries as this call. The
+	// refresh re-sends the request --max-cost-per-doc approved above, and records its spend
+	// the same way.
+	refreshThrough := func(model string) guardedCall {
+		return func(ctx context.Context, send func(ctx context.Context) (string, error)) (ModelResult, error) {
+			if err := CheckRateLimit(provider); err != nil {
+				return ModelResult{}, err
+			}
+			if err := CheckSpendRate(provider); err != nil {
+				return ModelResult{}, err
+			}
+			start := time.Now()
+			result, err := ResilientAPICall(ctx, provider, modelCall(ctx, provider, model, send))
+			LogAPICall(provider, model, 0, time.Since(start), err)
+			if err != nil {
+				return ModelResult{}, err
+			}
+			if !GetProviderCapabilities(provider, model).ReturnsCost {
+				RecordSpend(provider, costEstimate.TotalEstimatedCost)
+			}
+			return result.(ModelResult), nil
+		}
+	}
+
 	// Use resilient API call with retry and circuit breaker
-	start := time.Now()
-	result, err := ResilientAPICall(context.Background(), provider, func() (interface{}, error) {
-		return providerInstance.CallModel(context.Background(), optimizedPrompt, actualModel, settings.MaxTokens, settings.Temperature)
-	})
-	duration := time.Since(start)
+	chatProvider, multiTurn := providerInstance.(ChatProvider)
+	callModel := func(model string, messages []ChatMessage) (interface{}, error) {
+		start := time.Now()
+		ctx := withGuardedCall(ctx, refreshThrough(model))
+		result, err := ResilientAPICall(ctx, provider, modelCall(ctx, provider, model, func(ctx context.Context) (string, error) {
+			if multiTurn {
+				return chatProvider.CallChat(ctx, ChatRequest{
+					Model:       model,
+					Messages:    messages,
+					MaxTokens:   settings.MaxTokens,
+					Temperature: settings.Temperature,
+				})
+			}
+			return providerInstance.CallModel(ctx, flattenConversation(messages), model, settings.MaxTokens, settings.Temperature)
+		}))
+		
+		// Log API call details
+		tokensUsed := 0 // TODO: Extract from response if available
+		LogAPICall(provider, model, tokensUsed, time.Since(start), err)
+		return result, err
+	}
 	
-	// Log API call details
-	tokensUsed := 0 // TODO: Extract from response if available
-	LogAPICall(settings.Provider, actualModel, tokensUsed, duration, err)
+	result, err := callModel(actualModel, optimizedMessages)
+	
+	// Retrying an oversized prompt is futile; switch to the larger-context model once if configured
+	if isContextLengthError(err) {
+		providerConfig, _ := config.providerSettings(provider)
+		if overflowModel := providerConfig.ContextOverflowModel; overflowModel != "" && resolveModelID(config, provider, overflowModel) != actualModel {
+			LogFrom(ctx).WithError(err).
+				WithField("model", actualModel).
+				WithField("context_overflow_model", overflowModel).
+				Warn("Prompt exceeds the model's context window, switching to context_overflow_model")
+			settings.Model = overflowModel
+			actualModel = resolveModelID(config, provider, overflowModel)
+			result, err = callModel(actualModel, optimizedMessages)
+		}
+	}
 	
 	if err != nil {
+		if isContextLengthError(err) {
+			return "", fmt.Errorf("%w: %v", errContextLength, err)
+		}
 		return "", err
 	}
 	
-	response, ok := result.(string)
+	if !GetProviderCapabilities(provider, actualModel).ReturnsCost {
+		RecordSpend(provider, costEstimate.TotalEstimatedCost)
+	}
+	
+	response, ok := result.(ModelResult)
 	if !ok {
-		return "", fmt.Errorf("unexpected response type from API")
+		return "", fmt.Errorf("unexpected response type from API: %T", result)
+	}
+	
+	content := response.Content
+	completionTokens := response.CompletionTokens
+	if response.Truncated {
+		content, err = handleTruncation(ctx, docType, settings.MaxTokens, optimizedMessages, content, func(messages []ChatMessage) (ModelResult, error) {
+			result, err := callModel(actualModel, messages)
+			if err != nil {
+				return ModelResult{}, err
+			}
+			completionTokens += result.(ModelResult).CompletionTokens
+			return result.(ModelResult), nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	
+	// Calibrate future output estimates from the provider's completion token count, falling
+	// back to the size of the completion when the provider reported none
+	outputTokens := completionTokens
+	if outputTokens == 0 {
+		outputTokens = EstimateTokens(content)
 	}
+	getTokenHistory().Record(docType, outputTokens)
 	
-	return response, nil
+	generationCost := EstimateCost(provider, settings.Model, optimizedPrompt, outputTokens)
+	recordGeneration(ctx, provider, actualModel, docType, generationCost.InputTokens, outputTokens, generationCost.TotalEstimatedCost, false)
+	
+	return content, nil
 }
 
 // callModelAPIWithThinking calls the model API with thinking capabilities
@@ -225,10 +503,18 @@ func callModelAPIWithThinking(prompt, docType, componentType, provider string, t
 		provider = settings.Provider
 	}
 	
+	// Downgrade, or refuse, a document whose estimate exceeds --max-cost-per-doc
+	if settings.Model, err = fitModelToDocBudget(provider, settings.Model, prompt, docType, maxCostPerDoc); err != nil {
+		return "", err
+	}
+	
 	// Check provider-specific rate limit
 	if err := CheckRateLimit(provider); err != nil {
 		return "", err
 	}
+	if err := CheckSpendRate(provider); err != nil {
+		return "", err
+	}
 
 	// Get API key based on provider
 	var apiKey string
@@ -239,82 +525,84 @@ func callModelAPIWithThinking(prompt, docType, componentType, provider string, t
 		apiKey = config.OpenAI.APIKey
 	case "openrouter":
 		apiKey = config.OpenRouter.APIKey
+	case "mock":
+		// The mock provider never leaves the machine, so it needs no key
+		apiKey = config.Mock.APIKey
 	default:
 		return "", fmt.Errorf("unsupported provider: %s", provider)
 	}
 
-	if apiKey == "" {
-		return "", fmt.Errorf("%s API key not set in model-config.yaml", provider)
+	if apiKey == "" && provider != "mock" {
+		return "", config.missingKeyError(provider)
 	}
 
 	// Resolve model name using the models mapping
-	actualModel := settings.Model
-	var modelMap map[string]string
-	switch provider {
-	case "anthropic":
-		modelMap = config.Anthropic.Models
-	case "openai":
-		modelMap = config.OpenAI.Models
-	case "openrouter":
-		modelMap = config.OpenRouter.Models
-	}
-
-	if modelID, exists := modelMap[settings.Model]; exists {
-		actualModel = modelID
-	}
+	actualModel := resolveModelID(config, provider, settings.Model)
 
 	// Get provider and call model with thinking support
-	providerInstance := ProviderFactory(provider, apiKey)
+	providerInstance := newModelProvider(provider, apiKey)
 	if providerInstance == nil {
 		return "", fmt.Errorf("no provider found for: %s", provider)
 	}
 
 	// Use resilient API call with thinking support
+	ctx := WithCachePolicy(WithLogFields(runContext(), logrus.Fields{"doc_type": docType}), runCachePolicy(docType))
 	start := time.Now()
 	var result interface{}
 	var callErr error
 	
-	// Check if provider supports thinking
-	if thinkingConfig.EnableThinking {
-		switch provider {
-		case "openrouter":
-			if openRouterProvider, ok := providerInstance.(*OpenRouterProvider); ok {
-				result, callErr = ResilientAPICall(context.Background(), provider, func() (interface{}, error) {
-					return openRouterProvider.CallModelWithThinking(context.Background(), prompt, actualModel, settings.MaxTokens, settings.Temperature, thinkingConfig)
-				})
-			} else {
-				// Fallback to regular call if thinking not supported
-				result, callErr = ResilientAPICall(context.Background(), provider, func() (interface{}, error) {
-					return providerInstance.CallModel(context.Background(), prompt, actualModel, settings.MaxTokens, settings.Temperature)
-				})
-			}
-		default:
-			// For providers without thinking support yet, use regular call
-			result, callErr = ResilientAPICall(context.Background(), provider, func() (interface{}, error) {
-				return providerInstance.CallModel(context.Background(), prompt, actualModel, settings.MaxTokens, settings.Temperature)
-			})
-		}
+	// Send thinking parameters only when the model supports them and the provider can carry them
+	thinkingProvider, canThink := providerInstance.(ThinkingModelProvider)
+	if thinkingConfig.EnableThinking && canThink && GetProviderCapabilities(provider, actualModel).SupportsThinking {
+		result, callErr = ResilientAPICall(ctx, provider, modelCall(ctx, provider, actualModel, func(ctx context.Context) (string, error) {
+			return thinkingProvider.CallModelWithThinking(ctx, prompt, actualModel, settings.MaxTokens, settings.Temperature, thinkingConfig)
+		}))
 	} else {
-		// Regular call without thinking
-		result, callErr = ResilientAPICall(context.Background(), provider, func() (interface{}, error) {
-			return providerInstance.CallModel(context.Background(), prompt, actualModel, settings.MaxTokens, settings.Temperature)
-		})
+		result, callErr = ResilientAPICall(ctx, provider, modelCall(ctx, provider, actualModel, func(ctx context.Context) (string, error) {
+			return providerInstance.CallModel(ctx, prompt, actualModel, settings.MaxTokens, settings.Temperature)
+		}))
 	}
 	
 	duration := time.Since(start)
 	
 	// Log API call details
 	tokensUsed := 0 // TODO: Extract from response if available
-	LogAPICall(settings.Provider, actualModel, tokensUsed, duration, callErr)
+	LogAPICall(provider, actualModel, tokensUsed, duration, callErr)
 	
 	if callErr != nil {
 		return "", callErr
 	}
 	
-	response, ok := result.(string)
+	if !GetProviderCapabilities(provider, actualModel).ReturnsCost {
+		estimate := EstimateCost(provider, settings.Model, prompt, EstimateOutputTokens(docType, EstimateTokens(prompt)))
+		RecordSpend(provider, estimate.TotalEstimatedCost)
+	}
+	
+	response, ok := result.(ModelResult)
 	if !ok {
-		return "", fmt.Errorf("unexpected response type from API")
+		return "", fmt.Errorf("unexpected response type from API: %T", result)
 	}
 	
-	return response, nil
+	content := response.Content
+	if response.Truncated {
+		// Continuations go without thinking; the reasoning already happened in the first call
+		content, err = handleTruncation(ctx, docType, settings.MaxTokens, []ChatMessage{{Role: "user", Content: prompt}}, content, func(messages []ChatMessage) (ModelResult, error) {
+			result, err := ResilientAPICall(ctx, provider, modelCall(ctx, provider, actualModel, func(ctx context.Context) (string, error) {
+				return providerInstance.CallModel(ctx, flattenConversation(messages), actualModel, settings.MaxTokens, settings.Temperature)
+			}))
+			if err != nil {
+				return ModelResult{}, err
+			}
+			return result.(ModelResult), nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	
+	outputTokens := EstimateTokens(content)
+	generationCost := EstimateCost(provider, settings.Model, prompt, outputTokens)
+	recordGeneration(ctx, provider, actualModel, docType, generationCost.InputTokens, outputTokens, generationCost.TotalEstimatedCost, false)
+	
+	return content, nil
 }
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"docs-cli/pkg/config"
+)
+
+// scriptedProvider fails its first calls with the scripted errors, then answers through the
+// offline MockProvider, reporting completionTokens when set. It counts the calls that reach it.
+type scriptedProvider struct {
+	failures         []error
+	completionTokens int
+	mock             *MockProvider
+	calls            atomic.Int32
+}
+
+func (p *scriptedProvider) CallModel(ctx context.Context, prompt, model string, maxTokens int, temperature float64) (string, error) {
+	return p.CallChat(ctx, ChatRequest{Model: model, Messages: []ChatMessage{{Role: "user", Content: prompt}}, MaxTokens: maxTokens, Temperature: temperature})
+}
+
+func (p *scriptedProvider) CallChat(ctx context.Context, request ChatRequest) (string, error) {
+	call := int(p.calls.Add(1))
+	if call <= len(p.failures) {
+		return "", p.failures[call-1]
+	}
+	if p.completionTokens > 0 {
+		reportCompletionTokens(ctx, p.completionTokens)
+	}
+	return p.mock.CallChat(ctx, request)
+}
+
+// useScriptedProvider routes every model call in the test through a scriptedProvider
+// failing with failures, and returns it along with a count of providers created
+func useScriptedProvider(t *testing.T, failures ...error) (*scriptedProvider, *atomic.Int32) {
+	t.Helper()
+	provider := &scriptedProvider{failures: failures, mock: NewMockProvider(config.GetConfig().Providers.Mock)}
+	var created atomic.Int32
+	previous := newModelProvider
+	newModelProvider = func(providerName, apiKey string) ModelProvider {
+		created.Add(1)
+		return provider
+	}
+	t.Cleanup(func() { newModelProvider = previous })
+	return provider, &created
+}
+
+// useFastRetries shortens retry backoff so retry tests run in milliseconds
+func useFastRetries(t *testing.T, maxAttempts int) {
+	t.Helper()
+	useEnterpriseConfig(t, func(c *config.EnterpriseConfig) {
+		c.Application.Resilience.Retry.MaxAttempts = maxAttempts
+		c.Application.Resilience.Retry.InitialDelay = time.Millisecond
+		c.Application.Resilience.Retry.MaxDelay = 2 * time.Millisecond
+	})
+	rebuildCircuitBreakers()
+}
+
+// useRateLimit replaces the provider rate limiters with one allowing burst calls
+func useRateLimit(t *testing.T, burst int) {
+	t.Helper()
+	previous := rateLimiters
+	rateLimiters = map[string]*rate.Limiter{"default": rate.NewLimiter(rate.Every(time.Hour), burst)}
+	t.Cleanup(func() { rateLimiters = previous })
+}
+
+// generateReadme runs the model call path for a README prompt with the configured provider
+func generateReadme(prompt string) (string, error) {
+	messages := []ChatMessage{{Role: "user", Content: prompt}}
+	return callModelAPIWithConversation(context.Background(), messages, "README", "service", "")
+}
+
+func TestCallPathRetriesTransientErrors(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	useFastRetries(t, 3)
+	provider, _ := useScriptedProvider(t,
+		&APIStatusError{StatusCode: http.StatusServiceUnavailable, Err: errors.New("service unavailable")},
+		&APIStatusError{StatusCode: http.StatusTooManyRequests, Err: errors.New("too many requests")},
+	)
+
+	content, err := generateReadme("Document the billing service.")
+	if err != nil {
+		t.Fatalf("call failed after transient errors: %v", err)
+	}
+	if !strings.Contains(content, "## Overview") {
+		t.Errorf("content = %q, want the mock README", content)
+	}
+	if got := provider.calls.Load(); got != 3 {
+		t.Errorf("provider called %d times, want 3", got)
+	}
+}
+
+func TestCallPathGivesUpAfterMaxAttempts(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	useFastRetries(t, 2)
+	unavailable := &APIStatusError{StatusCode: http.StatusBadGateway, Err: errors.New("bad gateway")}
+	provider, _ := useScriptedProvider(t, unavailable, unavailable, unavailable, unavailable)
+
+	if _, err := generateReadme("Document the billing service."); err == nil {
+		t.Fatal("call succeeded despite failing every attempt")
+	}
+	// The first attempt plus two retries
+	if got := provider.calls.Load(); got != 3 {
+		t.Errorf("provider called %d times, want 3", got)
+	}
+}
+
+func TestCallPathFailsFastOnAuthErrors(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusBadRequest} {
+		t.Run(fmt.Sprint(status), func(t *testing.T) {
+			newTestProject(t, "components: []\n")
+			useFastRetries(t, 3)
+			provider, _ := useScriptedProvider(t, &APIStatusError{StatusCode: status, Err: fmt.Errorf("authentication_error (status %d)", status)})
+
+			_, err := generateReadme("Document the billing service.")
+			var statusErr *APIStatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != status {
+				t.Fatalf("error = %v, want the %d from the provider", err, status)
+			}
+			if got := provider.calls.Load(); got != 1 {
+				t.Errorf("provider called %d times, want 1", got)
+			}
+		})
+	}
+}
+
+func TestCallPathCacheHitSkipsProvider(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	provider, created := useScriptedProvider(t)
+
+	first, err := generateReadme("Document the billing service.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := generateReadme("Document the billing service.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second != first {
+		t.Errorf("cached content differs from the first response")
+	}
+	// The repeat is answered from the cache before a provider is even created
+	if provider.calls.Load() != 1 || created.Load() != 1 {
+		t.Errorf("provider created %d times and called %d times, want 1 and 1", created.Load(), provider.calls.Load())
+	}
+
+	// A different prompt is a miss
+	if _, err := generateReadme("Document the payments service."); err != nil {
+		t.Fatal(err)
+	}
+	if got := provider.calls.Load(); got != 2 {
+		t.Errorf("provider called %d times after a new prompt, want 2", got)
+	}
+}
+
+func TestCallPathRateLimit(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	provider, _ := useScriptedProvider(t)
+	useRateLimit(t, 2)
+
+	for i := 0; i < 2; i++ {
+		if _, err := generateReadme(fmt.Sprintf("Document service %d.", i)); err != nil {
+			t.Fatalf("call %d within the limit: %v", i+1, err)
+		}
+	}
+	_, err := generateReadme("Document service 2.")
+	if err == nil || !strings.Contains(err.Error(), "rate limit exceeded for provider mock") {
+		t.Fatalf("error = %v, want the rate limit", err)
+	}
+	if got := provider.calls.Load(); got != 2 {
+		t.Errorf("provider called %d times, want 2; a rate-limited call must not reach it", got)
+	}
+
+	// Cache hits don't spend rate limit tokens
+	if _, err := generateReadme("Document service 0."); err != nil {
+		t.Errorf("cached call was rate limited: %v", err)
+	}
+}
+
+func TestCallPathRecordsProviderCompletionTokens(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	provider, _ := useScriptedProvider(t)
+	provider.completionTokens = 777
+
+	content, err := generateReadme("Document the reported service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate := EstimateTokens(content); estimate == 777 {
+		t.Fatalf("the mock response estimates at %d tokens too; pick another reported count", estimate)
+	}
+	if median, count := getTokenHistory().Median("README"); count != 1 || median != 777 {
+		t.Errorf("token history = median %d over %d samples, want the reported 777 over 1", median, count)
+	}
+
+	// A provider that reports no usage falls back to the estimated size of the completion
+	provider.completionTokens = 0
+	content, err = generateReadme("Document the unreported service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if median, count := getTokenHistory().Median("README"); count != 2 || median != (777+EstimateTokens(content))/2 {
+		t.Errorf("token history = median %d over %d samples, want 777 and the estimate %d", median, count, EstimateTokens(content))
+	}
+}
+
+// useCacheFlags sets --no-cache and --no-cache-write for the rest of the test
+func useCacheFlags(t *testing.T, skipRead, skipWrite bool) {
+	t.Helper()
+	previousNoCache, previousNoCacheWrite := noCache, noCacheWrite
+	noCache, noCacheWrite = skipRead, skipWrite
+	t.Cleanup(func() { noCache, noCacheWrite = previousNoCache, previousNoCacheWrite })
+}
+
+func TestCallPathNoCacheIgnoresCachedResponse(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	provider, _ := useScriptedProvider(t)
+	const prompt = "Document the billing service."
+
+	if _, err := generateReadme(prompt); err != nil {
+		t.Fatal(err)
+	}
+	cache := GetProviderCache("mock")
+	if entries := cache.GetMetrics().EntryCount; entries != 1 {
+		t.Fatalf("cache holds %d entries after the first call, want 1", entries)
+	}
+
+	// --no-cache calls the provider despite the cached key, and still caches the response
+	useCacheFlags(t, true, false)
+	if _, err := generateReadme(prompt); err != nil {
+		t.Fatal(err)
+	}
+	if got := provider.calls.Load(); got != 2 {
+		t.Errorf("provider called %d times, want 2 with --no-cache ignoring the cached key", got)
+	}
+	if hits := cache.GetMetrics().Hits; hits != 0 {
+		t.Errorf("cache served %d hits under --no-cache, want 0", hits)
+	}
+	if entries := cache.GetMetrics().EntryCount; entries != 1 {
+		t.Errorf("cache holds %d entries, want the one entry refreshed", entries)
+	}
+
+	// --no-cache-write neither reads nor writes
+	useCacheFlags(t, false, true)
+	if _, err := generateReadme("Document the payments service."); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := generateReadme(prompt); err != nil {
+		t.Fatal(err)
+	}
+	if got := provider.calls.Load(); got != 4 {
+		t.Errorf("provider called %d times, want 4 with --no-cache-write skipping reads", got)
+	}
+	if entries := cache.GetMetrics().EntryCount; entries != 1 {
+		t.Errorf("cache holds %d entries, want --no-cache-write to add none", entries)
+	}
+
+	// Without the flags the cached key is served again
+	useCacheFlags(t, false, false)
+	if _, err := generateReadme(prompt); err != nil {
+		t.Fatal(err)
+	}
+	if got := provider.calls.Load(); got != 4 {
+		t.Errorf("provider called %d times, want the cached response served", got)
+	}
+}
+
+// withoutModelConfig removes the project's model-config.yaml and the provider API keys from
+// the environment, so the built-in defaults are loaded with no usable key
+func withoutModelConfig(t *testing.T, project *testProject) {
+	t.Helper()
+	if err := os.Remove(filepath.Join(project.CLI, "model-config.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"ANTHROPIC_API_KEY", "OPENAI_API_KEY", "OPENROUTER_API_KEY"} {
+		t.Setenv(name, "")
+	}
+	modelConfig.Store(nil)
+}
+
+func TestListWithoutModelConfig(t *testing.T) {
+	project, _ := singleServiceProject(t)
+	withoutModelConfig(t, project)
+
+	output, code := runCLI(t, project, "list")
+	if code != 0 || !strings.Contains(output, "Found 1 components") || !strings.Contains(output, "• svc (svc)") {
+		t.Errorf("list without model-config.yaml exited %d:\n%s", code, output)
+	}
+}
+
+func TestModelCallWithoutModelConfig(t *testing.T) {
+	project, _ := singleServiceProject(t)
+	withoutModelConfig(t, project)
+
+	loaded, err := loadModelConfig()
+	if err != nil || !loaded.builtIn || loaded.Default.Provider != "anthropic" {
+		t.Fatalf("loadModelConfig without the file = %+v, %v; want the built-in defaults", loaded, err)
+	}
+	settings, err := getModelSettingsForDocType("README")
+	if err != nil || settings.Model == "" {
+		t.Errorf("getModelSettingsForDocType without the file = %+v, %v", settings, err)
+	}
+
+	// Only an actual call fails, naming the missing file and the key it would use instead
+	_, err = callModelAPIWithContext("Document the billing service.", "README", "service", "")
+	if err == nil || !strings.Contains(err.Error(), "model-config.yaml not found") || !strings.Contains(err.Error(), "ANTHROPIC_API_KEY") {
+		t.Errorf("model call without model-config.yaml or a key = %v", err)
+	}
+
+	// A key in the environment makes the built-in defaults usable
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	modelConfig.Store(nil)
+	provider, _ := useScriptedProvider(t)
+	if _, err := callModelAPIWithContext("Document the billing service.", "README", "service", ""); err != nil {
+		t.Fatalf("model call with ANTHROPIC_API_KEY set = %v", err)
+	}
+	if provider.calls.Load() != 1 {
+		t.Errorf("provider called %d times, want 1", provider.calls.Load())
+	}
+}
+
+// onlyCachedValue returns the value of the single entry in cache, expired or not
+func onlyCachedValue(t *testing.T, cache *EnterpriseCache) string {
+	t.Helper()
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+	if len(cache.entries) != 1 {
+		t.Fatalf("cache holds %d entries, want 1", len(cache.entries))
+	}
+	for _, element := range cache.entries {
+		return element.Value.(*CacheEntry).Value
+	}
+	return ""
+}
+
+// waitForRefreshes waits until cache has no background refresh running
+func waitForRefreshes(t *testing.T, cache *EnterpriseCache) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		cache.mutex.RLock()
+		running := len(cache.refreshing)
+		cache.mutex.RUnlock()
+		if running == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background refresh did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCallPathRefreshesStaleEntriesThroughGuards(t *testing.T) {
+	tests := []struct {
+		name         string
+		burst        int
+		refreshed    string
+		wantRequests int
+		wantCached   string
+	}{
+		{"refreshed", 3, chatCompletion("new", "stop"), 2, "new"},
+		// The foreground calls take the whole burst, so the refresh is never sent
+		{"rate limited", 2, chatCompletion("new", "stop"), 1, "old"},
+		// A truncated refresh is dropped, so a cache hit is always complete
+		{"truncated", 3, chatCompletion("cut", "length"), 2, "old"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := newTestProject(t, "components: []\n")
+			useModelConfig(t, project, `default:
+  provider: "openai"
+  model: "gpt-4o"
+  max_tokens: 1000
+  temperature: 0.5
+openai:
+  api_key: "test-key"
+  models:
+    gpt-4o: "gpt-4o"
+`)
+			useEnterpriseConfig(t, func(c *config.EnterpriseConfig) {
+				c.Application.Cache.TTLOverrides = map[string]time.Duration{"README": time.Millisecond}
+			})
+			useRateLimit(t, tt.burst)
+			cache := GetProviderCache("openai")
+			cache.SetStaleWhileRevalidate(time.Minute)
+			t.Cleanup(func() { cache.SetStaleWhileRevalidate(0) })
+			server := newProviderServer(t, http.StatusOK, chatCompletion("old", "stop"))
+			previous := newModelProvider
+			newModelProvider = func(providerName, apiKey string) ModelProvider { return newHTTPProviders(server.URL)[providerName] }
+			t.Cleanup(func() { newModelProvider = previous })
+
+			const prompt = "Document the billing service."
+			if _, err := generateReadme(prompt); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(5 * time.Millisecond)
+			server.mu.Lock()
+			server.body = tt.refreshed
+			server.mu.Unlock()
+
+			// The expired entry is served while it is refreshed in the background
+			if content, err := generateReadme(prompt); err != nil || content != "old" {
+				t.Fatalf("call in the stale grace window = %q, %v; want the stale response", content, err)
+			}
+			waitForRefreshes(t, cache)
+			if got := len(server.Requests()); got != tt.wantRequests {
+				t.Errorf("server received %d requests, want %d", got, tt.wantRequests)
+			}
+			if got := onlyCachedValue(t, cache); got != tt.wantCached {
+				t.Errorf("cached response after the refresh = %q, want %q", got, tt.wantCached)
+			}
+		})
+	}
+}
@@ -1,21 +1,160 @@
 package main
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"docs-cli/pkg/config"
+)
+
+// DefaultMaxResponseBytes caps provider response bodies when no limit is configured
+const DefaultMaxResponseBytes = 5 * 1024 * 1024
 
 // ModelProvider defines the interface for all model providers
 type ModelProvider interface {
 	CallModel(ctx context.Context, prompt, model string, maxTokens int, temperature float64) (string, error)
 }
 
-// ProviderFactory creates model providers based on provider name
+// ModelResult is the value model calls return through ResilientAPICall.
+// Call metadata (token usage, finish reason) belongs here rather than in a
+// different result type, so callers can always type-assert to ModelResult.
+type ModelResult struct {
+	Content  string
+	Provider string
+	Model    string
+	// Truncated is set when the response stopped at the output token limit
+	Truncated bool
+	// CompletionTokens is the output token count the provider reported; 0 when it reported
+	// none, as on a cache hit
+	CompletionTokens int
+}
+
+// modelCall adapts a provider call into a RetryableFunc that yields a ModelResult.
+// call receives a context through which the provider reports a truncated response and
+// its completion token count.
+func modelCall(ctx context.Context, provider, model string, call func(ctx context.Context) (string, error)) RetryableFunc {
+	return func() (interface{}, error) {
+		callCtx, truncated := withTruncationFlag(ctx)
+		callCtx, completionTokens := withCompletionTokens(callCtx)
+		content, err := call(callCtx)
+		if err != nil {
+			return nil, err
+		}
+		return ModelResult{
+			Content:          content,
+			Provider:         provider,
+			Model:            model,
+			Truncated:        truncated.Load(),
+			CompletionTokens: int(completionTokens.Load()),
+		}, nil
+	}
+}
+
+// completionTokensKey is the context key for the completion token count a provider call reports
+type completionTokensKey struct{}
+
+// withCompletionTokens returns a context whose provider call reports its completion token
+// count into the returned counter
+func withCompletionTokens(ctx context.Context) (context.Context, *atomic.Int64) {
+	tokens := new(atomic.Int64)
+	return context.WithValue(ctx, completionTokensKey{}, tokens), tokens
+}
+
+// reportCompletionTokens records the completion token count of the provider call made with ctx
+func reportCompletionTokens(ctx context.Context, tokens int) {
+	if counter, ok := ctx.Value(completionTokensKey{}).(*atomic.Int64); ok {
+		counter.Store(int64(tokens))
+	}
+}
+
+// readLimitedBody reads a response body, failing once it exceeds maxBytes
+// so a runaway completion cannot exhaust memory
+func readLimitedBody(body io.Reader, maxBytes int64) ([]byte, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxResponseBytes
+	}
+	
+	data, err := io.ReadAll(io.LimitReader(body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("response body exceeds max_response_bytes limit of %d bytes", maxBytes)
+	}
+	
+	return data, nil
+}
+
+// ProviderOption overrides provider settings that otherwise come from enterprise config
+type ProviderOption func(*config.ProviderConfig)
+
+// WithBaseURL points a provider at a different API endpoint, e.g. an httptest server
+func WithBaseURL(url string) ProviderOption {
+	return func(providerConfig *config.ProviderConfig) {
+		providerConfig.APIURL = url
+	}
+}
+
+// WithTimeout overrides the per-request timeout
+func WithTimeout(timeout time.Duration) ProviderOption {
+	return func(providerConfig *config.ProviderConfig) {
+		providerConfig.Timeout = timeout
+	}
+}
+
+// WithAPIVersion overrides the API version header sent by providers that use one
+func WithAPIVersion(version string) ProviderOption {
+	return func(providerConfig *config.ProviderConfig) {
+		providerConfig.APIVersion = version
+	}
+}
+
+// WithHeaders merges extra headers over the configured ones
+func WithHeaders(headers map[string]string) ProviderOption {
+	return func(providerConfig *config.ProviderConfig) {
+		// Copy so overrides never leak into the shared config map
+		merged := make(map[string]string, len(providerConfig.Headers)+len(headers))
+		for name, value := range providerConfig.Headers {
+			merged[name] = value
+		}
+		for name, value := range headers {
+			merged[name] = value
+		}
+		providerConfig.Headers = merged
+	}
+}
+
+// applyProviderOptions returns a copy of providerConfig with opts applied
+func applyProviderOptions(providerConfig config.ProviderConfig, opts []ProviderOption) config.ProviderConfig {
+	for _, opt := range opts {
+		opt(&providerConfig)
+	}
+	return providerConfig
+}
+
+// ProviderFactoryFunc creates a model provider for a provider name and API key
+type ProviderFactoryFunc func(providerName, apiKey string) ModelProvider
+
+// newModelProvider is the factory the model call path uses; tests swap it for a
+// mock so retry, caching, and rate limiting run without network access
+var newModelProvider ProviderFactoryFunc = ProviderFactory
+
+// ProviderFactory creates model providers based on provider name, configured from enterprise config
 func ProviderFactory(providerName, apiKey string) ModelProvider {
+	providers := config.GetConfig().Providers
 	switch providerName {
 	case "anthropic":
-		return NewAnthropicProvider(apiKey)
+		return NewAnthropicProvider(apiKey, providers.Anthropic)
 	case "openai":
-		return NewOpenAIProvider(apiKey)
+		return NewOpenAIProvider(apiKey, providers.OpenAI)
 	case "openrouter":
-		return NewOpenRouterProvider(apiKey)
+		return NewOpenRouterProvider(apiKey, providers.OpenRouter)
+	case "mock":
+		return NewMockProvider(providers.Mock)
 	default:
 		return nil
 	}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"docs-cli/pkg/config"
+)
+
+func TestReadLimitedBody(t *testing.T) {
+	if data, err := readLimitedBody(strings.NewReader("12345"), 5); err != nil || string(data) != "12345" {
+		t.Errorf("body at the limit = %q, %v; want it read in full", data, err)
+	}
+	if _, err := readLimitedBody(strings.NewReader("123456"), 5); err == nil || !strings.Contains(err.Error(), "max_response_bytes") {
+		t.Errorf("body over the limit: err = %v, want a max_response_bytes error", err)
+	}
+}
+
+func TestOversizedResponseRejected(t *testing.T) {
+	const limit = 1024
+	oversized := `{"padding":"` + strings.Repeat("x", 4*limit) + `"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(oversized))
+	}))
+	defer server.Close()
+
+	providers := config.GetConfig().Providers
+	limited := func(settings config.ProviderConfig) config.ProviderConfig {
+		settings.MaxResponseBytes = limit
+		return settings
+	}
+	tests := map[string]ModelProvider{
+		"anthropic":  NewAnthropicProvider("test-key", limited(providers.Anthropic), WithBaseURL(server.URL)),
+		"openai":     NewOpenAIProvider("test-key", limited(providers.OpenAI), WithBaseURL(server.URL)),
+		"openrouter": NewOpenRouterProvider("test-key", limited(providers.OpenRouter), WithBaseURL(server.URL)),
+	}
+	for name, provider := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := provider.CallModel(context.Background(), "prompt", "model", 100, 0)
+			if err == nil || !strings.Contains(err.Error(), "max_response_bytes limit of 1024 bytes") {
+				t.Fatalf("err = %v, want the max_response_bytes limit error", err)
+			}
+			if DefaultShouldRetry(err) {
+				t.Error("an oversized response should not be retried")
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"crypto/md5"
 	"fmt"
-	"os"
+	"io"
 	"runtime"
+	"sync"
 	"time"
 
 	"docs-cli/pkg/config"
@@ -40,6 +42,56 @@ func GetMemoryStats() MemoryStats {
 	}
 }
 
+// MemoryPressure is the memory usage level reported by CheckMemoryUsage
+type MemoryPressure int
+
+const (
+	MemoryPressureNormal MemoryPressure = iota
+	MemoryPressureWarning
+	MemoryPressureCritical
+)
+
+func (p MemoryPressure) String() string {
+	switch p {
+	case MemoryPressureWarning:
+		return "warning"
+	case MemoryPressureCritical:
+		return "critical"
+	default:
+		return "normal"
+	}
+}
+
+var (
+	pressureMutex       sync.Mutex
+	pressureSubscribers []func(MemoryPressure)
+	lastPressure        MemoryPressure
+)
+
+// SubscribeMemoryPressure registers fn to be called whenever the memory pressure level changes
+func SubscribeMemoryPressure(fn func(MemoryPressure)) {
+	pressureMutex.Lock()
+	defer pressureMutex.Unlock()
+	pressureSubscribers = append(pressureSubscribers, fn)
+}
+
+// notifyMemoryPressure informs subscribers when the pressure level changes
+func notifyMemoryPressure(pressure MemoryPressure) {
+	pressureMutex.Lock()
+	if pressure == lastPressure {
+		pressureMutex.Unlock()
+		return
+	}
+	lastPressure = pressure
+	subscribers := append([]func(MemoryPressure){}, pressureSubscribers...)
+	pressureMutex.Unlock()
+	
+	LogWithContext().WithField("pressure", pressure.String()).Info("Memory pressure level changed")
+	for _, fn := range subscribers {
+		fn(pressure)
+	}
+}
+
 // CheckMemoryUsage logs memory usage and triggers GC if needed
 func CheckMemoryUsage() {
 	stats := GetMemoryStats()
@@ -49,6 +101,7 @@ func CheckMemoryUsage() {
 	monitoringConfig := getMonitoringConfig()
 	
 	if stats.AllocMB >= monitoringConfig.MemoryCriticalMB {
+		notifyMemoryPressure(MemoryPressureCritical)
 		entry.Error("Critical memory usage detected")
 		runtime.GC() // Force garbage collection
 		runtime.GC() // Run twice for better cleanup
@@ -60,8 +113,10 @@ func CheckMemoryUsage() {
 		
 	} else if stats.AllocMB >= monitoringConfig.MemoryWarningMB {
 		entry.Warn("High memory usage detected")
+		notifyMemoryPressure(MemoryPressureWarning)
 	} else {
 		entry.Debug("Memory usage normal")
+		notifyMemoryPressure(MemoryPressureNormal)
 	}
 }
 
@@ -109,6 +164,35 @@ var (
 	ErrMemoryLimitExceeded = fmt.Errorf("memory usage exceeds critical threshold")
 )
 
+// MemoryAwareFileHasher hashes a file by streaming it through md5, so large files
+// are never held in memory. It returns the hex digest and the number of bytes hashed.
+func MemoryAwareFileHasher(filePath string) (string, int64, error) {
+	// Validate size before opening, as MemoryAwareFileReader does
+	info, err := statSourceFile(filePath)
+	if err != nil {
+		return "", 0, err
+	}
+	
+	if err := ValidateFileSize(info.Size()); err != nil {
+		return "", 0, err
+	}
+	
+	file, err := openSourceFile(filePath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+	
+	hasher := md5.New()
+	size, err := io.Copy(hasher, file)
+	LogFileOperation("hash", filePath, size, err)
+	if err != nil {
+		return "", 0, err
+	}
+	
+	return fmt.Sprintf("%x", hasher.Sum(nil)), size, nil
+}
+
 // MemoryAwareFileReader reads files with memory monitoring
 func MemoryAwareFileReader(filePath string) ([]byte, error) {
 	// Check memory before reading
@@ -117,7 +201,7 @@ func MemoryAwareFileReader(filePath string) ([]byte, error) {
 	}
 	
 	// Get file size first
-	info, err := os.Stat(filePath)
+	info, err := statSourceFile(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -129,7 +213,7 @@ func MemoryAwareFileReader(filePath string) ([]byte, error) {
 	
 	// Log the operation
 	start := time.Now()
-	content, err := os.ReadFile(filePath)
+	content, err := readSourceFile(filePath)
 	duration := time.Since(start)
 	
 	LogFileOperation("read", filePath, info.Size(), err)
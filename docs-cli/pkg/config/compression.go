@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CompressionRule is one substitution CompressPrompt applies, in order. Pattern is a regular
+// expression unless Literal is set, in which case it is replaced verbatim.
+type CompressionRule struct {
+	Name        string `yaml:"name"`
+	Pattern     string `yaml:"pattern"`
+	Replacement string `yaml:"replacement"`
+	Literal     bool   `yaml:"literal,omitempty"`
+	Disabled    bool   `yaml:"disabled,omitempty"`
+}
+
+// MarshalYAML double-quotes whitespace-only strings such as the "\n\n" replacement:
+// yaml.v3 writes them as literal blocks that read back with newlines missing
+func (r CompressionRule) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	add := func(key string, value *yaml.Node) {
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	}
+	add("name", stringNode(r.Name))
+	add("pattern", stringNode(r.Pattern))
+	add("replacement", stringNode(r.Replacement))
+	if r.Literal {
+		add("literal", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+	}
+	if r.Disabled {
+		add("disabled", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+	}
+	return node, nil
+}
+
+// stringNode is a string scalar, double-quoted when it is whitespace only
+func stringNode(value string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	if value != "" && strings.TrimSpace(value) == "" {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	return node
+}
+
+// DefaultCompressionRules is the built-in ruleset used when cost_optimization.compression.rules is unset
+var DefaultCompressionRules = []CompressionRule{
+	{Name: "collapse_whitespace", Pattern: `\s+`, Replacement: " "},
+	{Name: "trim", Pattern: `^\s+|\s+$`, Replacement: ""},
+	{Name: "strip_hash_comments", Pattern: `(?m)^#.*$`, Replacement: ""},
+	{Name: "strip_slash_comments", Pattern: `(?m)^\s*//.*$`, Replacement: ""},
+	{Name: "collapse_blank_lines", Pattern: `\n\s*\n\s*\n+`, Replacement: "\n\n"},
+	{Name: "strip_file_extensions", Pattern: `\.(py|go|tsx|ts|jsx|js|md|yaml|yml|json)`, Replacement: ""},
+	{Name: "abbreviate_import", Pattern: "import ", Replacement: "imp ", Literal: true},
+	{Name: "abbreviate_export", Pattern: "export ", Replacement: "exp ", Literal: true},
+	{Name: "abbreviate_function", Pattern: "function ", Replacement: "fn ", Literal: true},
+	{Name: "abbreviate_interface", Pattern: "interface ", Replacement: "int ", Literal: true},
+	{Name: "abbreviate_component", Pattern: "component ", Replacement: "comp ", Literal: true},
+	{Name: "abbreviate_const", Pattern: "const ", Replacement: "c ", Literal: true},
+	{Name: "abbreviate_return", Pattern: "return ", Replacement: "ret ", Literal: true},
+	{Name: "strip_path_prefixes", Pattern: `/[a-zA-Z0-9_-]+/[a-zA-Z0-9_-]+/`, Replacement: ""},
+}
+
+// ResolveRules returns compression.rules, or DefaultCompressionRules when unset,
+// after checking every rule has a pattern and every regular expression compiles
+func (c CompressionConfig) ResolveRules() ([]CompressionRule, error) {
+	if len(c.Rules) == 0 {
+		return DefaultCompressionRules, nil
+	}
+
+	for i, rule := range c.Rules {
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("cost_optimization.compression.rules[%d] (%s): pattern is required", i, rule.Name)
+		}
+		if rule.Literal {
+			continue
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return nil, fmt.Errorf("cost_optimization.compression.rules[%d] (%s): %w", i, rule.Name, err)
+		}
+	}
+	return c.Rules, nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCompressionRulesYAMLRoundTrip(t *testing.T) {
+	rules := append([]CompressionRule{
+		{Name: "newline", Pattern: "\r\n", Replacement: "\n", Literal: true},
+		{Name: "tab", Pattern: "\t", Replacement: "    ", Literal: true, Disabled: true},
+		{Name: "yes", Pattern: "true", Replacement: "null", Literal: true},
+	}, DefaultCompressionRules...)
+
+	data, err := yaml.Marshal(CompressionConfig{Rules: rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded CompressionConfig
+	if err := yaml.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Rules, rules) {
+		for i := range rules {
+			if i < len(decoded.Rules) && decoded.Rules[i] != rules[i] {
+				t.Errorf("rule %d read back as %#v, want %#v", i, decoded.Rules[i], rules[i])
+			}
+		}
+		t.Fatalf("rules did not survive a YAML round trip:\n%s", data)
+	}
+}
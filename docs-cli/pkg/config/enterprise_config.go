@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -31,6 +33,22 @@ type CacheConfig struct {
 	MaxEntries         int           `yaml:"max_entries"`
 	CleanupInterval    time.Duration `yaml:"cleanup_interval"`
 	MetricsLogInterval time.Duration `yaml:"metrics_log_interval"`
+	// AdaptiveSizing shrinks the cache while the memory monitor reports pressure
+	AdaptiveSizing bool `yaml:"adaptive_sizing"`
+	// PressureWarningRatio and PressureCriticalRatio are the fractions of max_size_mb kept under
+	// each pressure level; 0 uses the defaults
+	PressureWarningRatio  float64 `yaml:"pressure_warning_ratio"`
+	PressureCriticalRatio float64 `yaml:"pressure_critical_ratio"`
+	// WritePolicy is "newest_wins" (overwrite) or "coalesce" (keep an existing unexpired entry)
+	WritePolicy string `yaml:"write_policy"`
+	// TTLOverrides sets a per-document-type TTL; types without an override use TTL
+	TTLOverrides map[string]time.Duration `yaml:"ttl_overrides,omitempty"`
+	// StaleWhileRevalidate serves entries up to StaleGrace past expiry while refreshing them in the background
+	StaleWhileRevalidate bool          `yaml:"stale_while_revalidate"`
+	StaleGrace           time.Duration `yaml:"stale_grace"`
+	// EntryOverheadBytes is charged per entry on top of its key and value against max_size_mb;
+	// 0 uses an estimate of the entry's struct, LRU list, and map overhead
+	EntryOverheadBytes int64 `yaml:"entry_overhead_bytes"`
 }
 
 // MonitoringConfig holds monitoring settings
@@ -61,6 +79,11 @@ type CircuitBreakerConfig struct {
 	Interval         time.Duration `yaml:"interval"`
 	Timeout          time.Duration `yaml:"timeout"`
 	FailureThreshold uint32        `yaml:"failure_threshold"`
+	FailureRatio     float64       `yaml:"failure_ratio"`
+	MinRequests      uint32        `yaml:"min_requests"`
+	// SuccessThreshold is how many consecutive half-open successes close the breaker;
+	// 0 requires all max_requests probes to succeed
+	SuccessThreshold uint32        `yaml:"success_threshold"`
 }
 
 // FileScanningConfig holds file scanning settings
@@ -69,6 +92,23 @@ type FileScanningConfig struct {
 	BinaryDetectionBuffer int            `yaml:"binary_detection_buffer"`
 	DefaultFileLimit      int            `yaml:"default_file_limit"`
 	FilePriorities        map[string]int `yaml:"file_priorities"`
+	ScanWorkers           int            `yaml:"scan_workers"`
+	// ExcludeTests drops files matching TestPatterns from source context
+	ExcludeTests bool     `yaml:"exclude_tests"`
+	TestPatterns []string `yaml:"test_patterns"`
+}
+
+// DefaultTestPatterns matches the test file conventions of the languages the scanner prioritizes.
+// Patterns ending in "/" match a directory name anywhere in the path; others match the file's base name.
+var DefaultTestPatterns = []string{
+	"*_test.go",
+	"*.test.ts",
+	"*.test.tsx",
+	"*.test.js",
+	"*.spec.ts",
+	"test_*.py",
+	"*_test.py",
+	"__tests__/",
 }
 
 // ProvidersConfig holds all provider configurations
@@ -76,6 +116,17 @@ type ProvidersConfig struct {
 	Anthropic  ProviderConfig `yaml:"anthropic"`
 	OpenAI     ProviderConfig `yaml:"openai"`
 	OpenRouter ProviderConfig `yaml:"openrouter"`
+	Mock       MockConfig     `yaml:"mock"`
+}
+
+// MockConfig configures the offline mock provider used for demos and tests
+type MockConfig struct {
+	// FixtureFile, when set, is returned as every response instead of the per-doc-type placeholder
+	FixtureFile string        `yaml:"fixture_file,omitempty"`
+	Latency     time.Duration `yaml:"latency"`
+	// ErrorRate is the fraction of calls, from 0 to 1, that fail with ErrorStatus
+	ErrorRate   float64 `yaml:"error_rate"`
+	ErrorStatus int     `yaml:"error_status"`
 }
 
 // ProviderConfig holds individual provider configuration
@@ -84,9 +135,49 @@ type ProviderConfig struct {
 	Timeout          time.Duration     `yaml:"timeout"`
 	APIVersion       string            `yaml:"api_version,omitempty"`
 	TemperatureRange TemperatureRange  `yaml:"temperature_range"`
-	StopSequences    []string          `yaml:"stop_sequences,omitempty"`
+	StopSequences    StopSequences     `yaml:"stop_sequences,omitempty"`
 	Metadata         map[string]string `yaml:"metadata,omitempty"`
 	Headers          map[string]string `yaml:"headers,omitempty"`
+	MaxResponseBytes int64             `yaml:"max_response_bytes"`
+	Transport        TransportConfig   `yaml:"transport"`
+	// PromptCaching marks the stable source-context prefix of prompts for provider-side
+	// caching (Anthropic only)
+	PromptCaching bool `yaml:"prompt_caching,omitempty"`
+}
+
+// TransportConfig tunes the pooled HTTP connections kept per provider
+type TransportConfig struct {
+	MaxIdleConns        int           `yaml:"max_idle_conns"`
+	MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host"`
+	IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout"`
+	TLSHandshakeTimeout time.Duration `yaml:"tls_handshake_timeout"`
+	DisableKeepAlives   bool          `yaml:"disable_keep_alives"`
+}
+
+// DefaultTransportConfig keeps a few idle connections per provider host between calls
+var DefaultTransportConfig = TransportConfig{
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 10,
+	IdleConnTimeout:     90 * time.Second,
+	TLSHandshakeTimeout: 10 * time.Second,
+}
+
+// StopSequences are provider stop strings. They usually start with newlines, which
+// yaml.v3 only writes back in a readable form as double-quoted scalars.
+type StopSequences []string
+
+// MarshalYAML writes each stop sequence double-quoted
+func (s StopSequences) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, sequence := range s {
+		node.Content = append(node.Content, &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Tag:   "!!str",
+			Value: sequence,
+			Style: yaml.DoubleQuotedStyle,
+		})
+	}
+	return node, nil
 }
 
 // TemperatureRange holds temperature validation ranges
@@ -101,11 +192,24 @@ type CostOptConfig struct {
 	Compression           CompressionConfig     `yaml:"compression"`
 	ComplexityThresholds  ComplexityConfig      `yaml:"complexity_thresholds"`
 	Pricing               PricingConfig         `yaml:"pricing"`
+	// MaxSpendPerMinute is the dollars-per-minute rate that triggers the spend alarm (0 disables it)
+	MaxSpendPerMinute float64       `yaml:"max_spend_per_minute"`
+	SpendRateWindow   time.Duration `yaml:"spend_rate_window"`
+	// MaxComponents is how many components a generating run may cover without --confirm;
+	// unset or 0 means DefaultMaxComponents, and -1 disables the guard
+	MaxComponents int `yaml:"max_components"`
+	// CrossProviderSelection lets each call go to the cheapest priced provider and model, among
+	// those with an API key, that is capable enough for the task
+	CrossProviderSelection bool `yaml:"cross_provider_selection"`
 }
 
 // CompressionConfig holds compression settings
 type CompressionConfig struct {
+	// MaxRatio reverts compression that shrinks a prompt below this fraction of its size
 	MaxRatio float64 `yaml:"max_ratio"`
+	// MinTokenSavings is the fraction of estimated tokens compression must save to be kept
+	MinTokenSavings float64           `yaml:"min_token_savings"`
+	Rules           []CompressionRule `yaml:"rules"`
 }
 
 // ComplexityConfig holds task complexity thresholds
@@ -131,16 +235,149 @@ type TemplatesConfig struct {
 	FallbackEnabled bool                       `yaml:"fallback_enabled"`
 	Directory       string                     `yaml:"directory"`
 	FallbackPrompts map[string]string          `yaml:"fallback_prompts"`
+	// Languages maps supported --lang codes to the language name used in prompts
+	Languages map[string]string `yaml:"languages"`
+	// ChainOrder is the context-chaining generation order; it must list every chained doc type once
+	ChainOrder []string `yaml:"chain_order"`
+	// RequiredSections lists the headings each generated doc type must contain
+	RequiredSections map[string][]string `yaml:"required_sections"`
+}
+
+// Default fractions of max_size_mb the cache keeps under warning and critical memory pressure
+const (
+	DefaultPressureWarningRatio  = 0.5
+	DefaultPressureCriticalRatio = 0.1
+)
+
+// ResolvePressureRatios returns the warning and critical pressure ratios, using the defaults
+// for ratios left unset, after checking each is in (0, 1]
+func (c CacheConfig) ResolvePressureRatios() (warning, critical float64, err error) {
+	warning, critical = c.PressureWarningRatio, c.PressureCriticalRatio
+	if warning == 0 {
+		warning = DefaultPressureWarningRatio
+	}
+	if critical == 0 {
+		critical = DefaultPressureCriticalRatio
+	}
+	if warning < 0 || warning > 1 {
+		return 0, 0, fmt.Errorf("cache.pressure_warning_ratio must be greater than 0 and at most 1, got %g", c.PressureWarningRatio)
+	}
+	if critical < 0 || critical > 1 {
+		return 0, 0, fmt.Errorf("cache.pressure_critical_ratio must be greater than 0 and at most 1, got %g", c.PressureCriticalRatio)
+	}
+	return warning, critical, nil
+}
+
+// DefaultMaxComponents is the component limit used when cost_optimization.max_components is unset
+const DefaultMaxComponents = 100
+
+// ResolveMaxComponents returns the component limit a run may cover without --confirm, using
+// DefaultMaxComponents when max_components is unset, and false when -1 disables the guard
+func (c CostOptConfig) ResolveMaxComponents() (limit int, enabled bool, err error) {
+	switch {
+	case c.MaxComponents == 0:
+		return DefaultMaxComponents, true, nil
+	case c.MaxComponents == -1:
+		return 0, false, nil
+	case c.MaxComponents < 0:
+		return 0, false, fmt.Errorf("cost_optimization.max_components must be a positive count, or -1 to disable the guard, got %d", c.MaxComponents)
+	}
+	return c.MaxComponents, true, nil
+}
+
+// DefaultChainOrder is the context-chaining order used when templates.chain_order is unset
+var DefaultChainOrder = []string{"ARCHITECTURE", "README", "SETUP", "CHECKLIST"}
+
+// ResolveChainOrder returns templates.chain_order, or DefaultChainOrder when unset,
+// after checking it is a permutation of the known chained doc types
+func (t TemplatesConfig) ResolveChainOrder() ([]string, error) {
+	if len(t.ChainOrder) == 0 {
+		return DefaultChainOrder, nil
+	}
+
+	known := make(map[string]bool, len(DefaultChainOrder))
+	for _, docType := range DefaultChainOrder {
+		known[docType] = true
+	}
+
+	seen := make(map[string]bool, len(t.ChainOrder))
+	for _, docType := range t.ChainOrder {
+		if !known[docType] {
+			return nil, fmt.Errorf("templates.chain_order: unknown document type %q (expected %s)", docType, strings.Join(DefaultChainOrder, ", "))
+		}
+		if seen[docType] {
+			return nil, fmt.Errorf("templates.chain_order: %s listed more than once", docType)
+		}
+		seen[docType] = true
+	}
+	for _, docType := range DefaultChainOrder {
+		if !seen[docType] {
+			return nil, fmt.Errorf("templates.chain_order: missing document type %s", docType)
+		}
+	}
+
+	return t.ChainOrder, nil
 }
 
-var globalConfig *EnterpriseConfig
+// globalConfig is swapped atomically so a reload never exposes a half-updated configuration
+var globalConfig atomic.Pointer[EnterpriseConfig]
+
+// Validate checks the settings that are resolved lazily at call time
+func (c *EnterpriseConfig) Validate() error {
+	if _, err := c.Templates.ResolveChainOrder(); err != nil {
+		return err
+	}
+	if _, err := c.CostOpt.Compression.ResolveRules(); err != nil {
+		return err
+	}
+	if _, _, err := c.Application.Cache.ResolvePressureRatios(); err != nil {
+		return err
+	}
+	if _, _, err := c.CostOpt.ResolveMaxComponents(); err != nil {
+		return err
+	}
+	if c.Application.Cache.EntryOverheadBytes < 0 {
+		return fmt.Errorf("cache.entry_overhead_bytes must not be negative, got %d", c.Application.Cache.EntryOverheadBytes)
+	}
+	if cb := c.Application.Resilience.CircuitBreaker; cb.SuccessThreshold > max(cb.MaxRequests, 1) {
+		return fmt.Errorf("resilience.circuit_breaker.success_threshold (%d) must not exceed max_requests (%d)", cb.SuccessThreshold, cb.MaxRequests)
+	}
+	if rate := c.Providers.Mock.ErrorRate; rate < 0 || rate > 1 {
+		return fmt.Errorf("providers.mock.error_rate must be between 0 and 1, got %g", rate)
+	}
+	return nil
+}
 
 // LoadEnterpriseConfig loads the enterprise configuration from file
 func LoadEnterpriseConfig() (*EnterpriseConfig, error) {
-	if globalConfig != nil {
-		return globalConfig, nil
+	if config := globalConfig.Load(); config != nil {
+		return config, nil
 	}
 
+	config, err := readEnterpriseConfig()
+	if err != nil {
+		return nil, err
+	}
+	globalConfig.CompareAndSwap(nil, config)
+	return globalConfig.Load(), nil
+}
+
+// ReloadEnterpriseConfig re-reads enterprise-config.yaml and, only if it parses and validates,
+// replaces the loaded configuration. On error the previous configuration stays in effect.
+func ReloadEnterpriseConfig() (*EnterpriseConfig, error) {
+	config, err := readEnterpriseConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	globalConfig.Store(config)
+	return config, nil
+}
+
+// readEnterpriseConfig parses enterprise-config.yaml without touching the loaded configuration
+func readEnterpriseConfig() (*EnterpriseConfig, error) {
 	configPath := "enterprise-config.yaml"
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("enterprise-config.yaml not found")
@@ -156,23 +393,29 @@ func LoadEnterpriseConfig() (*EnterpriseConfig, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error parsing enterprise-config.yaml: %w", err)
 	}
+	config.expandEnv()
 
-	globalConfig = &config
-	return globalConfig, nil
+	return &config, nil
 }
 
 // GetConfig returns the loaded enterprise configuration
 func GetConfig() *EnterpriseConfig {
-	if globalConfig == nil {
-		// Try to load config if not already loaded
-		config, err := LoadEnterpriseConfig()
-		if err != nil {
-			// Return default config if loading fails
-			return getDefaultConfig()
-		}
+	if config := globalConfig.Load(); config != nil {
 		return config
 	}
-	return globalConfig
+
+	// Try to load config if not already loaded
+	config, err := LoadEnterpriseConfig()
+	if err != nil {
+		// Return default config if loading fails
+		return getDefaultConfig()
+	}
+	return config
+}
+
+// DefaultConfig returns the built-in configuration, e.g. for scaffolding enterprise-config.yaml
+func DefaultConfig() *EnterpriseConfig {
+	return getDefaultConfig()
 }
 
 // getDefaultConfig returns a default configuration for fallback
@@ -180,11 +423,18 @@ func getDefaultConfig() *EnterpriseConfig {
 	return &EnterpriseConfig{
 		Application: ApplicationConfig{
 			Cache: CacheConfig{
-				TTL:                2 * time.Minute,
-				MaxSizeMB:          50,
-				MaxEntries:         1000,
-				CleanupInterval:    1 * time.Minute,
-				MetricsLogInterval: 10 * time.Minute,
+				TTL:                   2 * time.Minute,
+				MaxSizeMB:             50,
+				MaxEntries:            1000,
+				CleanupInterval:       1 * time.Minute,
+				MetricsLogInterval:    10 * time.Minute,
+				AdaptiveSizing:        false,
+				PressureWarningRatio:  DefaultPressureWarningRatio,
+				PressureCriticalRatio: DefaultPressureCriticalRatio,
+				WritePolicy:           "newest_wins",
+				StaleWhileRevalidate:  false,
+				StaleGrace:            10 * time.Minute,
+				EntryOverheadBytes:    0,
 			},
 			Monitoring: MonitoringConfig{
 				MemoryWarningMB:  500,
@@ -204,12 +454,17 @@ func getDefaultConfig() *EnterpriseConfig {
 					Interval:         60 * time.Second,
 					Timeout:          30 * time.Second,
 					FailureThreshold: 5,
+					FailureRatio:     0.6,
+					MinRequests:      10,
 				},
 			},
 			FileScanning: FileScanningConfig{
 				MaxDepth:              3,
 				BinaryDetectionBuffer: 512,
 				DefaultFileLimit:      10,
+				ScanWorkers:           0,
+				ExcludeTests:          false,
+				TestPatterns:          DefaultTestPatterns,
 				FilePriorities: map[string]int{
 					".go": 10, ".py": 9, ".ts": 8, ".tsx": 7, ".js": 6,
 					".jsx": 5, ".tex": 4, ".yaml": 3, ".yml": 2, ".json": 1, ".md": 0,
@@ -223,31 +478,54 @@ func getDefaultConfig() *EnterpriseConfig {
 				APIVersion: "2023-06-01",
 				TemperatureRange: TemperatureRange{Min: 0.0, Max: 1.0},
 				StopSequences:    []string{"\n\nHuman:"},
+				MaxResponseBytes: 5 * 1024 * 1024,
+				Transport:        DefaultTransportConfig,
 			},
 			OpenAI: ProviderConfig{
 				APIURL:           "https://api.openai.com/v1/chat/completions",
 				Timeout:          60 * time.Second,
 				TemperatureRange: TemperatureRange{Min: 0.0, Max: 2.0},
+				MaxResponseBytes: 5 * 1024 * 1024,
+				Transport:        DefaultTransportConfig,
 			},
 			OpenRouter: ProviderConfig{
 				APIURL:           "https://openrouter.ai/api/v1/chat/completions",
 				Timeout:          90 * time.Second,
 				TemperatureRange: TemperatureRange{Min: 0.0, Max: 2.0},
+				MaxResponseBytes: 5 * 1024 * 1024,
+				Transport:        DefaultTransportConfig,
+			},
+			Mock: MockConfig{
+				ErrorStatus: 503,
 			},
 		},
 		CostOpt: CostOptConfig{
 			TokenEstimationRatio: 0.25,
 			Compression: CompressionConfig{
-				MaxRatio: 0.3,
+				MaxRatio:        0.3,
+				MinTokenSavings: 0.05,
+				Rules:           DefaultCompressionRules,
 			},
 			ComplexityThresholds: ComplexityConfig{
 				Simple:  2000,
 				Complex: 10000,
 			},
+			MaxSpendPerMinute: 0,
+			SpendRateWindow:   5 * time.Minute,
+			MaxComponents:     DefaultMaxComponents,
 		},
 		Templates: TemplatesConfig{
 			FallbackEnabled: false,
 			Directory:       "templates",
+			Languages: map[string]string{
+				"en": "English",
+				"de": "German",
+			},
+			ChainOrder: DefaultChainOrder,
+			RequiredSections: map[string][]string{
+				"README": {"Overview", "Usage"},
+				"SETUP":  {"Installation"},
+			},
 		},
 	}
 }
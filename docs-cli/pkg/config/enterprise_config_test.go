@@ -0,0 +1,114 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolvePressureRatios(t *testing.T) {
+	tests := []struct {
+		warning, critical         float64
+		wantWarning, wantCritical float64
+		wantErr                   string
+	}{
+		{warning: 0, critical: 0, wantWarning: 0.5, wantCritical: 0.1},
+		{warning: 0.8, critical: 0, wantWarning: 0.8, wantCritical: 0.1},
+		{warning: 1, critical: 0.25, wantWarning: 1, wantCritical: 0.25},
+		{warning: 1.5, critical: 0.1, wantErr: "cache.pressure_warning_ratio"},
+		{warning: -0.5, critical: 0.1, wantErr: "cache.pressure_warning_ratio"},
+		{warning: 0.5, critical: 2, wantErr: "cache.pressure_critical_ratio"},
+		{warning: 0.5, critical: -1, wantErr: "cache.pressure_critical_ratio"},
+	}
+	for _, tt := range tests {
+		cache := CacheConfig{PressureWarningRatio: tt.warning, PressureCriticalRatio: tt.critical}
+		warning, critical, err := cache.ResolvePressureRatios()
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ResolvePressureRatios(%g, %g) error = %v, want %s", tt.warning, tt.critical, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || warning != tt.wantWarning || critical != tt.wantCritical {
+			t.Errorf("ResolvePressureRatios(%g, %g) = %g, %g, %v, want %g, %g", tt.warning, tt.critical, warning, critical, err, tt.wantWarning, tt.wantCritical)
+		}
+
+		config := getDefaultConfig()
+		config.Application.Cache = cache
+		if err := config.Validate(); err != nil {
+			t.Errorf("Validate with ratios %g, %g: %v", tt.warning, tt.critical, err)
+		}
+	}
+
+	config := getDefaultConfig()
+	config.Application.Cache.PressureCriticalRatio = 1.1
+	if err := config.Validate(); err == nil {
+		t.Error("Validate accepted pressure_critical_ratio 1.1")
+	}
+}
+
+func TestResolveChainOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   []string
+		want    []string
+		wantErr string
+	}{
+		{name: "unset uses the default", want: DefaultChainOrder},
+		{name: "custom order", order: []string{"README", "SETUP", "ARCHITECTURE", "CHECKLIST"}, want: []string{"README", "SETUP", "ARCHITECTURE", "CHECKLIST"}},
+		{name: "reversed", order: []string{"CHECKLIST", "SETUP", "README", "ARCHITECTURE"}, want: []string{"CHECKLIST", "SETUP", "README", "ARCHITECTURE"}},
+		{name: "unknown type", order: []string{"README", "SETUP", "ARCHITECTURE", "CHECKLIST", "API"}, wantErr: `unknown document type "API"`},
+		{name: "duplicate", order: []string{"README", "README", "SETUP", "ARCHITECTURE", "CHECKLIST"}, wantErr: "README listed more than once"},
+		{name: "missing type", order: []string{"README", "SETUP", "ARCHITECTURE"}, wantErr: "missing document type CHECKLIST"},
+		{name: "executive summary is not chained", order: []string{"EXECUTIVE_SUMMARY", "README", "SETUP", "ARCHITECTURE", "CHECKLIST"}, wantErr: `unknown document type "EXECUTIVE_SUMMARY"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := getDefaultConfig()
+			config.Templates.ChainOrder = tt.order
+			got, err := config.Templates.ResolveChainOrder()
+			validateErr := config.Validate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ResolveChainOrder(%v) error = %v, want %s", tt.order, err, tt.wantErr)
+				}
+				if validateErr == nil {
+					t.Errorf("Validate accepted chain_order %v", tt.order)
+				}
+				return
+			}
+			if err != nil || strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ResolveChainOrder(%v) = %v, %v, want %v", tt.order, got, err, tt.want)
+			}
+			if validateErr != nil {
+				t.Errorf("Validate with chain_order %v: %v", tt.order, validateErr)
+			}
+		})
+	}
+}
+
+func TestResolveMaxComponents(t *testing.T) {
+	tests := []struct {
+		configured  int
+		wantLimit   int
+		wantEnabled bool
+		wantErr     bool
+	}{
+		{configured: 0, wantLimit: DefaultMaxComponents, wantEnabled: true},
+		{configured: 25, wantLimit: 25, wantEnabled: true},
+		{configured: -1, wantEnabled: false},
+		{configured: -2, wantErr: true},
+	}
+	for _, tt := range tests {
+		costOpt := CostOptConfig{MaxComponents: tt.configured}
+		limit, enabled, err := costOpt.ResolveMaxComponents()
+		if (err != nil) != tt.wantErr || limit != tt.wantLimit || enabled != tt.wantEnabled {
+			t.Errorf("ResolveMaxComponents(%d) = %d, %t, %v, want %d, %t, error %t", tt.configured, limit, enabled, err, tt.wantLimit, tt.wantEnabled, tt.wantErr)
+		}
+
+		config := getDefaultConfig()
+		config.CostOpt = costOpt
+		if err := config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate with max_components %d = %v, want error %t", tt.configured, err, tt.wantErr)
+		}
+	}
+}
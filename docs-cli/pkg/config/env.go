@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"regexp"
+)
+
+// envReference matches ${VAR} and ${VAR:-default}. Bare $VAR is left alone so values
+// containing a literal dollar sign are not mangled.
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// ExpandEnv replaces ${VAR} references in value with the environment variable's value.
+// ${VAR:-default} uses default when VAR is unset or empty; an unset VAR without a
+// default expands to the empty string.
+func ExpandEnv(value string) string {
+	return envReference.ReplaceAllStringFunc(value, func(reference string) string {
+		match := envReference.FindStringSubmatch(reference)
+		if resolved := os.Getenv(match[1]); resolved != "" {
+			return resolved
+		}
+		return match[3]
+	})
+}
+
+// expandEnvMap expands every value of a string map in place
+func expandEnvMap(values map[string]string) {
+	for key, value := range values {
+		values[key] = ExpandEnv(value)
+	}
+}
+
+// expandEnv expands environment references in the config values meant to come from the
+// environment: provider endpoints, headers, and metadata. Other values stay literal.
+func (c *EnterpriseConfig) expandEnv() {
+	for _, provider := range []*ProviderConfig{&c.Providers.Anthropic, &c.Providers.OpenAI, &c.Providers.OpenRouter} {
+		provider.APIURL = ExpandEnv(provider.APIURL)
+		expandEnvMap(provider.Headers)
+		expandEnvMap(provider.Metadata)
+	}
+}
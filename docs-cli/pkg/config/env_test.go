@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+// unsetenv unsets key for the test, restoring it afterwards
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("DOCS_CLI_TEST_SET", "from-env")
+	t.Setenv("DOCS_CLI_TEST_EMPTY", "")
+	unsetenv(t, "DOCS_CLI_TEST_UNSET")
+
+	tests := []struct {
+		name, value, want string
+	}{
+		{"set", "${DOCS_CLI_TEST_SET}", "from-env"},
+		{"set ignores the default", "${DOCS_CLI_TEST_SET:-fallback}", "from-env"},
+		{"unset with default", "${DOCS_CLI_TEST_UNSET:-fallback}", "fallback"},
+		{"empty with default", "${DOCS_CLI_TEST_EMPTY:-fallback}", "fallback"},
+		{"unset without default", "${DOCS_CLI_TEST_UNSET}", ""},
+		{"unset with empty default", "${DOCS_CLI_TEST_UNSET:-}", ""},
+		{"default with punctuation", "${DOCS_CLI_TEST_UNSET:-https://api.example.com/v1}", "https://api.example.com/v1"},
+		{"embedded references", "https://${DOCS_CLI_TEST_SET}.example.com/${DOCS_CLI_TEST_UNSET:-v1}", "https://from-env.example.com/v1"},
+		{"bare dollar is literal", "pa$DOCS_CLI_TEST_SET", "pa$DOCS_CLI_TEST_SET"},
+		{"no references", "plain value", "plain value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandEnv(tt.value); got != tt.want {
+				t.Errorf("ExpandEnv(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnterpriseConfigExpandEnv(t *testing.T) {
+	t.Setenv("DOCS_CLI_TEST_REFERER", "https://docs.example.com")
+	unsetenv(t, "DOCS_CLI_TEST_UNSET")
+
+	config := getDefaultConfig()
+	config.Providers.OpenRouter.APIURL = "${DOCS_CLI_TEST_UNSET:-https://openrouter.ai/api/v1/chat/completions}"
+	config.Providers.OpenRouter.Headers = map[string]string{"http_referer": "${DOCS_CLI_TEST_REFERER}"}
+	config.Providers.OpenRouter.Metadata = map[string]string{"user_id": "${DOCS_CLI_TEST_UNSET}"}
+	config.Templates.Directory = "${DOCS_CLI_TEST_REFERER}"
+	config.expandEnv()
+
+	openRouter := config.Providers.OpenRouter
+	if openRouter.APIURL != "https://openrouter.ai/api/v1/chat/completions" {
+		t.Errorf("api_url = %q, want the default", openRouter.APIURL)
+	}
+	if openRouter.Headers["http_referer"] != "https://docs.example.com" {
+		t.Errorf("http_referer header = %q, want the environment value", openRouter.Headers["http_referer"])
+	}
+	if openRouter.Metadata["user_id"] != "" {
+		t.Errorf("user_id metadata = %q, want empty for an unset variable", openRouter.Metadata["user_id"])
+	}
+	// Only provider endpoints, headers, and metadata come from the environment
+	if config.Templates.Directory != "${DOCS_CLI_TEST_REFERER}" {
+		t.Errorf("templates.directory = %q, want it left literal", config.Templates.Directory)
+	}
+}
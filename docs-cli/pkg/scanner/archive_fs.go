@@ -0,0 +1,110 @@
+package scanner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// OpenArchiveFS opens a .zip, .tar.gz/.tgz, or .tar archive as a read-only filesystem.
+// The returned closer releases the archive and must be closed when scanning is done.
+func OpenArchiveFS(archivePath string) (iofs.FS, io.Closer, error) {
+	lowerPath := strings.ToLower(archivePath)
+
+	switch {
+	case strings.HasSuffix(lowerPath, ".zip"):
+		reader, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open zip archive: %w", err)
+		}
+		return reader, reader, nil
+
+	case strings.HasSuffix(lowerPath, ".tar.gz"), strings.HasSuffix(lowerPath, ".tgz"), strings.HasSuffix(lowerPath, ".tar"):
+		file, err := os.Open(archivePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open tar archive: %w", err)
+		}
+		defer file.Close()
+
+		var tarStream io.Reader = file
+		if !strings.HasSuffix(lowerPath, ".tar") {
+			gzipReader, err := gzip.NewReader(file)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read gzip stream: %w", err)
+			}
+			defer gzipReader.Close()
+			tarStream = gzipReader
+		}
+
+		fsys, err := tarToFS(tarStream)
+		if err != nil {
+			return nil, nil, err
+		}
+		return fsys, noopCloser{}, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported archive format: %s (expected .zip, .tar.gz, .tgz, or .tar)", archivePath)
+	}
+}
+
+// tarToFS loads a tar stream into memory. Tar has no random access, so entries are
+// re-packed into an uncompressed zip whose reader already implements fs.FS.
+func tarToFS(tarStream io.Reader) (iofs.FS, error) {
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+	tarReader := tar.NewReader(tarStream)
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar archive: %w", err)
+		}
+
+		// Only regular files are scanned; directories are implied by file paths
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if !iofs.ValidPath(name) {
+			continue
+		}
+
+		entryWriter, err := zipWriter.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Store,
+			Modified: header.ModTime,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to index tar entry %s: %w", name, err)
+		}
+		if _, err := io.Copy(entryWriter, tarReader); err != nil {
+			return nil, fmt.Errorf("failed to read tar entry %s: %w", name, err)
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to index tar archive: %w", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to index tar archive: %w", err)
+	}
+	return reader, nil
+}
+
+// noopCloser is returned for archives that are fully loaded into memory
+type noopCloser struct{}
+
+func (noopCloser) Close() error { return nil }
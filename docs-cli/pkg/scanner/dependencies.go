@@ -0,0 +1,128 @@
+package scanner
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	goImportLine  = regexp.MustCompile(`(?m)^\s*import\s+(?:[\w.]+\s+)?"([^"]+)"`)
+	goImportBlock = regexp.MustCompile(`(?s)import\s*\((.*?)\)`)
+	goBlockEntry  = regexp.MustCompile(`(?m)^\s*(?:[\w.]+\s+)?"([^"]+)"`)
+	jsImportFrom  = regexp.MustCompile(`(?m)(?:import|export)\s[^'"]*?from\s*['"]([^'"]+)['"]`)
+	jsImportBare  = regexp.MustCompile(`(?m)^\s*import\s*['"]([^'"]+)['"]`)
+	jsRequire     = regexp.MustCompile(`(?:require|import)\(\s*['"]([^'"]+)['"]\s*\)`)
+	pyImport      = regexp.MustCompile(`(?m)^\s*import\s+([\w.]+(?:\s*,\s*[\w.]+)*)`)
+	pyFromImport  = regexp.MustCompile(`(?m)^\s*from\s+(\.*[\w.]*)\s+import\s`)
+)
+
+// ParseImports extracts the import paths of a Go, JavaScript/TypeScript, or Python source file.
+// Python modules are returned with dots as slashes; relative imports keep their leading dots.
+func ParseImports(filePath string, content []byte) []string {
+	source := string(content)
+	var imports []string
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".go":
+		for _, match := range goImportLine.FindAllStringSubmatch(source, -1) {
+			imports = append(imports, match[1])
+		}
+		for _, block := range goImportBlock.FindAllStringSubmatch(source, -1) {
+			for _, match := range goBlockEntry.FindAllStringSubmatch(block[1], -1) {
+				imports = append(imports, match[1])
+			}
+		}
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+		for _, pattern := range []*regexp.Regexp{jsImportFrom, jsImportBare, jsRequire} {
+			for _, match := range pattern.FindAllStringSubmatch(source, -1) {
+				imports = append(imports, match[1])
+			}
+		}
+	case ".py":
+		for _, match := range pyImport.FindAllStringSubmatch(source, -1) {
+			for _, module := range strings.Split(match[1], ",") {
+				imports = append(imports, pythonModulePath(strings.TrimSpace(module)))
+			}
+		}
+		for _, match := range pyFromImport.FindAllStringSubmatch(source, -1) {
+			imports = append(imports, pythonModulePath(match[1]))
+		}
+	}
+
+	return imports
+}
+
+// pythonModulePath turns a dotted module into a slash path, keeping leading dots for relative imports
+func pythonModulePath(module string) string {
+	trimmed := strings.TrimLeft(module, ".")
+	return module[:len(module)-len(trimmed)] + strings.ReplaceAll(trimmed, ".", "/")
+}
+
+// BuildDependencyGraph maps each component key to the keys of the components its source files
+// import, sorted. readFile reads a component file; unreadable files are skipped.
+func BuildDependencyGraph(components []Component, readFile func(string) ([]byte, error)) map[string][]string {
+	graph := make(map[string][]string, len(components))
+	for _, component := range components {
+		dependencies := make(map[string]bool)
+		for _, file := range component.Files {
+			content, err := readFile(file)
+			if err != nil {
+				continue
+			}
+			for _, imported := range ParseImports(file, content) {
+				if target, ok := resolveImport(components, component, file, imported); ok {
+					dependencies[target] = true
+				}
+			}
+		}
+
+		keys := make([]string, 0, len(dependencies))
+		for key := range dependencies {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		graph[component.Key()] = keys
+	}
+	return graph
+}
+
+// resolveImport finds the component, other than from, that an import in file refers to.
+// Relative imports are resolved against the file; others match a component path segment-wise.
+func resolveImport(components []Component, from Component, file, imported string) (string, bool) {
+	relative := strings.HasPrefix(imported, ".")
+	var resolved string
+	if relative {
+		dots := len(imported) - len(strings.TrimLeft(imported, "."))
+		if !strings.HasPrefix(imported, "./") && !strings.HasPrefix(imported, "../") && dots > 0 {
+			// Python relative import: one dot is the file's package, each further dot a parent
+			imported = strings.Repeat("../", dots-1) + "./" + strings.TrimLeft(imported, ".")
+		}
+		resolved = filepath.Join(filepath.Dir(file), filepath.FromSlash(imported))
+	}
+
+	for _, component := range components {
+		if component.Key() == from.Key() {
+			continue
+		}
+		if relative {
+			rel, err := filepath.Rel(component.Dir(), resolved)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return component.Key(), true
+			}
+			continue
+		}
+
+		componentPath := path.Clean(filepath.ToSlash(component.Path))
+		if componentPath == "." || componentPath == "" {
+			continue
+		}
+		if imported == componentPath || strings.HasPrefix(imported, componentPath+"/") ||
+			strings.HasSuffix(imported, "/"+componentPath) || strings.Contains(imported, "/"+componentPath+"/") {
+			return component.Key(), true
+		}
+	}
+	return "", false
+}
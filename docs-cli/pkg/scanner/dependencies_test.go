@@ -0,0 +1,70 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseImports(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+		want    []string
+	}{
+		{"main.go", "package main\n\nimport \"fmt\"\nimport core \"example.com/app/core\"\n",
+			[]string{"fmt", "example.com/app/core"}},
+		{"main.go", "package main\n\nimport (\n\t\"os\"\n\tstore \"example.com/app/store\"\n)\n",
+			[]string{"os", "example.com/app/store"}},
+		{"index.ts", "import { a } from './util'\nimport '../styles.css'\nconst b = require(\"lodash\")\nexport * from '../core/api'\n",
+			[]string{"./util", "../core/api", "../styles.css", "lodash"}},
+		{"app.py", "import os, core.models\nfrom . import helpers\nfrom ..shared.db import session\n",
+			[]string{"os", "core/models", ".", "..shared/db"}},
+		{"notes.txt", "import \"fmt\"", nil},
+	}
+	for _, tt := range tests {
+		got := ParseImports(tt.file, []byte(tt.content))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseImports(%s) = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestBuildDependencyGraph(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	file := func(parts ...string) string { return filepath.Join(append([]string{root}, parts...)...) }
+	components := []Component{
+		{Name: "api", Path: "services/api", Root: root, Files: []string{file("services", "api", "main.go")}},
+		{Name: "core", Path: "core", Root: root, Files: []string{file("core", "core.go")}},
+		{Name: "web", Path: "web", Root: root, Files: []string{file("web", "src", "index.ts")}},
+		{Name: "worker", Path: "worker", Root: root, Files: []string{file("worker", "jobs", "run.py"), file("worker", "missing.py")}},
+		{Name: "shared", Path: "shared", Root: root, Files: []string{file("shared", "db.py")}},
+	}
+	sources := map[string]string{
+		file("services", "api", "main.go"): "package main\n\nimport (\n\t\"fmt\"\n\t\"example.com/repo/core\"\n)\n",
+		file("core", "core.go"):            "package core\n\nimport \"strings\"\n",
+		file("web", "src", "index.ts"):     "import { client } from '../../services/api/client'\n",
+		file("worker", "jobs", "run.py"):   "from ...shared.db import session\nimport core.models\n",
+		file("shared", "db.py"):            "import sqlite3\n",
+	}
+	readFile := func(path string) ([]byte, error) {
+		content, ok := sources[path]
+		if !ok {
+			return nil, fmt.Errorf("no such file: %s", path)
+		}
+		return []byte(content), nil
+	}
+
+	got := BuildDependencyGraph(components, readFile)
+	want := map[string][]string{
+		"api":    {"core"},
+		"core":   {},
+		"web":    {"api"},
+		"worker": {"core", "shared"},
+		"shared": {},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildDependencyGraph = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+package scanner
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxDescriptionLength caps a derived description so it stays a one-line summary
+const maxDescriptionLength = 160
+
+var (
+	goBlockComment     = regexp.MustCompile(`(?s)^/\*(.*?)\*/`)
+	jsDocComment       = regexp.MustCompile(`(?s)^/\*\*?(.*?)\*/`)
+	pythonDocstring    = regexp.MustCompile(`(?s)^(?:[rRuU]?)("""|''')(.*?)("""|''')`)
+	sentenceEnd        = regexp.MustCompile(`[.!?](\s|$)`)
+	leadingBoilerplate = regexp.MustCompile(`(?m)^(#!.*|\s*['"]use (strict|client|server)['"];?\s*)$`)
+)
+
+// ExtractDescription derives a one-line summary from a source file's leading documentation:
+// a Go package comment, a JS/TS header comment, or a Python module docstring. It returns ""
+// when the file has none.
+func ExtractDescription(filePath string, content []byte) string {
+	source := string(content)
+
+	var comment string
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".go":
+		comment = goPackageComment(source)
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+		comment = leadingComment(strings.TrimSpace(leadingBoilerplate.ReplaceAllString(source, "")), jsDocComment)
+	case ".py":
+		trimmed := strings.TrimSpace(leadingBoilerplate.ReplaceAllString(source, ""))
+		if match := pythonDocstring.FindStringSubmatch(trimmed); match != nil {
+			comment = match[2]
+		}
+	}
+
+	return summarize(comment)
+}
+
+// goPackageComment returns the comment directly above the package clause
+func goPackageComment(source string) string {
+	lines := strings.Split(source, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), "package ") {
+			continue
+		}
+
+		// Walk back over the contiguous comment block ending on the line above
+		var comment []string
+		for j := i - 1; j >= 0; j-- {
+			trimmed := strings.TrimSpace(lines[j])
+			if !strings.HasPrefix(trimmed, "//") {
+				break
+			}
+			comment = append([]string{strings.TrimPrefix(trimmed, "//")}, comment...)
+		}
+		if len(comment) > 0 {
+			return strings.Join(comment, "\n")
+		}
+
+		// A /* */ package comment must end right above the clause
+		before := strings.TrimSpace(strings.Join(lines[:i], "\n"))
+		if strings.HasSuffix(before, "*/") {
+			if start := strings.LastIndex(before, "/*"); start >= 0 {
+				if match := goBlockComment.FindStringSubmatch(before[start:]); match != nil {
+					return match[1]
+				}
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+// leadingComment returns a block comment or run of // lines at the very start of source
+func leadingComment(source string, block *regexp.Regexp) string {
+	if match := block.FindStringSubmatch(source); match != nil {
+		var lines []string
+		for _, line := range strings.Split(match[1], "\n") {
+			lines = append(lines, strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	var lines []string
+	for _, line := range strings.Split(source, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "//") {
+			break
+		}
+		lines = append(lines, strings.TrimPrefix(trimmed, "//"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// summarize reduces a comment to its first paragraph's first sentence, skipping tag lines
+// such as @file or eslint directives
+func summarize(comment string) string {
+	var words []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if len(words) > 0 {
+				break
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "@") || strings.HasPrefix(line, "eslint") || strings.HasPrefix(line, "go:") {
+			continue
+		}
+		words = append(words, strings.Fields(line)...)
+	}
+
+	summary := strings.Join(words, " ")
+	if loc := sentenceEnd.FindStringIndex(summary); loc != nil {
+		summary = summary[:loc[0]+1]
+	}
+	if len(summary) > maxDescriptionLength {
+		summary = strings.TrimSpace(summary[:maxDescriptionLength-3]) + "..."
+	}
+	return summary
+}
@@ -0,0 +1,85 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractDescription(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{
+			name:    "go package comment",
+			file:    "server.go",
+			content: "// Copyright 2026 Example\n\n// Package api serves the public HTTP API. It also\n// validates requests.\npackage api\n",
+			want:    "Package api serves the public HTTP API.",
+		},
+		{
+			name:    "go block package comment",
+			file:    "doc.go",
+			content: "/*\nPackage store persists jobs in PostgreSQL\n*/\npackage store\n",
+			want:    "Package store persists jobs in PostgreSQL",
+		},
+		{
+			name:    "go comment separated from package clause",
+			file:    "main.go",
+			content: "// Unrelated note\n\npackage main\n",
+			want:    "",
+		},
+		{
+			name:    "go build directive skipped",
+			file:    "tool.go",
+			content: "//go:build linux\n// Package tool wraps the CLI\npackage tool\n",
+			want:    "Package tool wraps the CLI",
+		},
+		{
+			name:    "js header block",
+			file:    "index.js",
+			content: "#!/usr/bin/env node\n'use strict';\n/**\n * @file Entry point\n * Renders the job board and its filters. More details follow.\n */\nimport React from 'react'\n",
+			want:    "Renders the job board and its filters.",
+		},
+		{
+			name:    "ts line comments",
+			file:    "client.ts",
+			content: "// eslint-disable-next-line\n// Typed client for the gateway API\nexport const client = {}\n",
+			want:    "Typed client for the gateway API",
+		},
+		{
+			name:    "js without header",
+			file:    "util.js",
+			content: "export const x = 1 // not a header\n",
+			want:    "",
+		},
+		{
+			name:    "python docstring",
+			file:    "worker.py",
+			content: "#!/usr/bin/env python3\n\"\"\"Background worker that scores job matches.\n\nLonger text.\"\"\"\n",
+			want:    "Background worker that scores job matches.",
+		},
+		{
+			name:    "unsupported extension",
+			file:    "notes.md",
+			content: "// Package nothing\npackage nothing\n",
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractDescription(tt.file, []byte(tt.content)); got != tt.want {
+				t.Errorf("ExtractDescription = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractDescriptionTruncatesLongSummaries(t *testing.T) {
+	long := "// Package big " + strings.Repeat("word ", 60) + "\npackage big\n"
+	got := ExtractDescription("big.go", []byte(long))
+	if len(got) > maxDescriptionLength || !strings.HasSuffix(got, "...") {
+		t.Errorf("long description = %q (%d bytes), want at most %d bytes ending in ...", got, len(got), maxDescriptionLength)
+	}
+}
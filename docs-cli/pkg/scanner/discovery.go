@@ -0,0 +1,128 @@
+package scanner
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// containerDirs hold one component per child directory rather than being components themselves
+var containerDirs = map[string]bool{"src": true, "services": true, "packages": true, "apps": true, "libs": true}
+
+// skippedDirs are never components
+var skippedDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "docs": true, "dist": true, "build": true,
+	"__pycache__": true, "test": true, "tests": true, "docker": true, "public": true,
+}
+
+// componentMarkers are manifest files that make a directory a component
+var componentMarkers = []string{"go.mod", "package.json", "pyproject.toml", "setup.py", "Cargo.toml", "__init__.py"}
+
+// sourceExtensions are the file types that make a directory without a manifest a component
+var sourceExtensions = map[string]bool{".go": true, ".py": true, ".ts": true, ".tsx": true, ".js": true, ".jsx": true}
+
+// discoveryDepth is how deep a directory is searched for source files
+const discoveryDepth = 2
+
+// DiscoverComponents proposes component definitions for projectRoot: top-level directories,
+// and the children of container directories such as src/, that hold a manifest or source files.
+// Names are the directory names, prefixed with the container when they collide.
+func DiscoverComponents(projectRoot string) ([]ComponentDef, error) {
+	var candidates []string
+
+	entries, err := os.ReadDir(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || skipDiscovery(entry.Name()) {
+			continue
+		}
+		if !containerDirs[entry.Name()] {
+			candidates = append(candidates, entry.Name())
+			continue
+		}
+
+		children, err := os.ReadDir(filepath.Join(projectRoot, entry.Name()))
+		if err != nil {
+			continue
+		}
+		for _, child := range children {
+			if child.IsDir() && !skipDiscovery(child.Name()) {
+				candidates = append(candidates, path.Join(entry.Name(), child.Name()))
+			}
+		}
+	}
+
+	var components []ComponentDef
+	nameCounts := make(map[string]int)
+	for _, candidate := range candidates {
+		dir := filepath.Join(projectRoot, filepath.FromSlash(candidate))
+		if !hasMarker(dir) && !hasSourceFiles(dir, 0) {
+			continue
+		}
+		components = append(components, ComponentDef{
+			Name: path.Base(candidate),
+			Path: candidate,
+			Type: componentType(dir),
+		})
+		nameCounts[path.Base(candidate)]++
+	}
+
+	for i, component := range components {
+		if nameCounts[component.Name] > 1 {
+			components[i].Name = strings.ReplaceAll(component.Path, "/", "-")
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool { return components[i].Path < components[j].Path })
+	return components, nil
+}
+
+// skipDiscovery reports whether a directory name is never a component
+func skipDiscovery(name string) bool {
+	return strings.HasPrefix(name, ".") || skippedDirs[name]
+}
+
+// hasMarker reports whether dir directly contains a component manifest
+func hasMarker(dir string) bool {
+	for _, marker := range componentMarkers {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSourceFiles reports whether dir contains a source file within discoveryDepth levels
+func hasSourceFiles(dir string, depth int) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && sourceExtensions[filepath.Ext(entry.Name())] {
+			return true
+		}
+	}
+	if depth >= discoveryDepth {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !skipDiscovery(entry.Name()) && hasSourceFiles(filepath.Join(dir, entry.Name()), depth+1) {
+			return true
+		}
+	}
+	return false
+}
+
+// componentType guesses "frontend" for React-based packages and "service" otherwise
+func componentType(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err == nil && strings.Contains(string(data), `"react"`) {
+		return "frontend"
+	}
+	return "service"
+}
@@ -2,11 +2,16 @@ package scanner
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	iofs "io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 
 	gitignore "github.com/sabhiram/go-gitignore"
 	"gopkg.in/yaml.v3"
@@ -22,6 +27,24 @@ type Component struct {
 	Description  string   `json:"description"`
 	ExistingDocs []string `json:"existing_docs"`
 	Files        []string `json:"files"`
+	Workspace    string   `json:"workspace,omitempty"`
+	Root         string   `json:"root"`
+	// PromptOverrides maps a document type to extra instructions appended to its prompt
+	PromptOverrides map[string]string `json:"prompt_overrides,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
+}
+
+// Key uniquely identifies a component across workspaces
+func (c Component) Key() string {
+	if c.Workspace == "" {
+		return c.Name
+	}
+	return c.Workspace + "/" + c.Name
+}
+
+// Dir returns the component directory, resolved against its workspace root
+func (c Component) Dir() string {
+	return filepath.Join(c.Root, c.Path)
 }
 
 // ComponentDef represents a component definition from configuration
@@ -30,24 +53,48 @@ type ComponentDef struct {
 	Path        string `yaml:"path"`
 	Type        string `yaml:"type"`
 	Description string `yaml:"description"`
+	Workspace   string `yaml:"workspace,omitempty"`
+	// PromptOverrides maps a document type to extra instructions for this component only
+	PromptOverrides map[string]string `yaml:"prompt_overrides,omitempty"`
+	// Tags group components across teams or concerns for --tags filtering
+	Tags []string `yaml:"tags,omitempty"`
+}
+
+// WorkspaceDef declares a named root that component paths can be resolved against
+type WorkspaceDef struct {
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
 }
 
 // ComponentConfig represents the component configuration structure
 type ComponentConfig struct {
+	Workspaces []WorkspaceDef `yaml:"workspaces,omitempty"`
 	Components []ComponentDef `yaml:"components"`
 }
 
 // FileScanner interface defines the contract for file scanning operations
 type FileScanner interface {
 	ScanComponents(projectRoot string) ([]Component, error)
+	RescanComponent(component Component) (Component, error)
 	FindSourceFiles(rootPath string, deepScan bool) ([]string, error)
 	LoadComponentConfig() (*ComponentConfig, error)
+	LimitFiles(files []string, fullScan bool) []string
+	SetWorkspaceRoots(roots map[string]string)
+	SetExcludeTests(exclude bool)
 }
 
 // DefaultFileScanner implements FileScanner with configurable behavior
 type DefaultFileScanner struct {
 	config       config.ConfigManager
 	useGitignore bool
+	// sourceFS, when set, replaces the local filesystem as the source of files.
+	// sourceRoot is the path that the root of sourceFS corresponds to.
+	sourceFS     iofs.FS
+	sourceRoot   string
+	// workspaceRoots overrides or adds workspace paths from components.yaml
+	workspaceRoots map[string]string
+	// excludeTests drops test files from source context, e.g. from --no-tests
+	excludeTests bool
 }
 
 // NewFileScanner creates a new file scanner with configuration
@@ -58,6 +105,69 @@ func NewFileScanner(configManager config.ConfigManager, useGitignore bool) FileS
 	}
 }
 
+// NewFileScannerWithFS creates a file scanner that reads source files from fsys,
+// treating sourceRoot as the path where the root of fsys is mounted. Tests can
+// inject an fstest.MapFS; archives are opened with OpenArchiveFS.
+func NewFileScannerWithFS(configManager config.ConfigManager, useGitignore bool, fsys iofs.FS, sourceRoot string) FileScanner {
+	return &DefaultFileScanner{
+		config:       configManager,
+		useGitignore: useGitignore,
+		sourceFS:     fsys,
+		sourceRoot:   filepath.Clean(sourceRoot),
+	}
+}
+
+// SetWorkspaceRoots overrides or adds workspace roots by name, e.g. from --root flags
+func (fs *DefaultFileScanner) SetWorkspaceRoots(roots map[string]string) {
+	fs.workspaceRoots = roots
+}
+
+// SetExcludeTests drops files matching file_scanning.test_patterns from scans, in addition
+// to file_scanning.exclude_tests
+func (fs *DefaultFileScanner) SetExcludeTests(exclude bool) {
+	fs.excludeTests = exclude
+}
+
+// resolveWorkspaces maps workspace names to absolute roots. Relative workspace
+// paths are resolved against projectRoot, which is also the unnamed default.
+func (fs *DefaultFileScanner) resolveWorkspaces(projectRoot string, workspaces []WorkspaceDef) map[string]string {
+	roots := map[string]string{"": filepath.Clean(projectRoot)}
+	for _, workspace := range workspaces {
+		roots[workspace.Name] = workspace.Path
+	}
+	for name, root := range fs.workspaceRoots {
+		roots[name] = root
+	}
+
+	for name, root := range roots {
+		if !filepath.IsAbs(root) {
+			roots[name] = filepath.Join(projectRoot, root)
+		}
+	}
+	return roots
+}
+
+// sourceFor returns the filesystem to scan and the path its root corresponds to.
+// Without an injected filesystem this is os.DirFS(defaultRoot).
+func (fs *DefaultFileScanner) sourceFor(defaultRoot string) (iofs.FS, string) {
+	if fs.sourceFS != nil {
+		return fs.sourceFS, fs.sourceRoot
+	}
+	return os.DirFS(defaultRoot), filepath.Clean(defaultRoot)
+}
+
+// fsName converts a path under root into the slash-separated name used by io/fs
+func fsName(root, path string) (string, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside source root %s", path, root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
 // ScanComponents scans all components defined in the configuration
 func (fs *DefaultFileScanner) ScanComponents(projectRoot string) ([]Component, error) {
 	// Load component configuration
@@ -67,21 +177,38 @@ func (fs *DefaultFileScanner) ScanComponents(projectRoot string) ([]Component, e
 	}
 
 	var components []Component
+	workspaceRoots := fs.resolveWorkspaces(projectRoot, componentConfig.Workspaces)
 
 	for _, compDef := range componentConfig.Components {
-		fullPath := filepath.Join(projectRoot, compDef.Path)
+		root, exists := workspaceRoots[compDef.Workspace]
+		if !exists {
+			return nil, fmt.Errorf("component %s: unknown workspace %q; declare it under workspaces in components.yaml or pass --root %s=<path>", compDef.Name, compDef.Workspace, compDef.Workspace)
+		}
+
+		fullPath := filepath.Join(root, compDef.Path)
+		fsys, sourceRoot := fs.sourceFor(root)
+
+		// A path escaping its root is a configuration mistake, not a missing directory.
+		// Archive sources also need every workspace inside the archive.
+		name, err := fsName(sourceRoot, fullPath)
+		if err != nil {
+			if compDef.Workspace != "" {
+				return nil, fmt.Errorf("component %s in workspace %s: %w", compDef.Name, compDef.Workspace, err)
+			}
+			return nil, fmt.Errorf("component %s: %w", compDef.Name, err)
+		}
 
 		// Check if component path exists
-		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+		if _, err := iofs.Stat(fsys, name); err != nil {
 			// Log warning but continue - don't fail entire scan
 			continue
 		}
 
 		// Find existing docs
-		existingDocs := fs.findExistingDocs(fullPath)
+		existingDocs := fs.findExistingDocs(fsys, name)
 
 		// Find all source files
-		files, err := fs.FindSourceFiles(fullPath, false)
+		files, err := fs.walkSourceFiles(fsys, sourceRoot, name, false)
 		if err != nil {
 			// Log warning but continue
 			continue
@@ -94,27 +221,49 @@ func (fs *DefaultFileScanner) ScanComponents(projectRoot string) ([]Component, e
 			Description:  compDef.Description,
 			ExistingDocs: existingDocs,
 			Files:        files,
+			Workspace:    compDef.Workspace,
+			Root:         root,
+			PromptOverrides: compDef.PromptOverrides,
+			Tags:            compDef.Tags,
 		})
 	}
 
 	return components, nil
 }
 
+// RescanComponent refreshes the existing docs and source files of one scanned component,
+// without reloading the component configuration or walking the other components
+func (fs *DefaultFileScanner) RescanComponent(component Component) (Component, error) {
+	fsys, sourceRoot := fs.sourceFor(component.Root)
+	name, err := fsName(sourceRoot, component.Dir())
+	if err != nil {
+		return component, err
+	}
+
+	files, err := fs.walkSourceFiles(fsys, sourceRoot, name, false)
+	if err != nil {
+		return component, err
+	}
+	component.ExistingDocs = fs.findExistingDocs(fsys, name)
+	component.Files = files
+	return component, nil
+}
+
 // findExistingDocs scans for existing documentation files
-func (fs *DefaultFileScanner) findExistingDocs(componentPath string) []string {
+func (fs *DefaultFileScanner) findExistingDocs(fsys iofs.FS, componentName string) []string {
 	var existingDocs []string
 
 	// Check for README in root
-	readmePath := filepath.Join(componentPath, "README.md")
-	if _, err := os.Stat(readmePath); err == nil {
+	readmePath := path.Join(componentName, "README.md")
+	if _, err := iofs.Stat(fsys, readmePath); err == nil {
 		existingDocs = append(existingDocs, "README.md")
 	}
 
 	// Check for other docs in docs/ subdirectory
-	docsDir := filepath.Join(componentPath, "docs")
+	docsDir := path.Join(componentName, "docs")
 	for _, docPattern := range []string{"SETUP.md", "ARCHITECTURE.md", "CHECKLIST.yaml"} {
-		docPath := filepath.Join(docsDir, docPattern)
-		if _, err := os.Stat(docPath); err == nil {
+		docPath := path.Join(docsDir, docPattern)
+		if _, err := iofs.Stat(fsys, docPath); err == nil {
 			existingDocs = append(existingDocs, "docs/"+docPattern)
 		}
 	}
@@ -124,7 +273,18 @@ func (fs *DefaultFileScanner) findExistingDocs(componentPath string) []string {
 
 // FindSourceFiles scans for source files with configurable depth and filtering
 func (fs *DefaultFileScanner) FindSourceFiles(rootPath string, deepScan bool) ([]string, error) {
-	var files []string
+	fsys, sourceRoot := fs.sourceFor(rootPath)
+	base, err := fsName(sourceRoot, rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return fs.walkSourceFiles(fsys, sourceRoot, base, deepScan)
+}
+
+// walkSourceFiles walks base within fsys and returns matching files as paths under sourceRoot
+func (fs *DefaultFileScanner) walkSourceFiles(fsys iofs.FS, sourceRoot, base string, deepScan bool) ([]string, error) {
+	var candidates []string
 	fileScanConfig := fs.config.GetFileScanningConfig()
 	
 	maxDepth := fileScanConfig.MaxDepth
@@ -132,55 +292,111 @@ func (fs *DefaultFileScanner) FindSourceFiles(rootPath string, deepScan bool) ([
 		maxDepth = -1 // unlimited
 	}
 
-	base := filepath.Clean(rootPath)
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+	// Test files would otherwise crowd the implementation out of the file limit
+	excludeTests := fs.excludeTests || fileScanConfig.ExcludeTests
+	testPatterns := fileScanConfig.TestPatterns
+	if len(testPatterns) == 0 {
+		testPatterns = config.DefaultTestPatterns
+	}
+
+	err := iofs.WalkDir(fsys, base, func(name string, entry iofs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
 		// Calculate depth
-		rel, err := filepath.Rel(base, path)
-		if err != nil {
-			return err
-		}
 		depth := 0
-		if rel != "." {
-			depth = len(strings.Split(rel, string(filepath.Separator)))
+		rel := "."
+		if name != base {
+			rel = strings.TrimPrefix(name, base+"/")
+			if base == "." {
+				rel = name
+			}
+			depth = len(strings.Split(rel, "/"))
 		}
 
 		// Apply depth limit
 		if maxDepth >= 0 && depth > maxDepth {
-			if info.IsDir() {
-				return filepath.SkipDir
+			if entry.IsDir() {
+				return iofs.SkipDir
 			}
 			return nil
 		}
 
 		// Skip directories
-		if info.IsDir() {
-			return nil
-		}
-
-		// Skip binary files
-		if fs.isBinaryFile(path) {
+		if entry.IsDir() {
+			if excludeTests && name != base && isTestDir(name, testPatterns) {
+				return iofs.SkipDir
+			}
 			return nil
 		}
 
-		// Apply gitignore filtering
-		if fs.useGitignore && fs.isGitIgnored(path) {
+		// Matched below base, so a component rooted at a test-named directory is still scanned
+		if excludeTests && isTestFile(rel, testPatterns) {
 			return nil
 		}
 
-		files = append(files, path)
+		candidates = append(candidates, name)
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Binary and gitignore checks open files, so run them in parallel
+	keep := fs.filterCandidates(fsys, candidates, fileScanConfig.ScanWorkers)
 
-	return files, err
+	var files []string
+	for i, name := range candidates {
+		if keep[i] {
+			files = append(files, filepath.Join(sourceRoot, filepath.FromSlash(name)))
+		}
+	}
+
+	// Sort for deterministic output regardless of check completion order
+	sort.Strings(files)
+	return files, nil
+}
+
+// filterCandidates reports which files to keep, skipping binary and gitignored files.
+// Checks run across a bounded pool of workers; workers <= 0 uses the number of CPUs.
+func (fs *DefaultFileScanner) filterCandidates(fsys iofs.FS, candidates []string, workers int) []bool {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(candidates) {
+		workers = len(candidates)
+	}
+
+	keep := make([]bool, len(candidates))
+	ignorers := &ignorerCache{fsys: fsys, byDir: make(map[string]*gitignore.GitIgnore)}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				name := candidates[i]
+				// Each index is written by exactly one worker, so no locking is needed
+				keep[i] = !fs.isBinaryFile(fsys, name) && !(fs.useGitignore && ignorers.ignored(name))
+			}
+		}()
+	}
+
+	for i := range candidates {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return keep
 }
 
 // isBinaryFile checks if a file is binary using configurable buffer size
-func (fs *DefaultFileScanner) isBinaryFile(path string) bool {
-	file, err := os.Open(path)
+func (fs *DefaultFileScanner) isBinaryFile(fsys iofs.FS, name string) bool {
+	file, err := fsys.Open(name)
 	if err != nil {
 		return true
 	}
@@ -195,25 +411,31 @@ func (fs *DefaultFileScanner) isBinaryFile(path string) bool {
 	return false
 }
 
-// isGitIgnored checks if a file should be ignored based on .gitignore
-func (fs *DefaultFileScanner) isGitIgnored(path string) bool {
-	dir := filepath.Dir(path)
-	gitignorePath := filepath.Join(dir, ".gitignore")
+// ignorerCache compiles each directory's .gitignore once per scan, rather than once per file
+type ignorerCache struct {
+	fsys  iofs.FS
+	mu    sync.Mutex
+	byDir map[string]*gitignore.GitIgnore // nil for directories without a .gitignore
+}
 
-	if _, err := os.Stat(gitignorePath); err != nil {
-		return false
-	}
+// ignored checks if a file should be ignored based on its directory's .gitignore
+func (c *ignorerCache) ignored(name string) bool {
+	dir := path.Dir(name)
 
-	ignorer, err := gitignore.CompileIgnoreFile(gitignorePath)
-	if err != nil {
-		return false
+	c.mu.Lock()
+	ignorer, cached := c.byDir[dir]
+	c.mu.Unlock()
+	if !cached {
+		if gitignoreData, err := iofs.ReadFile(c.fsys, path.Join(dir, ".gitignore")); err == nil {
+			ignorer = gitignore.CompileIgnoreLines(strings.Split(string(gitignoreData), "\n")...)
+		}
+		// Two workers may compile the same file; either result is the same
+		c.mu.Lock()
+		c.byDir[dir] = ignorer
+		c.mu.Unlock()
 	}
 
-	relPath, err := filepath.Rel(dir, path)
-	if err != nil {
-		return false
-	}
-	return ignorer.MatchesPath(relPath)
+	return ignorer != nil && ignorer.MatchesPath(path.Base(name))
 }
 
 // LoadComponentConfig loads component configuration from file
@@ -233,6 +455,11 @@ func (fs *DefaultFileScanner) LoadComponentConfig() (*ComponentConfig, error) {
 	if err != nil {
 		return nil, err
 	}
+	for _, def := range config.Components {
+		if err := validateTags(def); err != nil {
+			return nil, err
+		}
+	}
 	
 	return &config, nil
 }
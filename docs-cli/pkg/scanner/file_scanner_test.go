@@ -0,0 +1,419 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"docs-cli/pkg/config"
+)
+
+// scanningConfig serves fixed file scanning settings
+type scanningConfig struct {
+	config.ConfigManager
+	scanning config.FileScanningConfig
+}
+
+func (c scanningConfig) GetFileScanningConfig() config.FileScanningConfig {
+	return c.scanning
+}
+
+func newTestScanner(fsys fstest.MapFS, maxDepth int) *DefaultFileScanner {
+	configManager := scanningConfig{scanning: config.FileScanningConfig{
+		MaxDepth:              maxDepth,
+		BinaryDetectionBuffer: 512,
+		ScanWorkers:           2,
+	}}
+	return NewFileScannerWithFS(configManager, false, fsys, sourceRootForTest).(*DefaultFileScanner)
+}
+
+var sourceRootForTest = filepath.FromSlash("/src")
+
+func sourcePaths(names ...string) []string {
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(sourceRootForTest, filepath.FromSlash(name))
+	}
+	return paths
+}
+
+func TestFindSourceFilesDepthLimit(t *testing.T) {
+	fsys := fstest.MapFS{
+		"svc/main.go":                 {Data: []byte("package main\n")},
+		"svc/api/handler.go":          {Data: []byte("package api\n")},
+		"svc/api/v1/routes.go":        {Data: []byte("package v1\n")},
+		"svc/api/v1/internal/deep.go": {Data: []byte("package internal\n")},
+	}
+	root := filepath.Join(sourceRootForTest, "svc")
+
+	tests := []struct {
+		maxDepth int
+		deepScan bool
+		want     []string
+	}{
+		{1, false, sourcePaths("svc/main.go")},
+		{2, false, sourcePaths("svc/api/handler.go", "svc/main.go")},
+		{3, false, sourcePaths("svc/api/handler.go", "svc/api/v1/routes.go", "svc/main.go")},
+		{1, true, sourcePaths("svc/api/handler.go", "svc/api/v1/internal/deep.go", "svc/api/v1/routes.go", "svc/main.go")},
+	}
+	for _, tt := range tests {
+		got, err := newTestScanner(fsys, tt.maxDepth).FindSourceFiles(root, tt.deepScan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("max_depth %d, deep %v: got %v, want %v", tt.maxDepth, tt.deepScan, got, tt.want)
+		}
+	}
+}
+
+func TestFindSourceFilesSkipsBinaries(t *testing.T) {
+	fsys := fstest.MapFS{
+		"svc/main.go":   {Data: []byte("package main\n")},
+		"svc/logo.png":  {Data: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")},
+		"svc/empty.txt": {Data: nil},
+		// A NUL byte past the detection buffer is not seen
+		"svc/late.txt": {Data: append([]byte(strings.Repeat("a", 600)), 0)},
+	}
+	got, err := newTestScanner(fsys, 5).FindSourceFiles(filepath.Join(sourceRootForTest, "svc"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sourcePaths("svc/empty.txt", "svc/late.txt", "svc/main.go")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFindSourceFilesExcludesTests(t *testing.T) {
+	fsys := fstest.MapFS{
+		"svc/main.go":                    {Data: []byte("package main\n")},
+		"svc/main_test.go":               {Data: []byte("package main\n")},
+		"svc/web/app.ts":                 {Data: []byte("export {}\n")},
+		"svc/web/app.test.ts":            {Data: []byte("export {}\n")},
+		"svc/web/__tests__/render.ts":    {Data: []byte("export {}\n")},
+		"svc/jobs/run.py":                {Data: []byte("print()\n")},
+		"svc/jobs/test_run.py":           {Data: []byte("print()\n")},
+		"svc/jobs/fixtures/sample_it.py": {Data: []byte("print()\n")},
+		"svc/jobs/testing_helpers.py":    {Data: []byte("print()\n")},
+	}
+	root := filepath.Join(sourceRootForTest, "svc")
+	all := sourcePaths("svc/jobs/fixtures/sample_it.py", "svc/jobs/run.py", "svc/jobs/test_run.py", "svc/jobs/testing_helpers.py",
+		"svc/main.go", "svc/main_test.go", "svc/web/__tests__/render.ts", "svc/web/app.test.ts", "svc/web/app.ts")
+	implementation := sourcePaths("svc/jobs/fixtures/sample_it.py", "svc/jobs/run.py", "svc/jobs/testing_helpers.py", "svc/main.go", "svc/web/app.ts")
+
+	tests := []struct {
+		name         string
+		flag         bool // --no-tests
+		configured   bool // file_scanning.exclude_tests
+		testPatterns []string
+		want         []string
+	}{
+		{name: "kept by default", want: all},
+		{name: "--no-tests", flag: true, want: implementation},
+		{name: "exclude_tests", configured: true, want: implementation},
+		{name: "custom patterns replace the defaults", flag: true, testPatterns: []string{"*_it.py", "fixtures/"},
+			want: sourcePaths("svc/jobs/run.py", "svc/jobs/test_run.py", "svc/jobs/testing_helpers.py", "svc/main.go", "svc/main_test.go",
+				"svc/web/__tests__/render.ts", "svc/web/app.test.ts", "svc/web/app.ts")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := newTestScanner(fsys, 5)
+			scanning := config.FileScanningConfig{MaxDepth: 5, BinaryDetectionBuffer: 512, ExcludeTests: tt.configured, TestPatterns: tt.testPatterns}
+			scanner.config = scanningConfig{scanning: scanning}
+			scanner.SetExcludeTests(tt.flag)
+
+			got, err := scanner.FindSourceFiles(root, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindSourceFilesKeepsComponentNamedLikeATestDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"__tests__/runner.ts":          {Data: []byte("export {}\n")},
+		"__tests__/runner.test.ts":     {Data: []byte("export {}\n")},
+		"__tests__/__tests__/inner.ts": {Data: []byte("export {}\n")},
+	}
+	scanner := newTestScanner(fsys, 5)
+	scanner.SetExcludeTests(true)
+
+	// The component root itself is scanned; only test directories inside it are skipped
+	got, err := scanner.FindSourceFiles(filepath.Join(sourceRootForTest, "__tests__"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := sourcePaths("__tests__/runner.ts"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// useComponentsYAML runs the rest of the test in a directory holding components.yaml
+func useComponentsYAML(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "components.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestScanComponentsOverMapFS(t *testing.T) {
+	useComponentsYAML(t, `components:
+  - name: api
+    path: services/api
+    type: backend
+  - name: gone
+    path: services/gone
+    type: backend
+`)
+	fsys := fstest.MapFS{
+		"services/api/main.go":              {Data: []byte("package main\n")},
+		"services/api/README.md":            {Data: []byte("# api\n")},
+		"services/api/docs/ARCHITECTURE.md": {Data: []byte("# arch\n")},
+	}
+	components, err := newTestScanner(fsys, 5).ScanComponents(sourceRootForTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(components) != 1 || components[0].Name != "api" {
+		t.Fatalf("components = %+v, want only api (gone has no directory)", components)
+	}
+	api := components[0]
+	if want := []string{"README.md", "docs/ARCHITECTURE.md"}; !reflect.DeepEqual(api.ExistingDocs, want) {
+		t.Errorf("existing docs = %v, want %v", api.ExistingDocs, want)
+	}
+	if want := sourcePaths("services/api/README.md", "services/api/docs/ARCHITECTURE.md", "services/api/main.go"); !reflect.DeepEqual(api.Files, want) {
+		t.Errorf("files = %v, want %v", api.Files, want)
+	}
+}
+
+func TestRescanComponentPicksUpChangesToOneComponent(t *testing.T) {
+	useComponentsYAML(t, `components:
+  - name: api
+    path: services/api
+    type: backend
+`)
+	fsys := fstest.MapFS{
+		"services/api/main.go": {Data: []byte("package main\n")},
+	}
+	scanner := newTestScanner(fsys, 5)
+	components, err := scanner.ScanComponents(sourceRootForTest)
+	if err != nil || len(components) != 1 {
+		t.Fatalf("ScanComponents = %+v, %v", components, err)
+	}
+
+	fsys["services/api/handler.go"] = &fstest.MapFile{Data: []byte("package main\n")}
+	fsys["services/api/README.md"] = &fstest.MapFile{Data: []byte("# api\n")}
+	delete(fsys, "services/api/main.go")
+	api, err := scanner.RescanComponent(components[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"README.md"}; !reflect.DeepEqual(api.ExistingDocs, want) {
+		t.Errorf("existing docs = %v, want %v", api.ExistingDocs, want)
+	}
+	if want := sourcePaths("services/api/README.md", "services/api/handler.go"); !reflect.DeepEqual(api.Files, want) {
+		t.Errorf("files = %v, want %v", api.Files, want)
+	}
+	if api.Name != "api" || api.Type != "backend" {
+		t.Errorf("rescan changed the component definition: %+v", api)
+	}
+}
+
+func TestScanComponentsRejectsPathOutsideRoot(t *testing.T) {
+	useComponentsYAML(t, `components:
+  - name: escape
+    path: ../elsewhere
+    type: backend
+`)
+	fsys := fstest.MapFS{"elsewhere/main.go": {Data: []byte("package main\n")}}
+	_, err := newTestScanner(fsys, 5).ScanComponents(sourceRootForTest)
+	if err == nil || !strings.Contains(err.Error(), "component escape") || !strings.Contains(err.Error(), "outside source root") {
+		t.Fatalf("err = %v, want an error naming the component outside the root", err)
+	}
+}
+
+func TestScanComponentsAcrossWorkspaces(t *testing.T) {
+	useComponentsYAML(t, `workspaces:
+  - name: billing
+    path: /repos/billing
+  - name: search
+    path: /repos/search
+components:
+  - name: api
+    path: services/api
+    type: backend
+    workspace: billing
+  - name: api
+    path: services/api
+    type: backend
+    workspace: search
+`)
+	billing, search := filepath.FromSlash("/repos/billing"), filepath.FromSlash("/repos/search")
+	newScanner := func() *DefaultFileScanner {
+		return NewFileScanner(scanningConfig{scanning: config.FileScanningConfig{MaxDepth: 5, BinaryDetectionBuffer: 512}}, false).(*DefaultFileScanner)
+	}
+
+	// Point the workspaces at real directories, as --root does
+	billingDir, searchDir := t.TempDir(), t.TempDir()
+	for dir, source := range map[string]string{billingDir: "package billing\n", searchDir: "package search\n"} {
+		if err := os.MkdirAll(filepath.Join(dir, "services", "api"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "services", "api", "main.go"), []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fileScanner := newScanner()
+	fileScanner.SetWorkspaceRoots(map[string]string{"billing": billingDir, "search": searchDir})
+	components, err := fileScanner.ScanComponents(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(components) != 2 {
+		t.Fatalf("components = %+v, want both api components", components)
+	}
+	keys := map[string]string{}
+	for _, component := range components {
+		if component.Name != "api" || len(component.Files) != 1 {
+			t.Fatalf("unexpected component %+v", component)
+		}
+		keys[component.Key()] = component.Files[0]
+	}
+	want := map[string]string{
+		"billing/api": filepath.Join(billingDir, "services", "api", "main.go"),
+		"search/api":  filepath.Join(searchDir, "services", "api", "main.go"),
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("components by key = %v, want %v", keys, want)
+	}
+
+	// Archive sources must contain every workspace
+	archive := fstest.MapFS{"billing/services/api/main.go": {Data: []byte("package billing\n")}}
+	archiveScanner := NewFileScannerWithFS(scanningConfig{scanning: config.FileScanningConfig{MaxDepth: 5, BinaryDetectionBuffer: 512}}, false, archive, billing)
+	archiveScanner.SetWorkspaceRoots(map[string]string{"billing": billing, "search": search})
+	_, err = archiveScanner.ScanComponents(billing)
+	if err == nil || !strings.Contains(err.Error(), "workspace search") {
+		t.Errorf("err = %v, want an error naming the search workspace outside the archive", err)
+	}
+}
+
+func TestScanComponentsRejectsUnknownWorkspace(t *testing.T) {
+	useComponentsYAML(t, `components:
+  - name: api
+    path: services/api
+    type: backend
+    workspace: payments
+`)
+	_, err := newTestScanner(fstest.MapFS{}, 5).ScanComponents(sourceRootForTest)
+	if err == nil || !strings.Contains(err.Error(), `unknown workspace "payments"`) {
+		t.Fatalf("err = %v, want an error naming the unknown workspace", err)
+	}
+}
+
+// writeFixtureTree generates dirs*filesPerDir source files on disk, every tenth one binary,
+// with a .gitignore per directory that ignores one file
+func writeFixtureTree(tb testing.TB, dirs, filesPerDir int) string {
+	tb.Helper()
+	root := tb.TempDir()
+	source := []byte(strings.Repeat("package fixture // generated\n", 40))
+	binary := append([]byte("\x7fELF"), make([]byte, 1024)...)
+	for d := 0; d < dirs; d++ {
+		dir := filepath.Join(root, "svc", fmt.Sprintf("pkg%03d", d/10), fmt.Sprintf("sub%03d", d))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			tb.Fatal(err)
+		}
+		os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("ignored.go\n"), 0644)
+		os.WriteFile(filepath.Join(dir, "ignored.go"), source, 0644)
+		for f := 0; f < filesPerDir; f++ {
+			data := source
+			if f%10 == 9 {
+				data = binary
+			}
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%03d.go", f)), data, 0644); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+	return root
+}
+
+// newDirScanner scans root on disk with gitignore support and the given worker count
+func newDirScanner(root string, workers int) *DefaultFileScanner {
+	configManager := scanningConfig{scanning: config.FileScanningConfig{
+		MaxDepth:              -1,
+		BinaryDetectionBuffer: 512,
+		ScanWorkers:           workers,
+	}}
+	return NewFileScannerWithFS(configManager, true, os.DirFS(root), root).(*DefaultFileScanner)
+}
+
+func TestFindSourceFilesParallelMatchesSerial(t *testing.T) {
+	root := writeFixtureTree(t, 20, 20)
+	svc := filepath.Join(root, "svc")
+
+	serial, err := newDirScanner(root, 1).FindSourceFiles(svc, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Per directory: 18 text files and the .gitignore; the binaries and ignored.go are skipped
+	if want := 20 * 19; len(serial) != want {
+		t.Fatalf("found %d files, want %d", len(serial), want)
+	}
+	for _, file := range serial {
+		if strings.HasSuffix(file, "ignored.go") || strings.HasSuffix(file, "9.go") {
+			t.Fatalf("kept %s", file)
+		}
+	}
+
+	for _, workers := range []int{4, 16} {
+		parallel, err := newDirScanner(root, workers).FindSourceFiles(svc, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(parallel, serial) {
+			t.Errorf("%d workers found %d files in a different order or set than 1 worker", workers, len(parallel))
+		}
+	}
+}
+
+// BenchmarkFindSourceFiles compares serial and pooled checks on a 10k-file tree with a
+// .gitignore in every directory; pooling only pays off with more than one CPU, e.g.
+//
+//	go test ./pkg/scanner -run '^$' -bench FindSourceFiles
+func BenchmarkFindSourceFiles(b *testing.B) {
+	root := writeFixtureTree(b, 200, 50)
+	svc := filepath.Join(root, "svc")
+
+	for _, workers := range []int{1, 4, 0} {
+		name := fmt.Sprintf("workers=%d", workers)
+		if workers == 0 {
+			name = "workers=NumCPU"
+		}
+		b.Run(name, func(b *testing.B) {
+			scanner := newDirScanner(root, workers)
+			for i := 0; i < b.N; i++ {
+				if _, err := scanner.FindSourceFiles(svc, true); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package scanner
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ParseComponentFilter splits a comma-separated list of component globs
+func ParseComponentFilter(filter string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(filter, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// FilterComponents keeps components whose name or workspace/name key matches any
+// of the glob patterns. Every pattern must match at least one component, so a
+// typo fails loudly instead of silently shrinking the run.
+func FilterComponents(components []Component, patterns []string) ([]Component, error) {
+	if len(patterns) == 0 {
+		return components, nil
+	}
+
+	matchedPatterns := make(map[string]bool)
+	var filtered []Component
+
+	for _, component := range components {
+		keep := false
+		for _, pattern := range patterns {
+			matched, err := matchComponent(component, pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid component filter %q: %w", pattern, err)
+			}
+			if matched {
+				matchedPatterns[pattern] = true
+				keep = true
+			}
+		}
+		if keep {
+			filtered = append(filtered, component)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if !matchedPatterns[pattern] {
+			return nil, fmt.Errorf("component filter %q matched no components", pattern)
+		}
+	}
+
+	return filtered, nil
+}
+
+// matchComponent matches a glob against the component's name and key
+func matchComponent(component Component, pattern string) (bool, error) {
+	matched, err := path.Match(pattern, component.Name)
+	if err != nil || matched {
+		return matched, err
+	}
+	return path.Match(pattern, component.Key())
+}
+
+// FilterComponentsByTags keeps components carrying every tag in tags when matchAll is set,
+// or any of them otherwise. An empty tags list keeps all components.
+func FilterComponentsByTags(components []Component, tags []string, matchAll bool) []Component {
+	if len(tags) == 0 {
+		return components
+	}
+
+	var filtered []Component
+	for _, component := range components {
+		carried := make(map[string]bool, len(component.Tags))
+		for _, tag := range component.Tags {
+			carried[tag] = true
+		}
+
+		matches := 0
+		for _, tag := range tags {
+			if carried[tag] {
+				matches++
+			}
+		}
+		if (matchAll && matches == len(tags)) || (!matchAll && matches > 0) {
+			filtered = append(filtered, component)
+		}
+	}
+	return filtered
+}
+
+// validateTags rejects empty or whitespace-only tags on a component definition
+func validateTags(def ComponentDef) error {
+	for _, tag := range def.Tags {
+		if strings.TrimSpace(tag) == "" {
+			return fmt.Errorf("component %q has an empty tag", def.Name)
+		}
+	}
+	return nil
+}
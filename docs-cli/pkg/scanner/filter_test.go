@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func componentKeys(components []Component) []string {
+	keys := make([]string, len(components))
+	for i, component := range components {
+		keys[i] = component.Key()
+	}
+	return keys
+}
+
+var filterFixture = []Component{
+	{Name: "api-gateway"},
+	{Name: "api-users"},
+	{Name: "auth"},
+	{Name: "frontend"},
+	{Name: "worker", Workspace: "jobs"},
+	{Name: "api-jobs", Workspace: "jobs"},
+}
+
+func TestParseComponentFilter(t *testing.T) {
+	tests := map[string][]string{
+		"":                 nil,
+		"api-*":            {"api-*"},
+		" api-* , auth ,,": {"api-*", "auth"},
+	}
+	for filter, want := range tests {
+		if got := ParseComponentFilter(filter); !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseComponentFilter(%q) = %q, want %q", filter, got, want)
+		}
+	}
+}
+
+func TestFilterComponents(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"", []string{"api-gateway", "api-users", "auth", "frontend", "jobs/worker", "jobs/api-jobs"}},
+		{"api-*", []string{"api-gateway", "api-users", "jobs/api-jobs"}},
+		{"api-*,auth", []string{"api-gateway", "api-users", "auth", "jobs/api-jobs"}},
+		{"auth", []string{"auth"}},
+		{"api-?sers", []string{"api-users"}},
+		{"[af]*", []string{"api-gateway", "api-users", "auth", "frontend", "jobs/api-jobs"}},
+		// Patterns match the workspace/name key as well as the bare name
+		{"jobs/*", []string{"jobs/worker", "jobs/api-jobs"}},
+		{"worker", []string{"jobs/worker"}},
+		// A component matched by several patterns is kept once, in scan order
+		{"*,api-*", []string{"api-gateway", "api-users", "auth", "frontend", "jobs/worker", "jobs/api-jobs"}},
+		// * does not cross the workspace separator
+		{"*/api-*", []string{"jobs/api-jobs"}},
+	}
+	for _, tt := range tests {
+		got, err := FilterComponents(filterFixture, ParseComponentFilter(tt.filter))
+		if err != nil {
+			t.Errorf("%q: %v", tt.filter, err)
+			continue
+		}
+		if keys := componentKeys(got); !reflect.DeepEqual(keys, tt.want) {
+			t.Errorf("%q matched %q, want %q", tt.filter, keys, tt.want)
+		}
+	}
+}
+
+func TestFilterComponentsErrors(t *testing.T) {
+	tests := []struct {
+		filter  string
+		wantErr string
+	}{
+		{"billing", `component filter "billing" matched no components`},
+		// Every pattern must match, even when others do
+		{"api-*,biling", `component filter "biling" matched no components`},
+		{"api-[", `invalid component filter "api-["`},
+	}
+	for _, tt := range tests {
+		_, err := FilterComponents(filterFixture, ParseComponentFilter(tt.filter))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%q: error = %v, want %s", tt.filter, err, tt.wantErr)
+		}
+	}
+}
+
+var tagFixture = []Component{
+	{Name: "api", Tags: []string{"backend", "team-a"}},
+	{Name: "worker", Tags: []string{"backend", "team-b"}},
+	{Name: "web", Tags: []string{"frontend", "team-a"}},
+	{Name: "docs"},
+}
+
+func TestFilterComponentsByTags(t *testing.T) {
+	tests := []struct {
+		tags     string
+		matchAll bool
+		want     []string
+	}{
+		{"", false, []string{"api", "worker", "web", "docs"}},
+		{"", true, []string{"api", "worker", "web", "docs"}},
+		// Any of the tags (--tag-match any, the default)
+		{"backend", false, []string{"api", "worker"}},
+		{"backend,team-a", false, []string{"api", "worker", "web"}},
+		{"frontend,team-b", false, []string{"worker", "web"}},
+		// Every tag (--tag-match all)
+		{"backend", true, []string{"api", "worker"}},
+		{"backend,team-a", true, []string{"api"}},
+		{"frontend,team-b", true, []string{}},
+		// Tags match exactly, not as globs or case-insensitively
+		{"team-*", false, []string{}},
+		{"Backend", false, []string{}},
+		{"unknown", false, []string{}},
+	}
+	for _, tt := range tests {
+		got := componentKeys(FilterComponentsByTags(tagFixture, ParseComponentFilter(tt.tags), tt.matchAll))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tags %q, matchAll %v: kept %q, want %q", tt.tags, tt.matchAll, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	if err := validateTags(ComponentDef{Name: "api", Tags: []string{"backend", "team-a"}}); err != nil {
+		t.Errorf("validateTags rejected valid tags: %v", err)
+	}
+	for _, tags := range [][]string{{""}, {"backend", "  "}} {
+		err := validateTags(ComponentDef{Name: "api", Tags: tags})
+		if err == nil || !strings.Contains(err.Error(), `component "api" has an empty tag`) {
+			t.Errorf("validateTags(%q) = %v, want an empty tag error", tags, err)
+		}
+	}
+}
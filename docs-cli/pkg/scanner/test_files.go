@@ -0,0 +1,41 @@
+package scanner
+
+import (
+	"path"
+	"strings"
+)
+
+// isTestDir reports whether a slash-separated directory name matches a directory test pattern
+func isTestDir(name string, patterns []string) bool {
+	dir := path.Base(name)
+	for _, pattern := range patterns {
+		if !strings.HasSuffix(pattern, "/") {
+			continue
+		}
+		if matched, _ := path.Match(strings.TrimSuffix(pattern, "/"), dir); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isTestFile reports whether a slash-separated file path matches a test pattern,
+// either by its base name or by sitting under a test directory
+func isTestFile(name string, patterns []string) bool {
+	base := path.Base(name)
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "/") {
+			continue
+		}
+		if matched, _ := path.Match(pattern, base); matched {
+			return true
+		}
+	}
+
+	for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if isTestDir(dir, patterns) {
+			return true
+		}
+	}
+	return false
+}
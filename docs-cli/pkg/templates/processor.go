@@ -15,9 +15,14 @@ import (
 type TemplateProcessor interface {
 	ProcessTemplate(templateType string, component scanner.Component, contextData TemplateContext) (string, error)
 	LoadExternalTemplate(templateType string) (string, error)
+	LoadPartials(tmpl *template.Template) error
 	GeneratePrompt(component scanner.Component, docType, existingContent string) (string, error)
 }
 
+// PartialSuffix identifies shared template sections that prompt templates can
+// include by name, e.g. shared_context.partial.md -> {{template "shared_context" .}}
+const PartialSuffix = ".partial.md"
+
 // TemplateContext holds data for template processing
 type TemplateContext struct {
 	ComponentName        string
@@ -27,6 +32,8 @@ type TemplateContext struct {
 	ExistingDocs         []string
 	SourceContext        string
 	ConversationContext  string
+	// DependencyContext holds the docs of components this one imports (--with-deps)
+	DependencyContext    string
 	ExistingContent      string
 }
 
@@ -61,8 +68,14 @@ func (tp *DefaultTemplateProcessor) ProcessTemplate(templateType string, compone
 		}
 	}
 
+	// Load shared partials so the template can include them
+	tmpl := template.New(templateType)
+	if err := tp.LoadPartials(tmpl); err != nil {
+		return "", err
+	}
+
 	// Process template with context
-	tmpl, err := template.New(templateType).Parse(templateContent)
+	tmpl, err = tmpl.Parse(templateContent)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse template: %w", err)
 	}
@@ -73,6 +86,13 @@ func (tp *DefaultTemplateProcessor) ProcessTemplate(templateType string, compone
 		return "", fmt.Errorf("failed to execute template: %w", err)
 	}
 
+	// Append component-specific instructions for this document type only
+	if override := strings.TrimSpace(component.PromptOverrides[templateType]); override != "" {
+		result.WriteString("\n\n## Component-Specific Instructions\n")
+		result.WriteString(override)
+		result.WriteString("\n")
+	}
+
 	return result.String(), nil
 }
 
@@ -93,6 +113,29 @@ func (tp *DefaultTemplateProcessor) LoadExternalTemplate(templateType string) (s
 	return string(content), nil
 }
 
+// LoadPartials parses every partial in the templates directory into the given template set
+func (tp *DefaultTemplateProcessor) LoadPartials(tmpl *template.Template) error {
+	templatesConfig := tp.config.GetTemplatesConfig()
+	partialPaths, err := filepath.Glob(filepath.Join(templatesConfig.Directory, "*"+PartialSuffix))
+	if err != nil {
+		return fmt.Errorf("failed to list template partials: %w", err)
+	}
+
+	for _, partialPath := range partialPaths {
+		content, err := os.ReadFile(partialPath)
+		if err != nil {
+			return fmt.Errorf("failed to read template partial: %w", err)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(partialPath), PartialSuffix)
+		if _, err := tmpl.New(name).Parse(string(content)); err != nil {
+			return fmt.Errorf("failed to parse template partial %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
 // GeneratePrompt generates a complete prompt for documentation generation
 func (tp *DefaultTemplateProcessor) GeneratePrompt(component scanner.Component, docType, existingContent string) (string, error) {
 	// Create template context
@@ -0,0 +1,79 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"docs-cli/pkg/config"
+	"docs-cli/pkg/scanner"
+)
+
+// templatesDirConfig serves a fixed templates configuration
+type templatesDirConfig struct {
+	config.ConfigManager
+	templates config.TemplatesConfig
+}
+
+func (c templatesDirConfig) GetTemplatesConfig() config.TemplatesConfig {
+	return c.templates
+}
+
+func newTestProcessor(dir string) TemplateProcessor {
+	return NewTemplateProcessor(templatesDirConfig{templates: config.TemplatesConfig{Directory: dir}})
+}
+
+func TestPartialIncludedByTwoTemplates(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"header" + PartialSuffix: "Component {{.ComponentName}} at {{.ComponentPath}}",
+		"ONE.prompt.md":          "# One\n{{template \"header\" .}}\nfirst",
+		"TWO.prompt.md":          "# Two\n{{template \"header\" .}}\nsecond",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	processor := newTestProcessor(dir)
+	contextData := TemplateContext{ComponentName: "api", ComponentPath: "services/api"}
+	want := map[string]string{
+		"ONE": "# One\nComponent api at services/api\nfirst",
+		"TWO": "# Two\nComponent api at services/api\nsecond",
+	}
+	for templateType, expected := range want {
+		got, err := processor.ProcessTemplate(templateType, scanner.Component{Name: "api"}, contextData)
+		if err != nil {
+			t.Fatalf("%s: %v", templateType, err)
+		}
+		if got != expected {
+			t.Errorf("%s rendered %q, want %q", templateType, got, expected)
+		}
+	}
+}
+
+func TestPromptTemplatesShareContext(t *testing.T) {
+	processor := newTestProcessor(filepath.Join("..", "..", "templates"))
+	contextData := TemplateContext{
+		ComponentName:        "api",
+		ComponentPath:        "services/api",
+		ComponentType:        "backend",
+		ComponentDescription: "Serves the public HTTP API",
+		SourceContext:        "SOURCE-MARKER",
+		ConversationContext:  "CONVERSATION-MARKER",
+		DependencyContext:    "DEPENDENCY-MARKER",
+	}
+	for _, docType := range []string{"README", "ARCHITECTURE", "SETUP", "CHECKLIST"} {
+		prompt, err := processor.ProcessTemplate(docType, scanner.Component{Name: "api"}, contextData)
+		if err != nil {
+			t.Fatalf("%s: %v", docType, err)
+		}
+		for _, want := range []string{"services/api", "Serves the public HTTP API", "SOURCE-MARKER", "CONVERSATION-MARKER", "DEPENDENCY-MARKER"} {
+			if !strings.Contains(prompt, want) {
+				t.Errorf("%s prompt is missing %q", docType, want)
+			}
+		}
+	}
+}
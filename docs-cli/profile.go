@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+)
+
+var (
+	// CPU profile file while --profile is active
+	cpuProfileFile *os.File
+)
+
+// startProfiling begins CPU profiling for --profile cpu|both
+func startProfiling() error {
+	switch profileMode {
+	case "":
+		return nil
+	case "cpu", "mem", "both":
+	default:
+		return fmt.Errorf("invalid --profile %q (expected cpu, mem, or both)", profileMode)
+	}
+
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		return fmt.Errorf("failed to create profile directory: %w", err)
+	}
+
+	if profileMode == "cpu" || profileMode == "both" {
+		cpuPath := filepath.Join(profileDir, "cpu.pprof")
+		file, err := os.Create(cpuPath)
+		if err != nil {
+			return fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		cpuProfileFile = file
+		LogWithContext().WithField("path", cpuPath).Info("CPU profiling started")
+	}
+
+	return nil
+}
+
+// stopProfiling flushes the CPU profile and writes a heap profile for --profile mem|both
+func stopProfiling() {
+	if cpuProfileFile != nil {
+		pprof.StopCPUProfile()
+		cpuProfileFile.Close()
+		fmt.Printf("📈 CPU profile written to %s\n", cpuProfileFile.Name())
+		cpuProfileFile = nil
+	}
+
+	if profileMode != "mem" && profileMode != "both" {
+		return
+	}
+
+	memPath := filepath.Join(profileDir, "mem.pprof")
+	file, err := os.Create(memPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to create memory profile: %v\n", err)
+		return
+	}
+	defer file.Close()
+
+	// Collect garbage first so the heap profile reflects live objects
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		fmt.Printf("❌ Failed to write memory profile: %v\n", err)
+		return
+	}
+	fmt.Printf("📈 Memory profile written to %s\n", memPath)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// progressLogInterval is how often a non-terminal run prints a plain progress line
+const progressLogInterval = 30 * time.Second
+
+// ProgressReporter shows how far a multi-component run has got. On a terminal it redraws
+// one live line; otherwise it prints a plain line at most every progressLogInterval.
+type ProgressReporter struct {
+	mutex           sync.Mutex
+	totalComponents int
+	totalDocs       int
+	components      int
+	docs            int
+	failed          int
+	start           time.Time
+	lastLog         time.Time
+	live            bool
+	quiet           bool
+}
+
+// NewProgressReporter tracks totalComponents components expected to regenerate totalDocs documents
+func NewProgressReporter(totalComponents, totalDocs int) *ProgressReporter {
+	return &ProgressReporter{
+		totalComponents: totalComponents,
+		totalDocs:       totalDocs,
+		start:           time.Now(),
+		lastLog:         time.Now(),
+		live:            isTerminal(os.Stdout),
+		quiet:           quiet,
+	}
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// DocDone records one generated or failed document
+func (p *ProgressReporter) DocDone(ok bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.docs++
+	if !ok {
+		p.failed++
+	}
+	p.reportLocked(false)
+}
+
+// ComponentDone records a component whose documents have all been processed
+func (p *ProgressReporter) ComponentDone() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.components++
+	p.reportLocked(false)
+}
+
+// Printf prints a message without tearing the live progress line
+func (p *ProgressReporter) Printf(format string, args ...interface{}) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.clearLocked()
+	fmt.Printf(format, args...)
+	p.reportLocked(false)
+}
+
+// Finish prints the final progress line
+func (p *ProgressReporter) Finish() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.reportLocked(true)
+	if p.live && !p.quiet {
+		fmt.Println()
+	}
+}
+
+func (p *ProgressReporter) clearLocked() {
+	if p.live && !p.quiet {
+		fmt.Print("\r\033[K")
+	}
+}
+
+func (p *ProgressReporter) reportLocked(final bool) {
+	if p.quiet {
+		return
+	}
+	if p.live {
+		fmt.Print("\r\033[K" + p.lineLocked())
+		return
+	}
+	if final || time.Since(p.lastLog) >= progressLogInterval {
+		fmt.Println(p.lineLocked())
+		p.lastLog = time.Now()
+	}
+}
+
+// lineLocked renders "X/Y components, A/B docs, $Z spent, ETA"
+func (p *ProgressReporter) lineLocked() string {
+	line := fmt.Sprintf("⏳ %d/%d components, %d/%d docs", p.components, p.totalComponents, p.docs, p.totalDocs)
+	if p.failed > 0 {
+		line += fmt.Sprintf(" (%d failed)", p.failed)
+	}
+	line += fmt.Sprintf(", $%.4f spent", runCostSummary.TotalCost())
+
+	if p.docs > 0 && p.docs < p.totalDocs {
+		perDoc := time.Since(p.start) / time.Duration(p.docs)
+		eta := perDoc * time.Duration(p.totalDocs-p.docs)
+		line += fmt.Sprintf(", ETA %s", eta.Round(time.Second))
+	}
+	return line
+}
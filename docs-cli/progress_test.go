@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// useRunCost makes the run's spend so far cost
+func useRunCost(t *testing.T, cost float64) {
+	t.Helper()
+	previous := runCostSummary
+	runCostSummary = &CostSummary{rows: make(map[costSummaryKey]*costSummaryRow)}
+	t.Cleanup(func() { runCostSummary = previous })
+	if cost > 0 {
+		runCostSummary.Record("openai", "gpt-4o", 1000, 500, cost, false)
+	}
+}
+
+// newTestProgress is a reporter for a run that started elapsed ago and last logged just now
+func newTestProgress(components, docs int, elapsed time.Duration, live, quiet bool) *ProgressReporter {
+	return &ProgressReporter{
+		totalComponents: components,
+		totalDocs:       docs,
+		start:           time.Now().Add(-elapsed),
+		lastLog:         time.Now(),
+		live:            live,
+		quiet:           quiet,
+	}
+}
+
+func TestProgressLine(t *testing.T) {
+	useRunCost(t, 0.1234)
+	progress := newTestProgress(3, 4, 10*time.Second, false, false)
+	progress.DocDone(true)
+	progress.DocDone(false)
+	progress.ComponentDone()
+
+	// Two of four docs took 10s, so the other two should take about 10s more
+	want := "⏳ 1/3 components, 2/4 docs (1 failed), $0.1234 spent, ETA 10s"
+	if line := progress.lineLocked(); line != want {
+		t.Errorf("line = %q, want %q", line, want)
+	}
+
+	// With nothing done there is no rate to estimate from, and when done nothing is left
+	if line := newTestProgress(3, 4, time.Second, false, false).lineLocked(); strings.Contains(line, "ETA") {
+		t.Errorf("line before any doc = %q, want no ETA", line)
+	}
+	progress.DocDone(true)
+	progress.DocDone(true)
+	if line := progress.lineLocked(); strings.Contains(line, "ETA") {
+		t.Errorf("line after the last doc = %q, want no ETA", line)
+	}
+}
+
+func TestProgressOutputModes(t *testing.T) {
+	useRunCost(t, 0)
+	tests := []struct {
+		name        string
+		live, quiet bool
+		staleLog    bool
+		want        string
+	}{
+		{
+			name: "terminal redraws one line",
+			live: true,
+			want: "\r\033[K⏳ 0/1 components, 1/2 docs, $0.0000 spent, ETA 1m0s" +
+				"\r\033[K⏳ 1/1 components, 1/2 docs, $0.0000 spent, ETA 1m0s" +
+				"\r\033[K⏳ 1/1 components, 1/2 docs, $0.0000 spent, ETA 1m0s\n",
+		},
+		{
+			name: "plain output waits for the log interval, then prints the final line",
+			want: "⏳ 1/1 components, 1/2 docs, $0.0000 spent, ETA 1m0s\n",
+		},
+		{
+			name:     "plain output prints once the log interval has passed",
+			staleLog: true,
+			want: "⏳ 0/1 components, 1/2 docs, $0.0000 spent, ETA 1m0s\n" +
+				"⏳ 1/1 components, 1/2 docs, $0.0000 spent, ETA 1m0s\n",
+		},
+		{name: "quiet prints nothing", live: true, quiet: true},
+		{name: "quiet plain output prints nothing", quiet: true, staleLog: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := newTestProgress(1, 2, time.Minute, tt.live, tt.quiet)
+			if tt.staleLog {
+				progress.lastLog = time.Now().Add(-progressLogInterval)
+			}
+			output := captureStdout(t, func() {
+				progress.DocDone(true)
+				progress.ComponentDone()
+				progress.Finish()
+			})
+			if output != tt.want {
+				t.Errorf("output = %q, want %q", output, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressPrintfKeepsTheLiveLine(t *testing.T) {
+	useRunCost(t, 0)
+	progress := newTestProgress(1, 1, time.Second, true, false)
+	output := captureStdout(t, func() { progress.Printf("📝 Updated %s\n", "svc/README") })
+
+	want := "\r\033[K📝 Updated svc/README\n\r\033[K⏳ 0/1 components, 0/1 docs, $0.0000 spent"
+	if output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+}
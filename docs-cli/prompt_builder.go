@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"docs-cli/pkg/config"
+	"docs-cli/pkg/scanner"
+	"docs-cli/pkg/templates"
+)
+
+// contextDocOrder is the order previously generated documents appear in conversation context:
+// the executive summary, then templates.chain_order
+func contextDocOrder() []string {
+	return append([]string{"EXECUTIVE_SUMMARY"}, chainOrder()...)
+}
+
+// chainOrder returns templates.chain_order; it is validated at startup, so an invalid
+// value here falls back to the default order
+func chainOrder() []string {
+	order, err := config.GetConfig().Templates.ResolveChainOrder()
+	if err != nil {
+		return config.DefaultChainOrder
+	}
+	return order
+}
+
+// findComponentByName scans components and returns the one with the given name,
+// or with the given workspace/name key when names repeat across workspaces
+func findComponentByName(fileScanner scanner.FileScanner, name string) (scanner.Component, error) {
+	components, err := fileScanner.ScanComponents(projectRoot)
+	if err != nil {
+		return scanner.Component{}, fmt.Errorf("failed to scan components: %w", err)
+	}
+
+	for _, component := range components {
+		if component.Key() == name || component.Name == name {
+			return component, nil
+		}
+	}
+
+	return scanner.Component{}, fmt.Errorf("component '%s' not found", name)
+}
+
+// docOutputPath returns where a document type is written for a component, suffixed with --lang when set
+func docOutputPath(component scanner.Component, docType string) string {
+	componentPath := component.Dir()
+
+	var outputPath string
+	switch docType {
+	case "README":
+		outputPath = filepath.Join(componentPath, "README.md")
+	case "CHECKLIST":
+		outputPath = filepath.Join(componentPath, "docs", "CHECKLIST.yaml")
+	case "EXECUTIVE_SUMMARY":
+		outputPath = filepath.Join(componentPath, "docs", "executive_summary.md")
+	default:
+		outputPath = filepath.Join(componentPath, "docs", strings.ToUpper(docType)+".md")
+	}
+	return localizedPath(outputPath, docLanguage)
+}
+
+// buildSourceContext reads the component's prioritized source files into a single context block
+func buildSourceContext(fileScanner scanner.FileScanner, component scanner.Component) string {
+	var sourceContext strings.Builder
+
+	for _, filePath := range fileScanner.LimitFiles(component.Files, fullScan) {
+		content, err := MemoryAwareFileReader(filePath)
+		if err != nil {
+			LogWithContext().WithError(err).WithField("file", filePath).Warn("Skipping file in source context")
+			continue
+		}
+
+		displayPath := filePath
+		if relPath, err := filepath.Rel(component.Root, filePath); err == nil {
+			displayPath = relPath
+		}
+
+		sourceContext.WriteString(fmt.Sprintf("=== %s ===\n%s\n\n", displayPath, CleanupFileContent(string(content), filePath)))
+	}
+
+	return sourceContext.String()
+}
+
+// prioritizedSourceFiles returns the files sent as source context, highest priority first
+func prioritizedSourceFiles(fileScanner scanner.FileScanner, component scanner.Component) []string {
+	files := fileScanner.LimitFiles(component.Files, fullScan)
+	if prioritizer, ok := fileScanner.(interface{ SortFilesByPriority([]string) []string }); ok {
+		files = prioritizer.SortFilesByPriority(files)
+	}
+	return files
+}
+
+// componentDescription returns --description, the components.yaml description, or else a
+// summary of the leading doc comment of the highest-priority source file that has one
+func componentDescription(fileScanner scanner.FileScanner, component scanner.Component) string {
+	if descriptionOverride != "" {
+		return descriptionOverride
+	}
+	if component.Description != "" {
+		return component.Description
+	}
+
+	for _, filePath := range prioritizedSourceFiles(fileScanner, component) {
+		content, err := MemoryAwareFileReader(filePath)
+		if err != nil {
+			continue
+		}
+		if description := scanner.ExtractDescription(filePath, content); description != "" {
+			return description
+		}
+	}
+	return ""
+}
+
+// loadContextDocuments reads the component's existing documents, other than docType, in context order.
+// With --dedupe-context, paragraphs repeated from an earlier document are dropped.
+func loadContextDocuments(component scanner.Component, docType string) ([]string, []string) {
+	var contextDocTypes, contents []string
+	for _, contextDocType := range contextDocOrder() {
+		if contextDocType == docType {
+			continue
+		}
+
+		content, err := os.ReadFile(docOutputPath(component, contextDocType))
+		if err != nil {
+			continue
+		}
+		contextDocTypes = append(contextDocTypes, contextDocType)
+		contents = append(contents, string(content))
+	}
+
+	if dedupeContext && len(contents) > 0 {
+		contents = dedupeParagraphs(contents)
+	}
+	return contextDocTypes, contents
+}
+
+// buildConversationContext flattens the component's existing documents into a single conversation context block
+func buildConversationContext(component scanner.Component, docType string) string {
+	contextDocTypes, contents := loadContextDocuments(component, docType)
+	if len(contents) == 0 {
+		return ""
+	}
+
+	var conversationContext strings.Builder
+	conversationContext.WriteString("\n=== CONVERSATION CONTEXT ===\n")
+	conversationContext.WriteString("Previous documents in this conversation:\n\n")
+	for i, contextDocType := range contextDocTypes {
+		conversationContext.WriteString(fmt.Sprintf("## %s:\n%s\n\n", contextDocType, contents[i]))
+	}
+	conversationContext.WriteString("=== END CONVERSATION CONTEXT ===\n\n")
+
+	return conversationContext.String()
+}
+
+// BuildPrompt renders the full, uncompressed prompt for a component and document type
+func BuildPrompt(configManager config.ConfigManager, fileScanner scanner.FileScanner, component scanner.Component, docType string) (string, error) {
+	return renderPrompt(configManager, fileScanner, component, docType, buildConversationContext(component, docType), existingDocContent(component, docType), buildSourceContext(fileScanner, component))
+}
+
+// sourceContextInFirstTurn stands in for the source context in a conversation's prompt
+const sourceContextInFirstTurn = "(The source files are in the first message of this conversation.)"
+
+// BuildConversation renders the prompt as chat turns instead of one flattened string: each
+// existing context document becomes a user request answered by that document, followed by
+// the prompt itself, so providers see the chain's conversational structure. The source
+// context opens the first turn, ahead of the chained documents, so the conversation up to
+// it is the same for every document of the component and marked as the cacheable prefix.
+func BuildConversation(configManager config.ConfigManager, fileScanner scanner.FileScanner, component scanner.Component, docType string) ([]ChatMessage, error) {
+	var messages []ChatMessage
+	contextDocTypes, contents := loadContextDocuments(component, docType)
+	for i, contextDocType := range contextDocTypes {
+		messages = append(messages,
+			ChatMessage{Role: "user", Content: fmt.Sprintf("Write the %s document for %s.", contextDocType, component.Name)},
+			ChatMessage{Role: "assistant", Content: contents[i]},
+		)
+	}
+
+	sourceContext := buildSourceContext(fileScanner, component)
+	promptSourceContext := ""
+	if sourceContext != "" {
+		promptSourceContext = sourceContextInFirstTurn
+	}
+	prompt, err := renderPrompt(configManager, fileScanner, component, docType, "", existingDocContent(component, docType), promptSourceContext)
+	if err != nil {
+		return nil, err
+	}
+	messages = append(messages, ChatMessage{Role: "user", Content: prompt})
+
+	// Templates without the source context get none
+	if sourceContext != "" && strings.Contains(prompt, sourceContextInFirstTurn) {
+		opening := fmt.Sprintf("Source files of %s:\n\n%s", component.Name, sourceContext)
+		messages[0].Content = opening + messages[0].Content
+		messages[0].CacheablePrefix = len(opening)
+	}
+	return messages, nil
+}
+
+// existingDocContent returns the document currently on disk for docType, or "" if there is none
+func existingDocContent(component scanner.Component, docType string) string {
+	content, err := os.ReadFile(docOutputPath(component, docType))
+	if err != nil {
+		return ""
+	}
+	return string(content)
+}
+
+// renderPrompt processes the document template with the given conversation context,
+// existing document content, and source context
+func renderPrompt(configManager config.ConfigManager, fileScanner scanner.FileScanner, component scanner.Component, docType, conversationContext, existingContent, sourceContext string) (string, error) {
+	if err := validatePromptOverrides(component.Key(), component.PromptOverrides); err != nil {
+		return "", err
+	}
+
+	contextData := templates.TemplateContext{
+		ComponentName:        component.Name,
+		ComponentPath:        component.Path,
+		ComponentType:        component.Type,
+		ComponentDescription: componentDescription(fileScanner, component),
+		ExistingDocs:         component.ExistingDocs,
+		SourceContext:        sourceContext,
+		ConversationContext:  conversationContext,
+		DependencyContext:    buildDependencyContext(fileScanner, component),
+		ExistingContent:      existingContent,
+	}
+
+	templateProcessor := templates.NewTemplateProcessor(configManager)
+	prompt, err := templateProcessor.ProcessTemplate(docType, component, contextData)
+	if err != nil {
+		return "", err
+	}
+	return prompt + languageInstruction(docLanguage) + extraInstructionsSection(), nil
+}
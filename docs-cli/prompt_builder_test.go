@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"docs-cli/pkg/config"
+)
+
+func TestComponentDescriptionPrecedence(t *testing.T) {
+	project := newTestProject(t, `components:
+  - name: "api"
+    path: "api"
+    type: "backend"
+  - name: "web"
+    path: "web"
+    type: "frontend"
+    description: "Configured web description"
+`)
+	project.WriteFile("api/main.go", "// Package main runs the public API server.\npackage main\n")
+	project.WriteFile("web/index.js", "/** Renders the job board. */\n")
+
+	configManager := config.NewConfigManager()
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, web := project.Component("api"), project.Component("web")
+
+	if got := componentDescription(fileScanner, api); got != "Package main runs the public API server." {
+		t.Errorf("derived description = %q", got)
+	}
+	if got := componentDescription(fileScanner, web); got != "Configured web description" {
+		t.Errorf("configured description = %q, want it to win over the file header", got)
+	}
+
+	descriptionOverride = "Overridden for this run"
+	t.Cleanup(func() { descriptionOverride = "" })
+	prompt, err := renderPrompt(configManager, fileScanner, web, "README", "", "", buildSourceContext(fileScanner, web))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(prompt, "- Description: Overridden for this run") {
+		t.Error("--description override is missing from the prompt")
+	}
+}
+
+func TestPromptOverridesStayWithTheirComponent(t *testing.T) {
+	project := newTestProject(t, `components:
+  - name: "api"
+    path: "api"
+    type: "backend"
+    prompt_overrides:
+      README: "Document the rate limits on every endpoint."
+  - name: "web"
+    path: "web"
+    type: "frontend"
+`)
+	project.WriteFile("api/main.go", "package main\n")
+	project.WriteFile("web/index.js", "export {}\n")
+
+	configManager := config.NewConfigManager()
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	render := func(component, docType string) string {
+		t.Helper()
+		scanned := project.Component(component)
+		prompt, err := renderPrompt(configManager, fileScanner, scanned, docType, "", "", buildSourceContext(fileScanner, scanned))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return prompt
+	}
+
+	const override = "Document the rate limits on every endpoint."
+	// Render the overridden component first so any state it leaves behind shows up in web
+	if prompt := render("api", "README"); !strings.Contains(prompt, "## Component-Specific Instructions\n"+override) {
+		t.Error("api README prompt is missing its override")
+	}
+	if prompt := render("web", "README"); strings.Contains(prompt, override) || strings.Contains(prompt, "Component-Specific Instructions") {
+		t.Error("api's README override leaked into web's README prompt")
+	}
+	if prompt := render("api", "SETUP"); strings.Contains(prompt, override) {
+		t.Error("api's README override leaked into its SETUP prompt")
+	}
+}
+
+func TestCustomChainOrderDrivesGenerationOrder(t *testing.T) {
+	useEnterpriseConfig(t, func(c *config.EnterpriseConfig) {
+		c.Templates.ChainOrder = []string{"SETUP", "README", "CHECKLIST", "ARCHITECTURE"}
+	})
+
+	if got, want := strings.Join(contextDocOrder(), ","), "EXECUTIVE_SUMMARY,SETUP,README,CHECKLIST,ARCHITECTURE"; got != want {
+		t.Errorf("contextDocOrder = %s, want %s", got, want)
+	}
+	// update regenerates its doc types in the configured order
+	if got, want := strings.Join(orderedUpdateDocTypes(), ","), "SETUP,README,CHECKLIST,ARCHITECTURE"; got != want {
+		t.Errorf("orderedUpdateDocTypes = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"docs-cli/pkg/config"
+)
+
+// cachedConversationRequest builds svc's README conversation, with its ARCHITECTURE already
+// generated, sends it through the Anthropic provider with prompt caching set as given, and
+// returns the messages of the request body
+func cachedConversationRequest(t *testing.T, promptCaching bool) []interface{} {
+	t.Helper()
+	project, svc := singleServiceProject(t)
+	project.WriteFile("svc/docs/ARCHITECTURE.md", "# svc architecture\n")
+	useEnterpriseConfig(t, func(c *config.EnterpriseConfig) { c.Providers.Anthropic.PromptCaching = promptCaching })
+
+	configManager := config.NewConfigManager()
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages, err := BuildConversation(configManager, fileScanner, svc, "README")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := newProviderServer(t, http.StatusOK, `{"model":"served-model","content":[{"type":"text","text":"generated"}],"usage":{"input_tokens":12,"output_tokens":34,"cache_creation_input_tokens":10}}`)
+	provider := newHTTPProviders(server.URL)["anthropic"]
+	if _, err := provider.CallChat(context.Background(), ChatRequest{Model: "test-model", Messages: messages, MaxTokens: 500}); err != nil {
+		t.Fatal(err)
+	}
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(requests))
+	}
+	return requests[0].body["messages"].([]interface{})
+}
+
+func TestPromptCachingRequestStructure(t *testing.T) {
+	messages := cachedConversationRequest(t, true)
+	if len(messages) != 3 {
+		t.Fatalf("sent %d messages, want the ARCHITECTURE turn pair and the prompt", len(messages))
+	}
+
+	// The source context opens the conversation as its own block, carrying the only breakpoint
+	first := messages[0].(map[string]interface{})
+	blocks, ok := first["content"].([]interface{})
+	if first["role"] != "user" || !ok || len(blocks) != 2 {
+		t.Fatalf("first message = %v, want a user turn of two text blocks", first)
+	}
+	source := blocks[0].(map[string]interface{})
+	text, _ := source["text"].(string)
+	if !strings.HasPrefix(text, "Source files of svc:") || !strings.Contains(text, "func main() {}") {
+		t.Errorf("cached block = %q, want the source context", text)
+	}
+	if cacheControl, _ := source["cache_control"].(map[string]interface{}); cacheControl["type"] != "ephemeral" {
+		t.Errorf("cached block cache_control = %v, want ephemeral", source["cache_control"])
+	}
+	request := blocks[1].(map[string]interface{})
+	if request["text"] != "Write the ARCHITECTURE document for svc." || request["cache_control"] != nil {
+		t.Errorf("second block = %v, want the uncached ARCHITECTURE request", request)
+	}
+
+	if second := messages[1].(map[string]interface{}); second["role"] != "assistant" || second["content"] != "# svc architecture\n" {
+		t.Errorf("second message = %v, want the ARCHITECTURE document", second)
+	}
+
+	// The prompt refers back to the source instead of repeating it, and is not cached
+	last := messages[2].(map[string]interface{})
+	prompt, ok := last["content"].(string)
+	if last["role"] != "user" || !ok {
+		t.Fatalf("last message = %v, want a plain user prompt", last)
+	}
+	if strings.Contains(prompt, "func main() {}") || !strings.Contains(prompt, sourceContextInFirstTurn) {
+		t.Errorf("prompt should point at the first turn instead of repeating the source:\n%s", prompt)
+	}
+}
+
+func TestPromptCachingDisabledSendsPlainMessages(t *testing.T) {
+	messages := cachedConversationRequest(t, false)
+	for i, message := range messages {
+		if _, ok := message.(map[string]interface{})["content"].(string); !ok {
+			t.Errorf("message %d content = %v, want a plain string without cache_control", i, message)
+		}
+	}
+	if first := messages[0].(map[string]interface{})["content"].(string); !strings.HasPrefix(first, "Source files of svc:") {
+		t.Errorf("first message = %q, want the source context still sent first", first)
+	}
+}
+
+func TestCompressedPromptKeepsCacheablePrefix(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	opening := "Source files of svc:\n\n=== main.go ===\npackage main\n\n"
+	message := ChatMessage{Role: "user", Content: opening + "Write the README.", CacheablePrefix: len(opening)}
+
+	compressed := compressMessage(message)
+	if compressed.CacheablePrefix == 0 || compressed.CacheablePrefix > len(compressed.Content) {
+		t.Fatalf("compressed cacheable prefix = %d of %d bytes", compressed.CacheablePrefix, len(compressed.Content))
+	}
+	// The prefix compresses the same whatever follows it, and stays apart from the request
+	other := compressMessage(ChatMessage{Role: "user", Content: opening + "Write the SETUP guide.", CacheablePrefix: len(opening)})
+	prefix := compressed.Content[:compressed.CacheablePrefix]
+	if other.Content[:other.CacheablePrefix] != prefix {
+		t.Errorf("compressed prefixes differ: %q and %q", prefix, other.Content[:other.CacheablePrefix])
+	}
+	if !strings.HasSuffix(prefix, "\n\n") || !strings.HasSuffix(compressed.Content, "Write the README.") {
+		t.Errorf("compressed message = %q, want the prefix's trailing blank line kept before the request", compressed.Content)
+	}
+}
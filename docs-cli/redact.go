@@ -0,0 +1,56 @@
+package main
+
+import (
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+)
+
+// redactedPlaceholder replaces secrets scrubbed from errors and logs
+const redactedPlaceholder = "[REDACTED]"
+
+// secretPatterns match credentials that must never reach errors or logs. The first
+// submatch, when present, is kept so the surrounding header name stays readable.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`),
+	regexp.MustCompile(`(?i)(x-api-key["']?\s*[:=]\s*["']?)[^\s"',}]+`),
+	regexp.MustCompile(`()\bsk-(?:ant-)?[A-Za-z0-9_-]{8,}`),
+}
+
+// RedactSecrets scrubs bearer tokens, X-API-Key values, and sk-/sk-ant- keys from s
+func RedactSecrets(s string) string {
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllString(s, "${1}"+redactedPlaceholder)
+	}
+	return s
+}
+
+// redactedBody returns a provider response body safe to embed in an error
+func redactedBody(body []byte) string {
+	return RedactSecrets(string(body))
+}
+
+// redactingFormatter scrubs secrets from the message and string or error fields
+// before handing the entry to the wrapped formatter
+type redactingFormatter struct {
+	inner logrus.Formatter
+}
+
+// Format implements logrus.Formatter
+func (f *redactingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	redacted := entry.Dup()
+	redacted.Level = entry.Level
+	redacted.Message = RedactSecrets(entry.Message)
+	redacted.Caller = entry.Caller
+
+	for key, value := range redacted.Data {
+		switch v := value.(type) {
+		case string:
+			redacted.Data[key] = RedactSecrets(v)
+		case error:
+			redacted.Data[key] = RedactSecrets(v.Error())
+		}
+	}
+
+	return f.inner.Format(redacted)
+}
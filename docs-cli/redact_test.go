@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Fake credentials shaped like the real ones; none of them were ever issued
+const (
+	fakeAnthropicKey = "sk-ant-REDACTED"
+	fakeOpenAIKey    = "sk-proj-FAKEfakeFAKE0123456789"
+	fakeBearerToken  = "eyJhbGciOiJIUzI1NiJ9.ZmFrZQ.ZmFrZS1zaWc"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"anthropic key", "invalid key " + fakeAnthropicKey + " rejected", "invalid key [REDACTED] rejected"},
+		{"openai key", `{"error":"Incorrect API key provided: ` + fakeOpenAIKey + `"}`, `{"error":"Incorrect API key provided: [REDACTED]"}`},
+		{"bearer header", "Authorization: Bearer " + fakeBearerToken, "Authorization: Bearer [REDACTED]"},
+		{"x-api-key header", "X-API-Key: " + fakeAnthropicKey, "X-API-Key: [REDACTED]"},
+		{"x-api-key json", `{"x-api-key": "not-an-sk-key", "model": "m"}`, `{"x-api-key": "[REDACTED]", "model": "m"}`},
+		{"several secrets", fakeOpenAIKey + " and " + fakeAnthropicKey, "[REDACTED] and [REDACTED]"},
+		{"short sk- prefix is not a key", "task sk-1 done", "task sk-1 done"},
+		{"no secrets", "rate limit exceeded", "rate limit exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RedactSecrets(tt.input)
+			if got != tt.want {
+				t.Errorf("RedactSecrets(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			for _, secret := range []string{fakeAnthropicKey, fakeOpenAIKey, fakeBearerToken} {
+				if strings.Contains(got, secret) {
+					t.Errorf("redacted output still contains %q", secret)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactingFormatterScrubsMessagesAndFields(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&out)
+	logger.SetFormatter(&redactingFormatter{inner: &logrus.JSONFormatter{}})
+
+	logger.WithField("header", "Bearer "+fakeBearerToken).
+		WithError(errors.New("request with "+fakeOpenAIKey+" failed")).
+		WithField("attempt", 2).
+		Warn("calling with " + fakeAnthropicKey)
+
+	logged := out.String()
+	for _, secret := range []string{fakeAnthropicKey, fakeOpenAIKey, fakeBearerToken} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("log line contains %q: %s", secret, logged)
+		}
+	}
+	for _, kept := range []string{`"attempt":2`, `"level":"warning"`, "calling with [REDACTED]", "Bearer [REDACTED]"} {
+		if !strings.Contains(logged, kept) {
+			t.Errorf("log line is missing %s: %s", kept, logged)
+		}
+	}
+}
+
+func TestStatusErrorsRedactResponseBodies(t *testing.T) {
+	body := []byte(`{"error":{"message":"Incorrect API key provided: ` + fakeOpenAIKey + `"}}`)
+	for name, err := range map[string]error{
+		"openai":     openAIAdapter{}.statusError(400, "400 Bad Request", body),
+		"openrouter": openRouterAdapter{}.statusError(402, "402 Payment Required", body),
+		"anthropic":  anthropicAdapter{}.statusError(400, "400 Bad Request", body),
+	} {
+		if strings.Contains(err.Error(), fakeOpenAIKey) {
+			t.Errorf("%s status error leaks the key: %v", name, err)
+		}
+		if !strings.Contains(err.Error(), redactedPlaceholder) {
+			t.Errorf("%s status error = %v, want the key replaced by %s", name, err, redactedPlaceholder)
+		}
+	}
+}
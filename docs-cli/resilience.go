@@ -2,7 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/sony/gobreaker"
@@ -14,52 +19,145 @@ func getResilienceConfig() config.ResilienceConfig {
 	return config.GetConfig().Application.Resilience
 }
 
+// circuitBreakerNames lists the breakers managed by this package
+var circuitBreakerNames = []string{"anthropic", "openai", "default"}
+
 var (
-	// Circuit breakers for different providers
-	anthropicBreaker *gobreaker.CircuitBreaker
-	openaiBreaker    *gobreaker.CircuitBreaker
-	defaultBreaker   *gobreaker.CircuitBreaker
+	// Circuit breakers for different providers, guarded by breakersMutex so
+	// they can be recreated (reset) while calls are in flight
+	breakersMutex sync.RWMutex
+	breakers      = make(map[string]*gobreaker.CircuitBreaker)
 )
 
 func init() {
+	breakersMutex.Lock()
+	defer breakersMutex.Unlock()
+	
+	for _, name := range circuitBreakerNames {
+		breakers[name] = newCircuitBreaker(name)
+	}
+}
+
+// newCircuitBreaker creates a circuit breaker using the enterprise resilience settings
+func newCircuitBreaker(name string) *gobreaker.CircuitBreaker {
 	resilienceConfig := getResilienceConfig()
 	cbConfig := resilienceConfig.CircuitBreaker
 	
+	// gobreaker clears the counts before OnStateChange, so keep the ones that tripped it
+	var tripCounts gobreaker.Counts
 	settings := gobreaker.Settings{
-		Name:        "anthropic",
+		Name:        name,
 		MaxRequests: cbConfig.MaxRequests,
 		Interval:    cbConfig.Interval,
 		Timeout:     cbConfig.Timeout,
 		ReadyToTrip: func(counts gobreaker.Counts) bool {
-			return counts.ConsecutiveFailures >= cbConfig.FailureThreshold
+			if !shouldTripCircuit(counts, cbConfig) {
+				return false
+			}
+			tripCounts = counts
+			return true
 		},
 		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
 			LogWithContext().WithField("circuit_breaker", name).
 				WithField("from_state", from.String()).
 				WithField("to_state", to.String()).
 				Info("Circuit breaker state changed")
+			
+			var counts gobreaker.Counts
+			if to == gobreaker.StateOpen {
+				counts = tripCounts
+			}
+			forgetRestoredBreaker(name)
+			recordBreakerState(name, to, counts)
 		},
 	}
 	
-	anthropicBreaker = gobreaker.NewCircuitBreaker(settings)
+	return gobreaker.NewCircuitBreaker(settings)
+}
+
+// shouldTripCircuit opens the breaker on consecutive failures, or when the failure
+// ratio exceeds the configured limit once enough requests have been observed
+func shouldTripCircuit(counts gobreaker.Counts, cbConfig config.CircuitBreakerConfig) bool {
+	if counts.ConsecutiveFailures >= cbConfig.FailureThreshold {
+		return true
+	}
 	
-	settings.Name = "openai"
-	openaiBreaker = gobreaker.NewCircuitBreaker(settings)
+	if cbConfig.FailureRatio <= 0 || counts.Requests == 0 || counts.Requests < cbConfig.MinRequests {
+		return false
+	}
 	
-	settings.Name = "default"
-	defaultBreaker = gobreaker.NewCircuitBreaker(settings)
+	failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
+	return failureRatio >= cbConfig.FailureRatio
 }
 
 // GetCircuitBreaker returns the appropriate circuit breaker for a provider
 func GetCircuitBreaker(provider string) *gobreaker.CircuitBreaker {
-	switch provider {
-	case "anthropic":
-		return anthropicBreaker
-	case "openai":
-		return openaiBreaker
-	default:
-		return defaultBreaker
+	breakersMutex.RLock()
+	defer breakersMutex.RUnlock()
+	
+	if breaker, exists := breakers[provider]; exists {
+		return breaker
 	}
+	return breakers["default"]
+}
+
+// ResetCircuitBreaker forces a circuit breaker back to the closed state, in this process
+// and in the state persisted for later runs. gobreaker has no reset API, so the breaker
+// instance is recreated.
+func ResetCircuitBreaker(name string) error {
+	breakersMutex.Lock()
+	defer breakersMutex.Unlock()
+	
+	previous, exists := breakers[name]
+	if !exists {
+		return fmt.Errorf("unknown circuit breaker: %s", name)
+	}
+	
+	breakers[name] = newCircuitBreaker(name)
+	if err := clearBreakerState(name); err != nil {
+		return fmt.Errorf("failed to clear persisted state of circuit breaker %s: %w", name, err)
+	}
+	
+	LogWithContext().WithField("circuit_breaker", name).
+		WithField("from_state", previous.State().String()).
+		Warn("Circuit breaker manually reset to closed")
+	
+	return nil
+}
+
+// rebuildCircuitBreakers recreates every breaker from the current resilience settings, e.g.
+// after a config reload. Recreated breakers start closed with fresh counts.
+func rebuildCircuitBreakers() {
+	breakersMutex.Lock()
+	defer breakersMutex.Unlock()
+
+	for _, name := range circuitBreakerNames {
+		breakers[name] = newCircuitBreaker(name)
+	}
+}
+
+// CircuitBreakerStatus holds a point-in-time view of a circuit breaker
+type CircuitBreakerStatus struct {
+	Name   string           `json:"name"`
+	State  string           `json:"state"`
+	Counts gobreaker.Counts `json:"counts"`
+}
+
+// GetCircuitBreakerStatus returns the current state and counts of a circuit breaker
+func GetCircuitBreakerStatus(name string) (CircuitBreakerStatus, error) {
+	breakersMutex.RLock()
+	breaker, exists := breakers[name]
+	breakersMutex.RUnlock()
+	
+	if !exists {
+		return CircuitBreakerStatus{}, fmt.Errorf("unknown circuit breaker: %s", name)
+	}
+	
+	return CircuitBreakerStatus{
+		Name:   name,
+		State:  breaker.State().String(),
+		Counts: breaker.Counts(),
+	}, nil
 }
 
 // RetryableFunc is a function that can be retried
@@ -72,6 +170,11 @@ type RetryConfig struct {
 	MaxDelay         time.Duration
 	BackoffMultiplier float64
 	ShouldRetry      func(error) bool
+	// Idempotent marks operations that are safe to repeat. Non-idempotent
+	// operations (issue creation, webhooks) only retry on failures that
+	// happened before the request reached the server, such as connection
+	// refused; timeouts are not retried since the server may have acted.
+	Idempotent       bool
 }
 
 // DefaultRetryConfig returns the default retry configuration
@@ -85,6 +188,7 @@ func DefaultRetryConfig() RetryConfig {
 		MaxDelay:         retryConfig.MaxDelay,
 		BackoffMultiplier: retryConfig.BackoffMultiplier,
 		ShouldRetry:      DefaultShouldRetry,
+		Idempotent:       true,
 	}
 }
 
@@ -94,6 +198,17 @@ func DefaultShouldRetry(err error) bool {
 		return false
 	}
 	
+	// An open or saturated breaker rejects calls until its timeout, far longer than a backoff
+	if breakerRejected(err) {
+		return false
+	}
+	
+	// Providers report the HTTP status directly; trust it over the message text
+	var statusErr *APIStatusError
+	if errors.As(err, &statusErr) {
+		return retryableStatus(statusErr.StatusCode)
+	}
+	
 	errStr := err.Error()
 	
 	// Retry on temporary network errors
@@ -121,6 +236,7 @@ func DefaultShouldRetry(err error) bool {
 		"invalid_request_error",
 		"permission_error",
 		"not_found_error",
+		"max_response_bytes",
 	}
 	
 	for _, nonRetryable := range nonRetryableErrors {
@@ -133,6 +249,65 @@ func DefaultShouldRetry(err error) bool {
 	return true
 }
 
+// retryableStatus reports whether a provider HTTP status is worth retrying: timeouts,
+// rate limits, and server errors (including Anthropic's 529 overloaded) are; other
+// client errors such as 400/401/403/404/422 are not
+func retryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	default:
+		return statusCode >= 500 && statusCode != http.StatusNotImplemented
+	}
+}
+
+// breakerRejected reports whether a call was refused by an open or half-open circuit breaker
+func breakerRejected(err error) bool {
+	return errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests)
+}
+
+// IsPreExecutionError reports whether an error happened before the request
+// could reach the server, making it safe to retry non-idempotent operations
+func IsPreExecutionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	
+	// Rejected by the circuit breaker: the call never ran, but retrying within the
+	// backoff window would only be rejected again
+	if breakerRejected(err) {
+		return false
+	}
+	
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && !dnsErr.IsTimeout {
+		return true
+	}
+	
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" && !opErr.Timeout() {
+		return true
+	}
+	
+	errStr := err.Error()
+	preExecutionErrors := []string{
+		"connection refused",
+		"no such host",
+	}
+	
+	for _, preExecution := range preExecutionErrors {
+		if contains(errStr, preExecution) {
+			return true
+		}
+	}
+	
+	return false
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && 
 		   (s == substr || len(s) > len(substr) && 
@@ -156,7 +331,7 @@ func RetryWithBackoff(ctx context.Context, fn RetryableFunc, config RetryConfig)
 	
 	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
 		if attempt > 0 {
-			LogWithContext().WithField("attempt", attempt).
+			LogFrom(ctx).WithField("attempt", attempt).
 				WithField("delay_ms", delay.Milliseconds()).
 				Info("Retrying operation after delay")
 			
@@ -174,7 +349,7 @@ func RetryWithBackoff(ctx context.Context, fn RetryableFunc, config RetryConfig)
 		
 		if err == nil {
 			if attempt > 0 {
-				LogWithContext().WithField("attempt", attempt).
+				LogFrom(ctx).WithField("attempt", attempt).
 					WithField("duration_ms", duration.Milliseconds()).
 					Info("Operation succeeded after retry")
 			}
@@ -183,14 +358,18 @@ func RetryWithBackoff(ctx context.Context, fn RetryableFunc, config RetryConfig)
 		
 		lastErr = err
 		
-		LogWithContext().WithError(err).
+		LogFrom(ctx).WithError(err).
 			WithField("attempt", attempt).
 			WithField("duration_ms", duration.Milliseconds()).
 			Info("Operation failed")
 		
 		// Check if we should retry this error
-		if !config.ShouldRetry(err) {
-			LogWithContext().WithError(err).
+		shouldRetry := config.ShouldRetry(err)
+		if !config.Idempotent {
+			shouldRetry = IsPreExecutionError(err)
+		}
+		if !shouldRetry {
+			LogFrom(ctx).WithError(err).
 				Info("Error is not retryable, stopping")
 			break
 		}
@@ -212,18 +391,61 @@ func RetryWithBackoff(ctx context.Context, fn RetryableFunc, config RetryConfig)
 
 // CallWithCircuitBreaker executes a function with circuit breaker protection
 func CallWithCircuitBreaker(breaker *gobreaker.CircuitBreaker, fn RetryableFunc) (interface{}, error) {
-	return breaker.Execute(func() (interface{}, error) {
+	result, err := breaker.Execute(func() (interface{}, error) {
 		return fn()
 	})
+	if err == nil {
+		closeOnHalfOpenSuccesses(breaker, getResilienceConfig().CircuitBreaker)
+	}
+	return result, err
 }
 
-// ResilientAPICall combines retry logic with circuit breaker for API calls
+// closeOnHalfOpenSuccesses closes a half-open breaker once success_threshold probes in a
+// row have succeeded. gobreaker itself only closes after max_requests successes, so the
+// breaker is replaced with a closed one, as a reset would.
+func closeOnHalfOpenSuccesses(breaker *gobreaker.CircuitBreaker, cbConfig config.CircuitBreakerConfig) {
+	if cbConfig.SuccessThreshold == 0 || breaker.State() != gobreaker.StateHalfOpen {
+		return
+	}
+	counts := breaker.Counts()
+	if counts.ConsecutiveSuccesses < cbConfig.SuccessThreshold {
+		return
+	}
+	
+	breakersMutex.Lock()
+	name := breaker.Name()
+	if breakers[name] != breaker {
+		// Already replaced by another success or a reset
+		breakersMutex.Unlock()
+		return
+	}
+	breakers[name] = newCircuitBreaker(name)
+	breakersMutex.Unlock()
+	
+	LogWithContext().WithField("circuit_breaker", name).
+		WithField("from_state", gobreaker.StateHalfOpen.String()).
+		WithField("to_state", gobreaker.StateClosed.String()).
+		WithField("consecutive_successes", counts.ConsecutiveSuccesses).
+		Info("Circuit breaker state changed")
+	recordBreakerState(name, gobreaker.StateClosed, counts)
+}
+
+// ResilientAPICall combines retry logic with circuit breaker for idempotent API calls
 func ResilientAPICall(ctx context.Context, provider string, fn RetryableFunc) (interface{}, error) {
+	return ResilientAPICallWithConfig(ctx, provider, fn, DefaultRetryConfig())
+}
+
+// ResilientAPICallWithConfig is ResilientAPICall with an explicit retry configuration.
+// Set config.Idempotent to false for side-effecting calls.
+func ResilientAPICallWithConfig(ctx context.Context, provider string, fn RetryableFunc, config RetryConfig) (interface{}, error) {
 	breaker := GetCircuitBreaker(provider)
-	config := DefaultRetryConfig()
 	
-	// Wrap the function with circuit breaker
+	// Wrap the function with circuit breaker. A breaker an earlier run left open stays
+	// open until its timeout, as it would have in that run.
 	wrappedFn := func() (interface{}, error) {
+		if _, open := persistedOpenUntil(breaker.Name()); open {
+			return nil, gobreaker.ErrOpenState
+		}
 		return CallWithCircuitBreaker(breaker, fn)
 	}
 	
@@ -238,23 +460,26 @@ func MonitorCircuitBreakers() {
 	for {
 		select {
 		case <-ticker.C:
-			logCircuitBreakerStatus("anthropic", anthropicBreaker)
-			logCircuitBreakerStatus("openai", openaiBreaker)
-			logCircuitBreakerStatus("default", defaultBreaker)
+			for _, name := range circuitBreakerNames {
+				logCircuitBreakerStatus(name)
+			}
 		}
 	}
 }
 
-func logCircuitBreakerStatus(name string, breaker *gobreaker.CircuitBreaker) {
-	state := breaker.State()
-	counts := breaker.Counts()
+func logCircuitBreakerStatus(name string) {
+	status, err := GetCircuitBreakerStatus(name)
+	if err != nil {
+		LogWithContext().WithError(err).Warn("Failed to read circuit breaker status")
+		return
+	}
 	
-	LogWithContext().WithField("circuit_breaker", name).
-		WithField("state", state.String()).
-		WithField("requests", counts.Requests).
-		WithField("total_successes", counts.TotalSuccesses).
-		WithField("total_failures", counts.TotalFailures).
-		WithField("consecutive_successes", counts.ConsecutiveSuccesses).
-		WithField("consecutive_failures", counts.ConsecutiveFailures).
+	LogWithContext().WithField("circuit_breaker", status.Name).
+		WithField("state", status.State).
+		WithField("requests", status.Counts.Requests).
+		WithField("total_successes", status.Counts.TotalSuccesses).
+		WithField("total_failures", status.Counts.TotalFailures).
+		WithField("consecutive_successes", status.Counts.ConsecutiveSuccesses).
+		WithField("consecutive_failures", status.Counts.ConsecutiveFailures).
 		Info("Circuit breaker status")
-}
\ No newline at end of file
+}
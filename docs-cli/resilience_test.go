@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+
+	"docs-cli/pkg/config"
+)
+
+func TestShouldTripCircuit(t *testing.T) {
+	cbConfig := config.CircuitBreakerConfig{FailureThreshold: 5, FailureRatio: 0.6, MinRequests: 10}
+	tests := []struct {
+		name   string
+		counts gobreaker.Counts
+		want   bool
+	}{
+		{"no requests", gobreaker.Counts{}, false},
+		{"consecutive failures below threshold", gobreaker.Counts{Requests: 4, TotalFailures: 4, ConsecutiveFailures: 4}, false},
+		{"consecutive failures at threshold", gobreaker.Counts{Requests: 5, TotalFailures: 5, ConsecutiveFailures: 5}, true},
+		{"high ratio below min requests", gobreaker.Counts{Requests: 9, TotalFailures: 8, ConsecutiveFailures: 1}, false},
+		{"ratio at limit", gobreaker.Counts{Requests: 10, TotalFailures: 6, ConsecutiveFailures: 1}, true},
+		{"ratio below limit", gobreaker.Counts{Requests: 10, TotalFailures: 5, ConsecutiveFailures: 2}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldTripCircuit(tt.counts, cbConfig); got != tt.want {
+				t.Errorf("shouldTripCircuit(%+v) = %v, want %v", tt.counts, got, tt.want)
+			}
+		})
+	}
+
+	cbConfig.FailureRatio = 0
+	if shouldTripCircuit(gobreaker.Counts{Requests: 100, TotalFailures: 99, ConsecutiveFailures: 1}, cbConfig) {
+		t.Error("failure_ratio 0 should disable ratio tripping")
+	}
+}
+
+// tripBreaker opens the named breaker and waits until it is half-open
+func tripBreaker(t *testing.T, name string, cbConfig config.CircuitBreakerConfig) {
+	t.Helper()
+	for i := uint32(0); i < cbConfig.FailureThreshold; i++ {
+		CallWithCircuitBreaker(GetCircuitBreaker(name), func() (interface{}, error) { return nil, errors.New("boom") })
+	}
+	if state := GetCircuitBreaker(name).State(); state != gobreaker.StateOpen {
+		t.Fatalf("breaker state = %s after failures, want open", state)
+	}
+	time.Sleep(cbConfig.Timeout + 10*time.Millisecond)
+	if state := GetCircuitBreaker(name).State(); state != gobreaker.StateHalfOpen {
+		t.Fatalf("breaker state = %s after timeout, want half-open", state)
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	succeed := func() (interface{}, error) { return "ok", nil }
+	tests := []struct {
+		name             string
+		successThreshold uint32
+		successesToClose int
+	}{
+		{"all probes must succeed", 0, 3},
+		{"success threshold closes early", 1, 1},
+		{"success threshold of two", 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempProject(t)
+			cfg := useEnterpriseConfig(t, func(c *config.EnterpriseConfig) {
+				c.Application.Resilience.CircuitBreaker = config.CircuitBreakerConfig{
+					MaxRequests:      3,
+					Interval:         time.Minute,
+					Timeout:          20 * time.Millisecond,
+					FailureThreshold: 2,
+					SuccessThreshold: tt.successThreshold,
+				}
+			})
+			resetBreakersForTest(t)
+			tripBreaker(t, "openai", cfg.Application.Resilience.CircuitBreaker)
+
+			for i := 1; i <= tt.successesToClose; i++ {
+				if _, err := CallWithCircuitBreaker(GetCircuitBreaker("openai"), succeed); err != nil {
+					t.Fatalf("probe %d: %v", i, err)
+				}
+				want := gobreaker.StateHalfOpen
+				if i == tt.successesToClose {
+					want = gobreaker.StateClosed
+				}
+				if state := GetCircuitBreaker("openai").State(); state != want {
+					t.Fatalf("state after %d successes = %s, want %s", i, state, want)
+				}
+			}
+			status, _, ok := PersistedBreakerStatus("openai")
+			if !ok || status.State != "closed" {
+				t.Errorf("persisted status = %+v, %v; want closed", status, ok)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpenFailureReopens(t *testing.T) {
+	useTempProject(t)
+	cfg := useEnterpriseConfig(t, func(c *config.EnterpriseConfig) {
+		c.Application.Resilience.CircuitBreaker = config.CircuitBreakerConfig{
+			MaxRequests:      3,
+			Interval:         time.Minute,
+			Timeout:          20 * time.Millisecond,
+			FailureThreshold: 2,
+			SuccessThreshold: 2,
+		}
+	})
+	resetBreakersForTest(t)
+	tripBreaker(t, "anthropic", cfg.Application.Resilience.CircuitBreaker)
+
+	breaker := GetCircuitBreaker("anthropic")
+	CallWithCircuitBreaker(breaker, func() (interface{}, error) { return "ok", nil })
+	CallWithCircuitBreaker(breaker, func() (interface{}, error) { return nil, errors.New("boom") })
+	if state := GetCircuitBreaker("anthropic").State(); state != gobreaker.StateOpen {
+		t.Fatalf("state after half-open failure = %s, want open", state)
+	}
+}
+
+func TestSuccessThresholdValidation(t *testing.T) {
+	edited := *config.GetConfig()
+	edited.Application.Resilience.CircuitBreaker.MaxRequests = 2
+	edited.Application.Resilience.CircuitBreaker.SuccessThreshold = 3
+	if err := edited.Validate(); err == nil {
+		t.Error("success_threshold above max_requests should fail validation")
+	}
+}
+
+func TestBreakerRejectionsAreNotRetried(t *testing.T) {
+	for _, rejection := range []error{gobreaker.ErrOpenState, gobreaker.ErrTooManyRequests} {
+		t.Run(rejection.Error(), func(t *testing.T) {
+			wrapped := fmt.Errorf("anthropic call: %w", rejection)
+			if DefaultShouldRetry(wrapped) {
+				t.Error("DefaultShouldRetry = true, want false")
+			}
+			if IsPreExecutionError(wrapped) {
+				t.Error("IsPreExecutionError = true, want false")
+			}
+
+			for _, idempotent := range []bool{true, false} {
+				calls := 0
+				_, err := RetryWithBackoff(context.Background(), func() (interface{}, error) {
+					calls++
+					return nil, wrapped
+				}, RetryConfig{MaxRetries: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond,
+					BackoffMultiplier: 1, ShouldRetry: DefaultShouldRetry, Idempotent: idempotent})
+				if !errors.Is(err, rejection) {
+					t.Errorf("idempotent=%v: err = %v, want %v", idempotent, err, rejection)
+				}
+				if calls != 1 {
+					t.Errorf("idempotent=%v: called %d times, want 1", idempotent, calls)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPStatusRetryDecision(t *testing.T) {
+	tests := []struct {
+		status int
+		retry  bool
+	}{
+		{http.StatusBadRequest, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusPaymentRequired, false},
+		{http.StatusForbidden, false},
+		{http.StatusNotFound, false},
+		{http.StatusRequestTimeout, true},
+		{http.StatusConflict, false},
+		{http.StatusRequestEntityTooLarge, false},
+		{http.StatusUnprocessableEntity, false},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusNotImplemented, false},
+		{http.StatusBadGateway, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusGatewayTimeout, true},
+		{529, true}, // Anthropic overloaded
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.status), func(t *testing.T) {
+			if got := retryableStatus(tt.status); got != tt.retry {
+				t.Errorf("retryableStatus(%d) = %v, want %v", tt.status, got, tt.retry)
+			}
+			// The status decides even when the message reads the other way
+			message := "timeout talking to upstream"
+			if tt.retry {
+				message = "authentication_error: invalid x-api-key"
+			}
+			err := fmt.Errorf("calling provider: %w", &APIStatusError{StatusCode: tt.status, Err: errors.New(message)})
+			if got := DefaultShouldRetry(err); got != tt.retry {
+				t.Errorf("DefaultShouldRetry(%d: %q) = %v, want %v", tt.status, message, got, tt.retry)
+			}
+		})
+	}
+}
+
+func TestHTTPStatusRetryAttempts(t *testing.T) {
+	for _, tt := range []struct {
+		status       int
+		wantRequests int
+	}{
+		// The first attempt plus two retries
+		{http.StatusServiceUnavailable, 3},
+		{http.StatusTooManyRequests, 3},
+		{http.StatusUnauthorized, 1},
+		{http.StatusUnprocessableEntity, 1},
+	} {
+		t.Run(strconv.Itoa(tt.status), func(t *testing.T) {
+			newTestProject(t, "components: []\n")
+			useFastRetries(t, 2)
+			server := newProviderServer(t, tt.status, `{"error":{"message":"upstream error"}}`)
+			provider := newHTTPProviders(server.URL)["openai"]
+
+			_, err := ResilientAPICall(context.Background(), "openai", func() (interface{}, error) {
+				return provider.CallModel(context.Background(), "prompt", "test-model", 100, 0)
+			})
+			var statusErr *APIStatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.status {
+				t.Fatalf("err = %v, want an APIStatusError with status %d", err, tt.status)
+			}
+			if requests := len(server.Requests()); requests != tt.wantRequests {
+				t.Errorf("server received %d requests, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// shutdownGrace is how long a cancelled run may take to unwind before cleanup runs anyway
+const shutdownGrace = 10 * time.Second
+
+var (
+	// errRunTimeout and errRunInterrupted are the causes a run is cancelled with
+	errRunTimeout     = errors.New("run exceeded --max-runtime")
+	errRunInterrupted = errors.New("run interrupted by signal")
+
+	runCtx    context.Context         = context.Background()
+	cancelRun context.CancelCauseFunc = func(error) {}
+
+	shutdownMutex sync.Mutex
+	shutdownHooks []func()
+	shutdownOnce  sync.Once
+)
+
+// runContext returns the context for the current run, cancelled on signal or --max-runtime
+func runContext() context.Context {
+	return runCtx
+}
+
+// newRunContext creates the run context and cancels it on SIGINT or SIGTERM
+func newRunContext() {
+	runCtx, cancelRun = context.WithCancelCause(context.Background())
+	runCtx = WithLogFields(runCtx, logrus.Fields{"run_id": newRunID()})
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		LogFrom(runCtx).WithField("signal", sig.String()).Warn("Received signal, cancelling run")
+		cancelRun(errRunInterrupted)
+	}()
+}
+
+// startRunDeadline cancels the run once --max-runtime elapses
+func startRunDeadline() {
+	if maxRuntime <= 0 {
+		return
+	}
+	time.AfterFunc(maxRuntime, func() {
+		LogFrom(runCtx).WithField("max_runtime", maxRuntime.String()).Error("Run timed out, cancelling in-flight work")
+		cancelRun(errRunTimeout)
+	})
+}
+
+// onShutdown registers cleanup that runs once when the run ends, however it ends
+func onShutdown(fn func()) {
+	shutdownMutex.Lock()
+	defer shutdownMutex.Unlock()
+	shutdownHooks = append(shutdownHooks, fn)
+}
+
+// runShutdown runs registered cleanup in reverse order, then the process-wide cleanup
+func runShutdown() {
+	shutdownOnce.Do(func() {
+		shutdownMutex.Lock()
+		hooks := shutdownHooks
+		shutdownMutex.Unlock()
+
+		for i := len(hooks) - 1; i >= 0; i-- {
+			hooks[i]()
+		}
+		stopProfiling()
+		closeSourceArchive()
+	})
+}
+
+// executeWithRunContext runs the root command under the run context. Normal completion,
+// a signal, and --max-runtime all converge on runShutdown; a cancelled run exits non-zero
+// even if the command does not unwind within shutdownGrace.
+func executeWithRunContext() error {
+	newRunContext()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- rootCmd.ExecuteContext(runCtx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-runCtx.Done():
+		select {
+		case err = <-done:
+		case <-time.After(shutdownGrace):
+			LogWithContext().Error("Command did not stop within shutdown grace period")
+		}
+	}
+
+	runShutdown()
+
+	if cause := context.Cause(runCtx); cause != nil {
+		return cause
+	}
+	return err
+}
+
+// runSeed returns the --seed for deterministic runs, or nil when it was not given
+func runSeed() *int {
+	if !seedSet {
+		return nil
+	}
+	return &seed
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RunJournal records which (component, docType) pairs a run has completed so an
+// interrupted run can be resumed with --resume. It is removed when a run finishes cleanly.
+type RunJournal struct {
+	mutex     sync.Mutex
+	path      string
+	StartedAt time.Time       `json:"started_at"`
+	Completed map[string]bool `json:"completed"`
+}
+
+// runJournalPath is the journal location for the current project
+func runJournalPath() string {
+	return filepath.Join(projectRoot, ".docs-cli-run.json")
+}
+
+// OpenRunJournal starts a journal at path. With resume, completed pairs from an existing
+// journal are kept; otherwise any previous journal is discarded.
+func OpenRunJournal(path string, resume bool) (*RunJournal, error) {
+	journal := &RunJournal{
+		path:      path,
+		StartedAt: time.Now(),
+		Completed: make(map[string]bool),
+	}
+
+	if resume {
+		data, err := os.ReadFile(path)
+		switch {
+		case os.IsNotExist(err):
+			LogWithContext().Info("No run journal to resume; starting a new run")
+		case err != nil:
+			return nil, fmt.Errorf("failed to read run journal: %w", err)
+		default:
+			if err := json.Unmarshal(data, journal); err != nil {
+				return nil, fmt.Errorf("failed to parse run journal %s: %w", path, err)
+			}
+			if journal.Completed == nil {
+				journal.Completed = make(map[string]bool)
+			}
+			return journal, nil
+		}
+	}
+
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+	if err := journal.save(); err != nil {
+		return nil, err
+	}
+	return journal, nil
+}
+
+// journalKey identifies a (component, docType) pair in the journal
+func journalKey(componentKey, docType string) string {
+	return componentKey + "/" + docType
+}
+
+// IsCompleted reports whether the pair was completed earlier in this logical run
+func (j *RunJournal) IsCompleted(componentKey, docType string) bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.Completed[journalKey(componentKey, docType)]
+}
+
+// MarkCompleted records the pair and persists the journal immediately, so a crash
+// right after still counts it as done
+func (j *RunJournal) MarkCompleted(componentKey, docType string) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.Completed[journalKey(componentKey, docType)] = true
+	if err := j.save(); err != nil {
+		LogWithContext().WithError(err).Warn("Failed to save run journal")
+	}
+}
+
+// CompletedPairs returns the completed pairs in sorted order
+func (j *RunJournal) CompletedPairs() []string {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	pairs := make([]string, 0, len(j.Completed))
+	for pair := range j.Completed {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// Clear removes the journal after a clean run
+func (j *RunJournal) Clear() error {
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove run journal: %w", err)
+	}
+	return nil
+}
+
+// save writes the journal through a temporary file so a crash mid-write cannot
+// corrupt it; callers must hold the lock
+func (j *RunJournal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run journal: %w", err)
+	}
+	tmpPath := j.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write run journal: %w", err)
+	}
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return fmt.Errorf("failed to write run journal: %w", err)
+	}
+	return nil
+}
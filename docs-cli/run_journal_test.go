@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// interruptingProvider answers through a scriptedProvider until its limit of calls, then
+// cancels the run and fails, the way a run dies to a signal partway through
+type interruptingProvider struct {
+	*scriptedProvider
+	limit  int32
+	cancel context.CancelFunc
+	calls  atomic.Int32
+}
+
+func (p *interruptingProvider) CallChat(ctx context.Context, request ChatRequest) (string, error) {
+	if p.calls.Add(1) > p.limit {
+		p.cancel()
+		return "", context.Canceled
+	}
+	return p.scriptedProvider.CallChat(ctx, request)
+}
+
+// runUpdate runs the update command under ctx, then runs the shutdown hooks it
+// registered so the run lock and snapshots are released as on process exit
+func runUpdate(t *testing.T, ctx context.Context) {
+	t.Helper()
+	runCommand(t, ctx, updateAllDocumentation)
+}
+
+// runCommand runs a command's Run function under ctx with args, then the shutdown hooks
+// it registered
+func runCommand(t *testing.T, ctx context.Context, run func(*cobra.Command, []string), args ...string) {
+	t.Helper()
+	shutdownMutex.Lock()
+	registered := len(shutdownHooks)
+	shutdownMutex.Unlock()
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(ctx)
+	run(cmd, args)
+
+	shutdownMutex.Lock()
+	hooks := shutdownHooks[registered:]
+	shutdownHooks = shutdownHooks[:registered]
+	shutdownMutex.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+}
+
+func TestResumeSkipsPairsCompletedBeforeACrash(t *testing.T) {
+	project := newTestProject(t, `components:
+  - name: "api"
+    path: "api"
+    type: "service"
+  - name: "web"
+    path: "web"
+    type: "service"
+`)
+	project.WriteFile("api/main.go", "package main\n\nfunc main() {}\n")
+	project.WriteFile("web/main.go", "package main\n\nfunc main() {}\n")
+	// --force regenerates every pair, so only the journal can make a run skip one
+	force = true
+	t.Cleanup(func() { force, resumeRun = false, false })
+	useCacheFlags(t, true, true)
+	scripted, _ := useScriptedProvider(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	const completedBeforeCrash = 2
+	interrupting := &interruptingProvider{scriptedProvider: scripted, limit: completedBeforeCrash, cancel: cancel}
+	newModelProvider = func(providerName, apiKey string) ModelProvider { return interrupting }
+	runUpdate(t, ctx)
+
+	journal, err := OpenRunJournal(runJournalPath(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	completed := journal.CompletedPairs()
+	if len(completed) != completedBeforeCrash {
+		t.Fatalf("journal after the crash = %v, want %d completed pairs", completed, completedBeforeCrash)
+	}
+	before := make(map[string]os.FileInfo)
+	for _, component := range project.Components() {
+		for _, docType := range orderedUpdateDocTypes() {
+			if journal.IsCompleted(component.Key(), docType) {
+				info, err := os.Stat(docOutputPath(component, docType))
+				if err != nil {
+					t.Fatalf("%s/%s is journaled as completed but was not written: %v", component.Key(), docType, err)
+				}
+				before[component.Key()+"/"+docType] = info
+			}
+		}
+	}
+
+	scripted.calls.Store(0)
+	newModelProvider = func(providerName, apiKey string) ModelProvider { return scripted }
+	resumeRun = true
+	runUpdate(t, context.Background())
+
+	total := len(project.Components()) * len(orderedUpdateDocTypes())
+	if calls := int(scripted.calls.Load()); calls != total-completedBeforeCrash {
+		t.Errorf("resumed run made %d provider calls, want %d for the pairs the crash left", calls, total-completedBeforeCrash)
+	}
+	for _, component := range project.Components() {
+		for _, docType := range orderedUpdateDocTypes() {
+			info, err := os.Stat(docOutputPath(component, docType))
+			if err != nil {
+				t.Errorf("%s/%s missing after the resumed run: %v", component.Key(), docType, err)
+				continue
+			}
+			if earlier, ok := before[component.Key()+"/"+docType]; ok && !info.ModTime().Equal(earlier.ModTime()) {
+				t.Errorf("%s/%s was completed before the crash but rewritten on resume", component.Key(), docType)
+			}
+		}
+	}
+	if _, err := os.Stat(runJournalPath()); !os.IsNotExist(err) {
+		t.Errorf("journal left behind after the resumed run finished cleanly: %v", err)
+	}
+}
+
+func TestOpenRunJournalWithoutResumeDiscardsCompletedPairs(t *testing.T) {
+	path := t.TempDir() + "/run.json"
+	journal, err := OpenRunJournal(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal.MarkCompleted("api", "README")
+
+	resumed, err := OpenRunJournal(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resumed.IsCompleted("api", "README") || !resumed.StartedAt.Equal(journal.StartedAt) {
+		t.Errorf("resumed journal = %v started %s, want api/README from the run started %s", resumed.CompletedPairs(), resumed.StartedAt, journal.StartedAt)
+	}
+
+	fresh, err := OpenRunJournal(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pairs := fresh.CompletedPairs(); len(pairs) != 0 {
+		t.Errorf("journal opened without --resume kept %v", pairs)
+	}
+}
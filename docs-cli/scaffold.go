@@ -0,0 +1,86 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"docs-cli/pkg/config"
+	"docs-cli/pkg/scanner"
+)
+
+// defaultModelConfig is written as model-config.yaml by init, with placeholder API keys
+//
+//go:embed model-config.yaml.example
+var defaultModelConfig []byte
+
+// scaffoldFile is a config file written by init
+type scaffoldFile struct {
+	path    string
+	content func() ([]byte, error)
+}
+
+func scaffoldConfigFiles(cmd *cobra.Command, args []string) {
+	files := []scaffoldFile{
+		{path: "components.yaml", content: discoveredComponentsYAML},
+		{path: "enterprise-config.yaml", content: defaultEnterpriseConfigYAML},
+		{path: "model-config.yaml", content: func() ([]byte, error) { return defaultModelConfig, nil }},
+	}
+
+	var failed bool
+	for _, file := range files {
+		if _, err := os.Stat(file.path); err == nil && !force {
+			fmt.Printf("⚠️  %s already exists, skipping (use --force to overwrite)\n", file.path)
+			continue
+		}
+
+		content, err := file.content()
+		if err == nil {
+			err = os.WriteFile(file.path, content, 0644)
+		}
+		LogFileOperation("write", file.path, int64(len(content)), err)
+		if err != nil {
+			fmt.Printf("❌ Failed to write %s: %v\n", file.path, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("✅ Wrote %s\n", file.path)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+	fmt.Println("📝 Next: add API keys to model-config.yaml and review the discovered components")
+}
+
+// discoveredComponentsYAML renders components.yaml from the components auto-discovered under projectRoot
+func discoveredComponentsYAML() ([]byte, error) {
+	components, err := scanner.DiscoverComponents(projectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover components: %w", err)
+	}
+	for i := range components {
+		components[i].Description = fmt.Sprintf("TODO: describe %s", components[i].Name)
+	}
+	fmt.Printf("🔍 Discovered %d components in %s\n", len(components), projectRoot)
+
+	data, err := yaml.Marshal(scanner.ComponentConfig{Components: components})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal components: %w", err)
+	}
+	header := "# Generated by docs-cli init; paths are relative to the project root\n"
+	return append([]byte(header), data...), nil
+}
+
+// defaultEnterpriseConfigYAML renders the built-in enterprise configuration
+func defaultEnterpriseConfigYAML() ([]byte, error) {
+	data, err := yaml.Marshal(config.DefaultConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal enterprise config: %w", err)
+	}
+	header := "# Generated by docs-cli init from the built-in defaults; see enterprise-config.yaml.example for documentation\n"
+	return append([]byte(header), data...), nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"docs-cli/pkg/config"
+	"docs-cli/pkg/scanner"
+)
+
+// decodeStrict decodes the YAML file at path into out, rejecting fields out does not declare
+func decodeStrict(t *testing.T, path string, out interface{}) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(out); err != nil {
+		t.Errorf("%s does not parse back cleanly: %v", filepath.Base(path), err)
+	}
+}
+
+func TestScaffoldedConfigFilesParseBack(t *testing.T) {
+	project := newTestProject(t, "components: []\n")
+	project.WriteFile("services/api/go.mod", "module api\n")
+	project.WriteFile("services/web/package.json", `{"dependencies":{"react":"^18.0.0"}}`)
+	project.WriteFile("worker/jobs/run.py", "print('run')\n")
+	project.WriteFile("docs/index.md", "# Docs\n")
+	force = true
+	t.Cleanup(func() { force = false })
+
+	scaffoldConfigFiles(nil, nil)
+
+	var components scanner.ComponentConfig
+	decodeStrict(t, filepath.Join(project.CLI, "components.yaml"), &components)
+	want := []scanner.ComponentDef{
+		{Name: "api", Path: "services/api", Type: "service", Description: "TODO: describe api"},
+		{Name: "web", Path: "services/web", Type: "frontend", Description: "TODO: describe web"},
+		{Name: "worker", Path: "worker", Type: "service", Description: "TODO: describe worker"},
+	}
+	if !reflect.DeepEqual(components.Components, want) {
+		t.Errorf("components.yaml = %+v, want %+v", components.Components, want)
+	}
+	var keys []string
+	for _, component := range project.Components() {
+		keys = append(keys, component.Key())
+	}
+	if !reflect.DeepEqual(keys, []string{"api", "web", "worker"}) {
+		t.Errorf("scanned components = %v, want api, web, worker", keys)
+	}
+
+	var enterprise config.EnterpriseConfig
+	decodeStrict(t, filepath.Join(project.CLI, "enterprise-config.yaml"), &enterprise)
+	loaded, err := reloadConfigFrom(project.CLI)
+	if err != nil {
+		t.Fatalf("scaffolded enterprise-config.yaml does not load: %v", err)
+	}
+	// Compared as YAML, where an empty map and a nil one are the same
+	loadedYAML, _ := yaml.Marshal(loaded)
+	defaultYAML, _ := yaml.Marshal(config.DefaultConfig())
+	if !bytes.Equal(loadedYAML, defaultYAML) {
+		t.Errorf("scaffolded enterprise-config.yaml does not load back as the built-in defaults:\n%s", loadedYAML)
+	}
+	if rules := loaded.CostOpt.Compression.Rules; !reflect.DeepEqual(rules, config.DefaultConfig().CostOpt.Compression.Rules) {
+		t.Errorf("compression rules loaded back as %+v", rules)
+	}
+
+	var models ModelConfig
+	decodeStrict(t, filepath.Join(project.CLI, "model-config.yaml"), &models)
+	modelConfig.Store(nil)
+	loadedModels, err := readModelConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loadedModels.validate(); err != nil {
+		t.Errorf("scaffolded model-config.yaml does not validate: %v", err)
+	}
+}
+
+func TestScaffoldKeepsExistingFilesWithoutForce(t *testing.T) {
+	project := newTestProject(t, "components: []\n")
+	before := project.ReadFile("cli/components.yaml")
+
+	scaffoldConfigFiles(nil, nil)
+
+	if after := project.ReadFile("cli/components.yaml"); after != before {
+		t.Errorf("components.yaml overwritten without --force: %q", after)
+	}
+	if after := project.ReadFile("cli/model-config.yaml"); after != testMockModelConfig {
+		t.Errorf("model-config.yaml overwritten without --force: %q", after)
+	}
+}
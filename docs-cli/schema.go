@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"docs-cli/pkg/config"
+	"docs-cli/pkg/scanner"
+)
+
+// schemaDir is where config schema writes the JSON Schema files
+var schemaDir string
+
+// configSchemas maps each config file to the struct it is parsed into
+var configSchemas = []struct {
+	file   string
+	title  string
+	target interface{}
+}{
+	{file: "enterprise-config.schema.json", title: "docs-cli enterprise-config.yaml", target: config.EnterpriseConfig{}},
+	{file: "model-config.schema.json", title: "docs-cli model-config.yaml", target: ModelConfig{}},
+	{file: "components.schema.json", title: "docs-cli components.yaml", target: scanner.ComponentConfig{}},
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func writeConfigSchemas(cmd *cobra.Command, args []string) {
+	if err := os.MkdirAll(schemaDir, 0755); err != nil {
+		fmt.Printf("❌ Failed to create %s: %v\n", schemaDir, err)
+		os.Exit(1)
+	}
+
+	for _, configSchema := range configSchemas {
+		schema := schemaForType(reflect.TypeOf(configSchema.target))
+		schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+		schema["title"] = configSchema.title
+
+		data, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			fmt.Printf("❌ Failed to encode %s: %v\n", configSchema.file, err)
+			os.Exit(1)
+		}
+
+		path := filepath.Join(schemaDir, configSchema.file)
+		err = os.WriteFile(path, append(data, '\n'), 0644)
+		LogFileOperation("write", path, int64(len(data)), err)
+		if err != nil {
+			fmt.Printf("❌ Failed to write %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Wrote %s\n", path)
+	}
+}
+
+// schemaForType derives a JSON Schema for a config type from its yaml tags
+func schemaForType(t reflect.Type) map[string]interface{} {
+	if t == durationType {
+		// yaml.v3 accepts Go duration strings ("30s", "1h30m") or integer nanoseconds
+		return map[string]interface{}{
+			"type":        []string{"string", "integer"},
+			"description": "Duration such as 30s, 5m, or 1h30m",
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaForType(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaForType(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				// yaml.v3 defaults to the lowercased field name
+				name = strings.ToLower(field.Name)
+			}
+			properties[name] = schemaForType(field.Type)
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	default:
+		return map[string]interface{}{}
+	}
+}
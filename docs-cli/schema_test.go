@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"docs-cli/pkg/config"
+	"docs-cli/pkg/scanner"
+)
+
+// validateSchema checks a decoded YAML value against the subset of JSON Schema that
+// schemaForType generates, returning one message per violation
+func validateSchema(schema map[string]interface{}, value interface{}, path string) []string {
+	if types, ok := schema["type"]; ok && !matchesSchemaType(types, value) {
+		return []string{fmt.Sprintf("%s: %T does not match type %v", path, value, types)}
+	}
+
+	var problems []string
+	switch value := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if property, ok := properties[key].(map[string]interface{}); ok {
+				problems = append(problems, validateSchema(property, value[key], path+"."+key)...)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case map[string]interface{}:
+				problems = append(problems, validateSchema(additional, value[key], path+"."+key)...)
+			case bool:
+				if !additional {
+					problems = append(problems, fmt.Sprintf("%s: unknown property %q", path, key))
+				}
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range value {
+				problems = append(problems, validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return problems
+}
+
+// matchesSchemaType reports whether value has the JSON Schema type, or one of the types
+func matchesSchemaType(types interface{}, value interface{}) bool {
+	if list, ok := types.([]string); ok {
+		for _, t := range list {
+			if matchesSchemaType(t, value) {
+				return true
+			}
+		}
+		return false
+	}
+	switch value.(type) {
+	case map[string]interface{}:
+		return types == "object"
+	case []interface{}:
+		return types == "array"
+	case string:
+		return types == "string"
+	case bool:
+		return types == "boolean"
+	case int:
+		return types == "integer" || types == "number"
+	case float64:
+		return types == "number"
+	case nil:
+		// An empty YAML value leaves the field at its zero value
+		return true
+	}
+	return false
+}
+
+// decodeYAMLDocument decodes YAML into the generic values a schema validator sees
+func decodeYAMLDocument(t *testing.T, data []byte) interface{} {
+	t.Helper()
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+	return document
+}
+
+func TestConfigSchemasValidateConfigs(t *testing.T) {
+	defaultConfig, err := yaml.Marshal(config.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	modelDefaults, err := yaml.Marshal(builtInModelConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	components, err := yaml.Marshal(scanner.ComponentConfig{
+		Workspaces: []scanner.WorkspaceDef{{Name: "platform"}},
+		Components: []scanner.ComponentDef{{Name: "api", Path: "api", Type: "service", Tags: []string{"team-a"}, PromptOverrides: map[string]string{"README": "Mention the SLA."}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	readFile := func(path string) []byte {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name   string
+		target interface{}
+		data   []byte
+	}{
+		{"default enterprise config", config.EnterpriseConfig{}, defaultConfig},
+		{"enterprise-config.yaml", config.EnterpriseConfig{}, readFile("enterprise-config.yaml")},
+		{"enterprise-config.yaml.example", config.EnterpriseConfig{}, readFile("enterprise-config.yaml.example")},
+		{"built-in model config", ModelConfig{}, modelDefaults},
+		{"model-config.yaml.example", ModelConfig{}, readFile("model-config.yaml.example")},
+		{"components", scanner.ComponentConfig{}, components},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := schemaForType(reflect.TypeOf(tt.target))
+			if problems := validateSchema(schema, decodeYAMLDocument(t, tt.data), "$"); len(problems) > 0 {
+				t.Errorf("%s does not validate against its schema:\n%s", tt.name, strings.Join(problems, "\n"))
+			}
+		})
+	}
+}
+
+func TestConfigSchemaRejectsInvalidConfigs(t *testing.T) {
+	schema := schemaForType(reflect.TypeOf(config.EnterpriseConfig{}))
+	tests := []struct {
+		name, yaml, want string
+	}{
+		{"unknown top-level key", "aplication: {}\n", `unknown property "aplication"`},
+		{"unknown nested key", "application:\n  cache:\n    max_size: 10\n", `$.application.cache: unknown property "max_size"`},
+		{"wrong scalar type", "application:\n  cache:\n    max_entries: lots\n", "$.application.cache.max_entries: string does not match type integer"},
+		{"list where a map belongs", "templates:\n  required_sections: [README]\n", "$.templates.required_sections: []interface {} does not match type object"},
+		{"wrong item type", "templates:\n  chain_order: [[README]]\n", "$.templates.chain_order[0]: []interface {} does not match type string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateSchema(schema, decodeYAMLDocument(t, []byte(tt.yaml)), "$")
+			if len(problems) != 1 || !strings.Contains(problems[0], tt.want) {
+				t.Errorf("problems = %q, want one containing %q", problems, tt.want)
+			}
+		})
+	}
+}
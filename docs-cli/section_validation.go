@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sectionNudgeRetries is how many times --strict asks the model to add missing sections
+const sectionNudgeRetries = 1
+
+// missingSections returns the required sections that no markdown heading in content names.
+// Matching is case-insensitive, and a heading matches when it contains the section name,
+// so "## Installation Guide" satisfies "Installation".
+func missingSections(content string, required []string) []string {
+	var headings []string
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			headings = append(headings, strings.ToLower(strings.TrimSpace(strings.TrimLeft(trimmed, "#"))))
+		}
+	}
+
+	var missing []string
+	for _, section := range required {
+		name := strings.ToLower(strings.TrimSpace(section))
+		found := false
+		for _, heading := range headings {
+			if strings.Contains(heading, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, section)
+		}
+	}
+	return missing
+}
+
+// sectionNudge asks the model to rewrite a document that lacks required sections
+func sectionNudge(docType string, missing []string) string {
+	return fmt.Sprintf("The %s document above is missing these required sections: %s. Rewrite the complete document so it includes a heading for each of them.",
+		docType, strings.Join(missing, ", "))
+}
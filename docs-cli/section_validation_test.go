@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"docs-cli/pkg/config"
+)
+
+func TestMissingSections(t *testing.T) {
+	const document = "# svc\n\n## Overview\n\nJobs.\n\n  ### Installation Guide\n\nRun make.\n\nUsage is covered elsewhere.\n"
+	tests := []struct {
+		name     string
+		required []string
+		want     []string
+	}{
+		{"all present", []string{"Overview", "Installation"}, nil},
+		{"case-insensitive", []string{"overview", "INSTALLATION"}, nil},
+		{"heading containing the name", []string{"Guide"}, nil},
+		{"named only in body text", []string{"Usage"}, []string{"Usage"}},
+		{"several missing keep their order", []string{"Usage", "Overview", "Troubleshooting"}, []string{"Usage", "Troubleshooting"}},
+		{"nothing required", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := missingSections(document, tt.required); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("missingSections(%v) = %v, want %v", tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+// sequenceProvider answers each call with the next of its responses and records the
+// conversations it was sent
+type sequenceProvider struct {
+	mu        sync.Mutex
+	responses []string
+	requests  [][]ChatMessage
+}
+
+func (p *sequenceProvider) CallModel(ctx context.Context, prompt, model string, maxTokens int, temperature float64) (string, error) {
+	return p.CallChat(ctx, userChatRequest(prompt, model, maxTokens, temperature))
+}
+
+func (p *sequenceProvider) CallChat(ctx context.Context, request ChatRequest) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, request.Messages)
+	response := p.responses[min(len(p.requests), len(p.responses))-1]
+	return response, nil
+}
+
+// useSequenceProvider routes model calls to a sequenceProvider answering with responses
+func useSequenceProvider(t *testing.T, responses ...string) *sequenceProvider {
+	t.Helper()
+	provider := &sequenceProvider{responses: responses}
+	previous := newModelProvider
+	newModelProvider = func(providerName, apiKey string) ModelProvider { return provider }
+	t.Cleanup(func() { newModelProvider = previous })
+	return provider
+}
+
+func TestRegenerateDocumentRequiredSections(t *testing.T) {
+	const (
+		complete   = "# svc\n\n## Overview\n\nJobs.\n\n## Usage\n\nRun it.\n"
+		incomplete = "# svc\n\n## Overview\n\nJobs.\n"
+	)
+	tests := []struct {
+		name      string
+		strict    bool
+		responses []string
+		wantCalls int
+		wantErr   string
+		wantDoc   string
+	}{
+		{name: "present", responses: []string{complete}, wantCalls: 1, wantDoc: complete},
+		{name: "missing is written with a warning", responses: []string{incomplete}, wantCalls: 1, wantDoc: incomplete},
+		{name: "strict present", strict: true, responses: []string{complete}, wantCalls: 1, wantDoc: complete},
+		{name: "strict retry adds the section", strict: true, responses: []string{incomplete, complete}, wantCalls: 2, wantDoc: complete},
+		{name: "strict still missing fails", strict: true, responses: []string{incomplete, incomplete}, wantCalls: 2, wantErr: "missing required sections: Usage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project, svc := singleServiceProject(t)
+			useEnterpriseConfig(t, func(c *config.EnterpriseConfig) {
+				c.Templates.RequiredSections = map[string][]string{"README": {"Overview", "Usage"}}
+			})
+			useCacheFlags(t, true, true)
+			strictSections = tt.strict
+			t.Cleanup(func() { strictSections = false })
+			provider := useSequenceProvider(t, tt.responses...)
+
+			err := regenerate(t, NewSnapshotManager(), svc, "README")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("regenerate error = %v, want %s", err, tt.wantErr)
+				}
+				if _, statErr := os.Stat(docOutputPath(svc, "README")); !os.IsNotExist(statErr) {
+					t.Errorf("README written despite missing sections under --strict: %v", statErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if got := project.ReadFile("svc/README.md"); got != tt.wantDoc {
+				t.Errorf("README.md = %q, want %q", got, tt.wantDoc)
+			}
+
+			if len(provider.requests) != tt.wantCalls {
+				t.Fatalf("provider called %d times, want %d", len(provider.requests), tt.wantCalls)
+			}
+			if tt.wantCalls > 1 {
+				// The retry shows the model its previous answer and names what is missing
+				retry := provider.requests[1]
+				previous, nudge := retry[len(retry)-2], retry[len(retry)-1]
+				if previous.Role != "assistant" || previous.Content != incomplete {
+					t.Errorf("retry's second-to-last turn = %s %q, want the incomplete answer", previous.Role, previous.Content)
+				}
+				if nudge.Role != "user" || !strings.Contains(nudge.Content, "missing these required sections: Usage") {
+					t.Errorf("retry's last turn = %s %q, want a nudge naming Usage", nudge.Role, nudge.Content)
+				}
+			}
+		})
+	}
+}
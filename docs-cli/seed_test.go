@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// useSeed runs the test as if --seed value had been given
+func useSeed(t *testing.T, value int) {
+	t.Helper()
+	previousSeed, previousSet := seed, seedSet
+	seed, seedSet = value, true
+	t.Cleanup(func() { seed, seedSet = previousSeed, previousSet })
+}
+
+func TestGenerateCacheKeyIncludesSeed(t *testing.T) {
+	key := func() string { return GenerateCacheKey("openai", "Document svc.", "gpt-4o", 1000, 0) }
+
+	useSeed(t, 42)
+	first, second := key(), key()
+	seedSet = false
+	unseeded := key()
+	seedSet = true
+	if first != second {
+		t.Errorf("same seed gave keys %s and %s", first, second)
+	}
+	if first == unseeded {
+		t.Error("seeded and unseeded calls share a cache key")
+	}
+	seed = 7
+	if key() == first {
+		t.Error("seeds 42 and 7 share a cache key")
+	}
+}
+
+func TestSeededRunsSendIdenticalRequests(t *testing.T) {
+	project := newTestProject(t, "components: []\n")
+	useModelConfig(t, project, `default:
+  provider: "openai"
+  model: "gpt-4o"
+  max_tokens: 1000
+  temperature: 0.7
+openai:
+  api_key: "test-key"
+  models:
+    gpt-4o: "gpt-4o"
+`)
+	server := newProviderServer(t, http.StatusOK, chatCompletion("# svc README", "stop"))
+	previous := newModelProvider
+	newModelProvider = func(providerName, apiKey string) ModelProvider {
+		return newHTTPProviders(server.URL)[providerName]
+	}
+	t.Cleanup(func() { newModelProvider = previous })
+	useSeed(t, 42)
+
+	conversation := []ChatMessage{{Role: "user", Content: "Document the billing service."}}
+	run := func() {
+		t.Helper()
+		if _, err := callModelAPIWithConversation(context.Background(), conversation, "README", "service", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Two runs that skip cache reads both reach the provider with the same request
+	useCacheFlags(t, true, false)
+	run()
+	run()
+	requests := server.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("server received %d requests, want 2", len(requests))
+	}
+	if !reflect.DeepEqual(requests[0].body, requests[1].body) {
+		t.Errorf("seeded runs sent different requests:\n%v\n%v", requests[0].body, requests[1].body)
+	}
+	if got := requests[0].body["seed"]; got != float64(42) {
+		t.Errorf("seed = %v, want 42", got)
+	}
+	if got := requests[0].body["temperature"]; got != float64(0) {
+		t.Errorf("temperature = %v, want 0 in deterministic mode", got)
+	}
+
+	// The same seed finds the cached response; another seed misses it
+	noCache = false
+	run()
+	if got := len(server.Requests()); got != 2 {
+		t.Errorf("same seed made request %d, want a cache hit", got)
+	}
+	seed = 7
+	run()
+	requests = server.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("another seed made %d requests in total, want a cache miss and 3", len(requests))
+	}
+	if got := requests[2].body["seed"]; got != float64(7) {
+		t.Errorf("seed = %v, want 7", got)
+	}
+}
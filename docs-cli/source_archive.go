@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"docs-cli/pkg/config"
+	"docs-cli/pkg/scanner"
+)
+
+var (
+	// Archive opened from --source-archive; nil when reading from disk
+	sourceFS     iofs.FS
+	sourceCloser io.Closer
+)
+
+// openSourceArchive opens the --source-archive once so all readers share it
+func openSourceArchive() error {
+	if sourceArchive == "" || sourceFS != nil {
+		return nil
+	}
+
+	fsys, closer, err := scanner.OpenArchiveFS(sourceArchive)
+	if err != nil {
+		return err
+	}
+
+	sourceFS = fsys
+	sourceCloser = closer
+	LogWithContext().WithField("archive", sourceArchive).Info("Reading source files from archive")
+	return nil
+}
+
+// closeSourceArchive releases the --source-archive if one was opened
+func closeSourceArchive() {
+	if sourceCloser != nil {
+		sourceCloser.Close()
+	}
+}
+
+// newFileScanner creates a file scanner that reads from disk, or from --source-archive when set
+func newFileScanner(configManager config.ConfigManager) (scanner.FileScanner, error) {
+	roots, err := parseWorkspaceRoots(workspaceRoots)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileScanner scanner.FileScanner
+	if sourceArchive == "" {
+		fileScanner = scanner.NewFileScanner(configManager, useGitignore)
+	} else {
+		if err := openSourceArchive(); err != nil {
+			return nil, err
+		}
+
+		// Archive entries are addressed relative to the project root
+		fileScanner = scanner.NewFileScannerWithFS(configManager, useGitignore, sourceFS, projectRoot)
+	}
+
+	fileScanner.SetWorkspaceRoots(roots)
+	fileScanner.SetExcludeTests(noTests)
+	return fileScanner, nil
+}
+
+// parseWorkspaceRoots parses --root values of the form name=path, or a bare path named after its directory
+func parseWorkspaceRoots(values []string) (map[string]string, error) {
+	roots := make(map[string]string)
+	for _, value := range values {
+		name, rootPath, found := strings.Cut(value, "=")
+		if !found {
+			rootPath = value
+			name = filepath.Base(filepath.Clean(value))
+		}
+
+		if name == "" || rootPath == "" {
+			return nil, fmt.Errorf("invalid --root %q: expected name=path", value)
+		}
+		if strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid --root %q: workspace name cannot contain '/'", value)
+		}
+		roots[name] = rootPath
+	}
+	return roots, nil
+}
+
+// sourceArchiveName maps a project path to its slash-separated name inside the source archive
+func sourceArchiveName(filePath string) (string, error) {
+	rel, err := filepath.Rel(projectRoot, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	name := filepath.ToSlash(rel)
+	if !iofs.ValidPath(name) {
+		return "", fmt.Errorf("path %s is outside the source archive", filePath)
+	}
+	return name, nil
+}
+
+// statSourceFile stats a source file from disk or the source archive
+func statSourceFile(filePath string) (iofs.FileInfo, error) {
+	if sourceFS == nil {
+		return os.Stat(filePath)
+	}
+
+	name, err := sourceArchiveName(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return iofs.Stat(sourceFS, name)
+}
+
+// openSourceFile opens a source file from disk or the source archive for streaming reads
+func openSourceFile(filePath string) (io.ReadCloser, error) {
+	if sourceFS == nil {
+		return os.Open(filePath)
+	}
+
+	name, err := sourceArchiveName(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return sourceFS.Open(name)
+}
+
+// readSourceFile reads a source file from disk or the source archive
+func readSourceFile(filePath string) ([]byte, error) {
+	if sourceFS == nil {
+		return os.ReadFile(filePath)
+	}
+
+	name, err := sourceArchiveName(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return iofs.ReadFile(sourceFS, name)
+}
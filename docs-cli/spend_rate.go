@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// spendSample is the cost of one API call at the time it completed
+type spendSample struct {
+	at   time.Time
+	cost float64
+}
+
+// SpendRateTracker computes dollars-per-minute over a rolling window of recent API calls
+type SpendRateTracker struct {
+	mutex   sync.Mutex
+	window  time.Duration
+	samples []spendSample
+}
+
+// NewSpendRateTracker creates a tracker that averages spend over window
+func NewSpendRateTracker(window time.Duration) *SpendRateTracker {
+	if window <= 0 {
+		window = time.Minute
+	}
+	return &SpendRateTracker{window: window}
+}
+
+// Record adds a call's cost at the given time and returns the resulting spend rate
+func (t *SpendRateTracker) Record(at time.Time, cost float64) float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.samples = append(t.samples, spendSample{at: at, cost: cost})
+	return t.rateLocked(at)
+}
+
+// Rate returns the dollars-per-minute spent in the window ending at now
+func (t *SpendRateTracker) Rate(now time.Time) float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.rateLocked(now)
+}
+
+// rateLocked drops samples older than the window and averages the rest; callers must hold the lock
+func (t *SpendRateTracker) rateLocked(now time.Time) float64 {
+	cutoff := now.Add(-t.window)
+	kept := t.samples[:0]
+	var total float64
+	for _, sample := range t.samples {
+		if sample.at.After(cutoff) {
+			kept = append(kept, sample)
+			total += sample.cost
+		}
+	}
+	t.samples = kept
+
+	return total / t.window.Minutes()
+}
+
+var (
+	spendTracker     *SpendRateTracker
+	spendTrackerOnce sync.Once
+)
+
+// getSpendTracker returns the process-wide spend tracker, sized from cost_optimization.spend_rate_window
+func getSpendTracker() *SpendRateTracker {
+	spendTrackerOnce.Do(func() {
+		spendTracker = NewSpendRateTracker(getCostOptConfig().SpendRateWindow)
+	})
+	return spendTracker
+}
+
+// RecordSpend records the cost of a completed API call and logs a warning when the
+// spend rate exceeds cost_optimization.max_spend_per_minute
+func RecordSpend(provider string, cost float64) {
+	if cost <= 0 {
+		return
+	}
+
+	rate := getSpendTracker().Record(time.Now(), cost)
+	maxRate := getCostOptConfig().MaxSpendPerMinute
+	if maxRate > 0 && rate > maxRate {
+		LogWithContext().WithField("provider", provider).
+			WithField("spend_per_minute", rate).
+			WithField("max_spend_per_minute", maxRate).
+			Warn("Spend rate exceeds max_spend_per_minute")
+	}
+}
+
+// CheckSpendRate refuses new API calls while the spend rate is over the limit and --cost-circuit-breaker is set
+func CheckSpendRate(provider string) error {
+	maxRate := getCostOptConfig().MaxSpendPerMinute
+	if !costCircuitBreaker || maxRate <= 0 {
+		return nil
+	}
+
+	if rate := getSpendTracker().Rate(time.Now()); rate > maxRate {
+		return fmt.Errorf("spend rate $%.4f/min exceeds max_spend_per_minute $%.4f/min, refusing %s call (--cost-circuit-breaker)", rate, maxRate, provider)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"docs-cli/pkg/config"
+)
+
+func TestSpendRateTrackerRecord(t *testing.T) {
+	const maxRate = 1.0 // dollars per minute
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewSpendRateTracker(5 * time.Minute)
+
+	// $2 per call averaged over a five minute window: the third call trips $1/min,
+	// and once the first two age out the rate falls back under it
+	steps := []struct {
+		at       time.Duration
+		cost     float64
+		wantRate float64
+	}{
+		{0, 2, 0.4},
+		{time.Minute, 2, 0.8},
+		{2 * time.Minute, 2, 1.2},
+		{5*time.Minute + 30*time.Second, 0.5, 0.9},
+	}
+	for _, step := range steps {
+		rate := tracker.Record(start.Add(step.at), step.cost)
+		if math.Abs(rate-step.wantRate) > 1e-9 {
+			t.Errorf("rate after $%.2f at +%s = %.4f, want %.4f", step.cost, step.at, rate, step.wantRate)
+		}
+		if tripped, wantTripped := rate > maxRate, step.wantRate > maxRate; tripped != wantTripped {
+			t.Errorf("at +%s over the limit = %v, want %v", step.at, tripped, wantTripped)
+		}
+	}
+
+	if rate := tracker.Rate(start.Add(20 * time.Minute)); rate != 0 {
+		t.Errorf("rate after every sample aged out = %.4f, want 0", rate)
+	}
+}
+
+func TestCheckSpendRateRefusesOverLimit(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	useEnterpriseConfig(t, func(cfg *config.EnterpriseConfig) {
+		cfg.CostOpt.MaxSpendPerMinute = 1
+	})
+	previousBreaker := costCircuitBreaker
+	costCircuitBreaker = true
+	t.Cleanup(func() { costCircuitBreaker = previousBreaker })
+
+	spendTrackerOnce.Do(func() {})
+	previousTracker := spendTracker
+	spendTracker = NewSpendRateTracker(time.Minute)
+	t.Cleanup(func() { spendTracker = previousTracker })
+
+	if err := CheckSpendRate("openai"); err != nil {
+		t.Fatalf("CheckSpendRate with no spend = %v, want nil", err)
+	}
+	RecordSpend("openai", 0.5)
+	if err := CheckSpendRate("openai"); err != nil {
+		t.Fatalf("CheckSpendRate under the limit = %v, want nil", err)
+	}
+	RecordSpend("openai", 0.75)
+	err := CheckSpendRate("openai")
+	if err == nil || !strings.Contains(err.Error(), "exceeds max_spend_per_minute") {
+		t.Fatalf("CheckSpendRate over the limit = %v, want a refusal", err)
+	}
+
+	costCircuitBreaker = false
+	if err := CheckSpendRate("openai"); err != nil {
+		t.Errorf("CheckSpendRate without --cost-circuit-breaker = %v, want nil", err)
+	}
+}
+
+func TestSpendRateTrackerConcurrentRecords(t *testing.T) {
+	now := time.Now()
+	tracker := NewSpendRateTracker(time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracker.Record(now, 0.01)
+		}()
+	}
+	wg.Wait()
+	if rate := tracker.Rate(now); math.Abs(rate-0.5) > 1e-9 {
+		t.Errorf("rate after 50 concurrent $0.01 records = %.4f, want 0.5", rate)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"docs-cli/pkg/config"
+)
+
+var (
+	staleOlderThan    string
+	staleChangedSince string
+)
+
+// parseAge parses a Go duration, also accepting a whole-day suffix such as "90d"
+func parseAge(value string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q: expected e.g. 90d or 36h", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q: expected e.g. 90d or 36h", value)
+	}
+	return age, nil
+}
+
+// parseSinceTime parses an RFC3339 timestamp or a YYYY-MM-DD date
+func parseSinceTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: expected RFC3339 or YYYY-MM-DD", value)
+}
+
+func listStaleComponents(cmd *cobra.Command, args []string) {
+	var olderThan time.Duration
+	if staleOlderThan != "" {
+		age, err := parseAge(staleOlderThan)
+		if err != nil {
+			fmt.Printf("❌ Invalid --older-than: %v\n", err)
+			return
+		}
+		olderThan = age
+	}
+
+	var changedSince time.Time
+	if staleChangedSince != "" {
+		since, err := parseSinceTime(staleChangedSince)
+		if err != nil {
+			fmt.Printf("❌ Invalid --changed-since: %v\n", err)
+			return
+		}
+		changedSince = since
+	}
+
+	if olderThan == 0 && changedSince.IsZero() {
+		fmt.Println("❌ stale requires --older-than or --changed-since")
+		return
+	}
+
+	configManager := config.NewConfigManager()
+	if _, err := configManager.LoadConfig(); err != nil {
+		fmt.Printf("❌ Configuration error: %v\n", err)
+		return
+	}
+
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		fmt.Printf("❌ Error opening source: %v\n", err)
+		return
+	}
+	components, err := fileScanner.ScanComponents(projectRoot)
+	if err != nil {
+		fmt.Printf("❌ Error scanning components: %v\n", err)
+		return
+	}
+
+	components, err = selectComponents(components)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	stale := NewSnapshotManager().GetStaleComponents(components, olderThan, changedSince, time.Now())
+	if len(stale) == 0 {
+		fmt.Printf("✅ No stale components among %d\n", len(components))
+		return
+	}
+
+	// Oldest documentation first; never-documented components sort to the top
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].LastUpdated.Before(stale[j].LastUpdated)
+	})
+
+	fmt.Printf("🕰️  Found %d stale components:\n\n", len(stale))
+	for _, entry := range stale {
+		fmt.Printf("• %s\n", entry.Key)
+		if entry.LastUpdated.IsZero() {
+			fmt.Printf("  Last documented: never\n")
+		} else {
+			fmt.Printf("  Last documented: %s\n", entry.LastUpdated.Format("2006-01-02"))
+		}
+		if !entry.LastModified.IsZero() {
+			fmt.Printf("  Last modified: %s\n", entry.LastModified.Format("2006-01-02"))
+		}
+		fmt.Printf("  Reasons: %s\n", strings.Join(entry.Reasons, ", "))
+		fmt.Println()
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/time/rate"
+	"gopkg.in/yaml.v3"
+
+	"docs-cli/pkg/config"
+	"docs-cli/pkg/scanner"
+)
+
+// useTempProject points projectRoot at a fresh temporary directory for the test
+func useTempProject(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	previous := projectRoot
+	projectRoot = root
+	t.Cleanup(func() { projectRoot = previous })
+	return root
+}
+
+// useEnterpriseConfig loads a copy of the current enterprise configuration with edit applied,
+// through the same file loading and validation as a config reload, for the rest of the test.
+// Circuit breakers are rebuilt from the original settings afterwards.
+func useEnterpriseConfig(t *testing.T, edit func(*config.EnterpriseConfig)) *config.EnterpriseConfig {
+	t.Helper()
+	edited := *config.GetConfig()
+	edit(&edited)
+	data, err := yaml.Marshal(&edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "enterprise-config.yaml"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := reloadConfigFrom(dir)
+	if err != nil {
+		t.Fatalf("loading edited enterprise config: %v", err)
+	}
+	t.Cleanup(func() {
+		wd, _ := os.Getwd()
+		if _, err := reloadConfigFrom(wd); err != nil {
+			t.Errorf("restoring enterprise config: %v", err)
+		}
+		rebuildCircuitBreakers()
+	})
+	return loaded
+}
+
+// reloadConfigFrom reloads enterprise-config.yaml from dir, leaving the working directory as it was
+func reloadConfigFrom(dir string) (*config.EnterpriseConfig, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return nil, err
+	}
+	defer os.Chdir(wd)
+	return config.ReloadEnterpriseConfig()
+}
+
+// testMockModelConfig routes every doc type to the offline mock provider
+const testMockModelConfig = `default:
+  provider: "mock"
+  model: "demo"
+  max_tokens: 4000
+  temperature: 0.7
+`
+
+// testProject is a temporary project: cli/ holds the configuration and is the working
+// directory, and component sources live beside it as they do in a real checkout
+type testProject struct {
+	t    *testing.T
+	Root string
+	CLI  string
+}
+
+// newTestProject creates a project whose components.yaml is componentsYAML and whose
+// model-config.yaml routes to the mock provider, makes cli/ the working directory, and
+// clears process-wide state so the test sees only this project. Everything is restored
+// when the test ends.
+func newTestProject(t *testing.T, componentsYAML string) *testProject {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := useTempProject(t)
+	cli := filepath.Join(root, "cli")
+	if err := os.MkdirAll(cli, 0755); err != nil {
+		t.Fatal(err)
+	}
+	enterpriseConfig, err := os.ReadFile(filepath.Join(wd, "enterprise-config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := &testProject{t: t, Root: root, CLI: cli}
+	project.WriteFile("cli/enterprise-config.yaml", string(enterpriseConfig))
+	project.WriteFile("cli/components.yaml", componentsYAML)
+	project.WriteFile("cli/model-config.yaml", testMockModelConfig)
+	if err := os.Symlink(filepath.Join(wd, "templates"), filepath.Join(cli, "templates")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(cli); err != nil {
+		t.Fatal(err)
+	}
+	resetProcessState(t)
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Errorf("restoring working directory: %v", err)
+		}
+		resetProcessState(t)
+	})
+	return project
+}
+
+// WriteFile writes content to a slash-separated path relative to the project root
+func (p *testProject) WriteFile(path, content string) string {
+	p.t.Helper()
+	full := filepath.Join(p.Root, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		p.t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		p.t.Fatal(err)
+	}
+	return full
+}
+
+// ReadFile reads a slash-separated path relative to the project root
+func (p *testProject) ReadFile(path string) string {
+	p.t.Helper()
+	content, err := os.ReadFile(filepath.Join(p.Root, filepath.FromSlash(path)))
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	return string(content)
+}
+
+// Components scans the project's components
+func (p *testProject) Components() []scanner.Component {
+	p.t.Helper()
+	fileScanner, err := newFileScanner(config.NewConfigManager())
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	components, err := fileScanner.ScanComponents(projectRoot)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	return components
+}
+
+// Component returns the scanned component with the given key
+func (p *testProject) Component(key string) scanner.Component {
+	p.t.Helper()
+	var keys []string
+	for _, component := range p.Components() {
+		if component.Key() == key {
+			return component
+		}
+		keys = append(keys, component.Key())
+	}
+	p.t.Fatalf("component %s not found among %s", key, strings.Join(keys, ", "))
+	return scanner.Component{}
+}
+
+// resetProcessState reloads the enterprise and model configuration from the working
+// directory and drops caches and per-run state built from the previous one
+func resetProcessState(t *testing.T) {
+	t.Helper()
+	if _, err := config.ReloadEnterpriseConfig(); err != nil {
+		t.Fatalf("loading enterprise config: %v", err)
+	}
+	modelConfig.Store(nil)
+	rebuildCircuitBreakers()
+	breakerStateMutex.Lock()
+	restoredBreakers = nil
+	breakerStateMutex.Unlock()
+	for _, provider := range []string{"anthropic", "openai", "default"} {
+		GetProviderCache(provider).Clear()
+	}
+	dependencyGraphOnce = sync.Once{}
+	dependencyGraph, dependencyIndex = nil, nil
+	tokenHistoryOnce = sync.Once{}
+	tokenHistory = nil
+	// Each test starts with full rate limit bursts rather than what earlier tests left
+	for provider, limiter := range rateLimiters {
+		rateLimiters[provider] = rate.NewLimiter(limiter.Limit(), limiter.Burst())
+	}
+}
+
+// captureStdout returns what fn prints to standard output
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := os.Stdout
+	os.Stdout = writer
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+	defer func() {
+		os.Stdout = previous
+	}()
+	fn()
+	writer.Close()
+	return <-output
+}
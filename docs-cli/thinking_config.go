@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"strings"
 )
 
 // ThinkingConfig defines thinking parameters for different providers
@@ -132,31 +131,7 @@ func configureAnthropicThinking(config ThinkingConfig, model string) ThinkingCon
 
 // supportsThinking checks if a model supports thinking capabilities
 func supportsThinking(provider, model string) bool {
-	config, err := loadModelConfig()
-	if err != nil {
-		LogWithContext().WithError(err).Error("Failed to load model config for thinking support check")
-		return false
-	}
-	
-	var thinkingModels []string
-	switch provider {
-	case "openrouter":
-		thinkingModels = config.OpenRouter.ThinkingModels
-	case "openai":
-		thinkingModels = config.OpenAI.ThinkingModels
-	case "anthropic":
-		thinkingModels = config.Anthropic.ThinkingModels
-	default:
-		return false
-	}
-	
-	for _, supportedModel := range thinkingModels {
-		if strings.Contains(model, supportedModel) || supportedModel == model {
-			return true
-		}
-	}
-	
-	return false
+	return GetProviderCapabilities(provider, model).SupportsThinking
 }
 
 // getThinkingCostMultiplier returns cost multiplier for thinking-enabled calls
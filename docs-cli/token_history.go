@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const (
+	// tokenHistorySize is how many recent completions are kept per document type
+	tokenHistorySize = 50
+	// tokenHistoryHalfWeight is the sample count at which history and heuristic weigh equally
+	tokenHistoryHalfWeight = 5
+)
+
+// TokenHistory keeps a rolling record of completion token counts per document type so
+// output estimates self-calibrate across runs. It is persisted next to the snapshots file.
+type TokenHistory struct {
+	mutex   sync.Mutex
+	path    string
+	samples map[string][]int
+}
+
+var (
+	tokenHistory     *TokenHistory
+	tokenHistoryOnce sync.Once
+)
+
+// getTokenHistory returns the process-wide token history, loading it from disk on first use
+func getTokenHistory() *TokenHistory {
+	tokenHistoryOnce.Do(func() {
+		tokenHistory = NewTokenHistory(filepath.Join(projectRoot, ".docs-cli-token-history.json"))
+	})
+	return tokenHistory
+}
+
+// NewTokenHistory creates a token history backed by path, loading any existing samples
+func NewTokenHistory(path string) *TokenHistory {
+	history := &TokenHistory{
+		path:    path,
+		samples: make(map[string][]int),
+	}
+	history.load()
+	return history
+}
+
+// load reads persisted samples; a missing or unreadable file starts an empty history
+func (h *TokenHistory) load() {
+	data, err := os.ReadFile(h.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			LogWithContext().WithError(err).Warn("Failed to load token history")
+		}
+		return
+	}
+
+	var samples map[string][]int
+	if err := json.Unmarshal(data, &samples); err != nil {
+		LogWithContext().WithError(err).Warn("Failed to parse token history")
+		return
+	}
+	h.samples = samples
+}
+
+// save writes the samples to disk; callers must hold the lock
+func (h *TokenHistory) save() error {
+	data, err := json.MarshalIndent(h.samples, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal token history: %w", err)
+	}
+	if err := os.WriteFile(h.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write token history: %w", err)
+	}
+	return nil
+}
+
+// Record adds a completion token count for docType, keeping the most recent tokenHistorySize samples
+func (h *TokenHistory) Record(docType string, completionTokens int) {
+	if completionTokens <= 0 {
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	samples := append(h.samples[docType], completionTokens)
+	if len(samples) > tokenHistorySize {
+		samples = samples[len(samples)-tokenHistorySize:]
+	}
+	h.samples[docType] = samples
+
+	if err := h.save(); err != nil {
+		LogWithContext().WithError(err).Warn("Failed to save token history")
+	}
+}
+
+// Median returns the median completion token count for docType and the number of samples
+func (h *TokenHistory) Median(docType string) (int, int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	samples := h.samples[docType]
+	if len(samples) == 0 {
+		return 0, 0
+	}
+
+	sorted := append([]int(nil), samples...)
+	sort.Ints(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2, len(sorted)
+	}
+	return sorted[mid], len(sorted)
+}
+
+// Blend mixes the historical median with a heuristic estimate. The history weight
+// n/(n+tokenHistoryHalfWeight) grows with the sample count, so estimates lean on the
+// heuristic at first and converge on observed completions as history accumulates.
+func (h *TokenHistory) Blend(docType string, heuristic int) int {
+	median, count := h.Median(docType)
+	if count == 0 {
+		return heuristic
+	}
+
+	weight := float64(count) / float64(count+tokenHistoryHalfWeight)
+	return int(weight*float64(median) + (1-weight)*float64(heuristic))
+}
+
+// DocTypes returns the document types with recorded history, sorted
+func (h *TokenHistory) DocTypes() []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	docTypes := make([]string, 0, len(h.samples))
+	for docType := range h.samples {
+		docTypes = append(docTypes, docType)
+	}
+	sort.Strings(docTypes)
+	return docTypes
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestTokenHistoryBlendConvergesOnObservedCompletions(t *testing.T) {
+	const (
+		heuristic = 1000
+		observed  = 2400
+	)
+	history := NewTokenHistory(filepath.Join(t.TempDir(), "history.json"))
+	if got := history.Blend("README", heuristic); got != heuristic {
+		t.Fatalf("Blend with no history = %d, want the heuristic %d", got, heuristic)
+	}
+
+	// Each recorded completion moves the estimate closer to what the provider reports
+	previousGap := observed - heuristic
+	for i := 1; i <= tokenHistorySize; i++ {
+		history.Record("README", observed)
+		estimate := history.Blend("README", heuristic)
+		gap := observed - estimate
+		if gap < 0 || gap >= previousGap {
+			t.Fatalf("after %d samples the estimate %d is no closer to %d than before (gap %d, previous %d)", i, estimate, observed, gap, previousGap)
+		}
+		previousGap = gap
+		if i == tokenHistoryHalfWeight {
+			if want := (observed + heuristic) / 2; estimate != want {
+				t.Errorf("after %d samples Blend = %d, want the midpoint %d", i, estimate, want)
+			}
+		}
+	}
+	if estimate := history.Blend("README", heuristic); observed-estimate > observed/10 {
+		t.Errorf("after %d samples Blend = %d, want within 10%% of %d", tokenHistorySize, estimate, observed)
+	}
+}
+
+func TestTokenHistorySeededFromDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	seeded := NewTokenHistory(path)
+	for _, tokens := range []int{900, 1100, 1000, 5000, 0} {
+		seeded.Record("ARCHITECTURE", tokens)
+	}
+
+	reloaded := NewTokenHistory(path)
+	if median, count := reloaded.Median("ARCHITECTURE"); median != 1050 || count != 4 {
+		t.Errorf("reloaded Median = %d over %d samples, want 1050 over 4 (zero counts are not recorded)", median, count)
+	}
+	if docTypes := reloaded.DocTypes(); len(docTypes) != 1 || docTypes[0] != "ARCHITECTURE" {
+		t.Errorf("DocTypes = %v, want [ARCHITECTURE]", docTypes)
+	}
+
+	for i := 0; i < tokenHistorySize+10; i++ {
+		reloaded.Record("ARCHITECTURE", 2000)
+	}
+	if median, count := reloaded.Median("ARCHITECTURE"); median != 2000 || count != tokenHistorySize {
+		t.Errorf("Median after overflowing the window = %d over %d samples, want 2000 over %d", median, count, tokenHistorySize)
+	}
+}
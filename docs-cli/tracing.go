@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceShutdownTimeout bounds how long the run waits to export its last spans
+const traceShutdownTimeout = 10 * time.Second
+
+// Span is one timed operation in a trace. A nil *Span is valid and records nothing,
+// so call sites need no checks when tracing is disabled.
+type Span struct {
+	span trace.Span
+}
+
+// SetAttribute records a key/value on the span, e.g. provider, model, tokens, or cost
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.span.SetAttributes(spanAttribute(key, value))
+}
+
+// RecordError marks the span as failed
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End finishes the span and queues it for export
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.span.End()
+}
+
+// tracer starts spans for the run; a nil tracer disables tracing
+type tracer struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+	root     *Span
+	// rootContext carries the root span, to parent spans started from a bare context
+	rootContext context.Context
+}
+
+var activeTracer *tracer
+
+// traceContext reads and writes W3C trace context
+var traceContext = propagation.TraceContext{}
+
+// startTracing enables tracing when OTEL_EXPORTER_OTLP_ENDPOINT is set and opens the
+// root span for the command. The OTLP exporter reads its endpoint, headers, and timeouts
+// from the standard OTEL_EXPORTER_OTLP_* variables.
+func startTracing(command string) {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" {
+		return
+	}
+	exporter, err := otlptracehttp.New(context.Background())
+	if err != nil {
+		LogWithContext().WithError(err).Warn("Failed to start trace exporter; tracing disabled")
+		return
+	}
+	installTracer(exporter, command)
+}
+
+// installTracer starts tracing into exporter under a root span named after the command.
+// Spans are exported in batches as they finish, through a bounded queue that drops spans
+// rather than grow when the exporter falls behind, so a long watch run neither holds
+// every span in memory nor waits for exit to export them.
+func installTracer(exporter sdktrace.SpanExporter, command string) {
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(semconv.ServiceName("docs-cli")),
+		resource.WithFromEnv(), // OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence
+	)
+	if err != nil {
+		LogWithContext().WithError(err).Warn("Failed to read trace resource attributes")
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	activeTracer = &tracer{provider: provider, tracer: provider.Tracer("docs-cli")}
+	ctx, root := activeTracer.tracer.Start(parentTraceContext(), command)
+	activeTracer.root = &Span{span: root}
+	activeTracer.rootContext = ctx
+
+	onShutdown(func() {
+		activeTracer.root.RecordError(context.Cause(runContext()))
+		activeTracer.root.End()
+
+		// The run context may already be cancelled, so the last export gets its own deadline
+		ctx, cancel := context.WithTimeout(context.Background(), traceShutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			LogWithContext().WithError(err).Warn("Failed to export trace spans")
+		}
+	})
+}
+
+// parentTraceContext continues the trace of whatever started docs-cli, such as a CI job,
+// when it passes its span in the TRACEPARENT and TRACESTATE environment variables
+func parentTraceContext() context.Context {
+	carrier := propagation.MapCarrier{}
+	if parent := os.Getenv("TRACEPARENT"); parent != "" {
+		carrier.Set("traceparent", parent)
+		carrier.Set("tracestate", os.Getenv("TRACESTATE"))
+	}
+	return traceContext.Extract(context.Background(), carrier)
+}
+
+// StartSpan starts a child of the span in ctx, or of the command's root span
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	return startSpan(ctx, name, trace.SpanKindInternal)
+}
+
+func startSpan(ctx context.Context, name string, kind trace.SpanKind) (context.Context, *Span) {
+	if activeTracer == nil {
+		return ctx, nil
+	}
+	ctx, span := activeTracer.tracer.Start(withParentSpan(ctx), name, trace.WithSpanKind(kind))
+	return ctx, &Span{span: span}
+}
+
+// SpanFrom returns the current span in ctx, falling back to the root span
+func SpanFrom(ctx context.Context) *Span {
+	if activeTracer == nil {
+		return nil
+	}
+	return &Span{span: trace.SpanFromContext(withParentSpan(ctx))}
+}
+
+// withParentSpan returns ctx, carrying the root span if it carries no span of its own
+func withParentSpan(ctx context.Context) context.Context {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	return trace.ContextWithSpan(ctx, activeTracer.root.span)
+}
+
+// injectTraceContext propagates the span in ctx to a downstream request
+func injectTraceContext(ctx context.Context, header http.Header) {
+	if activeTracer == nil {
+		return
+	}
+	traceContext.Inject(withParentSpan(ctx), propagation.HeaderCarrier(header))
+}
+
+// spanAttribute converts a value to an attribute, keeping numbers and booleans typed
+func spanAttribute(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case string:
+		return attribute.String(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// keptSpansExporter keeps its spans through shutdown, where an InMemoryExporter drops them
+type keptSpansExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (keptSpansExporter) Shutdown(context.Context) error { return nil }
+
+// useMemoryTracer traces into an in-memory exporter under a root span for command;
+// finishTrace ends the root span and exports what was recorded
+func useMemoryTracer(t *testing.T, command string) (exporter *tracetest.InMemoryExporter, finishTrace func()) {
+	t.Helper()
+	shutdownMutex.Lock()
+	registered := len(shutdownHooks)
+	shutdownMutex.Unlock()
+
+	exporter = tracetest.NewInMemoryExporter()
+	installTracer(keptSpansExporter{exporter}, command)
+
+	// installTracer's shutdown hook is run here rather than left for later tests
+	shutdownMutex.Lock()
+	hooks := append([]func(){}, shutdownHooks[registered:]...)
+	shutdownHooks = shutdownHooks[:registered]
+	shutdownMutex.Unlock()
+	t.Cleanup(func() { activeTracer = nil })
+	return exporter, func() {
+		for _, hook := range hooks {
+			hook()
+		}
+	}
+}
+
+// onlySpan returns the one exported span called name
+func onlySpan(t *testing.T, exporter *tracetest.InMemoryExporter, name string) tracetest.SpanStub {
+	t.Helper()
+	var named []tracetest.SpanStub
+	for _, span := range exporter.GetSpans() {
+		if span.Name == name {
+			named = append(named, span)
+		}
+	}
+	if len(named) != 1 {
+		t.Fatalf("exported %d %q spans, want 1", len(named), name)
+	}
+	return named[0]
+}
+
+// spanAttributes returns a span's attributes by key
+func spanAttributes(span tracetest.SpanStub) map[string]interface{} {
+	attributes := make(map[string]interface{}, len(span.Attributes))
+	for _, attribute := range span.Attributes {
+		attributes[string(attribute.Key)] = attribute.Value.AsInterface()
+	}
+	return attributes
+}
+
+func TestTracingCoversTheUpdateCallPath(t *testing.T) {
+	project, _ := singleServiceProject(t)
+	useModelConfig(t, project, `default:
+  provider: "openai"
+  model: "gpt-4o"
+  max_tokens: 1000
+  temperature: 0.5
+openai:
+  api_key: "test-key"
+  models:
+    gpt-4o: "gpt-4o"
+`)
+	useCacheFlags(t, true, true)
+	previousDocTypes := updateDocTypes
+	updateDocTypes = []string{"README"}
+	t.Cleanup(func() { updateDocTypes = previousDocTypes })
+	server := newProviderServer(t, http.StatusOK, chatCompletion("# svc\n\nServes jobs.\n", "stop"))
+	previous := newModelProvider
+	newModelProvider = func(providerName, apiKey string) ModelProvider {
+		return newHTTPProviders(server.URL)[providerName]
+	}
+	t.Cleanup(func() { newModelProvider = previous })
+
+	exporter, finishTrace := useMemoryTracer(t, "docs-cli update")
+	runUpdate(t, context.Background())
+	finishTrace()
+
+	root := onlySpan(t, exporter, "docs-cli update")
+	scan := onlySpan(t, exporter, "scan components")
+	generate := onlySpan(t, exporter, "generate svc/README")
+	call := onlySpan(t, exporter, "POST openai")
+
+	if root.Parent.IsValid() {
+		t.Errorf("root span has parent %s", root.Parent.SpanID())
+	}
+	for _, parent := range []struct {
+		child, parent tracetest.SpanStub
+	}{{scan, root}, {generate, root}, {call, generate}} {
+		if parent.child.SpanContext.TraceID() != root.SpanContext.TraceID() {
+			t.Errorf("%s is in trace %s, want %s", parent.child.Name, parent.child.SpanContext.TraceID(), root.SpanContext.TraceID())
+		}
+		if parent.child.Parent.SpanID() != parent.parent.SpanContext.SpanID() {
+			t.Errorf("%s has parent %s, want %s (%s)", parent.child.Name, parent.child.Parent.SpanID(), parent.parent.SpanContext.SpanID(), parent.parent.Name)
+		}
+	}
+	if call.SpanKind != trace.SpanKindClient {
+		t.Errorf("provider call span kind = %s, want client", call.SpanKind)
+	}
+	if got := spanAttributes(scan)["components"]; got != int64(1) {
+		t.Errorf("scan span components = %v, want 1", got)
+	}
+
+	// Provider, model, tokens, and cost are recorded on the spans, with the model the
+	// optimizer actually sent
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("server received %d requests, want 1", len(requests))
+	}
+	sentModel := requests[0].body["model"]
+	callAttributes := spanAttributes(call)
+	for key, want := range map[string]interface{}{"provider": "openai", "model": sentModel, "prompt_tokens": int64(12), "completion_tokens": int64(34), "http.status_code": int64(http.StatusOK)} {
+		if got := callAttributes[key]; got != want {
+			t.Errorf("provider call span %s = %v, want %v", key, got, want)
+		}
+	}
+	generateAttributes := spanAttributes(generate)
+	for key, want := range map[string]interface{}{"component": "svc", "doc_type": "README", "provider": "openai", "model": sentModel, "cached": false} {
+		if got := generateAttributes[key]; got != want {
+			t.Errorf("generation span %s = %v, want %v", key, got, want)
+		}
+	}
+	for _, key := range []string{"input_tokens", "output_tokens"} {
+		if tokens, ok := generateAttributes[key].(int64); !ok || tokens <= 0 {
+			t.Errorf("generation span %s = %v, want a positive count", key, generateAttributes[key])
+		}
+	}
+	if cost, ok := generateAttributes["estimated_cost_usd"].(float64); !ok || cost <= 0 {
+		t.Errorf("generation span estimated_cost_usd = %v, want a positive cost", generateAttributes["estimated_cost_usd"])
+	}
+
+	// The provider request carries the call span as its W3C trace context
+	if got, want := requests[0].header.Get("traceparent"), "00-"+root.SpanContext.TraceID().String()+"-"+call.SpanContext.SpanID().String()+"-01"; got != want {
+		t.Errorf("traceparent = %q, want %q", got, want)
+	}
+}
+
+func TestFailedProviderCallSpanRecordsTheError(t *testing.T) {
+	server := newProviderServer(t, http.StatusBadRequest, `{"error":{"message":"invalid temperature"}}`)
+	exporter, finishTrace := useMemoryTracer(t, "docs-cli generate")
+
+	_, err := newHTTPProviders(server.URL)["openai"].CallModel(context.Background(), "Document svc.", "gpt-4o", 100, 0.5)
+	if err == nil {
+		t.Fatal("CallModel succeeded against a 400")
+	}
+	finishTrace()
+
+	call := onlySpan(t, exporter, "POST openai")
+	if call.Status.Code != codes.Error || !strings.Contains(call.Status.Description, "invalid temperature") {
+		t.Errorf("span status = %v, want an error with the provider's message", call.Status)
+	}
+	if got := spanAttributes(call)["http.status_code"]; got != int64(http.StatusBadRequest) {
+		t.Errorf("http.status_code = %v, want 400", got)
+	}
+	var apiErr *APIStatusError
+	if !errors.As(err, &apiErr) {
+		t.Errorf("err = %v, want an APIStatusError", err)
+	}
+}
+
+func TestTracingDisabledWithoutEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	activeTracer = nil
+	startTracing("docs-cli update")
+	if activeTracer != nil {
+		activeTracer = nil
+		t.Fatal("tracing enabled without OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+
+	ctx, span := StartSpan(context.Background(), "scan components")
+	span.SetAttribute("components", 1)
+	span.End()
+	if span != nil || SpanFrom(ctx) != nil {
+		t.Error("StartSpan returned a span with tracing disabled")
+	}
+	header := http.Header{}
+	injectTraceContext(ctx, header)
+	if header.Get("traceparent") != "" {
+		t.Error("traceparent injected with tracing disabled")
+	}
+}
+
+func TestTracingContinuesTheParentTrace(t *testing.T) {
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	t.Setenv("TRACEPARENT", "00-"+traceID+"-"+parentID+"-01")
+	exporter, finishTrace := useMemoryTracer(t, "docs-cli update")
+	_, span := StartSpan(context.Background(), "scan components")
+	span.End()
+	finishTrace()
+
+	root := onlySpan(t, exporter, "docs-cli update")
+	if got := root.SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("root span trace = %s, want the parent's %s", got, traceID)
+	}
+	if got := root.Parent.SpanID().String(); got != parentID || !root.Parent.IsRemote() {
+		t.Errorf("root span parent = %s (remote %v), want remote %s", got, root.Parent.IsRemote(), parentID)
+	}
+	if got := onlySpan(t, exporter, "scan components").SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("child span trace = %s, want %s", got, traceID)
+	}
+}
+
+func TestTracingExportsFinishedSpansBeforeTheRunEnds(t *testing.T) {
+	exporter, finishTrace := useMemoryTracer(t, "docs-cli watch")
+	defer finishTrace()
+	_, span := StartSpan(context.Background(), "generate svc/README")
+	span.End()
+
+	// A long-running command exports its spans as they finish rather than holding them
+	// until exit; flushing stands in for the batch timeout here
+	if err := activeTracer.provider.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	onlySpan(t, exporter, "generate svc/README")
+	if spans := exporter.GetSpans(); len(spans) != 1 {
+		t.Errorf("exported %d spans before the run ended, want only the finished one", len(spans))
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// maxContinuations caps the follow-up calls --continue-truncated makes for one document
+const maxContinuations = 3
+
+// continuationPrompt asks the model to pick up a response the output token limit cut off
+const continuationPrompt = "Your previous response was cut off by the output token limit. Continue exactly where it stopped, without repeating any of it and without an introduction."
+
+var (
+	// continueTruncated asks the model to continue responses cut off at max_tokens
+	continueTruncated bool
+	// failTruncated fails documents whose response was cut off at max_tokens
+	failTruncated bool
+)
+
+// errTruncated reports a response cut off at the output token limit under --fail-truncated
+var errTruncated = errors.New("response was cut off at the output token limit")
+
+// truncationKey is the context key for the flag a provider call sets when its response is truncated
+type truncationKey struct{}
+
+// withTruncationFlag returns a context whose provider call reports truncation into the returned flag
+func withTruncationFlag(ctx context.Context) (context.Context, *atomic.Bool) {
+	truncated := new(atomic.Bool)
+	return context.WithValue(ctx, truncationKey{}, truncated), truncated
+}
+
+// markTruncated records that the provider call made with ctx stopped at the output token limit
+func markTruncated(ctx context.Context) {
+	if truncated, ok := ctx.Value(truncationKey{}).(*atomic.Bool); ok {
+		truncated.Store(true)
+	}
+}
+
+// handleTruncation applies --continue-truncated or --fail-truncated to content, a response to
+// messages that stopped at maxTokens, and otherwise warns. call makes one follow-up model call
+// and reports whether its response was truncated too.
+func handleTruncation(ctx context.Context, docType string, maxTokens int, messages []ChatMessage, content string, call func([]ChatMessage) (ModelResult, error)) (string, error) {
+	if failTruncated {
+		return "", fmt.Errorf("%s: %w (max_tokens %d); raise max_tokens or use --continue-truncated", docType, errTruncated, maxTokens)
+	}
+
+	if continueTruncated {
+		for attempt := 1; attempt <= maxContinuations; attempt++ {
+			LogFrom(ctx).WithField("attempt", attempt).
+				WithField("max_tokens", maxTokens).
+				Info("Response truncated at max_tokens, asking the model to continue")
+			followUp := append(messages[:len(messages):len(messages)],
+				ChatMessage{Role: "assistant", Content: content},
+				ChatMessage{Role: "user", Content: continuationPrompt},
+			)
+			response, err := call(followUp)
+			if err != nil {
+				return "", fmt.Errorf("failed to continue truncated response: %w", err)
+			}
+			// The cut can fall mid-word, so the continuation is appended as is
+			content += response.Content
+			if !response.Truncated {
+				return content, nil
+			}
+		}
+	}
+
+	LogFrom(ctx).WithField("max_tokens", maxTokens).
+		WithField("continue_truncated", continueTruncated).
+		Warn("Response truncated at max_tokens; the document is incomplete")
+	fmt.Printf("⚠️  %s response was cut off at max_tokens (%d); the document is incomplete. Raise max_tokens or use --continue-truncated\n", docType, maxTokens)
+	return content, nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// truncatedPart is one scripted model response and whether it stopped at max_tokens
+type truncatedPart struct {
+	content   string
+	truncated bool
+}
+
+// truncatingProvider answers each call with the next of its parts, reporting truncation the
+// way the HTTP providers do, and records the conversations it was sent
+type truncatingProvider struct {
+	mu       sync.Mutex
+	parts    []truncatedPart
+	requests [][]ChatMessage
+}
+
+func (p *truncatingProvider) CallModel(ctx context.Context, prompt, model string, maxTokens int, temperature float64) (string, error) {
+	return p.CallChat(ctx, userChatRequest(prompt, model, maxTokens, temperature))
+}
+
+func (p *truncatingProvider) CallChat(ctx context.Context, request ChatRequest) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, request.Messages)
+	part := p.parts[min(len(p.requests), len(p.parts))-1]
+	if part.truncated {
+		markTruncated(ctx)
+	}
+	return part.content, nil
+}
+
+// useTruncatingProvider routes model calls to a truncatingProvider answering with parts
+func useTruncatingProvider(t *testing.T, parts ...truncatedPart) *truncatingProvider {
+	t.Helper()
+	provider := &truncatingProvider{parts: parts}
+	previous := newModelProvider
+	newModelProvider = func(providerName, apiKey string) ModelProvider { return provider }
+	t.Cleanup(func() { newModelProvider = previous })
+	return provider
+}
+
+// useTruncationFlags sets --continue-truncated and --fail-truncated for the rest of the test
+func useTruncationFlags(t *testing.T, continueFlag, failFlag bool) {
+	t.Helper()
+	previousContinue, previousFail := continueTruncated, failTruncated
+	continueTruncated, failTruncated = continueFlag, failFlag
+	t.Cleanup(func() { continueTruncated, failTruncated = previousContinue, previousFail })
+}
+
+func TestTruncatedResponseModes(t *testing.T) {
+	const cut = "# svc\n\n## Overview\n\nServes jo"
+	tests := []struct {
+		name         string
+		continueFlag bool
+		failFlag     bool
+		parts        []truncatedPart
+		want         string
+		wantErr      error
+		wantCalls    int
+		wantWarning  bool
+	}{
+		{
+			name:        "default warns and keeps the partial document",
+			parts:       []truncatedPart{{cut, true}},
+			want:        cut,
+			wantCalls:   1,
+			wantWarning: true,
+		},
+		{
+			name:      "fail-truncated fails the document",
+			failFlag:  true,
+			parts:     []truncatedPart{{cut, true}},
+			wantErr:   errTruncated,
+			wantCalls: 1,
+		},
+		{
+			name:         "continue-truncated joins the continuation",
+			continueFlag: true,
+			parts:        []truncatedPart{{cut, true}, {"bs.\n", false}},
+			want:         cut + "bs.\n",
+			wantCalls:    2,
+		},
+		{
+			name:         "continue-truncated follows several cuts",
+			continueFlag: true,
+			parts:        []truncatedPart{{cut, true}, {"bs", true}, {".\n", false}},
+			want:         cut + "bs.\n",
+			wantCalls:    3,
+		},
+		{
+			name:         "continue-truncated gives up after the last continuation and warns",
+			continueFlag: true,
+			parts:        []truncatedPart{{cut, true}, {"b", true}, {"s", true}, {".", true}},
+			want:         cut + "bs.",
+			wantCalls:    1 + maxContinuations,
+			wantWarning:  true,
+		},
+		{
+			name:      "complete response is untouched",
+			failFlag:  true,
+			parts:     []truncatedPart{{cut + "bs.\n", false}},
+			want:      cut + "bs.\n",
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestProject(t, "components: []\n")
+			useTruncationFlags(t, tt.continueFlag, tt.failFlag)
+			provider := useTruncatingProvider(t, tt.parts...)
+
+			var content string
+			var err error
+			output := captureStdout(t, func() { content, err = generateReadme("Document the billing service.") })
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if content != tt.want {
+				t.Errorf("content = %q, want %q", content, tt.want)
+			}
+			if calls := len(provider.requests); calls != tt.wantCalls {
+				t.Errorf("provider called %d times, want %d", calls, tt.wantCalls)
+			}
+			if warned := strings.Contains(output, "cut off at max_tokens"); warned != tt.wantWarning {
+				t.Errorf("warning printed = %v, want %v:\n%s", warned, tt.wantWarning, output)
+			}
+		})
+	}
+}
+
+func TestContinuationRequestCarriesThePartialResponse(t *testing.T) {
+	newTestProject(t, "components: []\n")
+	useTruncationFlags(t, true, false)
+	provider := useTruncatingProvider(t, truncatedPart{"# svc\n\nServes jo", true}, truncatedPart{"bs.\n", false})
+
+	if _, err := generateReadme("Document the billing service."); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.requests) != 2 {
+		t.Fatalf("provider called %d times, want 2", len(provider.requests))
+	}
+	first, followUp := provider.requests[0], provider.requests[1]
+	if len(followUp) != len(first)+2 {
+		t.Fatalf("follow-up has %d messages, want the original %d plus two", len(followUp), len(first))
+	}
+	if partial := followUp[len(first)]; partial.Role != "assistant" || partial.Content != "# svc\n\nServes jo" {
+		t.Errorf("follow-up turn = %+v, want the partial response as the assistant", partial)
+	}
+	if ask := followUp[len(first)+1]; ask.Role != "user" || ask.Content != continuationPrompt {
+		t.Errorf("follow-up turn = %+v, want the continuation prompt", ask)
+	}
+}
+
+func TestProvidersReportTruncation(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		body     string
+	}{
+		{"openai finish_reason length", "openai", chatCompletion("# svc\n\nServes jo", "length")},
+		{"openrouter finish_reason length", "openrouter", chatCompletion("# svc\n\nServes jo", "length")},
+		{"anthropic stop_reason max_tokens", "anthropic", `{"model":"served-model","content":[{"type":"text","text":"# svc\n\nServes jo"}],"stop_reason":"max_tokens","usage":{"input_tokens":12,"output_tokens":34}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestProject(t, "components: []\n")
+			server := newProviderServer(t, http.StatusOK, tt.body)
+			provider := newHTTPProviders(server.URL)[tt.provider]
+
+			for i := 0; i < 2; i++ {
+				ctx, truncated := withTruncationFlag(context.Background())
+				content, err := provider.CallChat(ctx, userChatRequest("Document the billing service.", "served-model", 100, 0.5))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if content != "# svc\n\nServes jo" || !truncated.Load() {
+					t.Errorf("call %d = %q, truncated %v; want the partial content reported as truncated", i+1, content, truncated.Load())
+				}
+			}
+			// A truncated response is never cached, so the repeat reached the server
+			if requests := len(server.Requests()); requests != 2 {
+				t.Errorf("server received %d requests, want 2", requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"docs-cli/pkg/config"
+	"docs-cli/pkg/scanner"
+)
+
+// tuiSelection is the interactive picker's state: which components and doc types are chosen,
+// plus cost estimates cached per component/doc type pair
+type tuiSelection struct {
+	components   []scanner.Component
+	docTypes     []string
+	selected     map[string]bool
+	docSelected  map[string]bool
+	estimates    map[string]float64
+	estimateCost func(component scanner.Component, docType string) float64
+}
+
+func newTUISelection(components []scanner.Component, docTypes []string, estimateCost func(scanner.Component, string) float64) *tuiSelection {
+	selection := &tuiSelection{
+		components:   components,
+		docTypes:     docTypes,
+		selected:     make(map[string]bool),
+		docSelected:  make(map[string]bool),
+		estimates:    make(map[string]float64),
+		estimateCost: estimateCost,
+	}
+	for _, docType := range docTypes {
+		selection.docSelected[docType] = true
+	}
+	return selection
+}
+
+// estimate returns the estimated cost of generating docType for component, computed once
+func (s *tuiSelection) estimate(component scanner.Component, docType string) float64 {
+	key := component.Key() + "/" + docType
+	if cost, ok := s.estimates[key]; ok {
+		return cost
+	}
+	cost := s.estimateCost(component, docType)
+	s.estimates[key] = cost
+	return cost
+}
+
+// total returns the number of selected documents and their estimated cost
+func (s *tuiSelection) total() (int, float64) {
+	var docs int
+	var cost float64
+	for _, component := range s.components {
+		if !s.selected[component.Key()] {
+			continue
+		}
+		for _, docType := range s.docTypes {
+			if s.docSelected[docType] {
+				docs++
+				cost += s.estimate(component, docType)
+			}
+		}
+	}
+	return docs, cost
+}
+
+// selectedComponents returns the selected components in order
+func (s *tuiSelection) selectedComponents() []scanner.Component {
+	var components []scanner.Component
+	for _, component := range s.components {
+		if s.selected[component.Key()] {
+			components = append(components, component)
+		}
+	}
+	return components
+}
+
+// rows is the number of toggleable rows: the components, then the doc types
+func (s *tuiSelection) rows() int {
+	return len(s.components) + len(s.docTypes)
+}
+
+// toggle flips the component or doc type on row
+func (s *tuiSelection) toggle(row int) {
+	if row < len(s.components) {
+		key := s.components[row].Key()
+		s.selected[key] = !s.selected[key]
+		return
+	}
+	docType := s.docTypes[row-len(s.components)]
+	s.docSelected[docType] = !s.docSelected[docType]
+}
+
+// selectAll selects every component, or none
+func (s *tuiSelection) selectAll(selected bool) {
+	s.selected = make(map[string]bool)
+	if !selected {
+		return
+	}
+	for _, component := range s.components {
+		s.selected[component.Key()] = true
+	}
+}
+
+func checkbox(checked bool) string {
+	if checked {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+// tuiModel is the bubbletea picker over a tuiSelection. The cursor moves over the
+// components, then the doc types; the program quits with confirmed set when the user asks
+// to generate a non-empty selection.
+type tuiModel struct {
+	selection *tuiSelection
+	cursor    int
+	confirmed bool
+	notice    string
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	m.notice = ""
+	switch key.String() {
+	case "ctrl+c", "esc", "q":
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < m.selection.rows()-1 {
+			m.cursor++
+		}
+	case " ", "x":
+		m.selection.toggle(m.cursor)
+	case "a":
+		m.selection.selectAll(true)
+	case "n":
+		m.selection.selectAll(false)
+	case "enter", "g":
+		if docs, _ := m.selection.total(); docs == 0 {
+			m.notice = "Nothing selected"
+			return m, nil
+		}
+		m.confirmed = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m *tuiModel) View() string {
+	if m.confirmed {
+		return ""
+	}
+	var view strings.Builder
+	view.WriteString("📁 Components:\n")
+	for i, component := range m.selection.components {
+		view.WriteString(fmt.Sprintf("%s %s %s (%s)\n", m.pointer(i), checkbox(m.selection.selected[component.Key()]), component.Key(), component.Path))
+	}
+	view.WriteString("📄 Document types:\n")
+	for i, docType := range m.selection.docTypes {
+		view.WriteString(fmt.Sprintf("%s %s %s\n", m.pointer(len(m.selection.components)+i), checkbox(m.selection.docSelected[docType]), docType))
+	}
+
+	docs, cost := m.selection.total()
+	view.WriteString(fmt.Sprintf("💰 %d documents selected, estimated $%.4f\n", docs, cost))
+	if m.notice != "" {
+		view.WriteString("⚠️  " + m.notice + "\n")
+	}
+	view.WriteString("   ↑/↓ move · space toggle · a all · n none · enter generate · q quit\n")
+	return view.String()
+}
+
+// pointer marks the row under the cursor
+func (m *tuiModel) pointer(row int) string {
+	if row == m.cursor {
+		return ">"
+	}
+	return " "
+}
+
+func runTUI(cmd *cobra.Command, args []string) {
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		fmt.Println("❌ tui needs an interactive terminal; use create or update in scripts and CI")
+		return
+	}
+
+	configManager := config.NewConfigManager()
+	if _, err := configManager.LoadConfig(); err != nil {
+		fmt.Printf("❌ Configuration error: %v\n", err)
+		return
+	}
+
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		fmt.Printf("❌ Error opening source: %v\n", err)
+		return
+	}
+	components, err := fileScanner.ScanComponents(projectRoot)
+	if err != nil {
+		fmt.Printf("❌ Error scanning components: %v\n", err)
+		return
+	}
+	components, err = selectComponents(components)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	selection := newTUISelection(components, orderedUpdateDocTypes(), func(component scanner.Component, docType string) float64 {
+		return estimateDocumentCost(configManager, fileScanner, component, docType)
+	})
+	model := &tuiModel{selection: selection}
+	if _, err := tea.NewProgram(model, tea.WithContext(cmd.Context())).Run(); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	if model.confirmed {
+		generateSelection(cmd, configManager, fileScanner, selection)
+	}
+}
+
+// estimateDocumentCost estimates one document the same way --explain does
+func estimateDocumentCost(configManager config.ConfigManager, fileScanner scanner.FileScanner, component scanner.Component, docType string) float64 {
+	messages, err := BuildConversation(configManager, fileScanner, component, docType)
+	if err != nil {
+		return 0
+	}
+	settings, err := getModelSettingsForDocType(docType)
+	if err != nil {
+		return 0
+	}
+	_, _, costEstimate := optimizeConversation(messages, docType, component.Type, settings.Provider)
+	return costEstimate.TotalEstimatedCost
+}
+
+// generateSelection regenerates every selected document, whether or not its snapshot
+// changed, the way update generates its documents
+func generateSelection(cmd *cobra.Command, configManager config.ConfigManager, fileScanner scanner.FileScanner, selection *tuiSelection) {
+	components := selection.selectedComponents()
+	if err := checkComponentLimit(len(components)); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	lock, err := acquireRunLock(cmd.Context(), false)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	onShutdown(lock.Release)
+
+	snapshotManager := NewSnapshotManager()
+	onShutdown(snapshotManager.Flush)
+
+	docs, _ := selection.total()
+	ctx := cmd.Context()
+	progress := NewProgressReporter(len(components), docs)
+	var counts generationCounts
+	for _, component := range components {
+		componentCtx := WithLogFields(ctx, logrus.Fields{"component": component.Key()})
+		for _, docType := range selection.docTypes {
+			if !selection.docSelected[docType] {
+				continue
+			}
+			if ctx.Err() != nil {
+				progress.Finish()
+				fmt.Printf("⚠️  Generation cancelled after %d documents: %v\n", counts.generated, context.Cause(ctx))
+				return
+			}
+			counts.generate(componentCtx, configManager, fileScanner, snapshotManager, progress, component, docType)
+		}
+		progress.ComponentDone()
+	}
+	progress.Finish()
+
+	if counts.failed > 0 {
+		fmt.Printf("⚠️  Updated %d documents, %d failed\n", counts.generated, counts.failed)
+		return
+	}
+	if counts.skipped > 0 {
+		fmt.Printf("✅ Updated %d documents, skipped %d over --max-cost-per-doc\n", counts.generated, counts.skipped)
+		return
+	}
+	if counts.kept > 0 {
+		fmt.Printf("✅ Updated %d documents, kept %d existing after --show-diff\n", counts.generated, counts.kept)
+		return
+	}
+	fmt.Printf("✅ Updated %d documents\n", counts.generated)
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"docs-cli/pkg/config"
+	"docs-cli/pkg/scanner"
+)
+
+// newTestSelection offers api, web, and jobs/worker with README and SETUP, each document
+// estimated at $0.01 for README and $0.02 for SETUP, and counts the estimates made
+func newTestSelection() (*tuiSelection, *int) {
+	estimates := 0
+	components := []scanner.Component{{Name: "api", Path: "api"}, {Name: "web", Path: "web"}, {Name: "worker", Workspace: "jobs", Path: "jobs/worker"}}
+	selection := newTUISelection(components, []string{"README", "SETUP"}, func(component scanner.Component, docType string) float64 {
+		estimates++
+		if docType == "SETUP" {
+			return 0.02
+		}
+		return 0.01
+	})
+	return selection, &estimates
+}
+
+// selectedKeys lists the selected components in order
+func selectedKeys(selection *tuiSelection) []string {
+	keys := []string{}
+	for _, component := range selection.components {
+		if selection.selected[component.Key()] {
+			keys = append(keys, component.Key())
+		}
+	}
+	return keys
+}
+
+// namedKeys are the keys press takes by name rather than as typed runes
+var namedKeys = map[string]tea.KeyType{"up": tea.KeyUp, "down": tea.KeyDown, "enter": tea.KeyEnter, "space": tea.KeySpace, "esc": tea.KeyEsc, "ctrl+c": tea.KeyCtrlC}
+
+// keyMsg returns the message for a typed rune or a key in namedKeys
+func keyMsg(key string) tea.KeyMsg {
+	if keyType, ok := namedKeys[key]; ok {
+		return tea.KeyMsg{Type: keyType}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+// press sends each key to model as if typed
+func press(model *tuiModel, keys ...string) {
+	for _, key := range keys {
+		model.Update(keyMsg(key))
+	}
+}
+
+func TestTUIModelKeys(t *testing.T) {
+	tests := []struct {
+		name          string
+		keys          []string
+		wantSelected  []string
+		wantDocs      []string
+		wantConfirmed bool
+	}{
+		{name: "nothing selected at first", keys: nil, wantSelected: []string{}, wantDocs: []string{"README", "SETUP"}},
+		{name: "space toggles the row under the cursor", keys: []string{"space", "down", "down", "x"}, wantSelected: []string{"api", "jobs/worker"}, wantDocs: []string{"README", "SETUP"}},
+		{name: "toggling twice deselects", keys: []string{"space", "space", "j", "space"}, wantSelected: []string{"web"}, wantDocs: []string{"README", "SETUP"}},
+		{name: "cursor stops at the first row", keys: []string{"up", "k", "space"}, wantSelected: []string{"api"}, wantDocs: []string{"README", "SETUP"}},
+		{name: "all then none", keys: []string{"a", "n"}, wantSelected: []string{}, wantDocs: []string{"README", "SETUP"}},
+		{name: "all then toggle one off", keys: []string{"a", "down", "space"}, wantSelected: []string{"api", "jobs/worker"}, wantDocs: []string{"README", "SETUP"}},
+		// The doc types follow the components
+		{name: "doc type rows toggle doc types", keys: []string{"down", "down", "down", "down", "space"}, wantSelected: []string{}, wantDocs: []string{"README"}},
+		{name: "cursor stops at the last row", keys: []string{"j", "j", "j", "j", "j", "j", "j", "space"}, wantSelected: []string{}, wantDocs: []string{"README"}},
+		{name: "enter confirms a selection", keys: []string{"space", "enter"}, wantSelected: []string{"api"}, wantDocs: []string{"README", "SETUP"}, wantConfirmed: true},
+		{name: "enter with nothing selected does not", keys: []string{"enter"}, wantSelected: []string{}, wantDocs: []string{"README", "SETUP"}},
+		{name: "enter with no doc types does not", keys: []string{"a", "down", "down", "down", "space", "down", "space", "g"}, wantSelected: []string{"api", "web", "jobs/worker"}, wantDocs: nil},
+		{name: "quit", keys: []string{"space", "q"}, wantSelected: []string{"api"}, wantDocs: []string{"README", "SETUP"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection, _ := newTestSelection()
+			model := &tuiModel{selection: selection}
+			press(model, tt.keys...)
+			if model.confirmed != tt.wantConfirmed {
+				t.Errorf("confirmed = %v, want %v", model.confirmed, tt.wantConfirmed)
+			}
+			if got := selectedKeys(selection); !reflect.DeepEqual(got, tt.wantSelected) {
+				t.Errorf("selected = %q, want %q", got, tt.wantSelected)
+			}
+			var docs []string
+			for _, docType := range selection.docTypes {
+				if selection.docSelected[docType] {
+					docs = append(docs, docType)
+				}
+			}
+			if !reflect.DeepEqual(docs, tt.wantDocs) {
+				t.Errorf("doc types = %q, want %q", docs, tt.wantDocs)
+			}
+		})
+	}
+}
+
+func TestTUIModelQuitKeys(t *testing.T) {
+	for _, key := range []string{"q", "esc", "ctrl+c"} {
+		selection, _ := newTestSelection()
+		model := &tuiModel{selection: selection}
+		press(model, "space")
+		if _, cmd := model.Update(keyMsg(key)); cmd == nil || cmd() != tea.Quit() {
+			t.Errorf("%s did not quit", key)
+		}
+		if model.confirmed {
+			t.Errorf("%s confirmed the selection", key)
+		}
+	}
+}
+
+func TestTUIModelView(t *testing.T) {
+	selection, _ := newTestSelection()
+	model := &tuiModel{selection: selection}
+	press(model, "down", "space", "down", "down", "down", "space")
+
+	view := model.View()
+	for _, want := range []string{
+		"  [ ] api (api)",
+		"  [x] web (web)",
+		"  [ ] jobs/worker (jobs/worker)",
+		"  [x] README",
+		"> [ ] SETUP",
+		// The estimate follows the selection as it changes
+		"💰 1 documents selected, estimated $0.0100",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view is missing %q:\n%s", want, view)
+		}
+	}
+
+	press(model, "n", "enter")
+	if view := model.View(); !strings.Contains(view, "Nothing selected") {
+		t.Errorf("view does not say nothing is selected:\n%s", view)
+	}
+}
+
+func TestTUISelectionTotal(t *testing.T) {
+	selection, estimates := newTestSelection()
+	if docs, cost := selection.total(); docs != 0 || cost != 0 {
+		t.Errorf("empty selection total = %d docs, $%.2f", docs, cost)
+	}
+
+	selection.toggle(0)
+	selection.toggle(1)
+	if docs, cost := selection.total(); docs != 4 || !approxEqual(cost, 0.06) {
+		t.Errorf("total = %d docs, $%.4f; want 4 docs, $0.06", docs, cost)
+	}
+	selection.toggle(4)
+	if docs, cost := selection.total(); docs != 2 || !approxEqual(cost, 0.02) {
+		t.Errorf("total without SETUP = %d docs, $%.4f; want 2 docs, $0.02", docs, cost)
+	}
+
+	// Each pair is estimated once however often the total is shown
+	selection.toggle(4)
+	selection.total()
+	selection.total()
+	if *estimates != 4 {
+		t.Errorf("made %d estimates, want 4, one per selected pair", *estimates)
+	}
+}
+
+func approxEqual(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
+
+func TestTUIGeneratesOnlyTheSelection(t *testing.T) {
+	project := newTestProject(t, `components:
+  - name: "api"
+    path: "api"
+    type: "service"
+  - name: "web"
+    path: "web"
+    type: "service"
+`)
+	project.WriteFile("api/main.go", "package main\n\nfunc main() {}\n")
+	project.WriteFile("web/main.go", "package main\n\nfunc main() {}\n")
+	useCacheFlags(t, true, true)
+	provider, _ := useScriptedProvider(t)
+
+	configManager := config.NewConfigManager()
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selection := newTUISelection(project.Components(), orderedUpdateDocTypes(), func(scanner.Component, string) float64 { return 0 })
+	selection.toggle(1)
+	selection.toggle(len(selection.components) + slices.Index(selection.docTypes, "SETUP"))
+
+	output := captureStdout(t, func() {
+		runCommand(t, context.Background(), func(cmd *cobra.Command, args []string) {
+			generateSelection(cmd, configManager, fileScanner, selection)
+		})
+	})
+
+	var want []string
+	for _, docType := range orderedUpdateDocTypes() {
+		if docType != "SETUP" {
+			want = append(want, "web/"+docType)
+		}
+	}
+	if calls := int(provider.calls.Load()); calls != len(want) {
+		t.Errorf("provider called %d times, want %d", calls, len(want))
+	}
+	var written []string
+	for _, component := range project.Components() {
+		for _, docType := range orderedUpdateDocTypes() {
+			if _, err := os.Stat(docOutputPath(component, docType)); err == nil {
+				written = append(written, component.Key()+"/"+docType)
+			}
+		}
+	}
+	sort.Strings(want)
+	sort.Strings(written)
+	if !reflect.DeepEqual(written, want) {
+		t.Errorf("wrote %q, want %q", written, want)
+	}
+	if !strings.Contains(output, fmt.Sprintf("✅ Updated %d documents", len(want))) {
+		t.Errorf("summary missing:\n%s", output)
+	}
+}
+
+// runGenerateSelection generates every doc type of the project's components the way the
+// TUI does on confirm, and returns what it printed
+func runGenerateSelection(t *testing.T, project *testProject) string {
+	t.Helper()
+	configManager := config.NewConfigManager()
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selection := newTUISelection(project.Components(), []string{"README"}, func(scanner.Component, string) float64 { return 0 })
+	selection.selectAll(true)
+	return captureStdout(t, func() {
+		runCommand(t, context.Background(), func(cmd *cobra.Command, args []string) {
+			generateSelection(cmd, configManager, fileScanner, selection)
+		})
+	})
+}
+
+func TestTUIGenerationHonorsComponentLimit(t *testing.T) {
+	project := twoComponentProject(t)
+	useCacheFlags(t, true, true)
+	provider, _ := useScriptedProvider(t)
+	useComponentLimitFlags(t, 1, true, false)
+
+	output := runGenerateSelection(t, project)
+	if !strings.Contains(output, "❌") || !strings.Contains(output, "more than the limit of 1") {
+		t.Errorf("selection over --max-components was not refused:\n%s", output)
+	}
+	if calls := provider.calls.Load(); calls != 0 {
+		t.Errorf("provider called %d times for a refused selection", calls)
+	}
+}
+
+func TestTUIGenerationReportsSkippedDocuments(t *testing.T) {
+	project, _ := singleServiceProject(t)
+	useModelConfig(t, project, `default:
+  provider: "openai"
+  model: "gpt-4o"
+  max_tokens: 1000
+  temperature: 0.5
+openai:
+  api_key: "test-key"
+  models:
+    gpt-4o: "gpt-4o"
+    gpt-3.5-turbo: "gpt-3.5-turbo"
+`)
+	useCacheFlags(t, true, true)
+	useScriptedProvider(t)
+	maxCostPerDoc = 0.000001
+	t.Cleanup(func() { maxCostPerDoc = 0 })
+
+	// A document over --max-cost-per-doc is skipped, not a failure
+	output := runGenerateSelection(t, project)
+	if strings.Contains(output, "❌") || !strings.Contains(output, "⏭️  Skipped svc/README") {
+		t.Errorf("over-budget document not reported as skipped:\n%s", output)
+	}
+	if !strings.Contains(output, "✅ Updated 0 documents, skipped 1 over --max-cost-per-doc") {
+		t.Errorf("summary does not report the skip:\n%s", output)
+	}
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"docs-cli/pkg/config"
+	"docs-cli/pkg/scanner"
+)
+
+// updateDocTypes are the document types refreshed by the update command
+var updateDocTypes = []string{"README", "SETUP", "ARCHITECTURE", "CHECKLIST"}
+
+// orderedUpdateDocTypes returns updateDocTypes in templates.chain_order, so each
+// regenerated document sees the fresh versions of those before it
+func orderedUpdateDocTypes() []string {
+	var ordered []string
+	for _, docType := range chainOrder() {
+		for _, updateDocType := range updateDocTypes {
+			if docType == updateDocType {
+				ordered = append(ordered, docType)
+			}
+		}
+	}
+	return ordered
+}
+
+var (
+	reportOnly  bool
+	waitLock    bool
+	resumeRun   bool
+	onlyMissing bool
+)
+
+func updateAllDocumentation(cmd *cobra.Command, args []string) {
+	if onlyMissing && force {
+		fmt.Println("❌ --only-missing and --force cannot be used together")
+		os.Exit(1)
+	}
+
+	// Serialize snapshot writers; the lock is released on exit, signal, or timeout
+	if !reportOnly {
+		lock, err := acquireRunLock(cmd.Context(), waitLock)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		onShutdown(lock.Release)
+	}
+
+	configManager := config.NewConfigManager()
+	if _, err := configManager.LoadConfig(); err != nil {
+		fmt.Printf("❌ Configuration error: %v\n", err)
+		return
+	}
+
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		fmt.Printf("❌ Error opening source: %v\n", err)
+		return
+	}
+	_, scanSpan := StartSpan(cmd.Context(), "scan components")
+	components, err := fileScanner.ScanComponents(projectRoot)
+	scanSpan.SetAttribute("components", len(components))
+	scanSpan.RecordError(err)
+	scanSpan.End()
+	if err != nil {
+		fmt.Printf("❌ Error scanning components: %v\n", err)
+		return
+	}
+
+	components, err = selectComponents(components)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	docTypes := orderedUpdateDocTypes()
+	snapshotManager := NewSnapshotManager()
+	onShutdown(snapshotManager.Flush)
+	report := snapshotManager.GetCostSavingsEstimate(components, docTypes)
+	printCostSavingsReport(report)
+
+	if reportOnly {
+		return
+	}
+	if err := checkComponentLimit(len(components)); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		runShutdown() // release the run lock before exiting non-zero
+		os.Exit(1)
+	}
+	if force {
+		fmt.Println("⚠️  --force set: regenerating every document")
+	}
+
+	journal, err := OpenRunJournal(runJournalPath(), resumeRun)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	if resumeRun {
+		if done := len(journal.CompletedPairs()); done > 0 {
+			fmt.Printf("⏩ Resuming run started %s: skipping %d completed documents\n", journal.StartedAt.Format(time.RFC3339), done)
+		}
+	}
+
+	ctx := cmd.Context()
+	totalDocs := report.DocumentsToRegenerate
+	if force {
+		totalDocs = report.TotalDocuments
+	}
+	if onlyMissing {
+		totalDocs = report.TotalDocuments - countExistingDocs(components, docTypes)
+	}
+	progress := NewProgressReporter(len(components), totalDocs)
+	var counts generationCounts
+	var existing int
+	for _, component := range components {
+		componentCtx := WithLogFields(ctx, logrus.Fields{"component": component.Key()})
+		for _, docType := range docTypes {
+			if ctx.Err() != nil {
+				progress.Finish()
+				fmt.Printf("⚠️  Update cancelled after %d documents: %v\n", counts.generated, context.Cause(ctx))
+				fmt.Println("   Rerun with --resume to continue where this run stopped")
+				return
+			}
+			if journal.IsCompleted(component.Key(), docType) {
+				continue
+			}
+			if onlyMissing {
+				// Backfill: anything already on disk is left alone, whatever the snapshot says
+				if _, err := os.Stat(docOutputPath(component, docType)); err == nil {
+					existing++
+					continue
+				}
+			} else if !force {
+				regenerate, reason := snapshotManager.ShouldRegenerateDoc(component, docType)
+				if !regenerate {
+					continue
+				}
+				LogFrom(componentCtx).WithField("doc_type", docType).
+					WithField("reason", reason).
+					Info("Regenerating document")
+			}
+
+			if !counts.generate(componentCtx, configManager, fileScanner, snapshotManager, progress, component, docType) {
+				continue
+			}
+			journal.MarkCompleted(component.Key(), docType)
+		}
+		progress.ComponentDone()
+	}
+	progress.Finish()
+
+	if counts.failed > 0 {
+		fmt.Printf("⚠️  Updated %d documents, %d failed; rerun with --resume to retry only the failures\n", counts.generated, counts.failed)
+		return
+	}
+	if err := journal.Clear(); err != nil {
+		LogFrom(ctx).WithError(err).Warn("Failed to clear run journal")
+	}
+	if onlyMissing {
+		fmt.Printf("✅ Generated %d missing documents, left %d existing untouched\n", counts.generated, existing)
+		return
+	}
+	if counts.skipped > 0 {
+		fmt.Printf("✅ Updated %d documents, skipped %d over --max-cost-per-doc\n", counts.generated, counts.skipped)
+		return
+	}
+	if counts.kept > 0 {
+		fmt.Printf("✅ Updated %d documents, kept %d existing after --show-diff\n", counts.generated, counts.kept)
+		return
+	}
+	fmt.Printf("✅ Updated %d documents\n", counts.generated)
+}
+
+// countExistingDocs counts the component/doc type pairs whose output file already exists
+func countExistingDocs(components []scanner.Component, docTypes []string) int {
+	var existing int
+	for _, component := range components {
+		for _, docType := range docTypes {
+			if _, err := os.Stat(docOutputPath(component, docType)); err == nil {
+				existing++
+			}
+		}
+	}
+	return existing
+}
+
+func showCostReport(cmd *cobra.Command, args []string) {
+	history := getTokenHistory()
+	docTypes := history.DocTypes()
+	if len(docTypes) == 0 {
+		fmt.Println("📊 No completion history yet; output estimates use fixed fractions of input")
+		return
+	}
+
+	fmt.Println("📊 Learned output-token estimates:")
+	for _, docType := range docTypes {
+		median, count := history.Median(docType)
+		fmt.Printf("• %s: median %d tokens over %d completions\n", docType, median, count)
+	}
+}
+
+// printCostSavingsReport prints how many documents incremental updates will skip and the estimated savings
+func printCostSavingsReport(report CostSavingsReport) {
+	fmt.Printf("📊 %d of %d components changed\n", report.ComponentsChanged, report.TotalComponents)
+	fmt.Printf("💰 Skipping %d of %d docs, estimated savings $%.2f (~%d tokens)\n",
+		report.DocumentsSkipped, report.TotalDocuments, report.EstimatedCostSaved, report.EstimatedTokensSaved)
+}
+
+// generationCounts tallies the documents of a generating run by outcome
+type generationCounts struct {
+	generated, failed, skipped, kept int
+}
+
+// generate runs regenerateDocument for one document in its own span and reports the outcome
+// on progress: a document over --max-cost-per-doc is skipped, one whose overwrite was declined
+// after --show-diff is kept, and any other error is a failure. It returns whether the document
+// was written.
+func (c *generationCounts) generate(ctx context.Context, configManager config.ConfigManager, fileScanner scanner.FileScanner, snapshotManager *SnapshotManager, progress *ProgressReporter, component scanner.Component, docType string) bool {
+	docCtx, docSpan := StartSpan(WithLogFields(ctx, logrus.Fields{"doc_type": docType}), "generate "+component.Key()+"/"+docType)
+	docSpan.SetAttribute("component", component.Key())
+	docSpan.SetAttribute("doc_type", docType)
+	err := regenerateDocument(docCtx, configManager, fileScanner, snapshotManager, component, docType)
+	docSpan.RecordError(err)
+	docSpan.End()
+
+	switch {
+	case errors.Is(err, errDocOverBudget):
+		progress.Printf("⏭️  Skipped %s/%s: %v\n", component.Key(), docType, err)
+		progress.DocDone(true)
+		c.skipped++
+		return false
+	case errors.Is(err, errOverwriteDeclined):
+		progress.Printf("⏭️  Kept existing %s/%s\n", component.Key(), docType)
+		progress.DocDone(true)
+		c.kept++
+		return false
+	case err != nil:
+		progress.Printf("❌ %s/%s: %v\n", component.Key(), docType, err)
+		progress.DocDone(false)
+		c.failed++
+		return false
+	}
+	progress.Printf("📝 Updated %s/%s\n", component.Key(), docType)
+	progress.DocDone(true)
+	c.generated++
+	return true
+}
+
+// regenerateDocument generates one document, writes it, and records the snapshot on success.
+// With --combined, the component's combined file is refreshed to include the new document.
+func regenerateDocument(ctx context.Context, configManager config.ConfigManager, fileScanner scanner.FileScanner, snapshotManager *SnapshotManager, component scanner.Component, docType string) error {
+	messages, err := BuildConversation(configManager, fileScanner, component, docType)
+	if err != nil {
+		return fmt.Errorf("failed to build prompt: %w", err)
+	}
+
+	content, err := callModelAPIWithConversation(ctx, messages, docType, component.Type, "")
+	if errors.Is(err, errContextLength) {
+		// Drop the lower-priority half of the source files and try once more
+		trimmed, ok := dropLowPriorityFiles(fileScanner, component)
+		if !ok {
+			return err
+		}
+		LogFrom(ctx).WithField("files", len(trimmed.Files)).
+			Warn("Prompt exceeds the context window, retrying with fewer source files")
+		if messages, err = BuildConversation(configManager, fileScanner, trimmed, docType); err != nil {
+			return fmt.Errorf("failed to build prompt: %w", err)
+		}
+		content, err = callModelAPIWithConversation(ctx, messages, docType, component.Type, "")
+	}
+	if err != nil {
+		return err
+	}
+
+	required := configManager.GetTemplatesConfig().RequiredSections[docType]
+	missing := missingSections(content, required)
+	for attempt := 0; len(missing) > 0 && strictSections && attempt < sectionNudgeRetries; attempt++ {
+		LogFrom(ctx).WithField("missing_sections", missing).
+			Info("Retrying generation to add missing sections")
+		messages = append(messages,
+			ChatMessage{Role: "assistant", Content: content},
+			ChatMessage{Role: "user", Content: sectionNudge(docType, missing)},
+		)
+		if content, err = callModelAPIWithConversation(ctx, messages, docType, component.Type, ""); err != nil {
+			return err
+		}
+		missing = missingSections(content, required)
+	}
+	if len(missing) > 0 {
+		if strictSections {
+			return fmt.Errorf("missing required sections: %s", strings.Join(missing, ", "))
+		}
+		LogFrom(ctx).WithField("missing_sections", missing).
+			Warn("Generated document is missing required sections")
+		fmt.Printf("⚠️  %s/%s is missing sections: %s\n", component.Key(), docType, strings.Join(missing, ", "))
+	}
+
+	outputPath := docOutputPath(component, docType)
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if docType == "CHECKLIST" && mergeChecklist {
+		if content, err = mergeExistingChecklist(outputPath, content); err != nil {
+			return fmt.Errorf("failed to merge checklist: %w", err)
+		}
+	}
+	if err := writeGeneratedDocument(outputPath, content); err != nil {
+		return err
+	}
+
+	snapshotManager.UpdateSnapshot(component, docType, content)
+	if combined {
+		return writeCombinedDocument(component)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"docs-cli/pkg/config"
+	"docs-cli/pkg/scanner"
+)
+
+// singleServiceProject is a project with one component, svc, and a source file
+func singleServiceProject(t *testing.T) (*testProject, scanner.Component) {
+	t.Helper()
+	project := newTestProject(t, `components:
+  - name: "svc"
+    path: "svc"
+    type: "service"
+`)
+	project.WriteFile("svc/main.go", "// Package main serves jobs.\npackage main\n\nfunc main() {}\n")
+	return project, project.Component("svc")
+}
+
+// regenerate runs regenerateDocument for each doc type with the project's configuration
+func regenerate(t *testing.T, snapshotManager *SnapshotManager, component scanner.Component, docTypes ...string) error {
+	t.Helper()
+	configManager := config.NewConfigManager()
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, docType := range docTypes {
+		if err := regenerateDocument(context.Background(), configManager, fileScanner, snapshotManager, component, docType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRegenerateDocumentWritesCombinedFile(t *testing.T) {
+	project, svc := singleServiceProject(t)
+	combined = true
+	t.Cleanup(func() { combined = false })
+
+	if err := regenerate(t, NewSnapshotManager(), svc, "README", "CHECKLIST", "ARCHITECTURE"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"svc/README.md", "svc/docs/ARCHITECTURE.md", "svc/docs/CHECKLIST.yaml"} {
+		if _, err := os.Stat(filepath.Join(project.Root, path)); err != nil {
+			t.Errorf("per-type document %s not kept: %v", path, err)
+		}
+	}
+
+	content := project.ReadFile("svc/docs/svc.docs.md")
+	if !strings.HasPrefix(content, "# svc Documentation\n\n") {
+		t.Errorf("combined file starts with %q", content[:min(len(content), 40)])
+	}
+	architecture := strings.Index(content, "## ARCHITECTURE\n")
+	readme := strings.Index(content, "## README\n")
+	checklist := strings.Index(content, "## CHECKLIST\n\n```yaml\n")
+	if architecture < 0 || readme < 0 || checklist < 0 {
+		t.Fatalf("combined file is missing sections:\n%s", content)
+	}
+	if !(architecture < readme && readme < checklist) {
+		t.Errorf("sections out of chain order: ARCHITECTURE@%d README@%d CHECKLIST@%d", architecture, readme, checklist)
+	}
+	if strings.Contains(content, "## SETUP") {
+		t.Error("combined file has a section for a document that was never generated")
+	}
+	if !strings.HasSuffix(strings.TrimRight(content, "\n"), "```") {
+		t.Error("the CHECKLIST section should close its yaml fence")
+	}
+	if !strings.Contains(content, strings.TrimRight(project.ReadFile("svc/README.md"), "\n")) {
+		t.Error("README section does not match README.md")
+	}
+}
+
+func TestRegenerateDocumentWithoutCombined(t *testing.T) {
+	project, svc := singleServiceProject(t)
+	if err := regenerate(t, NewSnapshotManager(), svc, "README"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(project.Root, "svc", "docs", "svc.docs.md")); !os.IsNotExist(err) {
+		t.Errorf("combined file written without --combined: %v", err)
+	}
+}
+
+func TestUpdateOnlyMissingLeavesExistingDocsUntouched(t *testing.T) {
+	project, svc := singleServiceProject(t)
+	onlyMissing = true
+	t.Cleanup(func() { onlyMissing = false })
+	const handWritten = "# svc\n\nWritten by hand.\n"
+	readme := project.WriteFile("svc/README.md", handWritten)
+	before, err := os.Stat(readme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := captureStdout(t, func() { runUpdate(t, context.Background()) })
+
+	after, err := os.Stat(readme)
+	if err != nil || project.ReadFile("svc/README.md") != handWritten || !after.ModTime().Equal(before.ModTime()) {
+		t.Errorf("existing README.md was rewritten: %v", err)
+	}
+	if strings.Contains(output, "svc/README") || strings.Contains(output, "already exists") {
+		t.Errorf("existing README should be skipped silently:\n%s", output)
+	}
+	var missing []string
+	for _, docType := range orderedUpdateDocTypes() {
+		if docType == "README" {
+			continue
+		}
+		if _, err := os.Stat(docOutputPath(svc, docType)); err != nil {
+			t.Errorf("missing %s was not generated: %v", docType, err)
+		}
+		missing = append(missing, docType)
+	}
+	want := fmt.Sprintf("Generated %d missing documents, left 1 existing untouched", len(missing))
+	if !strings.Contains(output, want) {
+		t.Errorf("output does not report %q:\n%s", want, output)
+	}
+	recorded := NewSnapshotManager().snapshots[svc.Key()].DocsGenerated
+	if _, ok := recorded["README"]; ok || len(recorded) != len(missing) {
+		t.Errorf("snapshot records %d generated docs, want the %d missing ones and not README", len(recorded), len(missing))
+	}
+}
+
+func TestUpdateOnlyMissingWithForceExitsNonZero(t *testing.T) {
+	project, _ := singleServiceProject(t)
+	output, code := runCLI(t, project, "update", "--only-missing", "--force")
+	if code == 0 || !strings.Contains(output, "--only-missing and --force cannot be used together") {
+		t.Errorf("update --only-missing --force exited %d, want non-zero:\n%s", code, output)
+	}
+}
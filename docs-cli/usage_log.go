@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// UsageRecord is one billed API call, appended to the usage log for cost-export
+type UsageRecord struct {
+	Timestamp    time.Time `json:"timestamp"`
+	Component    string    `json:"component,omitempty"`
+	DocType      string    `json:"doc_type"`
+	Provider     string    `json:"provider"`
+	Model        string    `json:"model"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	Cost         float64   `json:"cost"`
+}
+
+var usageLogMutex sync.Mutex
+
+// usageLogPath is where every run appends its API calls, one JSON record per line
+func usageLogPath() string {
+	return filepath.Join(projectRoot, ".docs-cli-usage.jsonl")
+}
+
+// logFieldString returns a string field attached to ctx with WithLogFields, or ""
+func logFieldString(ctx context.Context, key string) string {
+	value, _ := LogFieldsFrom(ctx)[key].(string)
+	return value
+}
+
+// appendUsageRecord adds a call to the usage log; failures are logged, never fatal
+func appendUsageRecord(ctx context.Context, record UsageRecord) {
+	if record.Component == "" {
+		record.Component = logFieldString(ctx, "component")
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		LogFrom(ctx).WithError(err).Warn("Failed to encode usage record")
+		return
+	}
+
+	usageLogMutex.Lock()
+	defer usageLogMutex.Unlock()
+
+	file, err := os.OpenFile(usageLogPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		LogFrom(ctx).WithError(err).Warn("Failed to open usage log")
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		LogFrom(ctx).WithError(err).Warn("Failed to write usage log")
+	}
+}
+
+// readUsageRecords loads every record from the usage log at path
+func readUsageRecords(path string) ([]UsageRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []UsageRecord
+	lines := bufio.NewScanner(file)
+	for line := 1; lines.Scan(); line++ {
+		if len(lines.Bytes()) == 0 {
+			continue
+		}
+		var record UsageRecord
+		if err := json.Unmarshal(lines.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		records = append(records, record)
+	}
+	if err := lines.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return records, nil
+}
@@ -30,6 +30,8 @@ type Task struct {
 	Priority     string   `yaml:"priority"`
 	Description  string   `yaml:"description"`
 	Dependencies []string `yaml:"dependencies,omitempty"`
+	// Removed flags a task kept by --merge that the latest generation no longer produced
+	Removed bool `yaml:"removed,omitempty"`
 }
 
 const (
@@ -87,10 +89,15 @@ func validateComponentName(name string) error {
 		return errors.New("component name too long (max 100 characters)")
 	}
 	
+	// Allow a single workspace/name separator
+	if strings.Count(name, "/") > 1 || strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") {
+		return fmt.Errorf("component name must be name or workspace/name: %s", name)
+	}
+	
 	// Only allow alphanumeric, hyphens, and underscores
 	for _, r := range name {
 		if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || 
-			 (r >= '0' && r <= '9') || r == '-' || r == '_') {
+			 (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '/') {
 			return fmt.Errorf("component name contains invalid character: %c", r)
 		}
 	}
@@ -164,6 +171,16 @@ func validatePrompt(prompt string) error {
 	return nil
 }
 
+// validatePromptOverrides applies prompt validation, including the length limit, to each component prompt override
+func validatePromptOverrides(componentName string, overrides map[string]string) error {
+	for docType, override := range overrides {
+		if err := validatePrompt(override); err != nil {
+			return fmt.Errorf("prompt override for %s/%s: %w", componentName, docType, err)
+		}
+	}
+	return nil
+}
+
 // ValidateFileSize checks if a file size is within limits
 func ValidateFileSize(size int64) error {
 	if size > MaxFileSize {
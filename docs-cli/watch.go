@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"docs-cli/pkg/config"
+	"docs-cli/pkg/scanner"
+)
+
+var (
+	// watchDebounce is how long a component's sources must stay unchanged before
+	// regenerating, so a burst of saves triggers one generation
+	watchDebounce = 2 * time.Second
+	// watchMinInterval is the minimum time between generations of the same component
+	watchMinInterval time.Duration
+)
+
+// watchState tracks one component between source changes
+type watchState struct {
+	changedAt     time.Time
+	pending       bool
+	lastGenerated time.Time
+}
+
+// changed records a source change at now, restarting the debounce
+func (s *watchState) changed(now time.Time) {
+	s.changedAt = now
+	s.pending = true
+}
+
+// nextDue returns when the component may next be regenerated: once its sources have settled
+// for watchDebounce, and watchMinInterval after its last generation. It returns false when
+// no change is pending.
+func (s *watchState) nextDue() (time.Time, bool) {
+	if !s.pending {
+		return time.Time{}, false
+	}
+	at := s.changedAt.Add(watchDebounce)
+	if earliest := s.lastGenerated.Add(watchMinInterval); earliest.After(at) {
+		at = earliest
+	}
+	return at, true
+}
+
+// due reports whether to regenerate the component now, and if so marks it generated
+func (s *watchState) due(now time.Time) bool {
+	at, pending := s.nextDue()
+	if !pending || now.Before(at) {
+		return false
+	}
+	s.pending = false
+	s.lastGenerated = now
+	return true
+}
+
+func watchDocumentation(cmd *cobra.Command, args []string) {
+	// Watch writes snapshots, so it holds the same lock as update for its whole run
+	lock, err := acquireRunLock(cmd.Context(), waitLock)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	onShutdown(lock.Release)
+
+	configManager := config.NewConfigManager()
+	if _, err := configManager.LoadConfig(); err != nil {
+		fmt.Printf("❌ Configuration error: %v\n", err)
+		return
+	}
+
+	fileScanner, err := newFileScanner(configManager)
+	if err != nil {
+		fmt.Printf("❌ Error opening source: %v\n", err)
+		return
+	}
+
+	components, err := watchedComponents(fileScanner, args)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	if err := checkComponentLimit(len(components)); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		runShutdown() // release the run lock before exiting non-zero
+		os.Exit(1)
+	}
+
+	watcher, err := newComponentWatcher(components)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	defer watcher.Close()
+
+	snapshotManager := NewSnapshotManager()
+	onShutdown(snapshotManager.Flush)
+	reloadOnSIGHUP()
+
+	states := make(map[string]*watchState, len(components))
+	for _, component := range components {
+		states[component.Key()] = &watchState{}
+	}
+
+	fmt.Printf("👀 Watching %d components (regenerating %s after changes settle, at most once per component every %s); press Ctrl+C to stop\n",
+		len(components), watchDebounce, watchMinInterval)
+
+	ctx := cmd.Context()
+	var wake <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("👋 Stopped watching")
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if component, changed := watcher.changedComponent(event); changed {
+				states[component.Key()].changed(time.Now())
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			LogFrom(ctx).WithError(err).Warn("File watcher error")
+		case <-wake:
+		}
+
+		now := time.Now()
+		for i, component := range components {
+			if states[component.Key()].due(now) {
+				components[i] = regenerateChangedDocs(WithLogFields(ctx, logrus.Fields{"component": component.Key()}), configManager, fileScanner, snapshotManager, component)
+			}
+		}
+
+		// Sleep until the next pending component settles, or the next event
+		wake = nil
+		var next time.Time
+		for _, state := range states {
+			if at, pending := state.nextDue(); pending && (next.IsZero() || at.Before(next)) {
+				next = at
+			}
+		}
+		if !next.IsZero() {
+			wake = time.After(time.Until(next))
+		}
+	}
+}
+
+// watchedComponents returns the component named in args, or every selected component
+func watchedComponents(fileScanner scanner.FileScanner, args []string) ([]scanner.Component, error) {
+	if len(args) == 1 {
+		component, err := findComponentByName(fileScanner, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return []scanner.Component{component}, nil
+	}
+
+	components, err := fileScanner.ScanComponents(projectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("error scanning components: %w", err)
+	}
+	return selectComponents(components)
+}
+
+// componentWatcher reports changes to the watched components' sources through fsnotify
+type componentWatcher struct {
+	*fsnotify.Watcher
+	components []scanner.Component
+	// outputs are the documents docs-cli writes into the components itself
+	outputs map[string]bool
+}
+
+// newComponentWatcher watches every directory of each component
+func newComponentWatcher(components []scanner.Component) (*componentWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start file watcher: %w", err)
+	}
+
+	w := &componentWatcher{Watcher: watcher, components: components, outputs: make(map[string]bool)}
+	for _, component := range components {
+		for _, docType := range append([]string{"EXECUTIVE_SUMMARY"}, config.DefaultChainOrder...) {
+			w.outputs[docOutputPath(component, docType)] = true
+		}
+		if err := w.addTree(component.Dir()); err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", component.Key(), err)
+		}
+	}
+	return w, nil
+}
+
+// addTree watches dir and the directories below it, since fsnotify watches are not
+// recursive; hidden directories such as .git are skipped
+func (w *componentWatcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if path != dir && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return w.Add(path)
+	})
+}
+
+// changedComponent returns the component whose sources event changed, the innermost one
+// when component directories nest. Permission changes and the documents docs-cli writes
+// are not source changes. A directory created inside a component is watched from then on.
+func (w *componentWatcher) changedComponent(event fsnotify.Event) (scanner.Component, bool) {
+	if event.Op == fsnotify.Chmod || w.outputs[event.Name] {
+		return scanner.Component{}, false
+	}
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := w.addTree(event.Name); err != nil {
+				LogWithContext().WithError(err).WithField("path", event.Name).Warn("Failed to watch new directory")
+			}
+		}
+	}
+
+	var owner scanner.Component
+	found := false
+	for _, component := range w.components {
+		dir := component.Dir()
+		if event.Name != dir && !strings.HasPrefix(event.Name, dir+string(filepath.Separator)) {
+			continue
+		}
+		if !found || len(dir) > len(owner.Dir()) {
+			owner, found = component, true
+		}
+	}
+	return owner, found
+}
+
+// regenerateChangedDocs rescans a changed component and regenerates only the documents
+// the snapshot comparison says are out of date. It returns the rescanned component.
+func regenerateChangedDocs(ctx context.Context, configManager config.ConfigManager, fileScanner scanner.FileScanner, snapshotManager *SnapshotManager, component scanner.Component) scanner.Component {
+	// Pick up added and removed files before comparing snapshots
+	if rescanned, err := fileScanner.RescanComponent(component); err == nil {
+		component = rescanned
+	}
+
+	for _, docType := range orderedUpdateDocTypes() {
+		if ctx.Err() != nil {
+			return component
+		}
+		regenerate, reason := snapshotManager.ShouldRegenerateDoc(component, docType)
+		if !regenerate {
+			continue
+		}
+		LogFrom(ctx).WithField("doc_type", docType).
+			WithField("reason", reason).
+			Info("Regenerating document after source change")
+
+		if err := regenerateDocument(WithLogFields(ctx, logrus.Fields{"doc_type": docType}), configManager, fileScanner, snapshotManager, component, docType); err != nil {
+			fmt.Printf("❌ %s/%s: %v\n", component.Key(), docType, err)
+			continue
+		}
+		fmt.Printf("📝 Updated %s/%s\n", component.Key(), docType)
+	}
+	snapshotManager.Flush()
+	return component
+}
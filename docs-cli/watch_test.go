@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestWatchStateDebouncesAndLimitsGenerations(t *testing.T) {
+	previousDebounce, previousInterval := watchDebounce, watchMinInterval
+	watchDebounce, watchMinInterval = 2*time.Second, 30*time.Second
+	t.Cleanup(func() { watchDebounce, watchMinInterval = previousDebounce, previousInterval })
+
+	start := time.Now()
+	state := &watchState{}
+	steps := []struct {
+		after   time.Duration
+		changed bool
+		want    bool
+	}{
+		{0, false, false},                // nothing changed
+		{time.Second, true, false},       // changed, not yet settled
+		{2 * time.Second, true, false},   // changed again, debounce restarts
+		{3 * time.Second, false, false},  // settled for 1s of 2s
+		{4 * time.Second, false, true},   // settled: regenerate once
+		{5 * time.Second, false, false},  // nothing new
+		{10 * time.Second, true, false},  // changed, not yet settled
+		{20 * time.Second, false, false}, // settled, but within --min-interval
+		{34 * time.Second, false, true},  // --min-interval has passed
+		{35 * time.Second, false, false}, // nothing new
+	}
+	for _, step := range steps {
+		now := start.Add(step.after)
+		if step.changed {
+			state.changed(now)
+		}
+		if got := state.due(now); got != step.want {
+			t.Errorf("at %s (changed %v): due = %v, want %v", step.after, step.changed, got, step.want)
+		}
+	}
+}
+
+func TestWatchRegeneratesOnceForABurstOfChanges(t *testing.T) {
+	project, svc := singleServiceProject(t)
+	useCacheFlags(t, true, true)
+	previousDocTypes := updateDocTypes
+	updateDocTypes = []string{"README"}
+	t.Cleanup(func() { updateDocTypes = previousDocTypes })
+	provider, _ := useScriptedProvider(t)
+
+	// Start from up-to-date docs so only a source change regenerates
+	snapshotManager := NewSnapshotManager()
+	if err := regenerate(t, snapshotManager, svc, "README"); err != nil {
+		t.Fatal(err)
+	}
+	snapshotManager.Flush()
+	provider.calls.Store(0)
+
+	previousDebounce, previousInterval := watchDebounce, watchMinInterval
+	watchDebounce, watchMinInterval = 100*time.Millisecond, 0
+	t.Cleanup(func() { watchDebounce, watchMinInterval = previousDebounce, previousInterval })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan string)
+	go func() {
+		done <- captureStdout(t, func() { runCommand(t, ctx, watchDocumentation) })
+	}()
+
+	// Let watch start watching, then save the file several times in quick succession
+	time.Sleep(50 * time.Millisecond)
+	for i := 1; i <= 3; i++ {
+		project.WriteFile("svc/main.go", "// Package main serves jobs.\npackage main\n\nfunc main() {}\n"+strings.Repeat("\n", i))
+		time.Sleep(20 * time.Millisecond)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for provider.calls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Give any extra generation time to happen before stopping
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+	output := <-done
+
+	if calls := provider.calls.Load(); calls != 1 {
+		t.Errorf("provider called %d times for one burst of changes, want 1\n%s", calls, output)
+	}
+	if !strings.Contains(output, "📝 Updated svc/README") {
+		t.Errorf("watch did not report the update:\n%s", output)
+	}
+}
+
+func TestComponentWatcherMapsEventsToComponents(t *testing.T) {
+	project := newTestProject(t, `components:
+  - name: "api"
+    path: "api"
+    type: "service"
+  - name: "worker"
+    path: "api/worker"
+    type: "service"
+`)
+	project.WriteFile("api/main.go", "package main\n")
+	project.WriteFile("api/worker/main.go", "package main\n")
+	api, worker := project.Component("api"), project.Component("worker")
+
+	watcher, err := newComponentWatcher(project.Components())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+
+	tests := []struct {
+		name  string
+		event fsnotify.Event
+		want  string
+	}{
+		{"source write", fsnotify.Event{Name: filepath.Join(api.Dir(), "main.go"), Op: fsnotify.Write}, "api"},
+		{"nested component is innermost", fsnotify.Event{Name: filepath.Join(worker.Dir(), "main.go"), Op: fsnotify.Write}, "worker"},
+		{"permission change", fsnotify.Event{Name: filepath.Join(api.Dir(), "main.go"), Op: fsnotify.Chmod}, ""},
+		{"generated document", fsnotify.Event{Name: docOutputPath(api, "README"), Op: fsnotify.Write}, ""},
+		{"outside every component", fsnotify.Event{Name: filepath.Join(project.Root, "apiary", "main.go"), Op: fsnotify.Write}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			component, changed := watcher.changedComponent(tt.event)
+			if got := component.Key(); changed != (tt.want != "") || (changed && got != tt.want) {
+				t.Errorf("changedComponent = %q, %v; want %q", got, changed, tt.want)
+			}
+		})
+	}
+
+	// A directory created inside a component is watched from then on
+	dir := filepath.Join(api.Dir(), "handlers")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	watcher.changedComponent(fsnotify.Event{Name: dir, Op: fsnotify.Create})
+	if !slices.Contains(watcher.WatchList(), dir) {
+		t.Errorf("new directory %s not watched: %q", dir, watcher.WatchList())
+	}
+}